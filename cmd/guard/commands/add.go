@@ -2,9 +2,7 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -14,92 +12,91 @@ import (
 // For adding files to collections, use 'guard update <collection> add <files>...'
 func NewAddCmd() *cobra.Command {
 	addCmd := &cobra.Command{
-		Use:   "add [file] <paths>...",
-		Short: "Add files to the registry",
+		Use:               "add [file] <paths>...",
+		Short:             "Add files to the registry",
+		ValidArgsFunction: completeNames(completeDiskFiles),
 		Long: `Add files to the registry.
 
 The 'file' keyword is optional. Both of these work:
   guard add file.txt           - Add file (file keyword optional)
   guard add file file.txt      - Add file (file keyword explicit)
 
+With --git-tracked, --git-untracked, --git-staged, --git-except-staged,
+--git-changed <rev> or --git-unchanged-since <time>, the files git selects are
+added to the arguments. Only files below the .guardfile directory are selected.
+  guard add --git-tracked      - Register every file tracked by git
+
 To create collections, use: guard create <collection>...
 To add files to collections, use: guard update <collection> add <files>...`,
 		Args: cobra.ArbitraryArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			// When called without subcommand, treat args as files
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files specified. Usage: guard add <path>...")
-				os.Exit(1)
+			if len(args) == 0 && !hasGitSelector(cmd) {
+				failf("No files specified. Usage: guard add <path>...")
 			}
 
-			addFiles(args)
+			addFiles(cmd, args)
 		},
 	}
 
 	// Add file subcommand for explicit usage (backward compatibility)
-	addCmd.AddCommand(newAddFileCmd())
+	fileCmd := newAddFileCmd()
+	addCmd.AddCommand(fileCmd)
+
+	// --git-* selectors apply to the command and its file subcommand
+	addGitSelectorFlags(addCmd)
+	addGitSelectorFlags(fileCmd)
 
 	return addCmd
 }
 
 // addFiles is the shared implementation for adding files.
-func addFiles(args []string) {
-	mgr := manager.NewManager(".guardfile")
+func addFiles(cmd *cobra.Command, args []string) {
+	mgr := newManager()
 
 	// Load registry
 	if err := mgr.LoadRegistry(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fail(err)
 	}
 
-	// Count files already registered before adding
-	alreadyRegistered := 0
-	for _, path := range args {
-		if mgr.IsRegisteredFile(path) {
-			alreadyRegistered++
-		}
+	// Add the files selected by --git-* flags
+	args = appendGitSelectedFiles(cmd, mgr, args, false)
+	if len(args) == 0 {
+		return
 	}
 
 	// Add files
+	before := snapshotFiles(mgr, args)
 	if err := mgr.AddFiles(args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Count newly registered files
-	nowRegistered := 0
-	for _, path := range args {
-		if mgr.IsRegisteredFile(path) {
-			nowRegistered++
-		}
+		fail(err)
 	}
-	newlyRegistered := nowRegistered - alreadyRegistered
 
 	// Save registry
 	if err := mgr.SaveRegistry(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-		os.Exit(1)
+		failf("Failed to save registry: %w", err)
 	}
 
-	// Print success message
-	if newlyRegistered > 0 {
-		fmt.Printf("Registered %d file(s)\n", newlyRegistered)
-	}
-
-	// Print skipped count
-	if alreadyRegistered > 0 {
-		fmt.Printf("Skipped %d file(s) already in registry\n", alreadyRegistered)
-	}
+	result := newGuardResult()
+	result.addFiles(mgr, args, before, func(prev fileState) bool { return prev.registered })
+	result.Files = mgr.GetFileResults()
+	render(result, func() {
+		if result.Registered > 0 {
+			fmt.Fprintf(stdout, "Registered %d file(s)\n", result.Registered)
+		}
+		if result.Skipped > 0 {
+			fmt.Fprintf(stdout, "Skipped %d file(s) already in registry\n", result.Skipped)
+		}
+	})
 
 	// Print warnings
-	manager.PrintWarnings(mgr.GetWarnings())
+	printWarnings(mgr.GetWarnings())
 
 	// Print errors
-	manager.PrintErrors(mgr.GetErrors())
+	printErrors(mgr.GetErrors())
 
 	// Exit with error code if there were errors
 	if mgr.HasErrors() {
-		os.Exit(1)
+		exitFailure(mgr.Failure())
 	}
 }
 
@@ -107,8 +104,9 @@ func addFiles(args []string) {
 // This is kept for backward compatibility with explicit 'file' keyword.
 func newAddFileCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "file <paths>...",
-		Short: "Add files to the registry",
+		Use:               "file <paths>...",
+		Short:             "Add files to the registry",
+		ValidArgsFunction: completeNames(completeDiskFiles),
 		Long: `Add files to the registry.
 
 Example:
@@ -116,12 +114,11 @@ Example:
 
 To add files to collections, use: guard update <collection> add <files>...`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files specified. Usage: guard add file <path>...")
-				os.Exit(1)
+			if len(args) == 0 && !hasGitSelector(cmd) {
+				failf("No files specified. Usage: guard add file <path>...")
 			}
 
-			addFiles(args)
+			addFiles(cmd, args)
 		},
 	}
 }
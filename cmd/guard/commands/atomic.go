@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// addAtomicFlag registers the --atomic flag on a parent command so it is
+// available to the command itself and all of its file/folder/collection subcommands.
+func addAtomicFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool("atomic", false, "Revert all changes if any file fails (all-or-nothing)")
+}
+
+// enableAtomicMode switches the manager into all-or-nothing mode when --atomic was given.
+func enableAtomicMode(cmd *cobra.Command, mgr *manager.Manager) {
+	atomic, err := cmd.Flags().GetBool("atomic")
+	if err != nil || !atomic {
+		return
+	}
+	if err := mgr.SetAtomic(true); err != nil {
+		fail(err)
+	}
+}
+
+// exitIfAtomicAborted prints the single atomic failure report and exits
+// when an --atomic operation was reverted. Success output is skipped because
+// nothing was changed.
+func exitIfAtomicAborted(mgr *manager.Manager) {
+	if !mgr.AtomicAborted() {
+		return
+	}
+	printWarnings(mgr.GetWarnings())
+	printErrors(mgr.GetErrors())
+	exitFailure(mgr.Failure())
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCmd creates the audit command.
+// Queries the audit log of guard state changes.
+func NewAuditCmd() *cobra.Command {
+	var filter manager.AuditFilter
+	var since, until string
+	var asJSON bool
+	var toSyslog bool
+
+	cmd := &cobra.Command{
+		Use:   "audit [--file <path>] [--user <name>] [--since <time>] [--until <time>]",
+		Short: "Show the audit log of guard state changes",
+		Long: `Show who changed the guard state of which files and when.
+
+Every enable, disable, toggle, reset, undo/redo and config change is appended
+to .guard/audit.jsonl, one JSON object per line. Each entry records the time,
+the real user (SUDO_USER/SUDO_UID when run with sudo), the command line and
+the affected files with their modes before and after the change. Entries are
+only ever appended. When run as root the log is root-owned, readable by root
+only and append-only (chattr +a on Linux, chflags sappnd on macOS), so its
+entries cannot be rewritten or deleted until root clears the flag. Without
+root privileges the log is owned by the user, who can rewrite it; guard warns
+when it creates the log that way.
+
+Times are given as 2006-01-02, "2006-01-02 15:04", RFC 3339, or relative to
+now as a duration like 90m, 24h or 7d.
+
+Examples:
+  guard audit                          # Show the whole log
+  guard audit --file secrets.env       # Changes of one file
+  guard audit --user alice --since 7d  # What alice changed in the last week
+  guard audit --since 2026-01-01 --until 2026-02-01 --json
+  guard audit --since 24h --syslog     # Also send the entries to the local syslog`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if filter.Since, err = parseAuditTime(since); err != nil {
+				failf("invalid --since: %w", err)
+			}
+			if filter.Until, err = parseAuditTime(until); err != nil {
+				failf("invalid --until: %w", err)
+			}
+
+			mgr := newManager()
+
+			// Load registry (needed to resolve --file against the .guardfile directory)
+			if err := mgr.LoadRegistryForRecovery(); err != nil {
+				fail(err)
+			}
+
+			entries, err := mgr.ReadAuditLog(filter)
+			if err != nil {
+				fail(err)
+			}
+
+			if asJSON {
+				encoder := json.NewEncoder(stdout)
+				for _, entry := range entries {
+					if err := encoder.Encode(entry); err != nil {
+						fail(err)
+					}
+				}
+			} else if len(entries) == 0 {
+				fmt.Fprintln(stdout, "No matching audit entries")
+			} else {
+				for _, entry := range entries {
+					printAuditEntry(entry)
+				}
+			}
+
+			if toSyslog && len(entries) > 0 {
+				if err := manager.SendAuditToSyslog(entries); err != nil {
+					fail(err)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&filter.File, "file", "", "Only show changes of this file")
+	cmd.Flags().StringVar(&filter.User, "user", "", "Only show changes by this user (name or UID)")
+	cmd.Flags().StringVar(&since, "since", "", "Only show changes at or after this time")
+	cmd.Flags().StringVar(&until, "until", "", "Only show changes at or before this time")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the matching entries as JSON lines")
+	cmd.Flags().BoolVar(&toSyslog, "syslog", false, "Also send the matching entries to the local syslog")
+
+	return cmd
+}
+
+// printAuditEntry prints one entry with one indented line per file or config value.
+func printAuditEntry(entry manager.AuditEntry) {
+	fmt.Fprintf(stdout, "%s  %s (uid %s)  %s  [%s]\n",
+		entry.Time.Local().Format("2006-01-02 15:04:05"), entry.User, entry.UID, entry.Operation, entry.Command)
+	for _, file := range entry.Files {
+		state := "unguarded"
+		if file.Guard {
+			state = "guarded"
+		}
+		fmt.Fprintf(stdout, "    %-9s %s  %s -> %s", state, file.Path, file.BeforeMode, file.AfterMode)
+		if file.BeforeOwner != file.AfterOwner || file.BeforeGroup != file.AfterGroup {
+			fmt.Fprintf(stdout, "  %s:%s -> %s:%s", file.BeforeOwner, file.BeforeGroup, file.AfterOwner, file.AfterGroup)
+		}
+		fmt.Fprintln(stdout)
+	}
+	for _, change := range entry.Config {
+		fmt.Fprintf(stdout, "    config    %s  %s -> %s\n", change.Field, change.Old, change.New)
+	}
+}
+
+// parseAuditTime parses an absolute time or a duration back from now. Empty means no limit.
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is neither a date, a time nor a duration", value)
+}
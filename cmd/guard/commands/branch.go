@@ -0,0 +1,300 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/florianbuetow/guard/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// NewBranchProfileCmd creates the branch-profile command with set, remove, list, status and apply subcommands.
+func NewBranchProfileCmd() *cobra.Command {
+	branchCmd := &cobra.Command{
+		Use:   "branch-profile {set|remove|list|status|apply}",
+		Short: "Apply guard states per git branch",
+		Long: `Give git branches their own guard state, e.g. everything but the changelog
+frozen on release branches and only the API contract on feature branches.
+
+A profile maps a branch pattern to a saved state (see 'guard state save') or
+to collections. A collections profile guards exactly the files of those
+collections and unguards every other file, collection and folder.
+
+Patterns use shell glob syntax where "*" does not match "/". Profiles are
+matched in the order they were set; the first match applies. Quote patterns
+so the shell does not expand them.
+
+Profiles are applied with 'guard branch-profile apply', or automatically on
+checkout and merge after 'guard hooks install'.
+
+Examples:
+  guard branch-profile set 'release/*' frozen
+  guard branch-profile set 'feature/*' api-contract
+  guard branch-profile status
+  guard branch-profile apply --dry-run
+  guard hooks install`,
+	}
+
+	branchCmd.AddCommand(newBranchProfileSetCmd())
+	branchCmd.AddCommand(newBranchProfileRemoveCmd())
+	branchCmd.AddCommand(newBranchProfileListCmd())
+	branchCmd.AddCommand(newBranchProfileStatusCmd())
+	branchCmd.AddCommand(newBranchProfileApplyCmd())
+
+	return branchCmd
+}
+
+// newBranchProfileSetCmd creates the "branch-profile set" subcommand.
+func newBranchProfileSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <pattern> <saved-state | collections...>",
+		Short: "Set the guard state for branches matching a pattern",
+		Long: `Set the guard state for branches matching pattern, replacing a profile with
+the same pattern. A single name of a saved state loads that state; otherwise
+every name must be a collection.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			profile, err := mgr.SetBranchProfile(args[0], args[1:])
+			if err != nil {
+				fail(err)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				failf("Failed to save registry: %w", err)
+			}
+			fmt.Fprintf(stdout, "Branch profile %s: %s\n", profile.Pattern, describeBranchProfile(profile))
+
+			printWarnings(mgr.GetWarnings())
+		},
+	}
+}
+
+// newBranchProfileRemoveCmd creates the "branch-profile remove" subcommand.
+func newBranchProfileRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <pattern>...",
+		Short: "Remove branch profiles",
+		Long:  `Remove the profiles for the given patterns. Guard states are not changed.`,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			var failure error
+			for _, pattern := range args {
+				if err := mgr.RemoveBranchProfile(pattern); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					failure = err
+					continue
+				}
+				fmt.Fprintf(stdout, "Removed branch profile %s\n", pattern)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				failf("Failed to save registry: %w", err)
+			}
+			if failure != nil {
+				exitFailure(failure)
+			}
+		},
+	}
+}
+
+// newBranchProfileListCmd creates the "branch-profile list" subcommand.
+func newBranchProfileListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List branch profiles",
+		Long: `List branch profiles in the order they are matched. The profile for the
+checked out branch is marked with "*".
+
+Output format: [*] <pattern>  <state or collections>`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			profiles := mgr.GetBranchProfiles()
+			if len(profiles) == 0 {
+				fmt.Fprintln(stdout, "No branch profiles")
+				return
+			}
+
+			active := ""
+			if branch, ok, err := mgr.CurrentBranch(); err == nil && ok {
+				if profile := mgr.BranchProfileFor(branch); profile != nil {
+					active = profile.Pattern
+				}
+			}
+			for i := range profiles {
+				marker := " "
+				if profiles[i].Pattern == active {
+					marker = "*"
+				}
+				fmt.Fprintf(stdout, "%s %-20s  %s\n", marker, profiles[i].Pattern, describeBranchProfile(&profiles[i]))
+			}
+		},
+	}
+}
+
+// newBranchProfileStatusCmd creates the "branch-profile status" subcommand.
+func newBranchProfileStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [branch]",
+		Short: "Show the active branch profile",
+		Long: `Show the branch profile for the checked out branch (or the given branch) and
+whether the current guard state matches it.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			branch, ok := resolveProfileBranch(mgr, args)
+			if !ok {
+				return
+			}
+			profile, changes, err := mgr.BranchProfileChanges(branch)
+			if err != nil {
+				fail(err)
+			}
+
+			fmt.Fprintf(stdout, "Branch: %s\n", branch)
+			if profile == nil {
+				fmt.Fprintln(stdout, "Profile: none")
+				return
+			}
+			fmt.Fprintf(stdout, "Profile: %s (%s)\n", profile.Pattern, describeBranchProfile(profile))
+			if len(changes) == 0 {
+				fmt.Fprintln(stdout, "Guard state matches the profile")
+			} else {
+				fmt.Fprintf(stdout, "Guard state differs in %d item(s), run 'guard branch-profile apply'\n", len(changes))
+			}
+
+			printWarnings(mgr.GetWarnings())
+		},
+	}
+}
+
+// newBranchProfileApplyCmd creates the "branch-profile apply" subcommand.
+func newBranchProfileApplyCmd() *cobra.Command {
+	applyCmd := &cobra.Command{
+		Use:   "apply [branch]",
+		Short: "Apply the branch profile",
+		Long: `Guard and unguard files, collections and folders until they match the profile
+for the checked out branch (or the given branch). Without a matching profile
+or on a detached HEAD nothing changes. Applying is recorded in the history and
+can be undone with 'guard undo'.
+
+With --dry-run, only shows what would change.
+
+Output format with --dry-run: ~ <item>: <current> -> <profile>`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			branch, ok := resolveProfileBranch(mgr, args)
+			if !ok {
+				return
+			}
+
+			if dryRun {
+				profile, changes, err := mgr.BranchProfileChanges(branch)
+				if err != nil {
+					fail(err)
+				}
+				if profile == nil {
+					fmt.Fprintf(stdout, "No branch profile matches branch %s\n", branch)
+					return
+				}
+				if len(changes) == 0 {
+					fmt.Fprintf(stdout, "Guard state already matches branch profile %s\n", profile.Pattern)
+				}
+				for _, item := range changes {
+					fmt.Fprintf(stdout, "~ %s: %s -> %s\n", item.Label(), guardStateWord(item.Guard), guardStateWord(item.PrevGuard))
+				}
+				printWarnings(mgr.GetWarnings())
+				return
+			}
+
+			profile, changes, err := mgr.ApplyBranchProfile(branch)
+			if err != nil {
+				fail(err)
+			}
+			if profile == nil {
+				fmt.Fprintf(stdout, "No branch profile matches branch %s\n", branch)
+				return
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				failf("Failed to save registry: %w", err)
+			}
+
+			printRestoredItems(mgr, changes)
+			fmt.Fprintf(stdout, "Applied branch profile %s for branch %s\n", profile.Pattern, branch)
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+
+	applyCmd.Flags().Bool("dry-run", false, "Show what would change without applying it")
+
+	return applyCmd
+}
+
+// resolveProfileBranch returns the branch given in args or the checked out branch.
+// Returns false on a detached HEAD. Exits on error.
+func resolveProfileBranch(mgr *manager.Manager, args []string) (string, bool) {
+	if len(args) > 0 {
+		return args[0], true
+	}
+	branch, ok, err := mgr.CurrentBranch()
+	if err != nil {
+		fail(err)
+	}
+	if !ok {
+		fmt.Fprintln(stdout, "HEAD is detached, no branch profile applies")
+	}
+	return branch, ok
+}
+
+// describeBranchProfile summarizes a profile, e.g. "state frozen" or "collections api, docs".
+func describeBranchProfile(profile *registry.BranchProfile) string {
+	if profile.State != "" {
+		return "state " + profile.State
+	}
+	return "collections " + strings.Join(profile.Collections, ", ")
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewCheckStagedCmd creates the check-staged command.
+func NewCheckStagedCmd() *cobra.Command {
+	checkCmd := &cobra.Command{
+		Use:   "check-staged",
+		Short: "Fail if staged changes touch guarded paths",
+		Long: `Check the changes staged for the next commit against the registry and fail
+if any touches a guarded file, a file in a guarded folder or a file in a
+guarded collection. Deleted and renamed files count with their old path, so
+deleting and recreating a guarded file is caught too.
+
+With --message-file, a "Guard-Override: <reason>" trailer in the commit
+message lets the commit through. 'guard hooks install --pre-commit' installs
+a git hook that runs this check before every commit.
+
+Output format: <path>  (<reasons>)
+
+Examples:
+  guard check-staged
+  git commit -m "Regenerate client" -m "Guard-Override: API version bump"`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			messageFile, _ := cmd.Flags().GetString("message-file")
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			violations, err := mgr.CheckStaged()
+			if err != nil {
+				fail(err)
+			}
+			if len(violations) == 0 {
+				return
+			}
+
+			if messageFile != "" {
+				reason, err := mgr.CommitOverride(messageFile)
+				if err != nil {
+					fail(err)
+				}
+				if reason != "" {
+					fmt.Fprintf(os.Stderr, "Guard override (%s): committing changes to %d guarded path(s)\n", reason, len(violations))
+					return
+				}
+			}
+
+			fmt.Fprintf(os.Stderr, "Error: Staged changes touch %d guarded path(s):\n", len(violations))
+			for _, violation := range violations {
+				fmt.Fprintf(os.Stderr, "  %s  (%s)\n", violation.Path, strings.Join(violation.Reasons, ", "))
+			}
+			fmt.Fprintf(os.Stderr, "Unstage them, or add a \"%s: <reason>\" trailer to the commit message.\n", manager.OverrideTrailer)
+			exit(exitError)
+		},
+	}
+
+	checkCmd.Flags().String("message-file", "", "Commit message file to look for an override trailer in")
+
+	return checkCmd
+}
@@ -2,9 +2,7 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -18,44 +16,44 @@ func NewCleanupCmd() *cobra.Command {
 
 This command helps maintain registry integrity by cleaning up orphaned entries.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Run cleanup
 			result, err := mgr.Cleanup()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 
 			// Print success output per CLI-INTERFACE-SPECS.md
-			fmt.Println("Cleanup complete:")
-			if result.FilesRemoved > 0 || result.CollectionsRemoved > 0 {
-				if result.FilesRemoved > 0 {
-					fmt.Printf("  Removed %d file(s) (file not found)\n", result.FilesRemoved)
+			render(result, func() {
+				fmt.Fprintln(stdout, "Cleanup complete:")
+				if result.FilesRemoved > 0 || result.CollectionsRemoved > 0 {
+					if result.FilesRemoved > 0 {
+						fmt.Fprintf(stdout, "  Removed %d file(s) (file not found)\n", result.FilesRemoved)
+					}
+					if result.CollectionsRemoved > 0 {
+						fmt.Fprintf(stdout, "  Removed %d collection(s) (empty)\n", result.CollectionsRemoved)
+					}
+				} else {
+					fmt.Fprintln(stdout, "  No stale entries found")
 				}
-				if result.CollectionsRemoved > 0 {
-					fmt.Printf("  Removed %d collection(s) (empty)\n", result.CollectionsRemoved)
-				}
-			} else {
-				fmt.Println("  No stale entries found")
-			}
+			})
 		},
 	}
 }
@@ -2,9 +2,7 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -12,8 +10,9 @@ import (
 // Clears collections by disabling guard on files and removing files from the collection.
 func NewClearCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "clear <collection>...",
-		Short: "Clear files from collections (disable guard and unlink files)",
+		Use:               "clear <collection>...",
+		Short:             "Clear files from collections (disable guard and unlink files)",
+		ValidArgsFunction: completeNames(completeCollections),
 		Long: `Clear files from specified collections.
 
 This command:
@@ -25,16 +24,14 @@ This command:
 Use this to empty a collection while keeping the collection and file registrations.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No collections specified. Usage: guard clear <collection>...")
-				os.Exit(1)
+				failf("No collections specified. Usage: guard clear <collection>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Track collection file counts before clearing
@@ -58,41 +55,39 @@ Use this to empty a collection while keeping the collection and file registratio
 
 			// Clear collections
 			if err := mgr.ClearCollections(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
 			// Print success message
 			if len(existingCollections) > 0 {
-				fmt.Printf("Cleared %d collection(s):\n", len(existingCollections))
+				fmt.Fprintf(stdout, "Cleared %d collection(s):\n", len(existingCollections))
 				for _, coll := range existingCollections {
-					fmt.Printf("  - %s: removed %d file(s)\n", coll.name, coll.fileCount)
+					fmt.Fprintf(stdout, "  - %s: removed %d file(s)\n", coll.name, coll.fileCount)
 				}
 			}
 
 			// Print warning for already empty collections
 			if len(alreadyEmpty) > 0 {
-				fmt.Printf("Warning: The following collections are already empty:\n")
+				fmt.Fprintf(stdout, "Warning: The following collections are already empty:\n")
 				for _, name := range alreadyEmpty {
-					fmt.Printf("  - %s\n", name)
+					fmt.Fprintf(stdout, "  - %s\n", name)
 				}
 			}
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
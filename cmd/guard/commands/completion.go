@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of names suggested by completeNames
+const (
+	completeCollections = 1 << iota // Registered collection names
+	completeFolders                 // Registered folder paths, without the @ prefix
+	completeFiles                   // Registered file paths
+	completeDiskFiles               // Files and directories on disk
+	completeDiskDirs                // Directories on disk
+)
+
+// completionManager loads the registry read-only for shell completion.
+// Returns nil without a readable .guardfile; completion must never fail or print.
+func completionManager() *manager.Manager {
+	mgr := manager.NewManager(".guardfile", filesystem.NewFileSystem())
+	if err := mgr.LoadRegistryReadOnly(); err != nil {
+		return nil
+	}
+	return mgr
+}
+
+// completeNames returns a completion function suggesting the given kinds of names
+// for every argument. Names already on the command line are not suggested again.
+func completeNames(kinds int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return suggestNames(kinds, args, toComplete)
+	}
+}
+
+// completeSingleName is completeNames for commands taking at most one name.
+func completeSingleName(kinds int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return suggestNames(kinds, args, toComplete)
+	}
+}
+
+// completeUpdate completes 'guard update <collection> add|remove <files>...'.
+// Removing suggests the files of the collection, adding the files not yet in it.
+func completeUpdate(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return suggestNames(completeCollections, nil, toComplete)
+	case 1:
+		return filterPrefix([]string{"add", "remove"}, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	mgr := completionManager()
+	if mgr == nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	members := collectionFileNames(mgr, args[0])
+	switch args[1] {
+	case "remove":
+		return filterPrefix(members, args[2:], toComplete), cobra.ShellCompDirectiveNoFileComp
+	case "add":
+		candidates, directive := suggestNames(completeFiles|completeDiskFiles, args[2:], toComplete)
+		return filterPrefix(candidates, members, ""), directive
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// suggestNames collects the names of the given kinds that start with toComplete,
+// leaving out the names in exclude. Registered folders that exist on disk are
+// suggested as directories only.
+func suggestNames(kinds int, exclude []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	var names []string
+	if kinds&(completeCollections|completeFolders|completeFiles) != 0 {
+		if mgr := completionManager(); mgr != nil {
+			registry := mgr.GetRegistry()
+			if kinds&completeCollections != 0 {
+				names = append(names, registry.GetRegisteredCollections()...)
+			}
+			if kinds&completeFolders != 0 {
+				for _, name := range registry.GetRegisteredFolders() {
+					names = append(names, strings.TrimPrefix(name, "@"))
+				}
+			}
+			if kinds&completeFiles != 0 {
+				for _, path := range registry.GetRegisteredFiles() {
+					names = append(names, registry.ToDisplayPath(path))
+				}
+			}
+		}
+	}
+
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if kinds&(completeDiskFiles|completeDiskDirs) != 0 {
+		paths, hasDirs := diskPaths(toComplete, kinds&completeDiskFiles == 0)
+		// A registered folder that exists on disk is suggested once, as directory
+		exclude = append([]string{}, exclude...)
+		for _, path := range paths {
+			if strings.HasSuffix(path, "/") {
+				exclude = append(exclude, strings.TrimSuffix(path, "/"))
+			}
+		}
+		names = append(names, paths...)
+		if hasDirs {
+			// Let the user continue into the directory
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+	}
+
+	return filterPrefix(names, exclude, toComplete), directive
+}
+
+// diskPaths lists the entries of the directory toComplete points into that start with
+// its last element. Directories end with a slash. Hidden entries are only listed when
+// toComplete starts them, and guard's own files never.
+func diskPaths(toComplete string, dirsOnly bool) (paths []string, hasDirs bool) {
+	dir, prefix := filepath.Split(toComplete)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil, false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if dir == "" && (name == ".guardfile" || name == ".guard") {
+			continue
+		}
+		isDir := entry.IsDir()
+		if !isDir && entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(readDir, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		switch {
+		case isDir:
+			paths = append(paths, dir+name+"/")
+			hasDirs = true
+		case !dirsOnly:
+			paths = append(paths, dir+name)
+		}
+	}
+	return paths, hasDirs
+}
+
+// collectionFileNames returns the display paths of the files in a collection.
+func collectionFileNames(mgr *manager.Manager, collection string) []string {
+	registry := mgr.GetRegistry()
+	files, err := registry.GetRegisteredCollectionFiles(collection)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(files))
+	for i, path := range files {
+		names[i] = registry.ToDisplayPath(path)
+	}
+	return names
+}
+
+// filterPrefix returns the sorted, unique names starting with prefix that are not in exclude.
+func filterPrefix(names, exclude []string, prefix string) []string {
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		skip[name] = true
+	}
+	var result []string
+	for _, name := range names {
+		if skip[name] || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		skip[name] = true
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
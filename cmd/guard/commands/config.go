@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
@@ -37,19 +38,25 @@ Output format:
   Owner: <username or (empty)>
   Group: <group name or (empty)>`,
 		Run: func(cmd *cobra.Command, args []string) {
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Show config
-			if err := mgr.ShowConfig(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+			config, err := mgr.GetConfig()
+			if err != nil {
+				fail(err)
 			}
+			render(config, func() {
+				// Format output per CLI-INTERFACE-SPECS.md
+				fmt.Fprintln(stdout, "Configuration:")
+				fmt.Fprintf(stdout, "  Mode:  %s\n", config.Mode)
+				fmt.Fprintf(stdout, "  Owner: %s\n", formatConfigValue(config.Owner))
+				fmt.Fprintf(stdout, "  Group: %s\n", formatConfigValue(config.Group))
+			})
 		},
 	}
 }
@@ -77,71 +84,110 @@ Bulk update (positional):
 				fmt.Fprintln(os.Stderr, "   or: guard config set <mode> [owner] [group]")
 				fmt.Fprintln(os.Stderr)
 				fmt.Fprintln(os.Stderr, "Use 'guard help config set' for more information.")
-				os.Exit(1)
+				exit(exitError)
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			var err error
+			var updates []string
 
 			// Check if first arg is a keyword (mode/owner/group)
 			switch args[0] {
 			case "mode":
 				if len(args) < 2 {
-					fmt.Fprintln(os.Stderr, "Error: mode value required")
-					os.Exit(1)
+					failf("mode value required")
 				}
 				err = mgr.SetConfigMode(args[1])
+				updates = []string{"Mode:"}
 			case "owner":
 				if len(args) < 2 {
-					fmt.Fprintln(os.Stderr, "Error: owner value required")
-					os.Exit(1)
+					failf("owner value required")
 				}
 				err = mgr.SetConfigOwner(args[1])
+				updates = []string{"Owner:"}
 			case "group":
 				if len(args) < 2 {
-					fmt.Fprintln(os.Stderr, "Error: group value required")
-					os.Exit(1)
+					failf("group value required")
 				}
 				err = mgr.SetConfigGroup(args[1])
+				updates = []string{"Group:"}
 			default:
 				// Bulk update: args are positional (mode [owner] [group])
 				modeStr := args[0]
 				var owner, group *string
+				updates = []string{"Mode: "} // aligned with Owner and Group
 
 				if len(args) > 1 {
 					owner = &args[1]
+					updates = append(updates, "Owner:")
 				}
 				if len(args) > 2 {
 					group = &args[2]
+					updates = append(updates, "Group:")
 				}
 
 				err = mgr.SetConfig(&modeStr, owner, group)
 			}
 
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			config, err := mgr.GetConfig()
+			if err != nil {
+				fail(err)
+			}
+			render(config, func() {
+				printConfigUpdates(config, updates)
+			})
+
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
 
 	return setCmd
 }
+
+// printConfigUpdates prints the updated configuration values after their labels,
+// e.g. "Mode:"; cleared values are shown as "(cleared)".
+func printConfigUpdates(config *manager.Config, updates []string) {
+	fmt.Fprintln(stdout, "Config updated:")
+	for _, label := range updates {
+		var value string
+		switch {
+		case strings.HasPrefix(label, "Mode"):
+			value = config.Mode
+		case strings.HasPrefix(label, "Owner"):
+			value = config.Owner
+		default:
+			value = config.Group
+		}
+		if value == "" {
+			value = "(cleared)"
+		}
+		fmt.Fprintf(stdout, "  %s %s\n", label, value)
+	}
+}
+
+// formatConfigValue formats a config value for display
+func formatConfigValue(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	return value
+}
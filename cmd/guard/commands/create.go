@@ -2,38 +2,41 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
 // NewCreateCmd creates the create command for creating collections.
 // This replaces `guard add collection <name>...` with `guard create <name>...`
 func NewCreateCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "create <collection>...",
-		Short: "Create one or more collections",
+	createCmd := &cobra.Command{
+		Use:               "create <collection>...",
+		Short:             "Create one or more collections",
+		ValidArgsFunction: cobra.NoFileCompletions,
 		Long: `Create one or more collections in the registry.
 
 Examples:
   guard create mygroup                    - Create a single collection
   guard create group1 group2 group3       - Create multiple collections
+  guard create recent --git-changed main..HEAD - Collect the files changed on this branch
+
+With --git-tracked, --git-untracked, --git-staged, --git-except-staged,
+--git-changed <rev> or --git-unchanged-since <time>, the files git selects are
+added to every collection created or named. Only files below the .guardfile
+directory are selected.
 
 Note: Collection names cannot be reserved keywords (to, from, add, remove,
 file, collection, create, destroy, clear, update, uninstall).`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No collections specified. Usage: guard create <collection>...")
-				os.Exit(1)
+				failf("No collections specified. Usage: guard create <collection>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Track which collections already exist
@@ -46,8 +49,15 @@ file, collection, create, destroy, clear, update, uninstall).`,
 
 			// Create collections
 			if err := mgr.AddCollections(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
+			}
+
+			// Fill the collections with the files selected by --git-* flags
+			gitFiles := appendGitSelectedFiles(cmd, mgr, nil, false)
+			if len(gitFiles) > 0 {
+				if err := mgr.AddFilesToCollections(gitFiles, args); err != nil {
+					fail(err)
+				}
 			}
 
 			// Build list of newly created collections
@@ -64,33 +74,42 @@ file, collection, create, destroy, clear, update, uninstall).`,
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
 			// Print success message in multi-line format
 			if len(newlyCreated) > 0 {
-				fmt.Printf("Created %d collection(s):\n", len(newlyCreated))
+				fmt.Fprintf(stdout, "Created %d collection(s):\n", len(newlyCreated))
 				for _, name := range newlyCreated {
-					fmt.Printf("  - %s\n", name)
+					fmt.Fprintf(stdout, "  - %s\n", name)
 				}
 			}
 
 			// Print skipped count for already existing
 			if len(alreadyExisting) > 0 {
-				fmt.Printf("Skipped %d collection(s) already exist\n", len(alreadyExisting))
+				fmt.Fprintf(stdout, "Skipped %d collection(s) already exist\n", len(alreadyExisting))
+			}
+
+			if len(gitFiles) > 0 {
+				for _, name := range args {
+					fmt.Fprintf(stdout, "Added %d file(s) to collection '%s'\n", len(gitFiles), name)
+				}
 			}
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
+
+	addGitSelectorFlags(createCmd)
+
+	return createCmd
 }
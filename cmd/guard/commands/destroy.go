@@ -2,9 +2,7 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -12,8 +10,9 @@ import (
 // This replaces `guard remove collection <name>...` with `guard destroy <name>...`
 func NewDestroyCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "destroy <collection>...",
-		Short: "Remove one or more collections",
+		Use:               "destroy <collection>...",
+		Short:             "Remove one or more collections",
+		ValidArgsFunction: completeNames(completeCollections),
 		Long: `Remove one or more collections from the registry.
 
 This will:
@@ -27,16 +26,14 @@ Examples:
   guard destroy group1 group2 group3       - Remove multiple collections`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No collections specified. Usage: guard destroy <collection>...")
-				os.Exit(1)
+				failf("No collections specified. Usage: guard destroy <collection>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Track collection file counts before destroying
@@ -55,37 +52,35 @@ Examples:
 
 			// Remove collections
 			if err := mgr.RemoveCollections(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
 			// Print success message
 			if len(existingCollections) > 0 {
-				fmt.Printf("Destroyed %d collection(s):\n", len(existingCollections))
+				fmt.Fprintf(stdout, "Destroyed %d collection(s):\n", len(existingCollections))
 				for _, coll := range existingCollections {
 					if coll.fileCount == 1 {
-						fmt.Printf("  - %s (1 file)\n", coll.name)
+						fmt.Fprintf(stdout, "  - %s (1 file)\n", coll.name)
 					} else {
-						fmt.Printf("  - %s (%d files)\n", coll.name, coll.fileCount)
+						fmt.Fprintf(stdout, "  - %s (%d files)\n", coll.name, coll.fileCount)
 					}
 				}
 			}
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
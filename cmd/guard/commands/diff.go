@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewDiffCmd creates the diff command that compares two .guardfiles.
+func NewDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <guardfile-a> <guardfile-b>",
+		Short: "Show the differences between two .guardfiles",
+		Long: `Compare two .guardfiles entry by entry and print what changed from the first
+to the second: config, files, collections, folders, focus mode and branch
+profiles.
+
+To compare with a committed revision, write it to a file first:
+  git show HEAD~1:.guardfile > /tmp/guardfile.old
+  guard diff /tmp/guardfile.old .guardfile
+
+Output format:
+  + <section> <name>                       added
+  - <section> <name>                       removed
+  ~ <section> <name>: <field> <old> -> <new>  changed`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			diff, err := manager.DiffRegistryFiles(args[0], args[1])
+			if err != nil {
+				fail(err)
+			}
+			printRegistryDiff("", diff)
+		},
+	}
+}
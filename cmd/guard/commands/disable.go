@@ -1,11 +1,6 @@
 package commands
 
 import (
-	"fmt"
-	"os"
-	"sort"
-
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -13,118 +8,124 @@ import (
 // Per Requirement 5: Disables guard protection on files, folders, and collections.
 func NewDisableCmd() *cobra.Command {
 	disableCmd := &cobra.Command{
-		Use:   "disable [file|folder|collection] <names...>",
-		Short: "Disable guard protection",
+		Use:               "disable [file|folder|collection] <names...>",
+		Short:             "Disable guard protection",
+		ValidArgsFunction: completeNames(completeCollections | completeFolders | completeFiles | completeDiskFiles),
 		Long: `Disable guard protection for files, folders, or collections, restoring original permissions.
 
 Auto-detection: Arguments are automatically detected as files, folders, or collections.
 Use 'file', 'folder', or 'collection' keyword to disambiguate when needed.
 
+With --atomic, a failure on any file (chmod, chown or immutable flag) reverts
+all files changed so far and leaves the registry untouched.
+
+With --for or --until, the change is time-limited: once the time has passed,
+the next guard command (or a running 'guard watch') restores the previous guard
+state. 'guard show' lists the remaining time.
+
+With --git-tracked, --git-untracked, --git-staged, --git-except-staged,
+--git-changed <rev> or --git-unchanged-since <time>, the files git selects are
+added to the arguments, e.g. every tracked file or the files of the last commit.
+Only files below the .guardfile directory are selected. Files that are not
+registered are left out.
+
 Examples:
   guard disable myfile.txt           - Disable file (auto-detected)
   guard disable myfolder             - Disable folder (auto-detected if directory)
   guard disable mycollection         - Disable collection (auto-detected)
   guard disable file ambiguous       - Explicitly disable as file
   guard disable folder myfolder      - Explicitly disable as folder
-  guard disable collection ambiguous - Explicitly disable as collection`,
+  guard disable collection ambiguous - Explicitly disable as collection
+  guard disable --atomic a.txt b.txt  - All-or-nothing: revert every change if any file fails
+  guard disable --for 20m src/api   - Unlock src/api for 20 minutes
+  guard disable --git-changed HEAD  - Unguard the files of the last commit`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files, folders, or collections specified. Usage: guard disable <names>...")
-				os.Exit(1)
+			if len(args) == 0 && !hasGitSelector(cmd) {
+				failf("No files, folders, or collections specified. Usage: guard disable <names>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
-			// Use auto-detection to resolve arguments
-			files, folders, collections, err := mgr.ResolveArguments(args)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
-			}
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
 
-			// Disable files
-			if len(files) > 0 {
-				// Count files already disabled before operation
-				alreadyDisabled := 0
-				for _, path := range files {
-					if mgr.IsRegisteredFile(path) {
-						if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); !guard {
-							alreadyDisabled++
-						}
-					}
-				}
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
 
-				if err := mgr.DisableFiles(files); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+			// Use auto-detection to resolve arguments
+			var files, folders, collections []string
+			if len(args) > 0 {
+				var err error
+				files, folders, collections, err = mgr.ResolveArguments(args)
+				if err != nil {
+					fail(err)
 				}
+			}
 
-				// Count files now disabled
-				nowDisabled := 0
-				for _, path := range files {
-					if mgr.IsRegisteredFile(path) {
-						if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); !guard {
-							nowDisabled++
-						}
-					}
-				}
-				newlyDisabled := nowDisabled - alreadyDisabled
+			// Add the files selected by --git-* flags
+			files = appendGitSelectedFiles(cmd, mgr, files, true)
 
-				// Print success message
-				if newlyDisabled > 0 {
-					fmt.Printf("Guard disabled for %d file(s)\n", newlyDisabled)
-				}
+			result := newGuardResult()
 
-				// Print skipped count
-				if alreadyDisabled > 0 {
-					fmt.Printf("Skipped %d file(s) already disabled\n", alreadyDisabled)
+			// Disable files
+			if len(files) > 0 {
+				before := snapshotFiles(mgr, files)
+				if err := mgr.DisableFiles(files); err != nil {
+					fail(err)
 				}
+				exitIfAtomicAborted(mgr)
+				result.addFiles(mgr, files, before, func(prev fileState) bool { return prev.registered && !prev.guard })
 			}
 
 			// Disable folders
 			if len(folders) > 0 {
 				if err := mgr.DisableFolders(folders); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
-				fmt.Printf("Guard disabled for %d folder(s)\n", len(folders))
+				exitIfAtomicAborted(mgr)
+				result.Folders = folders
 			}
 
 			// Disable collections
 			if len(collections) > 0 {
 				if err := mgr.DisableCollections(collections); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
+				exitIfAtomicAborted(mgr)
+				result.addCollections(mgr, collections, false)
 			}
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCounts(false, false)
+			})
+
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
 
 	// Add file subcommand for explicit usage
-	disableCmd.AddCommand(newDisableFileCmd())
+	fileCmd := newDisableFileCmd()
+	disableCmd.AddCommand(fileCmd)
 
 	// Add folder subcommand for explicit usage
 	disableCmd.AddCommand(newDisableFolderCmd())
@@ -132,6 +133,16 @@ Examples:
 	// Add collection subcommand for explicit usage
 	disableCmd.AddCommand(newDisableCollectionCmd())
 
+	// --atomic applies to the command and all subcommands
+	addAtomicFlag(disableCmd)
+
+	// --for and --until apply to the command and all subcommands
+	addExpiryFlags(disableCmd)
+
+	// --git-* selectors apply to the command and its file subcommand
+	addGitSelectorFlags(disableCmd)
+	addGitSelectorFlags(fileCmd)
+
 	return disableCmd
 }
 
@@ -139,78 +150,64 @@ Examples:
 // Per Requirement 5.2: Restores original permissions for files.
 func newDisableFileCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "file <paths...>",
-		Short: "Disable guard for files",
+		Use:               "file <paths...>",
+		Short:             "Disable guard for files",
+		ValidArgsFunction: completeNames(completeFiles | completeDiskFiles),
 		Long: `Disable guard protection for the specified files, restoring original permissions.
 
 Files not in the registry or missing on disk will generate warnings.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files specified. Usage: guard disable file <path>...")
-				os.Exit(1)
+			if len(args) == 0 && !hasGitSelector(cmd) {
+				failf("No files specified. Usage: guard disable file <path>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
-			// Count files already disabled before operation
-			alreadyDisabled := 0
-			for _, path := range args {
-				if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); !guard {
-					// File exists in registry but guard is false (disabled)
-					if mgr.IsRegisteredFile(path) {
-						alreadyDisabled++
-					}
-				}
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
+			// Add the files selected by --git-* flags
+			args = appendGitSelectedFiles(cmd, mgr, args, true)
+			if len(args) == 0 {
+				return
 			}
 
 			// Disable files
+			before := snapshotFiles(mgr, args)
 			if err := mgr.DisableFiles(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
-			}
-
-			// Count files now disabled (guard=false and in registry)
-			nowDisabled := 0
-			for _, path := range args {
-				if mgr.IsRegisteredFile(path) {
-					if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); !guard {
-						nowDisabled++
-					}
-				}
+				fail(err)
 			}
-			newlyDisabled := nowDisabled - alreadyDisabled
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
-			// Print success message
-			if newlyDisabled > 0 {
-				fmt.Printf("Guard disabled for %d file(s)\n", newlyDisabled)
-			}
-
-			// Print skipped count
-			if alreadyDisabled > 0 {
-				fmt.Printf("Skipped %d file(s) already disabled\n", alreadyDisabled)
-			}
+			result := newGuardResult()
+			result.addFiles(mgr, args, before, func(prev fileState) bool { return prev.registered && !prev.guard })
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCounts(false, false)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -219,8 +216,9 @@ Files not in the registry or missing on disk will generate warnings.`,
 // newDisableFolderCmd creates the "disable folder" subcommand.
 func newDisableFolderCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "folder <paths...>",
-		Short: "Disable guard for folders",
+		Use:               "folder <paths...>",
+		Short:             "Disable guard for folders",
+		ValidArgsFunction: completeNames(completeFolders | completeDiskDirs),
 		Long: `Disable guard protection for files in the specified folders.
 
 Folders are dynamic collections that scan files from disk. On disable:
@@ -231,44 +229,49 @@ Folders are dynamic collections that scan files from disk. On disable:
 - Restores original permissions for all files`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No folders specified. Usage: guard disable folder <path>...")
-				os.Exit(1)
+				failf("No folders specified. Usage: guard disable folder <path>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
 			// Disable folders
 			if err := mgr.DisableFolders(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
-			// Print success message for folders
-			if len(args) > 0 {
-				fmt.Printf("Guard disabled for %d folder(s)\n", len(args))
-			}
+			result := newGuardResult()
+			result.Folders = args
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCounts(false, false)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -277,79 +280,58 @@ Folders are dynamic collections that scan files from disk. On disable:
 // newDisableCollectionCmd creates the "disable collection" subcommand.
 func newDisableCollectionCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "collection <names...>",
-		Short: "Disable guard for collections",
+		Use:               "collection <names...>",
+		Short:             "Disable guard for collections",
+		ValidArgsFunction: completeNames(completeCollections),
 		Long: `Disable guard protection for all files in the specified collections.
 
 Empty or non-existent collections will generate warnings.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No collections specified. Usage: guard disable collection <name>...")
-				os.Exit(1)
+				failf("No collections specified. Usage: guard disable collection <name>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
 			// Disable collections
 			if err := mgr.DisableCollections(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
-			// Print success messages - files first (sorted), then collection summary
-			for _, collectionName := range args {
-				// Check if collection exists in registry
-				if !mgr.GetRegistry().IsRegisteredCollection(collectionName) {
-					continue // Collection doesn't exist, warning already printed
-				}
-
-				// Get files from collection
-				files, err := mgr.GetRegistry().GetRegisteredCollectionFiles(collectionName)
-				if err != nil || len(files) == 0 {
-					continue // Error or empty collection, warnings already printed
-				}
-
-				// Check which files exist on disk and print them (sorted)
-				existing, _ := mgr.GetFileSystem().CheckFilesExist(files)
-				sort.Strings(existing)
-				for _, file := range existing {
-					fmt.Printf("Guard disabled for %s\n", file)
-				}
-			}
-			fmt.Println()
-			for _, collectionName := range args {
-				if !mgr.GetRegistry().IsRegisteredCollection(collectionName) {
-					continue
-				}
-				// Only print collection success if it has files
-				files, err := mgr.GetRegistry().GetRegisteredCollectionFiles(collectionName)
-				if err != nil || len(files) == 0 {
-					continue // Skip empty collections
-				}
-				fmt.Printf("Guard disabled for collection %s\n", collectionName)
-			}
+			// Files first (sorted), then the collections with files
+			result := newGuardResult()
+			result.addCollections(mgr, args, false)
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCollections(false)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
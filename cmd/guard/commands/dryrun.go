@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/florianbuetow/guard/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// dryRunUnsupported marks commands that write outside the manager and reject --dry-run
+const dryRunUnsupported = "dry-run-unsupported"
+
+// dryRunFormat is the value of the global --dry-run flag: empty, "text" or "json"
+var dryRunFormat string
+
+// AddDryRunFlag adds the global --dry-run flag: every command runs its full logic, but
+// against a filesystem and registry that only record changes, then prints the plan.
+// With --output json or yaml the plan is part of the command's document instead.
+func AddDryRunFlag(root *cobra.Command) {
+	root.PersistentFlags().StringVar(&dryRunFormat, "dry-run", "",
+		"Show what would change without changing anything (--dry-run=json for JSON)")
+	root.PersistentFlags().Lookup("dry-run").NoOptDefVal = "text"
+
+	preRun, postRun := root.PersistentPreRunE, root.PersistentPostRun
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if preRun != nil {
+			if err := preRun(cmd, args); err != nil {
+				return err
+			}
+		}
+		switch dryRunFormat {
+		case "":
+			return nil
+		case "text", "json":
+		default:
+			return fmt.Errorf("invalid --dry-run format '%s': use text or json", dryRunFormat)
+		}
+		if _, unsupported := cmd.Annotations[dryRunUnsupported]; unsupported {
+			return fmt.Errorf("'%s' does not support --dry-run", cmd.CommandPath())
+		}
+		if dryRunFormat == "json" {
+			// Keep stdout for the plan, the command's own output goes to stderr
+			redirectStdout()
+		}
+		return nil
+	}
+	root.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		if postRun != nil {
+			postRun(cmd, args)
+		}
+		if dryRunFormat != "" && !structuredOutput() {
+			printDryRunPlan()
+		}
+	}
+}
+
+// newManager creates the manager for the .guardfile in the current directory,
+// recording changes instead of making them during a dry run. The managers of a
+// command are tracked for its dry-run plan and structured output.
+func newManager() *manager.Manager {
+	mgr := manager.NewManager(".guardfile", filesystem.NewFileSystem())
+	if dryRunFormat != "" {
+		mgr.SetDryRun()
+	}
+	managers = append(managers, mgr)
+	return mgr
+}
+
+// printDryRunPlan prints what the command's managers would have changed.
+func printDryRunPlan() {
+	// Empty lists rather than null in JSON
+	plan := &manager.DryRunPlan{Operations: []filesystem.Operation{}, Registry: []registry.DiffEntry{}}
+	for _, mgr := range managers {
+		p, err := mgr.DryRunPlan()
+		if err != nil {
+			fail(err)
+		}
+		plan.Operations = append(plan.Operations, p.Operations...)
+		plan.Registry = append(plan.Registry, p.Registry...)
+	}
+
+	if dryRunFormat == "json" {
+		out, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		fmt.Fprintln(os.Stdout, string(out))
+		return
+	}
+
+	if plan.Empty() {
+		fmt.Fprintln(stdout, "\nDry run: nothing would change")
+		return
+	}
+	fmt.Fprintln(stdout, "\nDry run, nothing was changed. Plan:")
+	for _, op := range plan.Operations {
+		fmt.Fprintf(stdout, "  %s\n", op)
+	}
+	for _, entry := range plan.Registry {
+		fmt.Fprintf(stdout, "  %s\n", entry)
+	}
+}
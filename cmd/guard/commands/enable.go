@@ -1,11 +1,6 @@
 package commands
 
 import (
-	"fmt"
-	"os"
-	"sort"
-
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -13,129 +8,124 @@ import (
 // Per Requirement 5: Enables guard protection on files, folders, and collections.
 func NewEnableCmd() *cobra.Command {
 	enableCmd := &cobra.Command{
-		Use:   "enable [file|folder|collection] <names...>",
-		Short: "Enable guard protection",
+		Use:               "enable [file|folder|collection] <names...>",
+		Short:             "Enable guard protection",
+		ValidArgsFunction: completeNames(completeCollections | completeFolders | completeFiles | completeDiskFiles),
 		Long: `Enable guard protection for files, folders, or collections.
 
 Auto-detection: Arguments are automatically detected as files, folders, or collections.
 Use 'file', 'folder', or 'collection' keyword to disambiguate when needed.
 
+With --atomic, a failure on any file (chmod, chown or immutable flag) reverts
+all files changed so far and leaves the registry untouched.
+
+With --for or --until, the change is time-limited: once the time has passed,
+the next guard command (or a running 'guard watch') restores the previous guard
+state. 'guard show' lists the remaining time.
+
+With --git-tracked, --git-untracked, --git-staged, --git-except-staged,
+--git-changed <rev> or --git-unchanged-since <time>, the files git selects are
+added to the arguments, e.g. every tracked file or the files of the last commit.
+Only files below the .guardfile directory are selected.
+
 Examples:
   guard enable myfile.txt           - Enable file (auto-detected)
   guard enable myfolder             - Enable folder (auto-detected if directory)
   guard enable mycollection         - Enable collection (auto-detected)
   guard enable file ambiguous       - Explicitly enable as file
   guard enable folder myfolder      - Explicitly enable as folder
-  guard enable collection ambiguous - Explicitly enable as collection`,
+  guard enable collection ambiguous - Explicitly enable as collection
+  guard enable --atomic a.txt b.txt  - All-or-nothing: revert every change if any file fails
+  guard enable --until 18:00 tests   - Guard tests until 18:00, then unguard them again
+  guard enable --git-unchanged-since 30d - Guard tracked files unchanged for 30 days
+  guard enable --git-except-staged  - Guard every tracked file except the staged ones`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files, folders, or collections specified. Usage: guard enable <names>...")
-				os.Exit(1)
+			if len(args) == 0 && !hasGitSelector(cmd) {
+				failf("No files, folders, or collections specified. Usage: guard enable <names>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
-			}
-
-			// Use auto-detection to resolve arguments
-			files, folders, collections, err := mgr.ResolveArguments(args)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
-			// Enable files
-			if len(files) > 0 {
-				// Track registration status and guard state before operation
-				wasRegistered := make(map[string]bool)
-				alreadyEnabled := 0
-				for _, path := range files {
-					wasRegistered[path] = mgr.IsRegisteredFile(path)
-					if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); guard {
-						alreadyEnabled++
-					}
-				}
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
 
-				if err := mgr.EnableFiles(files); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
-				}
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
 
-				// Count newly registered files
-				newlyRegistered := 0
-				for _, path := range files {
-					if !wasRegistered[path] && mgr.IsRegisteredFile(path) {
-						newlyRegistered++
-					}
-				}
-
-				// Print registration message if any files were auto-registered
-				if newlyRegistered > 0 {
-					fmt.Printf("Registered %d file(s)\n", newlyRegistered)
+			// Use auto-detection to resolve arguments
+			var files, folders, collections []string
+			if len(args) > 0 {
+				var err error
+				files, folders, collections, err = mgr.ResolveArguments(args)
+				if err != nil {
+					fail(err)
 				}
+			}
 
-				// Count files now enabled
-				nowEnabled := 0
-				for _, path := range files {
-					if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); guard {
-						nowEnabled++
-					}
-				}
-				newlyEnabled := nowEnabled - alreadyEnabled
+			// Add the files selected by --git-* flags
+			files = appendGitSelectedFiles(cmd, mgr, files, false)
 
-				// Print success message
-				if newlyEnabled > 0 {
-					fmt.Printf("Guard enabled for %d file(s)\n", newlyEnabled)
-				}
+			result := newGuardResult()
 
-				// Print skipped count
-				if alreadyEnabled > 0 {
-					fmt.Printf("Skipped %d file(s) already enabled\n", alreadyEnabled)
+			// Enable files
+			if len(files) > 0 {
+				before := snapshotFiles(mgr, files)
+				if err := mgr.EnableFiles(files); err != nil {
+					fail(err)
 				}
+				exitIfAtomicAborted(mgr)
+				result.addFiles(mgr, files, before, func(prev fileState) bool { return prev.guard })
 			}
 
 			// Enable folders
 			if len(folders) > 0 {
 				if err := mgr.EnableFolders(folders); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
-				fmt.Printf("Guard enabled for %d folder(s)\n", len(folders))
+				exitIfAtomicAborted(mgr)
+				result.Folders = folders
 			}
 
 			// Enable collections
 			if len(collections) > 0 {
 				if err := mgr.EnableCollections(collections); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
+				exitIfAtomicAborted(mgr)
+				result.addCollections(mgr, collections, false)
 			}
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCounts(true, true)
+			})
+
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
 
 	// Add file subcommand for explicit usage
-	enableCmd.AddCommand(newEnableFileCmd())
+	fileCmd := newEnableFileCmd()
+	enableCmd.AddCommand(fileCmd)
 
 	// Add folder subcommand for explicit usage
 	enableCmd.AddCommand(newEnableFolderCmd())
@@ -143,6 +133,16 @@ Examples:
 	// Add collection subcommand for explicit usage
 	enableCmd.AddCommand(newEnableCollectionCmd())
 
+	// --atomic applies to the command and all subcommands
+	addAtomicFlag(enableCmd)
+
+	// --for and --until apply to the command and all subcommands
+	addExpiryFlags(enableCmd)
+
+	// --git-* selectors apply to the command and its file subcommand
+	addGitSelectorFlags(enableCmd)
+	addGitSelectorFlags(fileCmd)
+
 	return enableCmd
 }
 
@@ -150,74 +150,65 @@ Examples:
 // Per Requirement 5.1: Registers files if not in registry, then enables guard.
 func newEnableFileCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "file <paths...>",
-		Short: "Enable guard for files",
+		Use:               "file <paths...>",
+		Short:             "Enable guard for files",
+		ValidArgsFunction: completeNames(completeFiles | completeDiskFiles),
 		Long: `Enable guard protection for the specified files.
 
 If files are not in the registry, they will be registered first with guard disabled,
 then guard will be enabled. Files missing on disk will generate warnings.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files specified. Usage: guard enable file <path>...")
-				os.Exit(1)
+			if len(args) == 0 && !hasGitSelector(cmd) {
+				failf("No files specified. Usage: guard enable file <path>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
-			// Count files already enabled before operation
-			alreadyEnabled := 0
-			for _, path := range args {
-				if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); guard {
-					alreadyEnabled++
-				}
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
+			// Add the files selected by --git-* flags
+			args = appendGitSelectedFiles(cmd, mgr, args, false)
+			if len(args) == 0 {
+				return
 			}
 
 			// Enable files
+			before := snapshotFiles(mgr, args)
 			if err := mgr.EnableFiles(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
-
-			// Count files now enabled
-			nowEnabled := 0
-			for _, path := range args {
-				if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); guard {
-					nowEnabled++
-				}
-			}
-			newlyEnabled := nowEnabled - alreadyEnabled
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
-			}
-
-			// Print success message
-			if newlyEnabled > 0 {
-				fmt.Printf("Guard enabled for %d file(s)\n", newlyEnabled)
+				failf("Failed to save registry: %w", err)
 			}
 
-			// Print skipped count
-			if alreadyEnabled > 0 {
-				fmt.Printf("Skipped %d file(s) already enabled\n", alreadyEnabled)
-			}
+			result := newGuardResult()
+			result.addFiles(mgr, args, before, func(prev fileState) bool { return prev.guard })
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCounts(true, false)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -226,8 +217,9 @@ then guard will be enabled. Files missing on disk will generate warnings.`,
 // newEnableFolderCmd creates the "enable folder" subcommand.
 func newEnableFolderCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "folder <paths...>",
-		Short: "Enable guard for folders",
+		Use:               "folder <paths...>",
+		Short:             "Enable guard for folders",
+		ValidArgsFunction: completeNames(completeFolders | completeDiskDirs),
 		Long: `Enable guard protection for files in the specified folders.
 
 Folders are dynamic collections that scan files from disk. On enable:
@@ -237,44 +229,49 @@ Folders are dynamic collections that scan files from disk. On enable:
 - Sets guard state to true for ALL files in folder`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No folders specified. Usage: guard enable folder <path>...")
-				os.Exit(1)
+				failf("No folders specified. Usage: guard enable folder <path>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
 			// Enable folders
 			if err := mgr.EnableFolders(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
-			// Print success message for folders
-			if len(args) > 0 {
-				fmt.Printf("Guard enabled for %d folder(s)\n", len(args))
-			}
+			result := newGuardResult()
+			result.Folders = args
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCounts(true, false)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -284,79 +281,58 @@ Folders are dynamic collections that scan files from disk. On enable:
 // Per Requirement 5.4: Enables guard for all files in collections.
 func newEnableCollectionCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "collection <names...>",
-		Short: "Enable guard for collections",
+		Use:               "collection <names...>",
+		Short:             "Enable guard for collections",
+		ValidArgsFunction: completeNames(completeCollections),
 		Long: `Enable guard protection for all files in the specified collections.
 
 Empty or non-existent collections will generate warnings.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No collections specified. Usage: guard enable collection <name>...")
-				os.Exit(1)
+				failf("No collections specified. Usage: guard enable collection <name>...")
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
 			// Enable collections
 			if err := mgr.EnableCollections(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
-			// Print success messages - files first (sorted), then collection summary
-			for _, collectionName := range args {
-				// Check if collection exists in registry
-				if !mgr.GetRegistry().IsRegisteredCollection(collectionName) {
-					continue // Collection doesn't exist, warning already printed
-				}
-
-				// Get files from collection
-				files, err := mgr.GetRegistry().GetRegisteredCollectionFiles(collectionName)
-				if err != nil || len(files) == 0 {
-					continue // Error or empty collection, warnings already printed
-				}
-
-				// Check which files exist on disk and print them (sorted)
-				existing, _ := mgr.GetFileSystem().CheckFilesExist(files)
-				sort.Strings(existing)
-				for _, file := range existing {
-					fmt.Printf("Guard enabled for %s\n", file)
-				}
-			}
-			fmt.Println()
-			for _, collectionName := range args {
-				if !mgr.GetRegistry().IsRegisteredCollection(collectionName) {
-					continue
-				}
-				// Only print collection success if it has files
-				files, err := mgr.GetRegistry().GetRegisteredCollectionFiles(collectionName)
-				if err != nil || len(files) == 0 {
-					continue // Skip empty collections
-				}
-				fmt.Printf("Guard enabled for collection %s\n", collectionName)
-			}
+			// Files first (sorted), then the collections with files
+			result := newGuardResult()
+			result.addCollections(mgr, args, false)
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCollections(false)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"github.com/florianbuetow/guard/internal/manager"
+)
+
+// Exit codes of guard, documented in the README. Codes 3 and up follow the kind of a manager error.
+const (
+	exitOK             = 0  // Success
+	exitError          = 1  // Any other error, including invalid arguments
+	exitCheckFailed    = 2  // A check found drift or divergence (verify, sync --check)
+	exitNotInitialized = 3  // No .guardfile in the current directory
+	exitCorrupted      = 4  // The .guardfile or a state file cannot be parsed
+	exitTampering      = 5  // The .guardfile contains paths outside the repository
+	exitNeedsRoot      = 6  // Permission changes need root privileges
+	exitPartialFailure = 7  // The command failed for some of its files, folders or collections
+	exitConflict       = 8  // The items cannot be changed together, e.g. collections sharing files
+	exitNotFound       = 9  // A file, folder, collection or other item does not exist
+	exitPendingJournal = 10 // An interrupted operation has to be recovered first
+)
+
+// exitCodes maps the kinds of manager errors to exit codes
+var exitCodes = map[manager.ErrorKind]int{
+	manager.ErrorNotInitialized: exitNotInitialized,
+	manager.ErrorCorrupted:      exitCorrupted,
+	manager.ErrorTampering:      exitTampering,
+	manager.ErrorNeedsRoot:      exitNeedsRoot,
+	manager.ErrorPartialFailure: exitPartialFailure,
+	manager.ErrorConflict:       exitConflict,
+	manager.ErrorNotFound:       exitNotFound,
+	manager.ErrorPendingJournal: exitPendingJournal,
+}
+
+// exitCode returns the exit code for err.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if code, ok := exitCodes[manager.KindOf(err)]; ok {
+		return code
+	}
+	return exitError
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// untilLayouts are the accepted --until formats. A bare time of day means today,
+// or tomorrow if it has already passed.
+var untilLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"15:04",
+}
+
+// addExpiryFlags registers --for and --until on a parent command so they are
+// available to the command itself and all of its file/folder/collection subcommands.
+func addExpiryFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Duration("for", 0, "Revert the change after this duration (e.g. 20m, 2h)")
+	cmd.PersistentFlags().String("until", "", "Revert the change at this time (15:04, 2006-01-02 15:04 or RFC 3339)")
+	cmd.MarkFlagsMutuallyExclusive("for", "until")
+}
+
+// enableExpiry makes the manager's guard changes time-limited when --for or --until was given.
+func enableExpiry(cmd *cobra.Command, mgr *manager.Manager) {
+	duration, _ := cmd.Flags().GetDuration("for")
+	untilValue, _ := cmd.Flags().GetString("until")
+	if duration == 0 && untilValue == "" {
+		return
+	}
+
+	until := time.Now().Add(duration)
+	if untilValue != "" {
+		var err error
+		if until, err = parseUntil(untilValue, time.Now()); err != nil {
+			failf("invalid --until: %w", err)
+		}
+	}
+	if err := mgr.SetExpiry(until); err != nil {
+		fail(err)
+	}
+}
+
+// parseUntil parses an --until value in local time relative to now.
+func parseUntil(value string, now time.Time) (time.Time, error) {
+	for _, layout := range untilLayouts {
+		t, err := time.ParseInLocation(layout, value, now.Location())
+		if err != nil {
+			continue
+		}
+		if layout == "15:04" {
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a time (use 15:04, 2006-01-02 15:04 or RFC 3339)", value)
+}
+
+// describeExpiry describes what happens when a time-limited guard change expires,
+// e.g. "guarded again in 18m (15:04)".
+func describeExpiry(restoreGuard bool, until time.Time) string {
+	state := "unguarded"
+	if restoreGuard {
+		state = "guarded again"
+	}
+	layout := "15:04"
+	if time.Until(until) >= 24*time.Hour {
+		layout = "2006-01-02 15:04"
+	}
+	return fmt.Sprintf("%s in %s (%s)", state, manager.FormatRemaining(until), until.Local().Format(layout))
+}
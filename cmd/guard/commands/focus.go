@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewFocusCmd creates the focus command with add and remove subcommands.
+func NewFocusCmd() *cobra.Command {
+	focusCmd := &cobra.Command{
+		Use:   "focus [<paths/globs>...]",
+		Short: "Guard everything except the files you are working on",
+		Long: `Guard every file in the project except a focus set, e.g. the files an AI
+assistant is allowed to touch. Files outside the focus set are registered and
+guarded; files that were already guarded stay guarded and are left alone.
+
+Focus paths are files, folders or glob patterns relative to the current
+directory. A folder includes everything below it, "**" matches any number of
+folders. Quote globs so the shell does not expand them. The .git and .guard
+folders are never scanned. Inside a git working tree only the files git tracks
+or does not ignore are guarded, so dependencies and build output stay writable.
+
+Without arguments, shows the active focus set.
+
+  add <paths>...     Add paths to the focus set (releases them)
+  remove <paths>...  Remove paths from the focus set (guards them)
+
+Adding or removing paths also guards files created since focus mode started.
+End focus mode with 'guard unfocus', which restores exactly the files focus
+mode guarded or registered.
+
+Examples:
+  guard focus src/api 'docs/*.md'
+  guard focus add 'internal/**/*_test.go'
+  guard focus remove docs
+  guard unfocus`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				showFocus()
+				return
+			}
+			runFocus(func(mgr *manager.Manager) (*manager.FocusResult, error) {
+				return mgr.Focus(args)
+			})
+		},
+	}
+
+	focusCmd.AddCommand(&cobra.Command{
+		Use:   "add <paths/globs>...",
+		Short: "Add paths to the focus set",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runFocus(func(mgr *manager.Manager) (*manager.FocusResult, error) {
+				return mgr.AddFocusPaths(args)
+			})
+		},
+	})
+	focusCmd.AddCommand(&cobra.Command{
+		Use:   "remove <paths/globs>...",
+		Short: "Remove paths from the focus set",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runFocus(func(mgr *manager.Manager) (*manager.FocusResult, error) {
+				return mgr.RemoveFocusPaths(args)
+			})
+		},
+	})
+
+	return focusCmd
+}
+
+// NewUnfocusCmd creates the unfocus command.
+func NewUnfocusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unfocus",
+		Short: "End focus mode",
+		Long: `End focus mode: files guarded by 'guard focus' get their original permissions
+back and files it registered are unregistered. Files that were guarded before
+focus mode started stay guarded.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runFocus(func(mgr *manager.Manager) (*manager.FocusResult, error) {
+				return mgr.Unfocus()
+			})
+		},
+	}
+}
+
+// runFocus loads the registry, runs a focus operation, saves and reports the result.
+func runFocus(operation func(mgr *manager.Manager) (*manager.FocusResult, error)) {
+	mgr := newManager()
+
+	// Load registry
+	if err := mgr.LoadRegistry(); err != nil {
+		fail(err)
+	}
+
+	result, err := operation(mgr)
+	if err != nil {
+		fail(err)
+	}
+
+	// Save registry
+	if err := mgr.SaveRegistry(); err != nil {
+		failf("Failed to save registry: %w", err)
+	}
+
+	if len(result.Guarded) > 0 {
+		fmt.Fprintf(stdout, "Guard enabled for %d file(s) outside the focus set\n", len(result.Guarded))
+	}
+	if len(result.Unguarded) > 0 {
+		fmt.Fprintf(stdout, "Guard disabled for %d file(s)\n", len(result.Unguarded))
+	}
+	if focus := mgr.GetFocus(); focus != nil {
+		fmt.Fprintf(stdout, "Focus: %s\n", strings.Join(focus.Paths, ", "))
+	} else {
+		fmt.Fprintln(stdout, "Focus mode ended")
+	}
+
+	printWarnings(mgr.GetWarnings())
+	printErrors(mgr.GetErrors())
+	if mgr.HasErrors() {
+		exitFailure(mgr.Failure())
+	}
+}
+
+// showFocus prints the active focus set.
+func showFocus() {
+	mgr := newManager()
+
+	// Load registry
+	if err := mgr.LoadRegistry(); err != nil {
+		fail(err)
+	}
+
+	focus := mgr.GetFocus()
+	if focus == nil {
+		fmt.Fprintln(stdout, "Focus mode is not active")
+		return
+	}
+	fmt.Fprintf(stdout, "Focus: %s\n", strings.Join(focus.Paths, ", "))
+	fmt.Fprintf(stdout, "%d file(s) guarded by focus mode\n", len(focus.Guarded))
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/florianbuetow/guard/internal/git"
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewGitCmd creates the git command that runs git with the guards it would trip over lifted.
+func NewGitCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "git <git-args>...",
+		Short: "Run git with the guards it needs lifted",
+		Long: `Run a git command that writes to the working tree (checkout, switch, pull,
+merge, rebase, reset --hard, ...) without failing halfway on guarded files.
+
+guard works out which guarded files git will write, gives only those their
+original permissions back, runs git and guards them again afterwards - also
+when git fails or is interrupted. The mode git leaves a file with becomes its
+stored original mode, and the content baselines are recorded anew.
+
+For pull, the remote is fetched first to see what will be merged. When the
+files cannot be predicted (stash, cherry-pick, rebase --continue, paths after
+--, ...), every guarded file is lifted. Read-only commands (status, log, diff,
+...) lift nothing.
+
+If guard itself is killed while git runs, the lifted files stay unguarded;
+'guard verify --repair' guards them again. If git leaves a .guardfile that
+cannot be loaded, e.g. with conflict markers, the files are guarded again as
+the .guardfile was before git, which is left unsaved, and guard exits with an
+error.
+
+git's exit code is passed on.
+
+Examples:
+  guard git checkout feature
+  guard git pull --rebase
+  guard git rebase main
+  guard git reset --hard HEAD~1`,
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				failf("No git command specified. Usage: guard git <git-args>...")
+			}
+
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			affected, err := mgr.GitAffectedFiles(args)
+			if err != nil {
+				fail(err)
+			}
+			lifted := mgr.LiftGuards(affected)
+			if len(lifted) > 0 {
+				fmt.Fprintf(stdout, "Lifted guard from %d file(s) for git\n", len(lifted))
+			}
+			// git hooks may run guard and change the registry while git runs
+			if err := mgr.SaveRegistry(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
+			}
+
+			// Outlive Ctrl-C and SIGTERM so the guards are applied again, and forward them to
+			// git: a signal sent to guard alone, like kill does, would not reach it otherwise
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			gitCmd := git.Command(".", args...)
+			gitCmd.Stdout = stdout
+			runErr := gitCmd.Start()
+			if runErr == nil {
+				done := make(chan struct{})
+				go func() {
+					for {
+						select {
+						case sig := <-signals:
+							_ = gitCmd.Process.Signal(sig)
+						case <-done:
+							return
+						}
+					}
+				}()
+				runErr = gitCmd.Wait()
+				close(done)
+			}
+			signal.Stop(signals)
+
+			// The lifted files are guarded again even if git left a .guardfile that cannot be
+			// loaded (a conflict, a pending journal); the registry from before git is used then
+			reloadErr := mgr.ReloadRegistry()
+			mgr.RestoreGuards(lifted)
+
+			// Save registry, unless it is the one from before git: the .guardfile git left
+			// is for the user to fix, not to overwrite
+			if reloadErr == nil {
+				if err := mgr.SaveRegistry(); err != nil {
+					failf("Failed to save registry: %w", err)
+				}
+			}
+
+			result := &gitResult{Lifted: lifted}
+			if lifted == nil {
+				result.Lifted = []string{}
+			}
+			var exitErr *exec.ExitError
+			if errors.As(runErr, &exitErr) {
+				result.GitExitCode = exitErr.ExitCode()
+			}
+			result.Files = mgr.GetFileResults()
+			render(result, result.print)
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+
+			if reloadErr != nil {
+				failf("Failed to load the registry after git, it was not saved: %w", reloadErr)
+			}
+
+			if exitErr != nil && exitErr.ExitCode() > 0 {
+				exit(exitErr.ExitCode())
+			}
+			if runErr != nil {
+				failf("Failed to run git: %w", runErr)
+			}
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+}
+
+// gitResult is the result of guard git.
+type gitResult struct {
+	Lifted      []string             `json:"lifted"`
+	GitExitCode int                  `json:"git_exit_code"`
+	Files       []manager.FileResult `json:"files,omitempty"`
+}
+
+// print prints the files guarded again after git as text.
+func (r *gitResult) print() {
+	if len(r.Lifted) > 0 {
+		fmt.Fprintf(stdout, "Guard re-applied to %d file(s)\n", len(r.Lifted))
+	}
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// gitSelectorFlags are the flags registered by addGitSelectorFlags
+var gitSelectorFlags = []string{"git-tracked", "git-untracked", "git-staged", "git-except-staged", "git-changed", "git-unchanged-since"}
+
+// addGitSelectorFlags registers the git selector flags on a command that takes files.
+func addGitSelectorFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.Bool("git-tracked", false, "Select all files tracked by git")
+	flags.Bool("git-untracked", false, "Select untracked files (without ignored files)")
+	flags.Bool("git-staged", false, "Select files with staged changes")
+	flags.Bool("git-except-staged", false, "Leave staged files out of the selection (all tracked files if used alone)")
+	flags.String("git-changed", "", "Select files changed by a commit or range, e.g. HEAD or main..HEAD")
+	flags.String("git-unchanged-since", "", "Select tracked files unchanged since a duration or date, e.g. 30d")
+}
+
+// hasGitSelector returns true if any git selector flag was given.
+func hasGitSelector(cmd *cobra.Command) bool {
+	for _, name := range gitSelectorFlags {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+// appendGitSelectedFiles runs git for the git selector flags and appends the selected files
+// that are not in files yet. With registeredOnly, unregistered files are left out.
+// Exits on error.
+func appendGitSelectedFiles(cmd *cobra.Command, mgr *manager.Manager, files []string, registeredOnly bool) []string {
+	if !hasGitSelector(cmd) {
+		return files
+	}
+
+	flags := cmd.Flags()
+	var sel manager.GitSelector
+	sel.Tracked, _ = flags.GetBool("git-tracked")
+	sel.Untracked, _ = flags.GetBool("git-untracked")
+	sel.Staged, _ = flags.GetBool("git-staged")
+	sel.ExceptStaged, _ = flags.GetBool("git-except-staged")
+	sel.Changed, _ = flags.GetString("git-changed")
+	if since, _ := flags.GetString("git-unchanged-since"); since != "" {
+		t, err := parseAuditTime(since)
+		if err != nil {
+			failf("Invalid --git-unchanged-since: %w", err)
+		}
+		sel.UnchangedSince = t
+	}
+
+	selected, err := mgr.SelectGitFiles(sel)
+	if err != nil {
+		fail(err)
+	}
+
+	seen := make(map[string]bool, len(files))
+	for _, path := range files {
+		if absPath, err := filepath.Abs(path); err == nil {
+			seen[absPath] = true
+		}
+	}
+	cwd, _ := os.Getwd()
+	added := 0
+	for _, path := range selected {
+		if seen[path] || (registeredOnly && !mgr.IsRegisteredFile(path)) {
+			continue
+		}
+		// Report selected files like paths given on the command line
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		files = append(files, path)
+		added++
+	}
+	if added == 0 {
+		fmt.Fprintln(stdout, "No files match the git selection")
+	}
+	return files
+}
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/florianbuetow/guard/internal/manager"
+)
+
+// guardResult is the result of enable, disable and toggle.
+type guardResult struct {
+	Retoggled   string               `json:"retoggled,omitempty"`
+	Registered  int                  `json:"registered"`
+	Enabled     int                  `json:"enabled"`
+	Disabled    int                  `json:"disabled"`
+	Skipped     int                  `json:"skipped"`
+	Changed     []guardChange        `json:"changed"`
+	Folders     []string             `json:"folders,omitempty"`
+	Collections []guardCollection    `json:"collections,omitempty"`
+	Files       []manager.FileResult `json:"files,omitempty"`
+}
+
+// guardChange is a file whose guard state changed.
+type guardChange struct {
+	Path  string `json:"path"`
+	Guard bool   `json:"guard"`
+}
+
+// guardCollection is a collection of the command with its files on disk (sorted)
+// and the registered files missing on disk.
+type guardCollection struct {
+	Name    string   `json:"name"`
+	Guard   bool     `json:"guard"`
+	Files   []string `json:"files"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// fileState is the registration and guard state of a file before an operation.
+type fileState struct {
+	registered bool
+	guard      bool
+}
+
+// newGuardResult returns an empty result.
+func newGuardResult() *guardResult {
+	return &guardResult{Changed: []guardChange{}}
+}
+
+// snapshotFiles returns the state of the files before an operation.
+func snapshotFiles(mgr *manager.Manager, files []string) map[string]fileState {
+	states := make(map[string]fileState, len(files))
+	for _, path := range files {
+		state := fileState{registered: mgr.IsRegisteredFile(path)}
+		if state.registered {
+			state.guard, _ = mgr.GetRegistry().GetRegisteredFileGuard(path)
+		}
+		states[path] = state
+	}
+	return states
+}
+
+// addFiles counts the files of an operation from their state before it: newly
+// registered, enabled and disabled files, and the files skip reports as already
+// in the target state.
+func (r *guardResult) addFiles(mgr *manager.Manager, files []string, before map[string]fileState, skip func(fileState) bool) {
+	for _, path := range files {
+		prev := before[path]
+		if skip != nil && skip(prev) {
+			r.Skipped++
+		}
+		if !mgr.IsRegisteredFile(path) {
+			continue
+		}
+		if !prev.registered {
+			r.Registered++
+		}
+		guard, err := mgr.GetRegistry().GetRegisteredFileGuard(path)
+		if err != nil || guard == prev.guard {
+			continue
+		}
+		r.Changed = append(r.Changed, guardChange{Path: path, Guard: guard})
+		if guard {
+			r.Enabled++
+		} else {
+			r.Disabled++
+		}
+	}
+}
+
+// addCollections records the registered collections among names with their files.
+// Collections without files are only recorded with withEmpty.
+func (r *guardResult) addCollections(mgr *manager.Manager, names []string, withEmpty bool) {
+	for _, name := range names {
+		if !mgr.GetRegistry().IsRegisteredCollection(name) {
+			continue
+		}
+		files, err := mgr.GetRegistry().GetRegisteredCollectionFiles(name)
+		if err != nil {
+			files = nil
+		}
+		if len(files) == 0 && !withEmpty {
+			continue
+		}
+		coll := guardCollection{Name: name}
+		coll.Guard, _ = mgr.GetRegistry().GetRegisteredCollectionGuard(name)
+		coll.Files, coll.Missing = mgr.GetFileSystem().CheckFilesExist(files)
+		if coll.Files == nil {
+			coll.Files = []string{}
+		}
+		sort.Strings(coll.Files)
+		r.Collections = append(r.Collections, coll)
+	}
+}
+
+// guardWord returns "enabled" or "disabled" for a guard state.
+func guardWord(guard bool) string {
+	if guard {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// printCounts prints the file and folder counts of enable or disable as text.
+// The registered count is only printed with registered.
+func (r *guardResult) printCounts(guard, registered bool) {
+	if registered && r.Registered > 0 {
+		fmt.Fprintf(stdout, "Registered %d file(s)\n", r.Registered)
+	}
+	changed := r.Disabled
+	if guard {
+		changed = r.Enabled
+	}
+	if changed > 0 {
+		fmt.Fprintf(stdout, "Guard %s for %d file(s)\n", guardWord(guard), changed)
+	}
+	if r.Skipped > 0 {
+		fmt.Fprintf(stdout, "Skipped %d file(s) already %s\n", r.Skipped, guardWord(guard))
+	}
+	if len(r.Folders) > 0 {
+		fmt.Fprintf(stdout, "Guard %s for %d folder(s)\n", guardWord(guard), len(r.Folders))
+	}
+}
+
+// printCollections prints the files on disk of the collections, then the collections.
+// With header, the files of each collection follow a toggle header.
+func (r *guardResult) printCollections(header bool) {
+	for _, coll := range r.Collections {
+		if len(coll.Files)+len(coll.Missing) == 0 {
+			continue
+		}
+		if header {
+			fmt.Fprintf(stdout, "toggling guarded state for files in collection: %s\n", coll.Name)
+		}
+		for _, file := range coll.Files {
+			fmt.Fprintf(stdout, "Guard %s for %s\n", guardWord(coll.Guard), file)
+		}
+	}
+	fmt.Fprintln(stdout)
+	for _, coll := range r.Collections {
+		fmt.Fprintf(stdout, "Guard %s for collection %s\n", guardWord(coll.Guard), coll.Name)
+	}
+}
+
+// printToggled prints the re-toggled item, the registered count and every toggled file.
+func (r *guardResult) printToggled() {
+	if r.Retoggled != "" {
+		fmt.Fprintf(stdout, "Re-toggling %s\n", r.Retoggled)
+	}
+	if r.Registered > 0 {
+		fmt.Fprintf(stdout, "Registered %d file(s)\n", r.Registered)
+	}
+	for _, change := range r.Changed {
+		fmt.Fprintf(stdout, "Guard %s for %s\n", guardWord(change.Guard), change.Path)
+	}
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewHistoryCmd creates the history command.
+// Lists the recorded operations that undo and redo work on.
+func NewHistoryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history",
+		Short: "Show the operation history",
+		Long: `Show the recorded operations, oldest first.
+
+Every command that changes the guard state of files, collections or folders
+records an entry in .guard/history.yaml: which items changed, their previous
+guard state and when. 'guard undo' and 'guard redo' move through this list.
+The current position is marked with '>'; entries below it were undone and
+can be redone. The last 100 operations are kept.
+
+Examples:
+  guard history`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			history, err := mgr.ReadHistory()
+			if err != nil {
+				fail(err)
+			}
+			if len(history.Entries) == 0 {
+				fmt.Fprintln(stdout, "No operations recorded")
+				return
+			}
+
+			for i, entry := range history.Entries {
+				marker := " "
+				if i == history.Position-1 {
+					marker = ">"
+				}
+				state := ""
+				if i >= history.Position {
+					state = " (undone)"
+				}
+				fmt.Fprintf(stdout, "%s %3d  %s  %s%s\n", marker, i+1, entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Summary(), state)
+			}
+		},
+	}
+}
+
+// NewUndoCmd creates the undo command.
+func NewUndoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo",
+		Short: "Undo the last operation",
+		Long: `Undo the last recorded operation by returning every file, collection and
+folder it changed to its previous guard state.
+
+Examples:
+  guard undo   # Undo the last operation
+  guard redo   # Re-apply it`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runHistoryStep(true)
+		},
+	}
+}
+
+// NewRedoCmd creates the redo command.
+func NewRedoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo",
+		Short: "Redo the last undone operation",
+		Long: `Re-apply the operation undone last.
+
+Any new operation after an undo discards the operations that could be redone.
+
+Examples:
+  guard redo`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runHistoryStep(false)
+		},
+	}
+}
+
+// runHistoryStep performs an undo or redo and prints the outcome.
+func runHistoryStep(undo bool) {
+	mgr := newManager()
+
+	// Load registry
+	if err := mgr.LoadRegistry(); err != nil {
+		fail(err)
+	}
+
+	var entry *manager.HistoryEntry
+	var err error
+	action := "Undid"
+	if undo {
+		entry, err = mgr.Undo()
+	} else {
+		action = "Redid"
+		entry, err = mgr.Redo()
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Fprintf(stdout, "%s: %s\n", action, entry.Summary())
+
+	// Print warnings
+	printWarnings(mgr.GetWarnings())
+
+	// Print errors
+	printErrors(mgr.GetErrors())
+
+	// Exit with error code if there were errors
+	if mgr.HasErrors() {
+		exitFailure(mgr.Failure())
+	}
+}
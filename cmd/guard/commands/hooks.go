@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewHooksCmd creates the hooks command with install and uninstall subcommands.
+func NewHooksCmd() *cobra.Command {
+	hooksCmd := &cobra.Command{
+		Use:   "hooks {install|uninstall}",
+		Short: "Install git hooks that run guard",
+		Long: `Install git hooks that apply the branch profile (see 'guard branch-profile')
+whenever git checks out a branch or merges: post-checkout and post-merge.
+
+With --pre-commit, install a hook that runs 'guard check-staged' before every
+commit instead, rejecting commits that touch guarded files, folders or
+collections unless the message carries a "Guard-Override: <reason>" trailer.
+It is installed as the commit-msg hook, the first hook that sees the message.
+'git commit --no-verify' skips it like any other hook.
+
+The hooks change to the .guardfile directory and run the guard executable that
+installed them. If it was moved or removed, the hooks fail with an error, so
+reinstall them after moving guard. Existing hooks not installed by guard are
+only replaced with --force.
+
+Examples:
+  guard hooks install
+  guard hooks install --pre-commit
+  guard hooks uninstall`,
+	}
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the git hooks",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			commitCheck, _ := cmd.Flags().GetBool("pre-commit")
+			force, _ := cmd.Flags().GetBool("force")
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			executable, err := os.Executable()
+			if err != nil {
+				failf("Failed to locate the guard executable: %w", err)
+			}
+			installed, err := mgr.InstallHooks(executable, commitCheck, force)
+			for _, path := range installed {
+				fmt.Fprintf(stdout, "Installed git hook %s\n", path)
+			}
+			if err != nil {
+				fail(err)
+			}
+		},
+	}
+	installCmd.Flags().Bool("pre-commit", false, "Install the hook that rejects commits touching guarded paths")
+	installCmd.Flags().Bool("force", false, "Replace existing hooks not installed by guard")
+	hooksCmd.AddCommand(installCmd)
+
+	hooksCmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the git hooks installed by guard",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			removed, err := mgr.UninstallHooks()
+			for _, path := range removed {
+				fmt.Fprintf(stdout, "Removed git hook %s\n", path)
+			}
+			if err != nil {
+				fail(err)
+			}
+			if len(removed) == 0 {
+				fmt.Fprintln(stdout, "No git hooks installed by guard")
+			}
+		},
+	})
+
+	return hooksCmd
+}
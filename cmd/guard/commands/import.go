@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewImportCmd creates the import command with its codeowners subcommand.
+func NewImportCmd() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import codeowners",
+		Short: "Import collections from other tools",
+	}
+
+	codeownersCmd := &cobra.Command{
+		Use:   "codeowners",
+		Short: "Create a collection per CODEOWNERS owner",
+		Long: `Turn the rules of the CODEOWNERS file (.github/CODEOWNERS, CODEOWNERS or
+docs/CODEOWNERS next to the .guardfile) into one pattern collection per owner,
+named "codeowners-<owner>": @org/platform becomes codeowners-org-platform.
+
+A pattern collection holds the CODEOWNERS patterns instead of a fixed file
+list. As in CODEOWNERS the last matching rule owns a file, so later rules for
+other owners are kept as "!" patterns. The matching files are registered and
+added; 'guard enable' and 'guard toggle' pick up files created since.
+'guard show collection' lists the rule lines each collection came from.
+
+Run it again after CODEOWNERS changed to update the patterns and members.
+Files that no longer match leave the collection but keep their guard state.
+Collections of owners removed from CODEOWNERS are removed the same way.
+With --team only that owner's collection is imported or updated.
+
+New collections are not guarded: enable them with 'guard enable collection'.
+
+Examples:
+  guard import codeowners
+  guard import codeowners --team @org/platform
+  guard enable collection codeowners-org-platform`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			team, _ := cmd.Flags().GetString("team")
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			result, err := mgr.ImportCodeowners(team)
+			if err != nil {
+				fail(err)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				fail(err)
+			}
+
+			printCodeownersImport(result)
+
+			// Print warnings and errors
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+	codeownersCmd.Flags().String("team", "", "Import only the rules of this owner, e.g. @org/platform")
+	importCmd.AddCommand(codeownersCmd)
+
+	return importCmd
+}
+
+// printCodeownersImport prints the collections an import changed and their member changes.
+func printCodeownersImport(result *manager.CodeownersImport) {
+	fmt.Fprintf(stdout, "Imported %s\n", result.File)
+	for _, group := range []struct {
+		verb  string
+		names []string
+	}{{"Created", result.Created}, {"Updated", result.Updated}} {
+		for _, name := range group.names {
+			synced := result.Synced[name]
+			if synced == nil {
+				continue
+			}
+			fmt.Fprintf(stdout, "%s collection %s: %d added, %d removed\n", group.verb, name, len(synced.Added), len(synced.Removed))
+			for _, path := range synced.Added {
+				fmt.Fprintf(stdout, "  + %s\n", path)
+			}
+			for _, path := range synced.Removed {
+				fmt.Fprintf(stdout, "  - %s\n", path)
+			}
+		}
+	}
+	for _, name := range result.Removed {
+		fmt.Fprintf(stdout, "Removed collection %s: its owner is no longer in CODEOWNERS\n", name)
+	}
+}
@@ -14,13 +14,13 @@ func NewInfoCmd() *cobra.Command {
 		Short: "Display information about guard",
 		Long:  "Display about text with author and source information.",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Guard - File Permission Management Tool")
-			fmt.Println()
-			fmt.Println("Created by Florian Buetow")
-			fmt.Println("Source code available at github.com/florianbuetow/guard")
-			fmt.Println()
-			fmt.Println("Guard helps protect your files from accidental modifications")
-			fmt.Println("by managing file permissions, ownership, and group settings.")
+			fmt.Fprintln(stdout, "Guard - File Permission Management Tool")
+			fmt.Fprintln(stdout)
+			fmt.Fprintln(stdout, "Created by Florian Buetow")
+			fmt.Fprintln(stdout, "Source code available at github.com/florianbuetow/guard")
+			fmt.Fprintln(stdout)
+			fmt.Fprintln(stdout, "Guard helps protect your files from accidental modifications")
+			fmt.Fprintln(stdout, "by managing file permissions, ownership, and group settings.")
 		},
 	}
 }
@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -35,14 +34,12 @@ Examples:
 		Run: func(cmd *cobra.Command, args []string) {
 			// Per Requirement 1.1: guard init without arguments should error
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No arguments provided. Usage: guard init <mode> [owner] [group]")
-				os.Exit(1)
+				failf("No arguments provided. Usage: guard init <mode> [owner] [group]")
 			}
 
 			// Check if .guardfile already exists before prompting for parameters
 			if _, err := os.Stat(".guardfile"); err == nil {
-				fmt.Fprintln(os.Stderr, "Error: .guardfile already exists. Use 'guard config set' to modify settings.")
-				os.Exit(1)
+				failf(".guardfile already exists. Use 'guard config set' to modify settings.")
 			}
 
 			var mode, owner, group string
@@ -60,8 +57,7 @@ Examples:
 
 			// Validate mode early (before prompting for other values)
 			if mode != "" && !isValidOctalMode(mode) {
-				fmt.Fprintf(os.Stderr, "Error: Invalid mode '%s'. Mode must be an octal number between 000 and 777.\n", mode)
-				os.Exit(1)
+				failf("Invalid mode '%s'. Mode must be an octal number between 000 and 777.", mode)
 			}
 
 			// Prompt for missing parameters (Requirement 1.2)
@@ -70,7 +66,7 @@ Examples:
 
 			// Prompt for mode if not provided
 			if mode == "" {
-				fmt.Print("Enter guard mode (000-777) [0644]: ")
+				fmt.Fprint(stdout, "Enter guard mode (000-777) [0644]: ")
 				input, _ := reader.ReadString('\n')
 				input = strings.TrimSpace(input)
 				if input == "" {
@@ -88,14 +84,14 @@ Examples:
 					defaultOwner = currentUser
 				}
 
-				fmt.Print("No owner specified. Use current user's owner? [Y/n]: ")
+				fmt.Fprint(stdout, "No owner specified. Use current user's owner? [Y/n]: ")
 				input, _ := reader.ReadString('\n')
 				input = strings.TrimSpace(input)
 				if input == "" || strings.ToLower(input) == "y" {
 					owner = defaultOwner
 				} else {
 					// User said 'n', prompt for custom value
-					fmt.Print("Enter owner: ")
+					fmt.Fprint(stdout, "Enter owner: ")
 					customInput, _ := reader.ReadString('\n')
 					owner = strings.TrimSpace(customInput)
 				}
@@ -105,32 +101,31 @@ Examples:
 			if group == "" {
 				defaultGroup := "wheel"
 
-				fmt.Print("No group specified. Use current user's group? [Y/n]: ")
+				fmt.Fprint(stdout, "No group specified. Use current user's group? [Y/n]: ")
 				input, _ := reader.ReadString('\n')
 				input = strings.TrimSpace(input)
 				if input == "" || strings.ToLower(input) == "y" {
 					group = defaultGroup
 				} else {
 					// User said 'n', prompt for custom value
-					fmt.Print("Enter group: ")
+					fmt.Fprint(stdout, "Enter group: ")
 					customInput, _ := reader.ReadString('\n')
 					group = strings.TrimSpace(customInput)
 				}
 			}
 
 			// Create manager
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Initialize registry
 			if err := mgr.InitializeRegistry(mode, owner, group, false); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
-			fmt.Println("Initialized .guardfile with:")
-			fmt.Printf("  Mode:  %s\n", mode)
-			fmt.Printf("  Owner: %s\n", owner)
-			fmt.Printf("  Group: %s\n", group)
+			fmt.Fprintln(stdout, "Initialized .guardfile with:")
+			fmt.Fprintf(stdout, "  Mode:  %s\n", mode)
+			fmt.Fprintf(stdout, "  Owner: %s\n", owner)
+			fmt.Fprintf(stdout, "  Group: %s\n", group)
 		},
 	}
 }
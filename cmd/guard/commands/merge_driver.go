@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewMergeDriverCmd creates the merge-driver command git runs to merge .guardfile changes.
+func NewMergeDriverCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:         "merge-driver <base> <ours> <theirs>",
+		Annotations: map[string]string{dryRunUnsupported: ""},
+		Short:       "Merge .guardfile changes as a git merge driver",
+		Long: `Merge two .guardfile revisions with their common base entry by entry instead
+of line by line, and write the result to <ours>. git calls it with %O %A %B.
+
+Files, collections, folders and branch profiles registered on either side are
+kept. A field conflicts only when both sides changed it to different values,
+an entry only when one side removed it and the other changed it. Conflicts
+keep our value, are listed and make the merge fail, so git marks the
+.guardfile as conflicted. The file stays valid YAML: resolve the listed
+conflicts with guard commands, then 'git add .guardfile'.
+
+Merging changes only the .guardfile. Run 'guard verify --repair' afterwards
+to apply merged guard states to the files on disk.
+
+Setup:
+  git config merge.guard.name "guard registry merge"
+  git config merge.guard.driver "guard merge-driver %O %A %B"
+  echo ".guardfile merge=guard" >> .gitattributes
+
+Output format: ! <section> <name>: <field> base <value>, ours <value>, theirs <value>`,
+		Args: cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			conflicts, err := manager.MergeRegistryFiles(args[0], args[1], args[2])
+			if err != nil {
+				fail(err)
+			}
+			if len(conflicts) == 0 {
+				return
+			}
+
+			fmt.Fprintf(os.Stderr, "Error: .guardfile merge has %d conflict(s), kept our values:\n", len(conflicts))
+			for _, conflict := range conflicts {
+				fmt.Fprintf(os.Stderr, "  %s\n", conflict)
+			}
+			exit(exitConflict)
+		},
+	}
+}
@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// outputFormat is the value of the global --output flag: text, json or yaml
+	outputFormat = "text"
+	// outputCommand is the path of the running command, e.g. "guard enable"
+	outputCommand string
+	// outputResult is the structured result of the running command, set with render
+	outputResult any
+	// stdout receives the text output of the command: os.Stdout, or os.Stderr while
+	// stdout is kept for a JSON or YAML document (see redirectStdout)
+	stdout io.Writer = os.Stdout
+	// outputDone is set once the structured output was printed
+	outputDone bool
+	// managers are the managers of the running command (see newManager)
+	managers []*manager.Manager
+)
+
+// commandOutput is the document --output json and --output yaml print for every command.
+type commandOutput struct {
+	Command  string               `json:"command"`
+	OK       bool                 `json:"ok"`
+	ExitCode int                  `json:"exit_code"`
+	Error    string               `json:"error,omitempty"`
+	Kind     manager.ErrorKind    `json:"error_kind,omitempty"`
+	Items    []string             `json:"error_items,omitempty"`
+	Result   any                  `json:"result,omitempty"`
+	Files    []manager.FileResult `json:"files,omitempty"`
+	Warnings []manager.Warning    `json:"warnings"`
+	Errors   []string             `json:"errors"`
+	DryRun   *manager.DryRunPlan  `json:"dry_run,omitempty"`
+}
+
+// AddOutputFlag adds the global --output flag. With json or yaml, every command prints one
+// document to stdout when it ends: its result, per-file outcomes, warnings and errors.
+// The text a command prints along the way goes to stderr.
+func AddOutputFlag(root *cobra.Command) {
+	root.PersistentFlags().StringVar(&outputFormat, "output", "text", "Output format: text, json or yaml")
+
+	preRun, postRun := root.PersistentPreRunE, root.PersistentPostRun
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		switch outputFormat {
+		case "text":
+		case "json", "yaml":
+			outputCommand = cmd.CommandPath()
+			redirectStdout()
+		default:
+			return fmt.Errorf("invalid --output format '%s': use text, json or yaml", outputFormat)
+		}
+		if preRun != nil {
+			return preRun(cmd, args)
+		}
+		return nil
+	}
+	root.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		if postRun != nil {
+			postRun(cmd, args)
+		}
+		if structuredOutput() {
+			printOutput(exitOK, nil)
+		}
+	}
+}
+
+// structuredOutput returns true if the command prints a JSON or YAML document.
+func structuredOutput() bool {
+	return outputFormat == "json" || outputFormat == "yaml"
+}
+
+// redirectStdout sends the text output of the command to stderr, keeping stdout for a document
+func redirectStdout() {
+	stdout = os.Stderr
+}
+
+// render sets the result of the command for --output json|yaml, or prints it as text.
+func render(result any, text func()) {
+	if structuredOutput() {
+		outputResult = result
+		return
+	}
+	text()
+}
+
+// printWarnings prints the aggregated warnings of a command as text.
+// Structured output lists them in its document instead.
+func printWarnings(warnings []manager.Warning) {
+	if structuredOutput() {
+		return
+	}
+	for _, msg := range manager.AggregateWarnings(warnings) {
+		if msg != "" {
+			fmt.Fprintln(stdout, msg)
+		}
+	}
+}
+
+// printErrors prints the errors of a command as text.
+// Structured output lists them in its document instead.
+func printErrors(errors []string) {
+	if structuredOutput() {
+		return
+	}
+	for _, err := range errors {
+		if err != "" {
+			fmt.Fprintln(stdout, err)
+		}
+	}
+}
+
+// fail prints the error and exits with the exit code of its kind.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	exitFailure(err)
+}
+
+// exitFailure exits with the exit code of an error that was already printed,
+// e.g. the Failure of a manager whose errors were printed with printErrors.
+func exitFailure(err error) {
+	code := exitCode(err)
+	if structuredOutput() {
+		printOutput(code, err)
+	}
+	os.Exit(code)
+}
+
+// ExitOnError ends a command cobra rejected, e.g. for invalid arguments.
+func ExitOnError(cmd *cobra.Command, err error) {
+	fmt.Fprintln(os.Stderr, err)
+	if structuredOutput() {
+		if outputCommand == "" {
+			outputCommand = cmd.CommandPath()
+		}
+		printOutput(exitError, err)
+	}
+	os.Exit(exitError)
+}
+
+// failf formats an error like fmt.Errorf, prints it and exits with code 1.
+func failf(format string, args ...any) {
+	fail(fmt.Errorf(format, args...))
+}
+
+// exit ends the command with the given exit code, printing the document of structured output first.
+func exit(code int) {
+	if structuredOutput() {
+		printOutput(code, nil)
+	}
+	os.Exit(code)
+}
+
+// printOutput prints the document of the command once, merging the reports of all its managers
+func printOutput(code int, err error) {
+	if outputDone {
+		return
+	}
+	outputDone = true
+
+	out := commandOutput{
+		Command:  outputCommand,
+		OK:       code == exitOK,
+		ExitCode: code,
+		Result:   outputResult,
+		Warnings: []manager.Warning{},
+		Errors:   []string{},
+	}
+	if err != nil {
+		out.Error = err.Error()
+		out.Kind = manager.KindOf(err)
+		out.Items = manager.ItemsOf(err)
+	}
+	var warnings []manager.Warning
+	for _, mgr := range managers {
+		warnings = append(warnings, mgr.GetWarnings()...)
+		for _, msg := range mgr.GetErrors() {
+			out.Errors = append(out.Errors, strings.TrimPrefix(msg, "Error: "))
+		}
+		out.Files = append(out.Files, mgr.GetFileResults()...)
+		if mgr.DryRun() {
+			plan, err := mgr.DryRunPlan()
+			if err != nil {
+				out.Errors = append(out.Errors, err.Error())
+				continue
+			}
+			if out.DryRun == nil {
+				out.DryRun = plan
+			} else {
+				out.DryRun.Operations = append(out.DryRun.Operations, plan.Operations...)
+				out.DryRun.Registry = append(out.DryRun.Registry, plan.Registry...)
+			}
+		}
+	}
+	out.Warnings = append(out.Warnings, manager.GroupWarnings(warnings)...)
+
+	data, err := marshalOutput(out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	fmt.Fprint(os.Stdout, string(data))
+}
+
+// marshalOutput renders v in the --output format. YAML is converted from the JSON
+// rendering, so both use the same field names and order.
+func marshalOutput(v any) ([]byte, error) {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	data := out.Bytes()
+	if outputFormat != "yaml" {
+		return data, nil
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	var buf bytes.Buffer
+	yamlEnc := yaml.NewEncoder(&buf)
+	yamlEnc.SetIndent(2)
+	if err := yamlEnc.Encode(&node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), yamlEnc.Close()
+}
+
+// blockStyle drops the JSON flow style and quoting, leaving YAML to quote where needed
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewPromptCmd creates the prompt command.
+// Prints a one-line summary for shell prompts from the status index.
+func NewPromptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "prompt",
+		Short: "Print a short guard summary for shell prompts",
+		Long: `Print a short summary of the registered files for shell prompts:
+
+  G:<guarded> ~:<unguarded> drift:<drifted>
+
+The counts come from .guard/status.yaml, which 'guard status' and 'guard prompt'
+refresh. It is used while the .guardfile is unchanged and for at most a minute,
+so the prompt does not look at every file. Users who cannot write .guard/, e.g.
+when the project is guarded with sudo, keep their own copy in their cache
+directory (~/.cache/guard on Linux). The prompt never changes a file or the
+.guardfile. Outside a guard project, prompt prints nothing and exits with code 3.
+
+Examples:
+  guard prompt
+  PS1='$(guard prompt 2>/dev/null) \$ '`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			summary, err := mgr.StatusSummary()
+			if manager.KindOf(err) == manager.ErrorNotInitialized {
+				// Not a guard project: nothing to show in the prompt
+				exitFailure(err)
+			}
+			if err != nil {
+				fail(err)
+			}
+
+			render(summary, func() {
+				fmt.Fprintf(stdout, "G:%d ~:%d drift:%d\n", summary.Guarded, summary.Unguarded, summary.Drift)
+			})
+		},
+	}
+}
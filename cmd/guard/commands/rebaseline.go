@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewRebaselineCmd creates the rebaseline command.
+// Accepts the current content of guarded files as their new content baseline.
+func NewRebaselineCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rebaseline <files>...",
+		Short:             "Accept the current content of guarded files",
+		ValidArgsFunction: completeNames(completeFiles),
+		Long: `Accept the current content of guarded files as their new baseline.
+
+When a file is guarded, guard records the SHA-256 and size of its content.
+'guard verify --content' and 'guard show file' report files whose content
+changed since. After reviewing an intended change, rebaseline records the
+current content so the file is no longer reported.
+
+Examples:
+  guard rebaseline secrets.env
+  guard rebaseline config/*.yaml`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			rebaselined, err := mgr.Rebaseline(args)
+			if err != nil {
+				fail(err)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				failf("Failed to save registry: %w", err)
+			}
+
+			if len(rebaselined) > 0 {
+				fmt.Fprintf(stdout, "Rebaselined %d file(s)\n", len(rebaselined))
+			}
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewRecoverCmd creates the recover command.
+// Finishes or rolls back an operation that was interrupted during its filesystem phase.
+func NewRecoverCmd() *cobra.Command {
+	var rollback bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "recover [--rollback [file]...]",
+		Short: "Finish or roll back an interrupted operation",
+		Long: `Finish or roll back an operation that was interrupted while changing file permissions.
+
+Before guard changes any permissions it writes an intent journal to
+.guard/journal.yaml and removes it once the .guardfile records the outcome.
+If guard is killed, runs out of memory or the machine loses power in between,
+the journal stays behind and all other commands refuse to run (exit code 10)
+until it has been recovered.
+
+By default every file is brought to the state the operation intended.
+With --rollback every file is returned to the state it had before. With
+--rollback and a list of files, only those files are rolled back and all
+other files are finished.
+
+Examples:
+  guard recover                       # Finish the interrupted operation
+  guard recover --rollback            # Undo the interrupted operation
+  guard recover --rollback a.txt b.go # Undo for a.txt and b.go, finish the rest`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 && !rollback {
+				failf("files can only be given together with --rollback")
+			}
+
+			mgr := newManager()
+
+			// Load registry (the pending journal is expected here)
+			if err := mgr.LoadRegistryForRecovery(); err != nil {
+				fail(err)
+			}
+
+			journal, err := mgr.ReadJournal()
+			if err != nil {
+				fail(err)
+			}
+			result := &recoverResult{Entries: []recoverEntry{}}
+			if journal == nil {
+				render(result, result.print)
+				return
+			}
+
+			result.Operation, result.Started = journal.Operation, &journal.Started
+			for _, entry := range journal.Entries {
+				result.Entries = append(result.Entries, recoverEntry{
+					Path:  entry.Path,
+					State: mgr.JournalEntryState(entry),
+					Guard: entry.Guard,
+				})
+			}
+			result.printJournal()
+
+			action := "Finish"
+			if rollback && len(args) == 0 {
+				action = "Roll back"
+			} else if rollback {
+				action = fmt.Sprintf("Roll back %d file(s) and finish", len(args))
+			}
+			if !yes && !confirmPrompt(bufio.NewReader(os.Stdin), action+" the operation?") {
+				render(result, result.print)
+				return
+			}
+
+			result.Recovered, err = mgr.Recover(rollback && len(args) == 0, args)
+			if err != nil {
+				fail(err)
+			}
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				fmt.Fprintln(stdout, "The intent journal was kept. Fix the errors above and run 'guard recover' again.")
+				exitFailure(mgr.Failure())
+			}
+
+			result.Files = mgr.GetFileResults()
+			render(result, result.print)
+		},
+	}
+
+	cmd.Flags().BoolVar(&rollback, "rollback", false, "Return files to their state before the operation")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not prompt for confirmation")
+
+	return cmd
+}
+
+// recoverResult is the result of recover.
+type recoverResult struct {
+	Operation string                 `json:"operation,omitempty"`
+	Started   *time.Time             `json:"started,omitempty"`
+	Entries   []recoverEntry         `json:"entries"`
+	Recovered *manager.RecoverResult `json:"recovered,omitempty"`
+	Files     []manager.FileResult   `json:"files,omitempty"`
+}
+
+// recoverEntry is a file of the interrupted operation with how far its change got on disk.
+type recoverEntry struct {
+	Path  string                     `json:"path"`
+	State manager.JournalEntryStatus `json:"state"`
+	Guard bool                       `json:"guard"`
+}
+
+// printJournal prints the interrupted operation and its files as text, before recovering is confirmed.
+func (r *recoverResult) printJournal() {
+	fmt.Fprintf(stdout, "Interrupted '%s' operation from %s:\n", r.Operation, r.Started.Local().Format("2006-01-02 15:04:05"))
+	for _, entry := range r.Entries {
+		target := "unguard"
+		if entry.Guard {
+			target = "guard"
+		}
+		fmt.Fprintf(stdout, "  %-8s %-7s %s\n", entry.State, target, entry.Path)
+	}
+}
+
+// print prints the outcome of recover as text.
+func (r *recoverResult) print() {
+	switch {
+	case r.Started == nil:
+		fmt.Fprintln(stdout, "No interrupted operation found")
+	case r.Recovered == nil:
+		fmt.Fprintln(stdout, "Aborted, nothing was changed")
+	default:
+		fmt.Fprintln(stdout, "Recovery complete:")
+		if len(r.Recovered.Finished) > 0 {
+			fmt.Fprintf(stdout, "  Finished %d file(s)\n", len(r.Recovered.Finished))
+		}
+		if len(r.Recovered.RolledBack) > 0 {
+			fmt.Fprintf(stdout, "  Rolled back %d file(s)\n", len(r.Recovered.RolledBack))
+		}
+	}
+}
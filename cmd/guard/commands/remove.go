@@ -2,9 +2,7 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -14,9 +12,10 @@ import (
 // For removing files from collections, use 'guard update <collection> remove <files>...'
 func NewRemoveCmd() *cobra.Command {
 	removeCmd := &cobra.Command{
-		Use:   "remove [file] <paths>...",
-		Short: "Remove files from the registry",
-		Args:  cobra.ArbitraryArgs,
+		Use:               "remove [file] <paths>...",
+		Short:             "Remove files from the registry",
+		ValidArgsFunction: completeNames(completeFiles),
+		Args:              cobra.ArbitraryArgs,
 		Long: `Remove files from the registry.
 
 The 'file' keyword is optional. Both of these work:
@@ -33,8 +32,7 @@ To remove files from collections, use: guard update <collection> remove <files>.
 		Run: func(cmd *cobra.Command, args []string) {
 			// When called without subcommand, treat args as files
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files specified. Usage: guard remove <path>...")
-				os.Exit(1)
+				failf("No files specified. Usage: guard remove <path>...")
 			}
 
 			removeFiles(args)
@@ -49,12 +47,11 @@ To remove files from collections, use: guard update <collection> remove <files>.
 
 // removeFiles is the shared implementation for removing files.
 func removeFiles(args []string) {
-	mgr := manager.NewManager(".guardfile")
+	mgr := newManager()
 
 	// Load registry
 	if err := mgr.LoadRegistry(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fail(err)
 	}
 
 	// Count files in registry before removal
@@ -70,35 +67,33 @@ func removeFiles(args []string) {
 
 	// Remove files
 	if err := mgr.RemoveFiles(args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fail(err)
 	}
 
 	// Save registry
 	if err := mgr.SaveRegistry(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-		os.Exit(1)
+		failf("Failed to save registry: %w", err)
 	}
 
 	// Print success message
 	if inRegistry > 0 {
-		fmt.Printf("Removed %d file(s)\n", inRegistry)
+		fmt.Fprintf(stdout, "Removed %d file(s)\n", inRegistry)
 	}
 
 	// Print skipped count
 	if notInRegistry > 0 {
-		fmt.Printf("Skipped %d file(s) not in registry\n", notInRegistry)
+		fmt.Fprintf(stdout, "Skipped %d file(s) not in registry\n", notInRegistry)
 	}
 
 	// Print warnings
-	manager.PrintWarnings(mgr.GetWarnings())
+	printWarnings(mgr.GetWarnings())
 
 	// Print errors
-	manager.PrintErrors(mgr.GetErrors())
+	printErrors(mgr.GetErrors())
 
 	// Exit with error code if there were errors
 	if mgr.HasErrors() {
-		os.Exit(1)
+		exitFailure(mgr.Failure())
 	}
 }
 
@@ -106,8 +101,9 @@ func removeFiles(args []string) {
 // This is kept for backward compatibility with explicit 'file' keyword.
 func newRemoveFileCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "file <paths>...",
-		Short: "Remove files from the registry",
+		Use:               "file <paths>...",
+		Short:             "Remove files from the registry",
+		ValidArgsFunction: completeNames(completeFiles),
 		Long: `Remove files from the registry.
 
 Example:
@@ -116,8 +112,7 @@ Example:
 To remove files from collections, use: guard update <collection> remove <files>...`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files specified. Usage: guard remove file <path>...")
-				os.Exit(1)
+				failf("No files specified. Usage: guard remove file <path>...")
 			}
 
 			removeFiles(args)
@@ -2,9 +2,7 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -20,44 +18,44 @@ This restores original permissions for all files but keeps them in the registry.
 Files that don't exist on disk will generate warnings. Run cleanup afterwards
 to remove orphaned entries.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Run reset
 			result, err := mgr.Reset()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 
 			// Print success output per CLI-INTERFACE-SPECS.md
-			fmt.Println("Reset complete:")
-			if result.FilesDisabled > 0 || result.CollectionsDisabled > 0 {
-				if result.FilesDisabled > 0 {
-					fmt.Printf("  Guard disabled for %d file(s)\n", result.FilesDisabled)
+			render(result, func() {
+				fmt.Fprintln(stdout, "Reset complete:")
+				if result.FilesDisabled > 0 || result.CollectionsDisabled > 0 {
+					if result.FilesDisabled > 0 {
+						fmt.Fprintf(stdout, "  Guard disabled for %d file(s)\n", result.FilesDisabled)
+					}
+					if result.CollectionsDisabled > 0 {
+						fmt.Fprintf(stdout, "  Guard disabled for %d collection(s)\n", result.CollectionsDisabled)
+					}
+				} else {
+					fmt.Fprintln(stdout, "  No guarded files or collections found")
 				}
-				if result.CollectionsDisabled > 0 {
-					fmt.Printf("  Guard disabled for %d collection(s)\n", result.CollectionsDisabled)
-				}
-			} else {
-				fmt.Println("  No guarded files or collections found")
-			}
+			})
 		},
 	}
 }
@@ -0,0 +1,296 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/florianbuetow/guard/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// NewRestoreRegistryCmd creates the restore-registry command.
+// Lists and restores the rolling .guardfile backups kept by every registry save.
+func NewRestoreRegistryCmd() *cobra.Command {
+	var list bool
+	var generation int
+	var diff []int
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:         "restore-registry --list | --diff <n>[,<m>] | --to <n>",
+		Annotations: map[string]string{dryRunUnsupported: ""},
+		Short:       "List or restore .guardfile backups",
+		Long: `List or restore the rolling backups of the .guardfile.
+
+Every time guard saves the registry, the previous .guardfile is kept as a
+backup generation in .guard/backups (root-owned when run with sudo). The
+last 10 generations are kept; generation 1 is the most recent one.
+
+--diff shows what changed from generation n to generation m, where 0 is the
+current .guardfile and m defaults to it.
+
+Restoring only replaces the .guardfile. File permissions are left untouched
+until you confirm that the guard state of the affected files should be applied.
+
+Examples:
+  guard restore-registry --list     # List generations and what changed between them
+  guard restore-registry --diff 3,1 # What changed from generation 3 to 1
+  guard restore-registry --diff 2   # What changed since generation 2
+  guard restore-registry --to 2     # Show the diff and restore generation 2
+  guard restore-registry --to 1 -y  # Restore and apply without prompting`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			if list {
+				listRegistryBackups(mgr)
+				return
+			}
+			if cmd.Flags().Changed("diff") {
+				diffRegistryBackups(mgr, diff)
+				return
+			}
+			if generation == 0 {
+				failf("specify --list, --diff <n>[,<m>] or --to <n>")
+			}
+
+			restoreRegistryBackup(mgr, generation, yes)
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "List available backup generations")
+	cmd.Flags().IntSliceVar(&diff, "diff", nil, "Show the changes from generation n to m (0 is the current .guardfile, the default for m)")
+	cmd.Flags().IntVar(&generation, "to", 0, "Restore the given backup generation")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not prompt for confirmation")
+
+	return cmd
+}
+
+// registryBackupInfo is a backup generation listed by restore-registry --list with its
+// changes to the next newer state.
+type registryBackupInfo struct {
+	Generation int                  `json:"generation"`
+	Time       time.Time            `json:"time"`
+	Changes    []registry.DiffEntry `json:"changes,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// registryBackupList is the result of restore-registry --list.
+type registryBackupList struct {
+	Backups []registryBackupInfo `json:"backups"`
+}
+
+// print prints the generations, newest first, as text.
+func (r *registryBackupList) print() {
+	if len(r.Backups) == 0 {
+		fmt.Fprintln(stdout, "No registry backups found")
+		return
+	}
+	fmt.Fprintln(stdout, "Registry backups (newest first):")
+	for _, backup := range r.Backups {
+		fmt.Fprintf(stdout, "\n  %d  %s\n", backup.Generation, backup.Time.Local().Format("2006-01-02 15:04:05"))
+		if backup.Error != "" {
+			fmt.Fprintf(stdout, "     (unreadable: %s)\n", backup.Error)
+			continue
+		}
+		if backup.Changes != nil {
+			printRegistryDiff("     ", backup.Changes)
+		}
+	}
+}
+
+// listRegistryBackups lists all generations, each with its diff to the next newer state.
+func listRegistryBackups(mgr *manager.Manager) {
+	backups, err := mgr.ListRegistryBackups()
+	if err != nil {
+		fail(err)
+	}
+
+	// The newest backup is compared against the current .guardfile (if readable)
+	newer, err := mgr.ReadCurrentRegistryData()
+	if err != nil {
+		newer = nil
+	}
+
+	result := &registryBackupList{Backups: []registryBackupInfo{}}
+	for _, backup := range backups {
+		info := registryBackupInfo{Generation: backup.Generation, Time: backup.Time}
+		data, err := mgr.ReadRegistryBackup(backup.Generation)
+		if err != nil {
+			info.Error = err.Error()
+			newer = nil
+		} else {
+			if newer != nil {
+				info.Changes = registry.DiffRegistryData(data, newer)
+				if info.Changes == nil {
+					info.Changes = []registry.DiffEntry{}
+				}
+			}
+			newer = data
+		}
+		result.Backups = append(result.Backups, info)
+	}
+	render(result, result.print)
+}
+
+// registryDiffResult is the result of restore-registry --diff.
+type registryDiffResult struct {
+	From    int                  `json:"from"`
+	To      int                  `json:"to"`
+	Changes []registry.DiffEntry `json:"changes"`
+}
+
+// print prints the changes between the generations as text.
+func (r *registryDiffResult) print() {
+	fmt.Fprintf(stdout, "Changes from %s to %s:\n", generationName(r.From), generationName(r.To))
+	printRegistryDiff("  ", r.Changes)
+}
+
+// diffRegistryBackups shows the changes between two generations, 0 being the current .guardfile.
+func diffRegistryBackups(mgr *manager.Manager, generations []int) {
+	if len(generations) == 1 {
+		generations = append(generations, 0)
+	}
+	if len(generations) != 2 {
+		failf("--diff takes one or two generations, e.g. --diff 3,1")
+	}
+	from, to := generations[0], generations[1]
+	diff, err := mgr.DiffRegistryBackup(from, to)
+	if err != nil {
+		fail(err)
+	}
+	if diff == nil {
+		diff = []registry.DiffEntry{}
+	}
+
+	result := &registryDiffResult{From: from, To: to, Changes: diff}
+	render(result, result.print)
+}
+
+// generationName names a backup generation, 0 being the current .guardfile.
+func generationName(generation int) string {
+	if generation == 0 {
+		return "the current .guardfile"
+	}
+	return fmt.Sprintf("generation %d", generation)
+}
+
+// registryRestoreResult is the result of restore-registry --to.
+type registryRestoreResult struct {
+	Generation int                  `json:"generation"`
+	Changes    []registry.DiffEntry `json:"changes"`
+	Restored   bool                 `json:"restored"`
+	Changed    []string             `json:"changed"`
+	Applied    bool                 `json:"applied"`
+	Files      []manager.FileResult `json:"files,omitempty"`
+}
+
+// printChanges prints what the restore changes in the .guardfile, before the restore is confirmed.
+func (r *registryRestoreResult) printChanges() {
+	fmt.Fprintf(stdout, "Restoring generation %d changes the .guardfile as follows:\n", r.Generation)
+	printRegistryDiff("  ", r.Changes)
+}
+
+// printRestored prints the restored generation.
+func (r *registryRestoreResult) printRestored() {
+	fmt.Fprintf(stdout, "Restored .guardfile from generation %d\n", r.Generation)
+}
+
+// printChangedFiles prints the files whose guard state the restore changes.
+func (r *registryRestoreResult) printChangedFiles() {
+	fmt.Fprintf(stdout, "%d file(s) have a different guard state in the restored registry:\n", len(r.Changed))
+	for _, path := range r.Changed {
+		fmt.Fprintf(stdout, "  - %s\n", path)
+	}
+}
+
+// print prints the outcome of the restore as text once nothing is left to confirm.
+func (r *registryRestoreResult) print() {
+	switch {
+	case !r.Restored:
+		fmt.Fprintln(stdout, "Aborted, nothing was changed")
+	case len(r.Changed) > 0 && !r.Applied:
+		fmt.Fprintln(stdout, "File permissions were not changed. Run 'guard enable' or 'guard disable' to apply them later.")
+	}
+}
+
+// restoreRegistryBackup shows what the restore changes, restores the .guardfile and
+// then offers to bring the affected files on disk in line with the restored registry.
+// The changes are printed before each prompt, also with --output json|yaml.
+func restoreRegistryBackup(mgr *manager.Manager, generation int, yes bool) {
+	diff, err := mgr.DiffRegistryBackup(0, generation)
+	if err != nil {
+		fail(err)
+	}
+	if diff == nil {
+		diff = []registry.DiffEntry{}
+	}
+
+	result := &registryRestoreResult{Generation: generation, Changes: diff, Changed: []string{}}
+	result.printChanges()
+
+	reader := bufio.NewReader(os.Stdin)
+	if !yes && !confirmPrompt(reader, "Restore this generation?") {
+		render(result, result.print)
+		return
+	}
+
+	changed, err := mgr.RestoreRegistryBackup(generation)
+	if err != nil {
+		fail(err)
+	}
+	result.Restored = true
+	result.printRestored()
+	printWarnings(mgr.GetWarnings())
+	mgr.ClearWarnings()
+
+	if len(changed) == 0 {
+		render(result, result.print)
+		return
+	}
+
+	for _, path := range changed {
+		result.Changed = append(result.Changed, mgr.GetRegistry().ToDisplayPath(path))
+	}
+	result.printChangedFiles()
+	if !yes && !confirmPrompt(reader, "Apply the restored guard state to these files now?") {
+		render(result, result.print)
+		return
+	}
+
+	if err := mgr.ApplyRegisteredState(changed); err != nil {
+		fail(err)
+	}
+	result.Applied = true
+	result.Files = mgr.GetFileResults()
+	render(result, result.print)
+
+	printWarnings(mgr.GetWarnings())
+	printErrors(mgr.GetErrors())
+	if mgr.HasErrors() {
+		exitFailure(mgr.Failure())
+	}
+}
+
+// printRegistryDiff prints one line per registry change.
+func printRegistryDiff(indent string, diff []registry.DiffEntry) {
+	if len(diff) == 0 {
+		fmt.Fprintf(stdout, "%s(no changes)\n", indent)
+		return
+	}
+	for _, entry := range diff {
+		fmt.Fprintf(stdout, "%s%s\n", indent, entry)
+	}
+}
+
+// confirmPrompt asks a yes/no question and returns true only for an explicit yes.
+func confirmPrompt(reader *bufio.Reader, question string) bool {
+	fmt.Fprintf(stdout, "%s [y/N]: ", question)
+	input, _ := reader.ReadString('\n')
+	input = strings.ToLower(strings.TrimSpace(input))
+	return input == "y" || input == "yes"
+}
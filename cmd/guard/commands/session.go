@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewSessionCmd creates the session command with start, end, diff and status subcommands.
+func NewSessionCmd() *cobra.Command {
+	sessionCmd := &cobra.Command{
+		Use:   "session {start|end|diff|status}",
+		Short: "Record the guard state and restore it later",
+		Long: `Record the guard state of every file, collection and folder, change guards
+freely (e.g. while pairing with an AI assistant), and return to exactly the
+recorded state afterwards.
+
+  start <name>  Record the current guard state (one session at a time)
+  diff          Show what changed since the session started
+  end           Restore the recorded state and end the session
+  status        Show the active session
+
+The recording is kept in .guard/session.yaml. Ending a session is recorded in
+the history and can be undone with 'guard undo'. Files registered during the
+session are unguarded when it ends.
+
+Examples:
+  guard session start pairing
+  guard session diff
+  guard session end
+  guard session end --keep   # End without restoring`,
+	}
+
+	sessionCmd.AddCommand(newSessionStartCmd())
+	sessionCmd.AddCommand(newSessionEndCmd())
+	sessionCmd.AddCommand(newSessionDiffCmd())
+	sessionCmd.AddCommand(newSessionStatusCmd())
+
+	return sessionCmd
+}
+
+// newSessionStartCmd creates the "session start" subcommand.
+func newSessionStartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "start <name>",
+		Short: "Record the current guard state",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			session, err := mgr.StartSession(args[0])
+			if err != nil {
+				fail(err)
+			}
+			fmt.Fprintf(stdout, "Started session %s (%s)\n", session.Name, describeSavedState(session))
+
+			printWarnings(mgr.GetWarnings())
+		},
+	}
+}
+
+// newSessionEndCmd creates the "session end" subcommand.
+func newSessionEndCmd() *cobra.Command {
+	var keep bool
+
+	cmd := &cobra.Command{
+		Use:   "end [--keep]",
+		Short: "Restore the recorded guard state and end the session",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			session, restored, err := mgr.EndSession(keep)
+			if err != nil {
+				fail(err)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				failf("Failed to save registry: %w", err)
+			}
+
+			printRestoredItems(mgr, restored)
+			if keep {
+				fmt.Fprintf(stdout, "Ended session %s, guard state kept\n", session.Name)
+			} else {
+				fmt.Fprintf(stdout, "Ended session %s, restored %d item(s)\n", session.Name, len(restored))
+			}
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&keep, "keep", false, "End the session without restoring the recorded guard state")
+
+	return cmd
+}
+
+// newSessionDiffCmd creates the "session diff" subcommand.
+func newSessionDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff",
+		Short: "Show what changed since the session started",
+		Long: `Show the files, collections and folders whose guard state changed since the
+session started.
+
+Output format: ~ <item>: <recorded> -> <current>`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			session, changes, err := mgr.SessionDiff()
+			if err != nil {
+				fail(err)
+			}
+			if len(changes) == 0 {
+				fmt.Fprintf(stdout, "No changes since session %s started\n", session.Name)
+				return
+			}
+			printGuardChanges(changes)
+		},
+	}
+}
+
+// newSessionStatusCmd creates the "session status" subcommand.
+func newSessionStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the active session",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			session, err := mgr.ActiveSession()
+			if err != nil {
+				fail(err)
+			}
+			if session == nil {
+				fmt.Fprintln(stdout, "No active session")
+				return
+			}
+			fmt.Fprintf(stdout, "Session %s active since %s (%s)\n", session.Name,
+				session.Time.Local().Format("2006-01-02 15:04:05"), describeSavedState(session))
+		},
+	}
+}
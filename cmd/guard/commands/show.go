@@ -13,8 +13,9 @@ import (
 // Per Requirement 6: Displays status of files and collections.
 func NewShowCmd() *cobra.Command {
 	showCmd := &cobra.Command{
-		Use:   "show [file|collection] [names...]",
-		Short: "Display status of files or collections",
+		Use:               "show [file|collection] [names...]",
+		Short:             "Display status of files or collections",
+		ValidArgsFunction: completeNames(completeCollections | completeFolders | completeFiles),
 		Long: `Display the guard status of files or collections.
 
 Auto-detection: Arguments are automatically detected as files or collections.
@@ -29,54 +30,53 @@ Examples:
 
 When no names are specified, all registered items are shown.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			result := &showResult{}
 			if len(args) == 0 {
 				// Show all files and collections
-				showAllFiles(mgr)
-				showAllCollections(mgr)
+				result.Files = showFiles(mgr, nil)
+				result.Collections = showCollections(mgr, nil)
+				result.Expiries = showExpiries(mgr)
 			} else {
 				// Use auto-detection to resolve arguments
 				files, folders, collections, err := mgr.ResolveArguments(args)
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
 
 				// Show files
 				if len(files) > 0 {
-					showSpecificFiles(mgr, files)
+					result.Files = showFiles(mgr, files)
 				}
 
 				// Show folders (treat as files within the folder for now)
-				if len(folders) > 0 {
-					// For folders, show their files
-					for _, folder := range folders {
-						fmt.Printf("Folder: %s\n", folder)
-					}
-				}
+				result.Folders = folders
 
 				// Show collections
 				if len(collections) > 0 {
-					showSpecificCollections(mgr, collections)
+					result.Collections = showCollections(mgr, collections)
 				}
 			}
 
+			render(result, func() {
+				printShowResult(result, len(args) == 0)
+			})
+
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -90,53 +90,118 @@ When no names are specified, all registered items are shown.`,
 	return showCmd
 }
 
-// showAllFiles shows all registered files
-func showAllFiles(mgr *manager.Manager) {
-	fileInfos, err := mgr.ShowFiles(nil)
+// showResult is what 'guard show' displays
+type showResult struct {
+	Files       []manager.FileInfo       `json:"files,omitempty"`
+	Folders     []string                 `json:"folders,omitempty"`
+	Collections []manager.CollectionInfo `json:"collections,omitempty"`
+	Expiries    []manager.ExpiringItem   `json:"expiries,omitempty"`
+}
+
+// printShowResult prints files, folders, collections and time-limited guard changes.
+// Collections are listed with their files unless all are shown (summary view).
+func printShowResult(result *showResult, all bool) {
+	for _, info := range result.Files {
+		printFileInfo(info)
+	}
+	for _, folder := range result.Folders {
+		fmt.Fprintf(stdout, "Folder: %s\n", folder)
+	}
+	printCollectionInfos(result.Collections, all)
+
+	if len(result.Expiries) > 0 {
+		fmt.Fprintln(stdout, "\nTime-limited guard changes:")
+		for _, item := range result.Expiries {
+			fmt.Fprintf(stdout, "  %s: %s\n", item.Label(), describeExpiry(item.Guard, item.Until))
+		}
+	}
+}
+
+// showFiles returns the status of files, all registered files if none are given
+func showFiles(mgr *manager.Manager, files []string) []manager.FileInfo {
+	fileInfos, err := mgr.ShowFiles(files)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return
+		return nil
 	}
+	return fileInfos
+}
 
-	for _, info := range fileInfos {
-		printFileInfo(info)
+// showCollections returns the status of collections, all collections if none are given
+func showCollections(mgr *manager.Manager, collections []string) []manager.CollectionInfo {
+	infos, err := mgr.ShowCollections(collections)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil
+	}
+	return infos
+}
+
+// showExpiries returns all time-limited guard changes, soonest first
+func showExpiries(mgr *manager.Manager) []manager.ExpiringItem {
+	items, err := mgr.ListExpiries()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil
 	}
+	return items
 }
 
 // printFileInfo prints a single file in format: G/- filename (collections)
+// followed by notes if its content changed since it was guarded or its guard state is time-limited.
 func printFileInfo(info manager.FileInfo) {
 	guardFlag := "-"
 	if info.Guard {
 		guardFlag = "G"
 	}
 	collectionsStr := strings.Join(info.Collections, ", ")
-	fmt.Printf("%s %s (%s)\n", guardFlag, info.Path, collectionsStr)
-}
 
-// showSpecificFiles shows specific files
-func showSpecificFiles(mgr *manager.Manager, files []string) {
-	fileInfos, err := mgr.ShowFiles(files)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return
+	var notes []string
+	if info.ContentChanged {
+		notes = append(notes, "content changed since guarded")
 	}
-
-	for _, info := range fileInfos {
-		printFileInfo(info)
+	if info.Expiry != nil {
+		notes = append(notes, describeExpiry(info.Expiry.Guard, info.Expiry.Until))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(stdout, "%s %s (%s) - %s\n", guardFlag, info.Path, collectionsStr, strings.Join(notes, ", "))
+		return
 	}
+	fmt.Fprintf(stdout, "%s %s (%s)\n", guardFlag, info.Path, collectionsStr)
 }
 
-// showAllCollections shows all collections (summary view)
-func showAllCollections(mgr *manager.Manager) {
-	if err := mgr.ShowCollections(nil); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+// printCollectionInfos prints collections in format: G/- collection: name (n files).
+// The summary view of all collections ends with the totals; otherwise the files are listed.
+// Per CLI-INTERFACE-SPECS.md lines 162-167.
+func printCollectionInfos(infos []manager.CollectionInfo, summary bool) {
+	guarded := 0
+	for _, info := range infos {
+		guardFlag := "-"
+		if info.Guard {
+			guardFlag = "G"
+			guarded++
+		}
+		fmt.Fprintf(stdout, "%s collection: %s (%d files)\n", guardFlag, info.Name, len(info.Files))
+		if info.Source != "" {
+			fmt.Fprintf(stdout, "  source: %s\n", info.Source)
+		}
+		if summary {
+			continue
+		}
+		if len(info.Patterns) > 0 {
+			fmt.Fprintf(stdout, "  patterns: %s\n", strings.Join(info.Patterns, " "))
+		}
+		for _, file := range info.Files {
+			fileGuardFlag := "-"
+			if file.Guard {
+				fileGuardFlag = "G"
+			}
+			fmt.Fprintf(stdout, "  %s %s\n", fileGuardFlag, file.Path)
+		}
 	}
-}
 
-// showSpecificCollections shows specific collections (detailed view)
-func showSpecificCollections(mgr *manager.Manager, collections []string) {
-	if err := mgr.ShowCollections(collections); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if summary && len(infos) > 0 {
+		fmt.Fprintf(stdout, "\n%d collection(s) total: %d guarded, %d unguarded\n", len(infos), guarded, len(infos)-guarded)
 	}
 }
 
@@ -144,57 +209,61 @@ func showSpecificCollections(mgr *manager.Manager, collections []string) {
 // Per Requirement 6.1-6.2: Shows guard status and collection membership.
 func newShowFileCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "file [paths...]",
-		Short: "Display status of files",
+		Use:               "file [paths...]",
+		Short:             "Display status of files",
+		ValidArgsFunction: completeNames(completeFiles),
 		Long: `Display the guard status of files and which collections they belong to.
 
 Output format: G/- filename (collections)
   Where G indicates guard is enabled, - indicates disabled
   Collections are shown in parentheses, comma-separated
+  Guarded files whose content changed since they were guarded are marked
+  with "content changed since guarded" (see 'guard rebaseline')
+  Time-limited guard changes (--for, --until) show the remaining time
 
 If no files are specified, all registered files are shown.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Get file information from manager
 			fileInfos, err := mgr.ShowFiles(args)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
-			}
-
-			// Print file information
-			for _, info := range fileInfos {
-				printFileInfo(info)
+				fail(err)
 			}
 
-			// Print summary when showing all files (no args)
-			if len(args) == 0 && len(fileInfos) > 0 {
-				guarded := 0
+			render(&showResult{Files: fileInfos}, func() {
+				// Print file information
 				for _, info := range fileInfos {
-					if info.Guard {
-						guarded++
+					printFileInfo(info)
+				}
+
+				// Print summary when showing all files (no args)
+				if len(args) == 0 && len(fileInfos) > 0 {
+					guarded := 0
+					for _, info := range fileInfos {
+						if info.Guard {
+							guarded++
+						}
 					}
+					unguarded := len(fileInfos) - guarded
+					fmt.Fprintf(stdout, "\n%d file(s) total: %d guarded, %d unguarded\n", len(fileInfos), guarded, unguarded)
 				}
-				unguarded := len(fileInfos) - guarded
-				fmt.Printf("\n%d file(s) total: %d guarded, %d unguarded\n", len(fileInfos), guarded, unguarded)
-			}
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -204,8 +273,9 @@ If no files are specified, all registered files are shown.`,
 // Per Requirement 6.3-6.4: Shows collection status and file count.
 func newShowCollectionCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "collection [names...]",
-		Short: "Display status of collections",
+		Use:               "collection [names...]",
+		Short:             "Display status of collections",
+		ValidArgsFunction: completeNames(completeCollections),
 		Long: `Display the guard status of collections and their file count.
 
 Output format: G/- collection: name (n files)
@@ -215,29 +285,31 @@ If no collections are specified, all collections are shown with their status
 and file count (but not individual files). If specific collections are requested,
 individual files in those collections are also listed.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Show collections
-			if err := mgr.ShowCollections(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+			infos, err := mgr.ShowCollections(args)
+			if err != nil {
+				fail(err)
 			}
+			render(&showResult{Collections: infos}, func() {
+				printCollectionInfos(infos, len(args) == 0)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewRestoreCmd creates the restore command.
+// Brings deleted or altered files back from the snapshot store.
+func NewRestoreCmd() *cobra.Command {
+	var snapshotID string
+
+	cmd := &cobra.Command{
+		Use:               "restore <file|collection>... [--snapshot <id>]",
+		Short:             "Restore files from their snapshots",
+		ValidArgsFunction: completeNames(completeCollections | completeFiles),
+		Long: `Restore deleted or altered files from the snapshot store.
+
+Whenever a file is guarded (or rebaselined), guard keeps a compressed copy of
+its content in .guard/snapshots/, named after its SHA-256. restore writes the
+latest snapshot back, or the snapshot selected with --snapshot (an ID or its
+prefix, see 'guard snapshot list'). A guarded file gets the guard permissions
+again, an unguarded file its original permissions. A file that 'guard cleanup'
+removed from the registry after it was deleted is registered again.
+
+Collections restore every file they contain.
+
+Examples:
+  guard restore secrets.env                      # Latest snapshot
+  guard restore secrets.env --snapshot 3f2a9c1b  # A chosen snapshot
+  guard restore config                           # All files of a collection`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			restored, err := mgr.RestoreFiles(args, snapshotID)
+			if err != nil {
+				fail(err)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				failf("Failed to save registry: %w", err)
+			}
+
+			for _, path := range restored {
+				fmt.Fprintf(stdout, "Restored %s\n", path)
+			}
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&snapshotID, "snapshot", "", "Restore this snapshot (ID or prefix) instead of the latest")
+
+	return cmd
+}
+
+// NewSnapshotCmd creates the snapshot command with list and prune subcommands.
+func NewSnapshotCmd() *cobra.Command {
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot {list|prune}",
+		Short: "Manage the content snapshot store",
+		Long: `List or prune the content snapshots kept in .guard/snapshots/.
+
+After new snapshots are added, the oldest ones are pruned until the store is
+below 256 MiB. The latest snapshot of every file is always kept.`,
+	}
+
+	snapshotCmd.AddCommand(newSnapshotListCmd())
+	snapshotCmd.AddCommand(newSnapshotPruneCmd())
+
+	return snapshotCmd
+}
+
+// newSnapshotListCmd creates the "snapshot list" subcommand.
+func newSnapshotListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "list [file]",
+		Short:             "List stored snapshots",
+		ValidArgsFunction: completeSingleName(completeFiles),
+		Long: `List stored snapshots, newest first.
+
+Output format: <id>  <time>  <size>  <file>`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry (needed to resolve the file against the .guardfile directory)
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			file := ""
+			if len(args) == 1 {
+				file = args[0]
+			}
+			snapshots, err := mgr.ListSnapshots(file)
+			if err != nil {
+				fail(err)
+			}
+			if len(snapshots) == 0 {
+				fmt.Fprintln(stdout, "No snapshots found")
+				return
+			}
+			for _, s := range snapshots {
+				fmt.Fprintf(stdout, "%s  %s  %8d  %s\n", s.ID(), s.Time.Local().Format("2006-01-02 15:04:05"), s.Size, s.Path)
+			}
+		},
+	}
+}
+
+// newSnapshotPruneCmd creates the "snapshot prune" subcommand.
+func newSnapshotPruneCmd() *cobra.Command {
+	var keep int
+	var maxSize string
+
+	cmd := &cobra.Command{
+		Use:   "prune [--keep <n>] [--max-size <size>]",
+		Short: "Remove old snapshots",
+		Long: `Remove old snapshots and the content no snapshot refers to anymore.
+
+--keep keeps the newest n snapshots of every file. --max-size then removes
+the oldest snapshots until the store is smaller than the given size (bytes,
+or with a K, M or G suffix). The latest snapshot of a file is never removed
+to meet the size limit.
+
+Examples:
+  guard snapshot prune --keep 3
+  guard snapshot prune --max-size 50M`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			limit := int64(0)
+			if maxSize != "" {
+				var err error
+				if limit, err = parseByteSize(maxSize); err != nil {
+					failf("invalid --max-size: %w", err)
+				}
+			}
+			if keep <= 0 && limit <= 0 {
+				failf("specify --keep and/or --max-size")
+			}
+
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			result, err := mgr.PruneSnapshots(keep, limit)
+			if err != nil {
+				fail(err)
+			}
+			fmt.Fprintf(stdout, "Removed %d snapshot(s), freed %d bytes, %d bytes left\n", result.Removed, result.FreedBytes, result.TotalBytes)
+		},
+	}
+
+	cmd.Flags().IntVar(&keep, "keep", 0, "Keep the newest n snapshots of every file")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Shrink the store below this size")
+
+	return cmd
+}
+
+// parseByteSize parses a size like 1048576, 512K, 50M or 2G.
+func parseByteSize(value string) (int64, error) {
+	multiplier := int64(1)
+	upper := strings.TrimSuffix(strings.ToUpper(value), "B")
+	for suffix, m := range map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if strings.HasSuffix(upper, suffix) {
+			multiplier = m
+			upper = strings.TrimSuffix(upper, suffix)
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("'%s' is not a size", value)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewStateCmd creates the state command with save, load, list, diff and delete subcommands.
+func NewStateCmd() *cobra.Command {
+	stateCmd := &cobra.Command{
+		Use:   "state {save|load|list|diff|delete}",
+		Short: "Save and load named guard states",
+		Long: `Save the guard state of every file, collection and folder under a name and
+switch back to it later in one command, e.g. between a "refactoring" and a
+"feature" set of protections.
+
+States are stored in .guard/states/. Loading a state guards and unguards
+files, collections and folders until they match it; files registered after
+the state was saved are unguarded. Loading is recorded in the history and can
+be undone with 'guard undo'.
+
+Examples:
+  guard state save refactoring
+  guard state load feature
+  guard state diff refactoring
+  guard state list`,
+	}
+
+	stateCmd.AddCommand(newStateSaveCmd())
+	stateCmd.AddCommand(newStateLoadCmd())
+	stateCmd.AddCommand(newStateListCmd())
+	stateCmd.AddCommand(newStateDiffCmd())
+	stateCmd.AddCommand(newStateDeleteCmd())
+
+	return stateCmd
+}
+
+// newStateSaveCmd creates the "state save" subcommand.
+func newStateSaveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "save <name>",
+		Short: "Save the current guard state",
+		Long:  `Save the current guard state of every file, collection and folder under name, replacing a state of the same name.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			state, err := mgr.SaveState(args[0])
+			if err != nil {
+				fail(err)
+			}
+			fmt.Fprintf(stdout, "Saved state %s (%s)\n", state.Name, describeSavedState(state))
+
+			printWarnings(mgr.GetWarnings())
+		},
+	}
+}
+
+// newStateLoadCmd creates the "state load" subcommand.
+func newStateLoadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "load <name>",
+		Short: "Switch to a saved guard state",
+		Long:  `Guard and unguard files, collections and folders until they match the state saved under name.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			changes, err := mgr.LoadState(args[0])
+			if err != nil {
+				fail(err)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				failf("Failed to save registry: %w", err)
+			}
+
+			printRestoredItems(mgr, changes)
+			fmt.Fprintf(stdout, "Loaded state %s\n", args[0])
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+}
+
+// newStateListCmd creates the "state list" subcommand.
+func newStateListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List saved guard states",
+		Long: `List saved guard states.
+
+Output format: <name>  <time saved>  <guarded files>/<files>`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			states, err := mgr.ListStates()
+			if err != nil {
+				fail(err)
+			}
+			if len(states) == 0 {
+				fmt.Fprintln(stdout, "No saved states")
+			}
+			for i := range states {
+				state := &states[i]
+				fmt.Fprintf(stdout, "%-20s  %s  %s\n", state.Name, state.Time.Local().Format("2006-01-02 15:04:05"), describeSavedState(state))
+			}
+
+			printWarnings(mgr.GetWarnings())
+		},
+	}
+}
+
+// newStateDiffCmd creates the "state diff" subcommand.
+func newStateDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <name>",
+		Short: "Show what differs from a saved guard state",
+		Long: `Show the files, collections and folders whose guard state differs from the
+state saved under name.
+
+Output format: ~ <item>: <saved> -> <current>`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			changes, err := mgr.DiffState(args[0])
+			if err != nil {
+				fail(err)
+			}
+			if len(changes) == 0 {
+				fmt.Fprintf(stdout, "No changes since state %s\n", args[0])
+				return
+			}
+			printGuardChanges(changes)
+		},
+	}
+}
+
+// newStateDeleteCmd creates the "state delete" subcommand.
+func newStateDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>...",
+		Short: "Delete saved guard states",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			var failure error
+			for _, name := range args {
+				if err := mgr.DeleteState(name); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					failure = err
+					continue
+				}
+				fmt.Fprintf(stdout, "Deleted state %s\n", name)
+			}
+			if failure != nil {
+				exitFailure(failure)
+			}
+		},
+	}
+}
+
+// describeSavedState summarizes a saved state, e.g. "3/5 files guarded".
+func describeSavedState(state *manager.SavedState) string {
+	guarded := 0
+	for _, guard := range state.Files {
+		if guard {
+			guarded++
+		}
+	}
+	return fmt.Sprintf("%d/%d files guarded", guarded, len(state.Files))
+}
+
+// printGuardChanges prints guard state changes as "~ <item>: <before> -> <after>".
+func printGuardChanges(changes []manager.HistoryItem) {
+	for _, item := range changes {
+		fmt.Fprintf(stdout, "~ %s: %s -> %s\n", item.Label(), guardStateWord(item.PrevGuard), guardStateWord(item.Guard))
+	}
+}
+
+// printRestoredItems prints the items brought back to their recorded guard state.
+// changes hold the recorded state in PrevGuard. Files that could not be changed are skipped.
+func printRestoredItems(mgr *manager.Manager, changes []manager.HistoryItem) {
+	for _, item := range changes {
+		if item.Type == manager.HistoryItemFile {
+			guard, err := mgr.GetRegistry().GetRegisteredFileGuard(item.Name)
+			if err != nil || guard != item.PrevGuard {
+				continue
+			}
+		}
+		action := "disabled"
+		if item.PrevGuard {
+			action = "enabled"
+		}
+		fmt.Fprintf(stdout, "Guard %s for %s\n", action, item.Label())
+	}
+}
+
+func guardStateWord(guard bool) string {
+	if guard {
+		return "guarded"
+	}
+	return "unguarded"
+}
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// porcelainStates are the state letters of the porcelain format
+var porcelainStates = map[string]string{
+	manager.FileStateGuarded:   "G",
+	manager.FileStateUnguarded: "U",
+	manager.FileStateMissing:   "!",
+}
+
+// statusResult is the result of the status command in --output json|yaml
+type statusResult struct {
+	Files   []manager.FileStatus  `json:"files"`
+	Summary manager.StatusSummary `json:"summary"`
+}
+
+// NewStatusCmd creates the status command.
+// Lists every registered file with its guard flag, on-disk state, drift and collections.
+func NewStatusCmd() *cobra.Command {
+	var porcelain string
+	var nullTerminated bool
+
+	cmd := &cobra.Command{
+		Use:   "status [--porcelain[=v1]] [-z]",
+		Short: "List registered files with their guard state",
+		Long: `List every registered file with its guard flag, its state on disk,
+whether it drifted from the registry, and its collections.
+
+With --porcelain, status prints a format for editors and scripts that stays
+compatible across guard versions. Version v1 prints one line per file:
+
+  <flag> <state> <drift> <collections> <path>
+
+  flag         G if the guard flag is set, - otherwise
+  state        G guarded on disk, U unguarded, ! missing
+  drift        D if the file is missing or does not match the registry, - otherwise
+  collections  Comma-separated collection names, - if none
+  path         Relative to the .guardfile; always the last field
+
+Collection names, and paths containing a newline, tab, double quote or backslash,
+are quoted like Go strings. With -z, lines end with NUL instead of a newline and
+paths are never quoted. -z implies --porcelain=v1. New fields are only added in
+new versions.
+
+Examples:
+  guard status
+  guard status --porcelain
+  guard status --porcelain=v1 -z`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if nullTerminated && porcelain == "" {
+				porcelain = "v1"
+			}
+			if porcelain != "" && porcelain != "v1" {
+				failf("unsupported porcelain version '%s': use v1", porcelain)
+			}
+
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			statuses, err := mgr.Status()
+			if err != nil {
+				fail(err)
+			}
+
+			result := statusResult{Files: statuses, Summary: manager.SummarizeStatus(statuses)}
+			render(result, func() {
+				if porcelain != "" {
+					printPorcelainV1(statuses, nullTerminated)
+					return
+				}
+				printStatus(result)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&porcelain, "porcelain", "", "Print the stable format for scripts (version v1)")
+	cmd.Flags().Lookup("porcelain").NoOptDefVal = "v1"
+	cmd.Flags().BoolVarP(&nullTerminated, "null", "z", false, "End porcelain lines with NUL and do not quote paths")
+	return cmd
+}
+
+// printPorcelainV1 prints the statuses in porcelain format v1 (see NewStatusCmd).
+// Changes to this format need a new version.
+func printPorcelainV1(statuses []manager.FileStatus, nullTerminated bool) {
+	end := "\n"
+	if nullTerminated {
+		end = "\x00"
+	}
+	for _, status := range statuses {
+		flag := "-"
+		if status.Guard {
+			flag = "G"
+		}
+		drift := "-"
+		if status.Drift {
+			drift = "D"
+		}
+		collections := "-"
+		if len(status.Collections) > 0 {
+			quoted := make([]string, len(status.Collections))
+			for i, name := range status.Collections {
+				quoted[i] = porcelainQuote(name, " ,")
+			}
+			collections = strings.Join(quoted, ",")
+		}
+		path := status.Path
+		if !nullTerminated {
+			path = porcelainQuote(path, "")
+		}
+		fmt.Fprintf(stdout, "%s %s %s %s %s%s", flag, porcelainStates[status.State], drift, collections, path, end)
+	}
+}
+
+// porcelainQuote quotes s like a Go string if it contains a newline, tab, double quote,
+// backslash or one of the extra separator characters.
+func porcelainQuote(s, separators string) string {
+	if s == "" || strings.ContainsAny(s, "\n\t\"\\"+separators) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// printStatus prints the statuses for people.
+func printStatus(result statusResult) {
+	if len(result.Files) == 0 {
+		fmt.Fprintln(stdout, "No files registered")
+		return
+	}
+	for _, status := range result.Files {
+		flag := "unguarded"
+		if status.Guard {
+			flag = "guarded"
+		}
+		line := fmt.Sprintf("  %-9s  %s", flag, status.Path)
+		if len(status.Collections) > 0 {
+			line += fmt.Sprintf("  [%s]", strings.Join(status.Collections, ", "))
+		}
+		switch {
+		case status.State == manager.FileStateMissing:
+			line += "  (missing)"
+		case status.Drift:
+			line += fmt.Sprintf("  (drift, %s on disk)", status.State)
+		}
+		fmt.Fprintln(stdout, line)
+	}
+	fmt.Fprintf(stdout, "\n%d guarded, %d unguarded, %d drifted\n",
+		result.Summary.Guarded, result.Summary.Unguarded, result.Summary.Drift)
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewSyncCmd creates the sync command that reconciles the project with guard.policy.yaml.
+func NewSyncCmd() *cobra.Command {
+	var check, yes bool
+
+	cmd := &cobra.Command{
+		Use:   "sync [--check] [-y]",
+		Short: "Reconcile guard state with guard.policy.yaml",
+		Long: `Bring the registry and the files on disk in line with guard.policy.yaml, the
+reviewed file next to the .guardfile that holds the desired protections. Commit
+the policy; the .guardfile keeps the machine-specific original permissions.
+
+  profiles:                # "guarded" and "unguarded" are built in
+    frozen:
+      guard: true
+  rules:                   # the last matching rule decides
+    - pattern: "*.lock"
+      profile: guarded
+    - pattern: /vendor/
+      profile: frozen
+    - pattern: /vendor/patches/
+      profile: unguarded
+  collections:             # pattern collections, see 'guard import codeowners'
+    - name: migrations
+      patterns: [/db/migrations/]
+      profile: guarded
+  folders:                 # the folder and the files directly in it
+    - path: config
+      profile: guarded
+
+A file is guarded if a guarded collection or folder of the policy contains it,
+unless a rule matches it: then the last matching rule decides. Other files the
+policy's collections, folders and rules cover are unguarded; files, collections
+and folders it does not cover keep their guard state. Patterns use CODEOWNERS
+syntax. Files whose permissions drifted from their guard state are repaired as
+with 'guard verify --repair'.
+
+sync prints the plan, then asks before applying it. With --check it only
+prints the plan, for CI.
+
+Exit codes:
+  0  The project matches the policy (or the plan was applied)
+  1  An error occurred (3 to 10 for specific errors, see the README)
+  2  --check found protections that diverge from the policy
+
+Examples:
+  guard sync
+  guard sync -y
+  guard sync --check`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			policy, err := mgr.LoadPolicy()
+			if err != nil {
+				fail(err)
+			}
+			plan, err := mgr.PlanSync(policy)
+			if err != nil {
+				fail(err)
+			}
+
+			result := &syncResult{Policy: manager.PolicyFileName, Plan: plan, InSync: plan.Empty()}
+			if result.InSync {
+				render(result, result.print)
+				return
+			}
+
+			// The plan is printed before the prompt, also with --output json|yaml
+			printSyncPlan(plan)
+			if check {
+				result.Check = true
+				render(result, result.print)
+				fmt.Fprintf(os.Stderr, "Error: guard state diverges from %s, run 'guard sync'\n", manager.PolicyFileName)
+				exit(exitCheckFailed)
+			}
+			if !yes && !confirmPrompt(bufio.NewReader(os.Stdin), "Apply this plan?") {
+				render(result, result.print)
+				return
+			}
+
+			applied, err := mgr.Sync(policy)
+			if err != nil {
+				fail(err)
+			}
+
+			// Save registry
+			if err := mgr.SaveRegistry(); err != nil {
+				fail(err)
+			}
+			result.Plan, result.Applied = applied, true
+			result.Files = mgr.GetFileResults()
+			render(result, result.print)
+
+			// Print warnings and errors
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Only print the plan; exit 2 if it is not empty")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not prompt for confirmation")
+
+	return cmd
+}
+
+// syncResult is the result of sync.
+type syncResult struct {
+	Policy  string               `json:"policy"`
+	Plan    *manager.SyncPlan    `json:"plan"`
+	InSync  bool                 `json:"in_sync"`
+	Check   bool                 `json:"check,omitempty"`
+	Applied bool                 `json:"applied"`
+	Files   []manager.FileResult `json:"files,omitempty"`
+}
+
+// print prints the outcome of sync as text: in sync, aborted or synced.
+// The plan itself is printed with printSyncPlan before the prompt.
+func (r *syncResult) print() {
+	switch {
+	case r.InSync:
+		fmt.Fprintf(stdout, "Guard state matches %s\n", r.Policy)
+	case r.Applied:
+		fmt.Fprintf(stdout, "Synced with %s\n", r.Policy)
+	case !r.Check:
+		fmt.Fprintln(stdout, "Aborted")
+	}
+}
+
+// printSyncPlan prints the registrations of a sync plan as "+ <item>", pattern updates
+// as "~ collection <name>: patterns", guard changes like 'guard state diff' and
+// permission drift like 'guard verify'.
+func printSyncPlan(plan *manager.SyncPlan) {
+	for _, name := range plan.RegisterFolders {
+		fmt.Fprintf(stdout, "+ folder %s\n", name)
+	}
+	for _, name := range plan.CreateCollections {
+		fmt.Fprintf(stdout, "+ collection %s\n", name)
+	}
+	for _, name := range plan.UpdateCollections {
+		fmt.Fprintf(stdout, "~ collection %s: patterns\n", name)
+	}
+	for _, path := range plan.RegisterFiles {
+		fmt.Fprintf(stdout, "+ file %s\n", path)
+	}
+	printGuardChanges(plan.Changes)
+	for _, drift := range plan.Repair {
+		fmt.Fprintf(stdout, "! file %s: %s\n", drift.Path, strings.Join(drift.Issues, ", "))
+	}
+}
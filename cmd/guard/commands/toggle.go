@@ -3,157 +3,165 @@ package commands
 import (
 	"fmt"
 	"os"
-	"sort"
 
 	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
-// toggleFilesWithOutput toggles files and prints status messages.
-// Returns true if any errors occurred.
-func toggleFilesWithOutput(mgr *manager.Manager, files []string) bool {
-	// Track guard state and registration status before toggling
-	guardBefore := make(map[string]bool)
-	wasRegistered := make(map[string]bool)
-	for _, path := range files {
-		if mgr.IsRegisteredFile(path) {
-			wasRegistered[path] = true
-			guard, err := mgr.GetRegistry().GetRegisteredFileGuard(path)
-			if err == nil {
-				guardBefore[path] = guard
-			}
-		} else {
-			// File not registered yet - will be added with guard=false, then toggled to true
-			wasRegistered[path] = false
-			guardBefore[path] = false
-		}
-	}
-
-	// Toggle files
+// toggleFiles toggles files and adds them to the result.
+// Returns the error of the toggle, after printing it.
+func toggleFiles(mgr *manager.Manager, files []string, result *guardResult) error {
+	before := snapshotFiles(mgr, files)
 	if err := mgr.ToggleFiles(files); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return true
-	}
-
-	// Count newly registered files
-	newlyRegistered := 0
-	for _, path := range files {
-		if !wasRegistered[path] && mgr.IsRegisteredFile(path) {
-			newlyRegistered++
-		}
+		return err
 	}
+	exitIfAtomicAborted(mgr)
+	result.addFiles(mgr, files, before, nil)
+	return nil
+}
 
-	// Print registration message if any files were auto-registered
-	if newlyRegistered > 0 {
-		fmt.Printf("Registered %d file(s)\n", newlyRegistered)
+// lastToggleArguments returns the item recorded by the previous toggle as toggle arguments
+// and records it in the result.
+// Exits with the usage error if nothing was toggled yet.
+func lastToggleArguments(mgr *manager.Manager, result *guardResult) (files, folders, collections []string) {
+	name, toggleType := mgr.GetRegistry().GetLastToggle()
+	if name == "" {
+		fmt.Fprintln(os.Stderr, "Error: No files, folders, or collections specified and nothing was toggled before")
+		fmt.Fprintln(os.Stderr, "Usage: guard toggle [file|folder|collection] <names>...")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Use 'guard help toggle' for more information.")
+		exit(exitError)
 	}
 
-	// Print status messages for each file
-	for _, path := range files {
-		if mgr.IsRegisteredFile(path) {
-			guard, err := mgr.GetRegistry().GetRegisteredFileGuard(path)
-			if err == nil {
-				// Only print if state changed
-				if before, ok := guardBefore[path]; ok && before != guard {
-					if guard {
-						fmt.Printf("Guard enabled for %s\n", path)
-					} else {
-						fmt.Printf("Guard disabled for %s\n", path)
-					}
-				}
-			}
-		}
+	result.Retoggled = toggleType + " " + name
+	switch toggleType {
+	case "folder":
+		folders = []string{name}
+	case "collection":
+		collections = []string{name}
+	default:
+		files = []string{name}
 	}
-
-	return false
+	return files, folders, collections
 }
 
 // NewToggleCmd creates the toggle command with auto-detection and subcommands.
 // Per Requirement 2.7: Toggles guard status for files, folders, and collections.
 func NewToggleCmd() *cobra.Command {
 	toggleCmd := &cobra.Command{
-		Use:   "toggle [file|folder|collection] <names...>",
-		Short: "Toggle guard protection",
+		Use:               "toggle [file|folder|collection] [names...]",
+		Short:             "Toggle guard protection",
+		ValidArgsFunction: completeNames(completeCollections | completeFolders | completeFiles | completeDiskFiles),
 		Long: `Toggle guard protection for files, folders, or collections.
 
 Auto-detection: Arguments are automatically detected as files, folders, or collections.
 Use 'file', 'folder', or 'collection' keyword to disambiguate when needed.
+Without arguments, the item toggled last is toggled again.
+
+With --atomic, a failure on any file (chmod, chown or immutable flag) reverts
+all files changed so far and leaves the registry untouched.
+
+With --for or --until, the change is time-limited: once the time has passed,
+the next guard command (or a running 'guard watch') restores the previous guard
+state. 'guard show' lists the remaining time.
+
+With --git-tracked, --git-untracked, --git-staged, --git-except-staged,
+--git-changed <rev> or --git-unchanged-since <time>, the files git selects are
+added to the arguments, e.g. every tracked file or the files of the last commit.
+Only files below the .guardfile directory are selected.
 
 Examples:
+  guard toggle                      - Toggle the last toggled item again
   guard toggle myfile.txt           - Toggle file (auto-detected)
   guard toggle myfolder             - Toggle folder (auto-detected if directory)
   guard toggle mycollection         - Toggle collection (auto-detected)
   guard toggle file ambiguous       - Explicitly toggle as file
   guard toggle folder myfolder      - Explicitly toggle as folder
-  guard toggle collection ambiguous - Explicitly toggle as collection`,
+  guard toggle collection ambiguous - Explicitly toggle as collection
+  guard toggle --atomic a.txt b.txt  - All-or-nothing: revert every change if any file fails
+  guard toggle --for 1h config      - Toggle config, toggle it back after an hour
+  guard toggle --git-staged         - Toggle the files with staged changes`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No files, folders, or collections specified")
-				fmt.Fprintln(os.Stderr, "Usage: guard toggle [file|folder|collection] <names>...")
-				fmt.Fprintln(os.Stderr)
-				fmt.Fprintln(os.Stderr, "Use 'guard help toggle' for more information.")
-				os.Exit(1)
-			}
-
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
-			// Use auto-detection to resolve arguments
-			files, folders, collections, err := mgr.ResolveArguments(args)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
+			result := newGuardResult()
+			var files, folders, collections []string
+			if len(args) == 0 && !hasGitSelector(cmd) {
+				// Re-toggle the item toggled last
+				files, folders, collections = lastToggleArguments(mgr, result)
+			} else if len(args) > 0 {
+				// Use auto-detection to resolve arguments
+				var err error
+				files, folders, collections, err = mgr.ResolveArguments(args)
+				if err != nil {
+					fail(err)
+				}
 			}
 
+			// Add the files selected by --git-* flags
+			files = appendGitSelectedFiles(cmd, mgr, files, false)
+
 			// Toggle files
 			if len(files) > 0 {
-				if toggleFilesWithOutput(mgr, files) {
-					os.Exit(1)
+				if err := toggleFiles(mgr, files, result); err != nil {
+					exitFailure(err)
 				}
 			}
 
 			// Toggle folders
 			if len(folders) > 0 {
 				if err := mgr.ToggleFolders(folders); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
+				exitIfAtomicAborted(mgr)
+				result.Folders = folders
 			}
 
 			// Toggle collections
 			if len(collections) > 0 {
 				if err := mgr.ToggleCollections(collections); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
+				exitIfAtomicAborted(mgr)
+				result.addCollections(mgr, collections, true)
 			}
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
+			result.Files = mgr.GetFileResults()
+			render(result, result.printToggled)
+
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
 
 	// Add file subcommand for explicit usage
-	toggleCmd.AddCommand(newToggleFileCmd())
+	fileCmd := newToggleFileCmd()
+	toggleCmd.AddCommand(fileCmd)
 
 	// Add folder subcommand for explicit usage
 	toggleCmd.AddCommand(newToggleFolderCmd())
@@ -161,55 +169,80 @@ Examples:
 	// Add collection subcommand for explicit usage
 	toggleCmd.AddCommand(newToggleCollectionCmd())
 
+	// --atomic applies to the command and all subcommands
+	addAtomicFlag(toggleCmd)
+
+	// --for and --until apply to the command and all subcommands
+	addExpiryFlags(toggleCmd)
+
+	// --git-* selectors apply to the command and its file subcommand
+	addGitSelectorFlags(toggleCmd)
+	addGitSelectorFlags(fileCmd)
+
 	return toggleCmd
 }
 
 // newToggleFileCmd creates the "toggle file" subcommand.
 func newToggleFileCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "file <paths...>",
-		Short: "Toggle guard for files",
+		Use:               "file <paths...>",
+		Short:             "Toggle guard for files",
+		ValidArgsFunction: completeNames(completeFiles | completeDiskFiles),
 		Long: `Toggle guard protection for the specified files.
 
 Files not in the registry will be added first. Files missing on disk will
 generate warnings.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
+			if len(args) == 0 && !hasGitSelector(cmd) {
 				fmt.Fprintln(os.Stderr, "Error: No files specified")
 				fmt.Fprintln(os.Stderr, "Usage: guard toggle file <path>...")
 				fmt.Fprintln(os.Stderr)
 				fmt.Fprintln(os.Stderr, "Use 'guard help toggle' for more information.")
-				os.Exit(1)
+				exit(exitError)
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
+			}
+
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
+			// Add the files selected by --git-* flags
+			args = appendGitSelectedFiles(cmd, mgr, args, false)
+			if len(args) == 0 {
+				return
 			}
 
-			// Toggle files with output
-			if toggleFilesWithOutput(mgr, args) {
-				os.Exit(1)
+			// Toggle files
+			result := newGuardResult()
+			if err := toggleFiles(mgr, args, result); err != nil {
+				exitFailure(err)
 			}
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
+			result.Files = mgr.GetFileResults()
+			render(result, result.printToggled)
+
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -218,8 +251,9 @@ generate warnings.`,
 // newToggleFolderCmd creates the "toggle folder" subcommand.
 func newToggleFolderCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "folder <paths...>",
-		Short: "Toggle guard for folders",
+		Use:               "folder <paths...>",
+		Short:             "Toggle guard for folders",
+		ValidArgsFunction: completeNames(completeFolders | completeDiskDirs),
 		Long: `Toggle guard protection for files in the specified folders.
 
 Folders are dynamic collections that scan files from disk. On toggle:
@@ -233,38 +267,47 @@ Folders are dynamic collections that scan files from disk. On toggle:
 				fmt.Fprintln(os.Stderr, "Usage: guard toggle folder <path>...")
 				fmt.Fprintln(os.Stderr)
 				fmt.Fprintln(os.Stderr, "Use 'guard help toggle' for more information.")
-				os.Exit(1)
+				exit(exitError)
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
 			// Toggle folders
 			if err := mgr.ToggleFolders(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
+			result := newGuardResult()
+			result.Folders = args
+			result.Files = mgr.GetFileResults()
+			render(result, result.printToggled)
+
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -274,8 +317,9 @@ Folders are dynamic collections that scan files from disk. On toggle:
 // CRITICAL: Implements conflict detection per Requirement 3.5.
 func newToggleCollectionCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "collection <names...>",
-		Short: "Toggle guard for collections",
+		Use:               "collection <names...>",
+		Short:             "Toggle guard for collections",
+		ValidArgsFunction: completeNames(completeCollections),
 		Long: `Toggle guard protection for all files in the specified collections.
 
 If multiple collections are specified and they share files with different guard
@@ -286,17 +330,22 @@ states, an error will be returned and no changes will be made (conflict detectio
 				fmt.Fprintln(os.Stderr, "Usage: guard toggle collection <name>...")
 				fmt.Fprintln(os.Stderr)
 				fmt.Fprintln(os.Stderr, "Use 'guard help toggle' for more information.")
-				os.Exit(1)
+				exit(exitError)
 			}
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
+			// Revert everything if any file fails (--atomic)
+			enableAtomicMode(cmd, mgr)
+
+			// Revert the change later (--for, --until)
+			enableExpiry(cmd, mgr)
+
 			// Check if any collections exist
 			validCollections := 0
 			for _, name := range args {
@@ -309,70 +358,38 @@ states, an error will be returned and no changes will be made (conflict detectio
 				for _, name := range args {
 					mgr.AddWarning(manager.NewWarning(manager.WarningCollectionNotFound, "", name))
 				}
-				manager.PrintWarnings(mgr.GetWarnings())
-				os.Exit(1)
+				printWarnings(mgr.GetWarnings())
+				exit(exitNotFound)
 			}
 
 			// Toggle collections (with conflict detection)
 			if err := mgr.ToggleCollections(args); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
+			exitIfAtomicAborted(mgr)
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
-			// Print success messages - files first (sorted), then collection summary
-			for _, collectionName := range args {
-				if !mgr.GetRegistry().IsRegisteredCollection(collectionName) {
-					continue
-				}
-				files, err := mgr.GetRegistry().GetRegisteredCollectionFiles(collectionName)
-				if err != nil || len(files) == 0 {
-					continue
-				}
-
-				// Print header for this collection's files
-				fmt.Printf("toggling guarded state for files in collection: %s\n", collectionName)
-
-				// Get collection's current guard state to determine message
-				guardState, _ := mgr.GetRegistry().GetRegisteredCollectionGuard(collectionName)
-
-				existing, _ := mgr.GetFileSystem().CheckFilesExist(files)
-				sort.Strings(existing)
-				for _, file := range existing {
-					if guardState {
-						fmt.Printf("Guard enabled for %s\n", file)
-					} else {
-						fmt.Printf("Guard disabled for %s\n", file)
-					}
-				}
-			}
-			fmt.Println()
-			for _, collectionName := range args {
-				if !mgr.GetRegistry().IsRegisteredCollection(collectionName) {
-					continue
-				}
-				guardState, _ := mgr.GetRegistry().GetRegisteredCollectionGuard(collectionName)
-				if guardState {
-					fmt.Printf("Guard enabled for collection %s\n", collectionName)
-				} else {
-					fmt.Printf("Guard disabled for collection %s\n", collectionName)
-				}
-			}
+			// Files first (sorted), then the collections
+			result := newGuardResult()
+			result.addCollections(mgr, args, true)
+			result.Files = mgr.GetFileResults()
+			render(result, func() {
+				result.printCollections(true)
+			})
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
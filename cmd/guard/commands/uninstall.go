@@ -24,37 +24,71 @@ This command:
 
 If verification fails, the .guardfile is preserved and an error is returned.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			// Run uninstall (includes reset, cleanup, verification, and deletion)
-			if err := mgr.Destroy(); err != nil {
+			result, err := mgr.Destroy()
+			render(result, func() {
+				printDestroyResult(result)
+			})
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 
 				// Print warnings and errors
-				manager.PrintWarnings(mgr.GetWarnings())
-				manager.PrintErrors(mgr.GetErrors())
+				printWarnings(mgr.GetWarnings())
+				printErrors(mgr.GetErrors())
 
-				os.Exit(1)
+				exitFailure(err)
 			}
 
 			// Print warnings (if any)
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors (if any)
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
-
-			// Success message is printed by Destroy()
 		},
 	}
 }
+
+// printDestroyResult prints the completed steps of an uninstall per CLI-INTERFACE-SPECS.md
+func printDestroyResult(result *manager.DestroyResult) {
+	if result == nil {
+		return
+	}
+	if reset := result.Reset; reset != nil {
+		fmt.Fprintln(stdout, "Reset complete:")
+		if reset.FilesDisabled > 0 || reset.CollectionsDisabled > 0 {
+			if reset.FilesDisabled > 0 {
+				fmt.Fprintf(stdout, "  Guard disabled for %d file(s)\n", reset.FilesDisabled)
+			}
+			if reset.CollectionsDisabled > 0 {
+				fmt.Fprintf(stdout, "  Guard disabled for %d collection(s)\n", reset.CollectionsDisabled)
+			}
+		} else {
+			fmt.Fprintln(stdout, "  No guarded files or collections found")
+		}
+	}
+	if cleanup := result.Cleanup; cleanup != nil {
+		fmt.Fprintln(stdout, "Cleanup complete:")
+		if cleanup.FilesRemoved > 0 || cleanup.CollectionsRemoved > 0 {
+			fmt.Fprintf(stdout, "  Removed %d file(s) (file not found)\n", cleanup.FilesRemoved)
+			fmt.Fprintf(stdout, "  Removed %d collection(s) (empty)\n", cleanup.CollectionsRemoved)
+		} else {
+			fmt.Fprintln(stdout, "  No stale entries found")
+		}
+	}
+	if result.Removed {
+		fmt.Fprintln(stdout, "Removed .guardfile")
+		fmt.Fprintln(stdout, "Uninstall complete")
+	}
+}
@@ -2,10 +2,8 @@ package commands
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 
-	"github.com/florianbuetow/guard/internal/manager"
 	"github.com/spf13/cobra"
 )
 
@@ -15,8 +13,9 @@ import (
 //   - `guard remove file <files>... from <collection>` with `guard update <collection> remove <files>...`
 func NewUpdateCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "update <collection> add|remove <files>...",
-		Short: "Add or remove files from a collection",
+		Use:               "update <collection> add|remove <files>...",
+		Short:             "Add or remove files from a collection",
+		ValidArgsFunction: completeUpdate,
 		Long: `Modify collection membership by adding or removing files.
 
 Examples:
@@ -27,31 +26,27 @@ The collection will be created if it doesn't exist (with a warning).
 Files will be registered if they don't exist in the registry.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) < 2 {
-				fmt.Fprintln(os.Stderr, "Error: Invalid syntax. Usage: guard update <collection> add|remove <files>...")
-				os.Exit(1)
+				failf("Invalid syntax. Usage: guard update <collection> add|remove <files>...")
 			}
 
 			collectionName := args[0]
 			operation := args[1]
 
 			if operation != "add" && operation != "remove" {
-				fmt.Fprintf(os.Stderr, "Error: Invalid operation '%s'. Use 'add' or 'remove'.\n", operation)
-				os.Exit(1)
+				failf("Invalid operation '%s'. Use 'add' or 'remove'.", operation)
 			}
 
 			if len(args) < 3 {
-				fmt.Fprintln(os.Stderr, "Error: No files specified")
-				os.Exit(1)
+				failf("No files specified")
 			}
 
 			files := args[2:]
 
-			mgr := manager.NewManager(".guardfile")
+			mgr := newManager()
 
 			// Load registry
 			if err := mgr.LoadRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				fail(err)
 			}
 
 			if operation == "add" {
@@ -68,8 +63,7 @@ Files will be registered if they don't exist in the registry.`,
 				beforeCount, _ := mgr.CountFilesInCollection(collectionName)
 
 				if err := mgr.AddFilesToCollections(files, []string{collectionName}); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
 
 				// Get count after adding
@@ -77,18 +71,18 @@ Files will be registered if they don't exist in the registry.`,
 
 				// Print success messages
 				if newlyRegistered > 0 {
-					fmt.Printf("Registered %d file(s)\n", newlyRegistered)
+					fmt.Fprintf(stdout, "Registered %d file(s)\n", newlyRegistered)
 				}
 				added := afterCount - beforeCount
 				if added > 0 {
-					fmt.Printf("Added %d file(s) to collection '%s'\n", added, collectionName)
+					fmt.Fprintf(stdout, "Added %d file(s) to collection '%s'\n", added, collectionName)
 				}
 
 				// Calculate files that were already in collection
 				// Files are either added or already contained (newlyRegistered is separate)
 				alreadyContained := len(files) - added
 				if alreadyContained > 0 {
-					fmt.Printf("%d file(s) already contained in the collection\n", alreadyContained)
+					fmt.Fprintf(stdout, "%d file(s) already contained in the collection\n", alreadyContained)
 				}
 			} else {
 				// operation == "remove"
@@ -107,8 +101,7 @@ Files will be registered if they don't exist in the registry.`,
 				beforeCount, _ := mgr.CountFilesInCollection(collectionName)
 
 				if err := mgr.RemoveFilesFromCollections(normalizedFiles, []string{collectionName}); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
+					fail(err)
 				}
 
 				// Get count after removal
@@ -116,25 +109,24 @@ Files will be registered if they don't exist in the registry.`,
 
 				removed := beforeCount - afterCount
 				if removed > 0 {
-					fmt.Printf("Removed %d file(s) from collection '%s'\n", removed, collectionName)
+					fmt.Fprintf(stdout, "Removed %d file(s) from collection '%s'\n", removed, collectionName)
 				}
 			}
 
 			// Save registry
 			if err := mgr.SaveRegistry(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Failed to save registry: %v\n", err)
-				os.Exit(1)
+				failf("Failed to save registry: %w", err)
 			}
 
 			// Print warnings
-			manager.PrintWarnings(mgr.GetWarnings())
+			printWarnings(mgr.GetWarnings())
 
 			// Print errors
-			manager.PrintErrors(mgr.GetErrors())
+			printErrors(mgr.GetErrors())
 
 			// Exit with error code if there were errors
 			if mgr.HasErrors() {
-				os.Exit(1)
+				exitFailure(mgr.Failure())
 			}
 		},
 	}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewVerifyCmd creates the verify command.
+// Detects registered files whose permissions on disk no longer match the registry.
+func NewVerifyCmd() *cobra.Command {
+	var repair bool
+	var content bool
+
+	cmd := &cobra.Command{
+		Use:               "verify [files...] [--content] [--repair]",
+		Short:             "Detect and repair permission drift",
+		ValidArgsFunction: completeNames(completeFiles),
+		Long: `Check that registered files still have the permissions the registry expects.
+
+A guarded file is expected to have the configured guard mode, owner and group,
+and the immutable flag when run as root. An unguarded file is expected to have
+the mode, owner and group stored when it was registered, and no immutable flag.
+Anything with sudo, a package manager or a 'git checkout' can silently change
+these; verify reports every mismatch per file.
+
+With --content the content of guarded files is also compared against the
+SHA-256 and size recorded when they were guarded. Content changes cannot be
+repaired; accept them with 'guard rebaseline <files>'. Hashes of unchanged
+files (same mtime and size) are cached in .guard/hashcache.yaml.
+
+With --repair the expected permissions are applied again to every drifted file.
+
+Without files, all registered files are checked.
+
+Exit codes:
+  0  All files match the registry (or all permission drift was repaired)
+  1  An error occurred (3 to 10 for specific errors, see the README)
+  2  Drift was found and not repaired
+
+Examples:
+  guard verify                  # Check all registered files
+  guard verify secrets.env      # Check a single file
+  guard verify --content        # Also detect changed content
+  sudo guard verify --repair    # Re-apply the expected state`,
+		Run: func(cmd *cobra.Command, args []string) {
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			result, err := mgr.VerifyFiles(args, content)
+			if err != nil {
+				fail(err)
+			}
+
+			permissionDrift, contentChanged := 0, 0
+			for _, drift := range result.Drifted {
+				state := "unguarded"
+				if drift.Guard {
+					state = "guarded"
+				}
+				fmt.Fprintf(stdout, "Drift: %s (%s)\n", drift.Path, state)
+				for _, issue := range drift.Issues {
+					fmt.Fprintf(stdout, "  %s\n", issue)
+				}
+				if len(drift.Issues) > 0 {
+					permissionDrift++
+				}
+				if drift.ContentChanged {
+					fmt.Fprintln(stdout, "  content changed since guarded")
+					contentChanged++
+				}
+			}
+
+			code := exitOK
+			if len(result.Drifted) == 0 {
+				fmt.Fprintf(stdout, "Verified %d file(s), no drift found\n", result.Checked)
+			} else if repair {
+				if permissionDrift > 0 {
+					repaired, err := mgr.RepairDrift(result.Drifted)
+					if err != nil {
+						fail(err)
+					}
+					if err := mgr.SaveRegistry(); err != nil {
+						failf("Failed to save registry: %w", err)
+					}
+					fmt.Fprintf(stdout, "Repaired %d of %d drifted file(s)\n", len(repaired), permissionDrift)
+				}
+				if contentChanged > 0 {
+					fmt.Fprintf(stdout, "%d file(s) changed content. Run 'guard rebaseline <files>' to accept it.\n", contentChanged)
+					code = exitCheckFailed
+				}
+			} else {
+				fmt.Fprintf(stdout, "Verified %d file(s), %d drifted.", result.Checked, len(result.Drifted))
+				if permissionDrift > 0 {
+					fmt.Fprint(stdout, " Run 'guard verify --repair' to fix permissions.")
+				}
+				if contentChanged > 0 {
+					fmt.Fprint(stdout, " Run 'guard rebaseline <files>' to accept changed content.")
+				}
+				fmt.Fprintln(stdout)
+				code = exitCheckFailed
+			}
+
+			printWarnings(mgr.GetWarnings())
+			printErrors(mgr.GetErrors())
+			if mgr.HasErrors() {
+				exitFailure(mgr.Failure())
+			}
+			exit(code)
+		},
+	}
+
+	cmd.Flags().BoolVar(&content, "content", false, "Also compare guarded files against their content baselines")
+	cmd.Flags().BoolVar(&repair, "repair", false, "Re-apply the expected permissions to drifted files")
+
+	return cmd
+}
@@ -14,7 +14,7 @@ func NewVersionCmd(version string) *cobra.Command {
 		Short: "Display version information",
 		Long:  "Display the current version of the guard binary.",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("guard version %s\n", version)
+			fmt.Fprintf(stdout, "guard version %s\n", version)
 		},
 	}
 }
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// NewWatchCmd creates the watch command.
+// Enforces guards continuously instead of only at command time.
+func NewWatchCmd() *cobra.Command {
+	var debounce time.Duration
+	var systemdUnit bool
+
+	cmd := &cobra.Command{
+		Use:         "watch [--debounce <duration>] [--systemd-unit]",
+		Annotations: map[string]string{dryRunUnsupported: ""},
+		Short:       "Watch the project and keep guards enforced",
+		Long: `Run in the foreground and keep guards enforced as files change (Linux, inotify).
+
+  - Files created in a guarded folder, or matching the patterns of a guarded
+    collection (see 'guard import codeowners' and 'guard sync'), are registered
+    and guarded right away.
+  - Guarded files whose permissions, owner, group or immutable flag were
+    changed out of band, or that were replaced, get their guard re-applied.
+  - Changes to the .guardfile are picked up automatically. If the new
+    .guardfile cannot be loaded, the previous registry stays in effect.
+
+Events are collected until nothing changed for the --debounce duration, so an
+editor's save (temporary file, rename, chmod) is handled once. Every action is
+logged to stdout. Stop with Ctrl-C or SIGTERM.
+
+To run it as a service, write a systemd unit for the current directory:
+  guard watch --systemd-unit | sudo tee /etc/systemd/system/guard-watch.service
+  sudo systemctl enable --now guard-watch
+
+Examples:
+  sudo guard watch
+  sudo guard watch --debounce 2s`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if systemdUnit {
+				if err := printSystemdUnit(); err != nil {
+					fail(err)
+				}
+				return
+			}
+
+			mgr := newManager()
+
+			// Load registry
+			if err := mgr.LoadRegistry(); err != nil {
+				fail(err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			logger := log.New(stdout, "", log.LstdFlags)
+			if err := mgr.Watch(ctx, debounce, logger.Printf); err != nil {
+				fail(err)
+			}
+			logger.Printf("Stopped watching")
+		},
+	}
+
+	cmd.Flags().DurationVar(&debounce, "debounce", manager.DefaultWatchDebounce, "Wait until events settled for this long before acting")
+	cmd.Flags().BoolVar(&systemdUnit, "systemd-unit", false, "Print a systemd unit that runs 'guard watch' in the current directory")
+
+	return cmd
+}
+
+// printSystemdUnit prints a service unit running this executable's watch command in the current directory.
+func printSystemdUnit() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the guard executable: %w", err)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	fmt.Fprintf(stdout, `[Unit]
+Description=guard watch for %s
+After=local-fs.target
+
+[Service]
+Type=simple
+WorkingDirectory=%s
+ExecStart=%s watch
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`, filepath.Base(dir), dir, executable)
+	return nil
+}
@@ -29,19 +29,44 @@ Available Commands:
   toggle      Toggle guard protection
   enable      Enable guard protection
   disable     Disable guard protection
+  undo        Undo the last operation
+  redo        Redo the last undone operation
+  history     Show the operation history
+  audit       Show the audit log of guard state changes
+  session     Record the guard state and restore it later
+  state       Save and load named guard states
+  focus       Guard everything except the files you are working on
+  unfocus     End focus mode
+  git         Run git with the guards it needs lifted
+  branch-profile  Apply guard states per git branch
+  hooks       Install git hooks that run guard
+  check-staged  Fail if staged changes touch guarded paths
+  merge-driver  Merge .guardfile changes as a git merge driver
+  diff        Show the differences between two .guardfiles
+  sync        Reconcile guard state with guard.policy.yaml
 
   create      Create one or more collections
   update      Add or remove files from a collection
+  import      Import collections from other tools
   clear       Clear files from collections (disable guard and unlink files)
   destroy     Remove one or more collections
 
   show        Display status of files or collections
+  status      List registered files with their guard state
+  prompt      Print a short guard summary for shell prompts
   info        Display information about guard
   config      Manage guard configuration
 
   cleanup     Remove empty collections and missing files
   reset       Disable guard for all files and collections
   uninstall   Reset, cleanup, verify, and delete the .guardfile
+  restore-registry  List or restore .guardfile backups
+  recover     Finish or roll back an interrupted operation
+  verify      Detect and repair permission drift
+  watch       Watch the project and keep guards enforced
+  rebaseline  Accept the current content of guarded files
+  restore     Restore files from their snapshots
+  snapshot    Manage the content snapshot store
 
   completion  Generate the autocompletion script for the specified shell
   help        Help about any command
@@ -93,6 +118,8 @@ and other tools that might change file permissions.`,
 
 	// Add interactive mode flag
 	rootCmd.PersistentFlags().BoolVarP(&interactive, "interactive", "i", false, "Launch interactive TUI mode")
+	commands.AddDryRunFlag(rootCmd)
+	commands.AddOutputFlag(rootCmd)
 
 	// Add all subcommands
 	rootCmd.AddCommand(commands.NewInitCmd())
@@ -101,21 +128,45 @@ and other tools that might change file permissions.`,
 	rootCmd.AddCommand(commands.NewToggleCmd())
 	rootCmd.AddCommand(commands.NewEnableCmd())
 	rootCmd.AddCommand(commands.NewDisableCmd())
+	rootCmd.AddCommand(commands.NewUndoCmd())
+	rootCmd.AddCommand(commands.NewRedoCmd())
+	rootCmd.AddCommand(commands.NewHistoryCmd())
+	rootCmd.AddCommand(commands.NewAuditCmd())
+	rootCmd.AddCommand(commands.NewSessionCmd())
+	rootCmd.AddCommand(commands.NewStateCmd())
+	rootCmd.AddCommand(commands.NewFocusCmd())
+	rootCmd.AddCommand(commands.NewUnfocusCmd())
+	rootCmd.AddCommand(commands.NewGitCmd())
+	rootCmd.AddCommand(commands.NewBranchProfileCmd())
+	rootCmd.AddCommand(commands.NewHooksCmd())
+	rootCmd.AddCommand(commands.NewCheckStagedCmd())
+	rootCmd.AddCommand(commands.NewMergeDriverCmd())
+	rootCmd.AddCommand(commands.NewDiffCmd())
+	rootCmd.AddCommand(commands.NewSyncCmd())
 	rootCmd.AddCommand(commands.NewCreateCmd())
 	rootCmd.AddCommand(commands.NewUpdateCmd())
+	rootCmd.AddCommand(commands.NewImportCmd())
 	rootCmd.AddCommand(commands.NewClearCmd())
 	rootCmd.AddCommand(commands.NewDestroyCmd())
 	rootCmd.AddCommand(commands.NewShowCmd())
+	rootCmd.AddCommand(commands.NewStatusCmd())
+	rootCmd.AddCommand(commands.NewPromptCmd())
 	rootCmd.AddCommand(commands.NewInfoCmd())
 	rootCmd.AddCommand(commands.NewConfigCmd())
 	rootCmd.AddCommand(commands.NewCleanupCmd())
 	rootCmd.AddCommand(commands.NewResetCmd())
 	rootCmd.AddCommand(commands.NewUninstallCmd())
+	rootCmd.AddCommand(commands.NewRestoreRegistryCmd())
+	rootCmd.AddCommand(commands.NewRecoverCmd())
+	rootCmd.AddCommand(commands.NewVerifyCmd())
+	rootCmd.AddCommand(commands.NewRebaselineCmd())
+	rootCmd.AddCommand(commands.NewRestoreCmd())
+	rootCmd.AddCommand(commands.NewSnapshotCmd())
+	rootCmd.AddCommand(commands.NewWatchCmd())
 	rootCmd.AddCommand(commands.NewVersionCmd(version))
 
 	// Execute root command
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		commands.ExitOnError(cmd, err)
 	}
 }
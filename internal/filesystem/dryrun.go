@@ -0,0 +1,239 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+)
+
+// Operation is a change a DryRunFileSystem recorded instead of making it.
+// Op is one of chmod, chown, chgrp, set-immutable, clear-immutable, write, mkdir, remove
+// or restore.
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value,omitempty"`
+}
+
+// String renders the operation as a single line, e.g. "chmod 0600 main.go"
+func (o Operation) String() string {
+	if o.Value == "" {
+		return fmt.Sprintf("%s %s", o.Op, o.Path)
+	}
+	return fmt.Sprintf("%s %s %s", o.Op, o.Value, o.Path)
+}
+
+// plannedState is what a dry run changed about a file; nil fields are unchanged
+type plannedState struct {
+	mode      *os.FileMode
+	owner     string
+	group     string
+	immutable *bool
+}
+
+// DryRunFileSystem wraps a FileSystem and records permission changes instead of
+// making them. Reads see the recorded changes, so later steps behave as in a real run.
+type DryRunFileSystem struct {
+	FileSystem
+	operations []Operation
+	planned    map[string]*plannedState
+}
+
+// NewDryRunFileSystem creates a DryRunFileSystem reading from base.
+func NewDryRunFileSystem(base FileSystem) *DryRunFileSystem {
+	return &DryRunFileSystem{FileSystem: base, planned: make(map[string]*plannedState)}
+}
+
+// Operations returns the recorded changes, in order.
+func (fs *DryRunFileSystem) Operations() []Operation {
+	return append([]Operation(nil), fs.operations...)
+}
+
+// Record adds a change made outside the FileSystem, such as writing a state file,
+// to the operations of the dry run.
+func (fs *DryRunFileSystem) Record(op, path, value string) {
+	fs.operations = append(fs.operations, Operation{Op: op, Path: path, Value: value})
+}
+
+// GetFileInfo returns the file mode, owner and group, including the recorded changes.
+func (fs *DryRunFileSystem) GetFileInfo(path string) (mode os.FileMode, owner, group string, err error) {
+	mode, owner, group, err = fs.FileSystem.GetFileInfo(path)
+	if err != nil {
+		return 0, "", "", err
+	}
+	if planned, ok := fs.planned[path]; ok {
+		if planned.mode != nil {
+			mode = *planned.mode
+		}
+		if planned.owner != "" {
+			owner = planned.owner
+		}
+		if planned.group != "" {
+			group = planned.group
+		}
+	}
+	return mode, owner, group, nil
+}
+
+// ApplyPermissions records the mode, owner and group changes in the order a real run makes them.
+func (fs *DryRunFileSystem) ApplyPermissions(path string, mode os.FileMode, owner, group string) error {
+	if err := fs.Chmod(path, mode); err != nil {
+		return err
+	}
+	if owner != "" {
+		if err := fs.Chown(path, owner); err != nil {
+			return err
+		}
+	}
+	if group != "" {
+		if err := fs.Chgrp(path, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestorePermissions is an alias for ApplyPermissions.
+func (fs *DryRunFileSystem) RestorePermissions(path string, mode os.FileMode, owner, group string) error {
+	return fs.ApplyPermissions(path, mode, owner, group)
+}
+
+// Chmod records a mode change, unless the file already has that mode.
+func (fs *DryRunFileSystem) Chmod(path string, mode os.FileMode) error {
+	current, _, _, err := fs.GetFileInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to set permissions %o for file %s: %w", mode, path, err)
+	}
+	if current.Perm() == mode.Perm() {
+		return nil
+	}
+	fs.Record("chmod", path, fmt.Sprintf("%04o", mode.Perm()))
+	perm := mode.Perm()
+	fs.plan(path).mode = &perm
+	return nil
+}
+
+// Chown records an owner change, unless the file already has that owner.
+func (fs *DryRunFileSystem) Chown(path string, owner string) error {
+	_, current, _, err := fs.GetFileInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to set owner %s for file %s: %w", owner, path, err)
+	}
+	if current == owner {
+		return nil
+	}
+	fs.Record("chown", path, owner)
+	fs.plan(path).owner = owner
+	return nil
+}
+
+// Chgrp records a group change, unless the file already has that group.
+func (fs *DryRunFileSystem) Chgrp(path string, group string) error {
+	_, _, current, err := fs.GetFileInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to set group %s for file %s: %w", group, path, err)
+	}
+	if current == group {
+		return nil
+	}
+	fs.Record("chgrp", path, group)
+	fs.plan(path).group = group
+	return nil
+}
+
+// SetImmutable records setting the immutable flag. Without root privileges the
+// wrapped FileSystem decides, as it would in a real run.
+func (fs *DryRunFileSystem) SetImmutable(path string) error {
+	if !fs.HasRootPrivileges() {
+		return fs.FileSystem.SetImmutable(path)
+	}
+	fs.planImmutable(path, true)
+	return nil
+}
+
+// ClearImmutable records clearing the immutable flag. Without root privileges the
+// wrapped FileSystem decides, as it would in a real run.
+func (fs *DryRunFileSystem) ClearImmutable(path string) error {
+	if !fs.HasRootPrivileges() {
+		return fs.FileSystem.ClearImmutable(path)
+	}
+	fs.planImmutable(path, false)
+	return nil
+}
+
+// SetAppendOnly records setting the append-only flag. Without root privileges the
+// wrapped FileSystem decides, as it would in a real run.
+func (fs *DryRunFileSystem) SetAppendOnly(path string) error {
+	if !fs.HasRootPrivileges() {
+		return fs.FileSystem.SetAppendOnly(path)
+	}
+	fs.Record("set-append-only", path, "")
+	return nil
+}
+
+// ClearAppendOnly records clearing the append-only flag. Without root privileges the
+// wrapped FileSystem decides, as it would in a real run.
+func (fs *DryRunFileSystem) ClearAppendOnly(path string) error {
+	if !fs.HasRootPrivileges() {
+		return fs.FileSystem.ClearAppendOnly(path)
+	}
+	fs.Record("clear-append-only", path, "")
+	return nil
+}
+
+// IsImmutable returns the immutable flag, including the recorded changes.
+func (fs *DryRunFileSystem) IsImmutable(path string) (bool, error) {
+	if planned, ok := fs.planned[path]; ok && planned.immutable != nil {
+		return *planned.immutable, nil
+	}
+	return fs.FileSystem.IsImmutable(path)
+}
+
+// WriteFile records writing the file. Reads still see the old content.
+func (fs *DryRunFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	fs.Record("write", path, "")
+	return nil
+}
+
+// Rename records writing newpath, which is what moving oldpath there amounts to.
+func (fs *DryRunFileSystem) Rename(oldpath, newpath string) error {
+	fs.Record("write", newpath, "")
+	return nil
+}
+
+// MkdirAll records creating a directory that does not exist yet.
+func (fs *DryRunFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	if isDir, err := fs.IsDir(path); err == nil && isDir {
+		return nil
+	}
+	fs.Record("mkdir", path, fmt.Sprintf("%04o", perm.Perm()))
+	return nil
+}
+
+// Remove records removing the file.
+func (fs *DryRunFileSystem) Remove(path string) error {
+	fs.Record("remove", path, "")
+	return nil
+}
+
+// plan returns the planned state of path, creating it on first use
+func (fs *DryRunFileSystem) plan(path string) *plannedState {
+	state, ok := fs.planned[path]
+	if !ok {
+		state = &plannedState{}
+		fs.planned[path] = state
+	}
+	return state
+}
+
+// planImmutable records a change of the immutable flag, unless the flag already has that value
+func (fs *DryRunFileSystem) planImmutable(path string, immutable bool) {
+	if current, err := fs.IsImmutable(path); err == nil && current == immutable {
+		return
+	}
+	op := "clear-immutable"
+	if immutable {
+		op = "set-immutable"
+	}
+	fs.Record(op, path, "")
+	fs.plan(path).immutable = &immutable
+}
@@ -1,7 +1,10 @@
 package filesystem
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -10,32 +13,76 @@ import (
 	"syscall"
 )
 
+// ErrNeedsRoot is returned by the immutable and append-only flag setters without root
+// privileges.
+// It matches os.ErrPermission.
+var ErrNeedsRoot = fmt.Errorf("changing the immutable flag requires root privileges (sudo): %w", os.ErrPermission)
+
 // FileSystem provides file system operations for the guard tool.
-// It handles file existence checks, permission changes, and owner/group management.
-type FileSystem struct {
+// It handles file existence checks, content I/O, permission changes, owner/group
+// management and immutable flags of the managed files. OSFileSystem changes the real
+// files; MemFileSystem models them in memory, so guard can be simulated without root.
+// Guard's own files, the .guardfile and the .guard/ state directory, are not
+// accessed through a FileSystem, except for the append-only flag of the audit log.
+type FileSystem interface {
+	// HasRootPrivileges reports whether immutable and append-only flags can be changed.
+	HasRootPrivileges() bool
+	FileExists(path string) bool
+	GetFileInfo(path string) (mode os.FileMode, owner, group string, err error)
+	ApplyPermissions(path string, mode os.FileMode, owner, group string) error
+	RestorePermissions(path string, mode os.FileMode, owner, group string) error
+	Chmod(path string, mode os.FileMode) error
+	Chown(path string, owner string) error
+	Chgrp(path string, group string) error
+	CheckFilesExist(paths []string) (existing, missing []string)
+	ReadDir(path string) ([]DirEntry, error)
+	Lstat(path string) (os.FileInfo, error)
+	IsSymlink(path string) (bool, error)
+	IsDir(path string) (bool, error)
+	HashFile(path string) (string, int64, error)
+	Stat(path string) (os.FileInfo, error)
+	Open(path string) (io.ReadCloser, error)
+	ReadFile(path string) ([]byte, error)
+	WriteFile(path string, data []byte, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(path string) error
+	CollectImmediateFiles(folder string) ([]string, error)
+	CollectFilesRecursive(folder string, skipDirs ...string) ([]string, error)
+	SetImmutable(path string) error
+	ClearImmutable(path string) error
+	IsImmutable(path string) (bool, error)
+	SetAppendOnly(path string) error
+	ClearAppendOnly(path string) error
+	IsAppendOnly(path string) (bool, error)
+	NewWatcher() (*Watcher, error)
+}
+
+// OSFileSystem is the FileSystem of the operating system.
+type OSFileSystem struct {
 	// Stateless - no fields needed
 }
 
-// NewFileSystem creates a new FileSystem instance.
-func NewFileSystem() *FileSystem {
-	return &FileSystem{}
+// NewFileSystem creates a new OSFileSystem instance.
+func NewFileSystem() *OSFileSystem {
+	return &OSFileSystem{}
 }
 
 // HasRootPrivileges returns true if the effective UID is 0 (root or sudo-elevated).
 // This is required for setting system-level immutable flags.
-func (fs *FileSystem) HasRootPrivileges() bool {
+func (fs *OSFileSystem) HasRootPrivileges() bool {
 	return os.Geteuid() == 0
 }
 
 // FileExists checks if a file exists at the given path.
-func (fs *FileSystem) FileExists(path string) bool {
+func (fs *OSFileSystem) FileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
 }
 
 // GetFileInfo retrieves the current file mode, owner, and group for a file.
 // Returns an error if the file doesn't exist or if owner/group lookup fails.
-func (fs *FileSystem) GetFileInfo(path string) (mode os.FileMode, owner, group string, err error) {
+func (fs *OSFileSystem) GetFileInfo(path string) (mode os.FileMode, owner, group string, err error) {
 	// Get file info
 	fileInfo, err := os.Stat(path)
 	if err != nil {
@@ -80,7 +127,7 @@ func (fs *FileSystem) GetFileInfo(path string) (mode os.FileMode, owner, group s
 //
 // Empty owner or group strings mean "don't change".
 // Returns an error if any operation fails.
-func (fs *FileSystem) ApplyPermissions(path string, mode os.FileMode, owner, group string) error {
+func (fs *OSFileSystem) ApplyPermissions(path string, mode os.FileMode, owner, group string) error {
 	// Step 1: Set permissions first (security - prevents race conditions)
 	if err := fs.Chmod(path, mode); err != nil {
 		return err
@@ -105,12 +152,12 @@ func (fs *FileSystem) ApplyPermissions(path string, mode os.FileMode, owner, gro
 
 // RestorePermissions is an alias for ApplyPermissions.
 // It restores a file's original permissions, owner, and group.
-func (fs *FileSystem) RestorePermissions(path string, mode os.FileMode, owner, group string) error {
+func (fs *OSFileSystem) RestorePermissions(path string, mode os.FileMode, owner, group string) error {
 	return fs.ApplyPermissions(path, mode, owner, group)
 }
 
 // Chmod changes the file mode (permissions) for the specified file.
-func (fs *FileSystem) Chmod(path string, mode os.FileMode) error {
+func (fs *OSFileSystem) Chmod(path string, mode os.FileMode) error {
 	if err := os.Chmod(path, mode); err != nil {
 		return fmt.Errorf("failed to set permissions %o for file %s: %w", mode, path, err)
 	}
@@ -119,7 +166,7 @@ func (fs *FileSystem) Chmod(path string, mode os.FileMode) error {
 
 // Chown changes the owner of the specified file.
 // The owner parameter should be a username. It will be converted to UID.
-func (fs *FileSystem) Chown(path string, owner string) error {
+func (fs *OSFileSystem) Chown(path string, owner string) error {
 	// Look up the user to get UID
 	ownerUser, err := user.Lookup(owner)
 	if err != nil {
@@ -142,7 +189,7 @@ func (fs *FileSystem) Chown(path string, owner string) error {
 
 // Chgrp changes the group of the specified file.
 // The group parameter should be a group name. It will be converted to GID.
-func (fs *FileSystem) Chgrp(path string, group string) error {
+func (fs *OSFileSystem) Chgrp(path string, group string) error {
 	// Look up the group to get GID
 	groupInfo, err := user.LookupGroup(group)
 	if err != nil {
@@ -165,7 +212,7 @@ func (fs *FileSystem) Chgrp(path string, group string) error {
 
 // CheckFilesExist checks which files exist and which are missing.
 // Returns two slices: existing files and missing files.
-func (fs *FileSystem) CheckFilesExist(paths []string) (existing, missing []string) {
+func (fs *OSFileSystem) CheckFilesExist(paths []string) (existing, missing []string) {
 	for _, path := range paths {
 		if fs.FileExists(path) {
 			existing = append(existing, path)
@@ -187,7 +234,7 @@ type DirEntry struct {
 
 // ReadDir reads a directory and returns entries sorted with folders first, then alphabetically.
 // Dotfiles (hidden files starting with .) are included as per TUI spec line 193.
-func (fs *FileSystem) ReadDir(path string) ([]DirEntry, error) {
+func (fs *OSFileSystem) ReadDir(path string) ([]DirEntry, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
@@ -225,7 +272,14 @@ func (fs *FileSystem) ReadDir(path string) ([]DirEntry, error) {
 	}
 
 	// Sort: directories first, then alphabetically by name
-	slices.SortFunc(result, func(a, b DirEntry) int {
+	sortDirEntries(result)
+
+	return result, nil
+}
+
+// sortDirEntries sorts directories first, then alphabetically by name
+func sortDirEntries(entries []DirEntry) {
+	slices.SortFunc(entries, func(a, b DirEntry) int {
 		// Directories come first
 		if a.IsDir && !b.IsDir {
 			return -1
@@ -244,12 +298,10 @@ func (fs *FileSystem) ReadDir(path string) ([]DirEntry, error) {
 		}
 		return 0
 	})
-
-	return result, nil
 }
 
 // Lstat returns file info without following symlinks.
-func (fs *FileSystem) Lstat(path string) (os.FileInfo, error) {
+func (fs *OSFileSystem) Lstat(path string) (os.FileInfo, error) {
 	info, err := os.Lstat(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to lstat %s: %w", path, err)
@@ -258,7 +310,7 @@ func (fs *FileSystem) Lstat(path string) (os.FileInfo, error) {
 }
 
 // IsSymlink checks if a path is a symbolic link.
-func (fs *FileSystem) IsSymlink(path string) (bool, error) {
+func (fs *OSFileSystem) IsSymlink(path string) (bool, error) {
 	info, err := os.Lstat(path)
 	if err != nil {
 		return false, fmt.Errorf("failed to check symlink %s: %w", path, err)
@@ -267,7 +319,7 @@ func (fs *FileSystem) IsSymlink(path string) (bool, error) {
 }
 
 // IsDir checks if a path is a directory.
-func (fs *FileSystem) IsDir(path string) (bool, error) {
+func (fs *OSFileSystem) IsDir(path string) (bool, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return false, fmt.Errorf("failed to check directory %s: %w", path, err)
@@ -275,9 +327,60 @@ func (fs *FileSystem) IsDir(path string) (bool, error) {
 	return info.IsDir(), nil
 }
 
+// HashFile returns the hex-encoded SHA-256 of a file's content and the number of bytes read.
+func (fs *OSFileSystem) HashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// Stat returns file info, following symlinks.
+func (fs *OSFileSystem) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// Open opens a file for reading.
+func (fs *OSFileSystem) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// ReadFile returns the content of a file.
+func (fs *OSFileSystem) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// WriteFile writes data to a file, creating it with perm if it does not exist.
+func (fs *OSFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(path, data, perm)
+}
+
+// Rename moves oldpath to newpath, replacing a file at newpath.
+func (fs *OSFileSystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// MkdirAll creates a directory and any missing parents.
+func (fs *OSFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// Remove removes a file or an empty directory.
+func (fs *OSFileSystem) Remove(path string) error {
+	return os.Remove(path)
+}
+
 // CollectImmediateFiles returns a list of regular files (not directories) directly in the folder.
 // Does not recurse into subdirectories. Excludes symlinks. Dotfiles are included.
-func (fs *FileSystem) CollectImmediateFiles(folder string) ([]string, error) {
+func (fs *OSFileSystem) CollectImmediateFiles(folder string) ([]string, error) {
 	entries, err := fs.ReadDir(folder)
 	if err != nil {
 		return nil, err
@@ -294,7 +397,8 @@ func (fs *FileSystem) CollectImmediateFiles(folder string) ([]string, error) {
 
 // CollectFilesRecursive returns a list of all regular files in the folder and its subdirectories.
 // Excludes symlinks. Dotfiles (hidden files) are included as per TUI spec line 193.
-func (fs *FileSystem) CollectFilesRecursive(folder string) ([]string, error) {
+// Subdirectories named in skipDirs (e.g. ".git") are not descended into.
+func (fs *OSFileSystem) CollectFilesRecursive(folder string, skipDirs ...string) ([]string, error) {
 	var files []string
 
 	err := filepath.WalkDir(folder, func(path string, d os.DirEntry, err error) error {
@@ -302,6 +406,10 @@ func (fs *FileSystem) CollectFilesRecursive(folder string) ([]string, error) {
 			return err
 		}
 
+		if d.IsDir() && path != folder && slices.Contains(skipDirs, d.Name()) {
+			return filepath.SkipDir
+		}
+
 		// Skip symlinks
 		info, err := os.Lstat(path)
 		if err != nil {
@@ -333,11 +441,10 @@ func (fs *FileSystem) CollectFilesRecursive(folder string) ([]string, error) {
 // SetImmutable sets the system-level immutable flag on a file.
 // macOS: Sets SF_IMMUTABLE (schg) - requires sudo to unset
 // Linux: Sets FS_IMMUTABLE_FL (+i) - requires sudo to unset
-// Prints a warning and returns nil if not running with root privileges.
-func (fs *FileSystem) SetImmutable(path string) error {
+// Returns ErrNeedsRoot without a change if not running with root privileges.
+func (fs *OSFileSystem) SetImmutable(path string) error {
 	if !fs.HasRootPrivileges() {
-		fmt.Printf("Warning: Setting immutable flag requires root privileges (sudo) for file %s - skipping\n", path)
-		return nil
+		return pathError("setimmutable", path, ErrNeedsRoot)
 	}
 
 	return fs.setImmutable(path)
@@ -346,11 +453,10 @@ func (fs *FileSystem) SetImmutable(path string) error {
 // ClearImmutable removes the system-level immutable flag from a file.
 // macOS: Clears SF_IMMUTABLE (chflags noschg) - requires sudo
 // Linux: Clears FS_IMMUTABLE_FL (chattr -i) - requires sudo
-// Prints a warning and returns nil if not running with root privileges.
-func (fs *FileSystem) ClearImmutable(path string) error {
+// Returns ErrNeedsRoot without a change if not running with root privileges.
+func (fs *OSFileSystem) ClearImmutable(path string) error {
 	if !fs.HasRootPrivileges() {
-		fmt.Printf("Warning: Clearing immutable flag requires root privileges (sudo) for file %s - skipping\n", path)
-		return nil
+		return pathError("clearimmutable", path, ErrNeedsRoot)
 	}
 
 	return fs.clearImmutable(path)
@@ -359,6 +465,38 @@ func (fs *FileSystem) ClearImmutable(path string) error {
 // IsImmutable checks if a file has the system-level immutable flag set.
 // macOS: Checks for SF_IMMUTABLE (schg)
 // Linux: Checks for FS_IMMUTABLE_FL (+i)
-func (fs *FileSystem) IsImmutable(path string) (bool, error) {
+func (fs *OSFileSystem) IsImmutable(path string) (bool, error) {
 	return fs.isImmutable(path)
 }
+
+// SetAppendOnly sets the system-level append-only flag on a file: it can only be
+// opened for appending, and neither renamed, removed nor changed in its permissions.
+// macOS: Sets SF_APPEND (sappnd) - requires sudo to unset
+// Linux: Sets FS_APPEND_FL (+a) - requires sudo to unset
+// Returns ErrNeedsRoot without a change if not running with root privileges.
+func (fs *OSFileSystem) SetAppendOnly(path string) error {
+	if !fs.HasRootPrivileges() {
+		return pathError("setappendonly", path, ErrNeedsRoot)
+	}
+
+	return fs.setAppendOnly(path)
+}
+
+// ClearAppendOnly removes the system-level append-only flag from a file.
+// macOS: Clears SF_APPEND (nosappnd)
+// Linux: Clears FS_APPEND_FL (-a)
+// Returns ErrNeedsRoot without a change if not running with root privileges.
+func (fs *OSFileSystem) ClearAppendOnly(path string) error {
+	if !fs.HasRootPrivileges() {
+		return pathError("clearappendonly", path, ErrNeedsRoot)
+	}
+
+	return fs.clearAppendOnly(path)
+}
+
+// IsAppendOnly checks if a file has the system-level append-only flag set.
+// macOS: Checks for SF_APPEND (sappnd)
+// Linux: Checks for FS_APPEND_FL (+a)
+func (fs *OSFileSystem) IsAppendOnly(path string) (bool, error) {
+	return fs.isAppendOnly(path)
+}
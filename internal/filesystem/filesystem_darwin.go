@@ -9,7 +9,7 @@ import (
 )
 
 // setImmutable sets SF_IMMUTABLE flag on macOS (schg)
-func (fs *FileSystem) setImmutable(path string) error {
+func (fs *OSFileSystem) setImmutable(path string) error {
 	// Get current flags to preserve them
 	var stat unix.Stat_t
 	if err := unix.Stat(path, &stat); err != nil {
@@ -25,7 +25,7 @@ func (fs *FileSystem) setImmutable(path string) error {
 }
 
 // clearImmutable clears SF_IMMUTABLE flag on macOS (chflags noschg)
-func (fs *FileSystem) clearImmutable(path string) error {
+func (fs *OSFileSystem) clearImmutable(path string) error {
 	// Get current flags
 	var stat unix.Stat_t
 	if err := unix.Stat(path, &stat); err != nil {
@@ -41,7 +41,7 @@ func (fs *FileSystem) clearImmutable(path string) error {
 }
 
 // isImmutable checks if SF_IMMUTABLE flag is set on macOS
-func (fs *FileSystem) isImmutable(path string) (bool, error) {
+func (fs *OSFileSystem) isImmutable(path string) (bool, error) {
 	var stat unix.Stat_t
 	if err := unix.Stat(path, &stat); err != nil {
 		return false, fmt.Errorf("failed to get file flags for %s: %w", path, err)
@@ -49,3 +49,45 @@ func (fs *FileSystem) isImmutable(path string) (bool, error) {
 
 	return (stat.Flags & unix.SF_IMMUTABLE) != 0, nil
 }
+
+// setAppendOnly sets SF_APPEND flag on macOS (sappnd)
+func (fs *OSFileSystem) setAppendOnly(path string) error {
+	// Get current flags to preserve them
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return fmt.Errorf("failed to get file flags for %s: %w", path, err)
+	}
+
+	// Set SF_APPEND flag while preserving existing flags
+	newFlags := stat.Flags | unix.SF_APPEND
+	if err := unix.Chflags(path, int(newFlags)); err != nil {
+		return fmt.Errorf("failed to set system append-only flag for file %s: %w", path, err)
+	}
+	return nil
+}
+
+// clearAppendOnly clears SF_APPEND flag on macOS (chflags nosappnd)
+func (fs *OSFileSystem) clearAppendOnly(path string) error {
+	// Get current flags
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return fmt.Errorf("failed to get file flags for %s: %w", path, err)
+	}
+
+	// Clear SF_APPEND flag
+	newFlags := stat.Flags &^ unix.SF_APPEND
+	if err := unix.Chflags(path, int(newFlags)); err != nil {
+		return fmt.Errorf("failed to clear system append-only flag for file %s: %w", path, err)
+	}
+	return nil
+}
+
+// isAppendOnly checks if SF_APPEND flag is set on macOS
+func (fs *OSFileSystem) isAppendOnly(path string) (bool, error) {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return false, fmt.Errorf("failed to get file flags for %s: %w", path, err)
+	}
+
+	return (stat.Flags & unix.SF_APPEND) != 0, nil
+}
@@ -1,6 +1,7 @@
 package filesystem
 
 import (
+	"errors"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -743,14 +744,14 @@ func TestSetImmutableBehavior(t *testing.T) {
 			}
 		}
 	} else {
-		// Running as non-root - test warning behavior
-		t.Log("Running as non-root - testing warning behavior")
+		// Running as non-root - test the ErrNeedsRoot error
+		t.Log("Running as non-root - testing ErrNeedsRoot")
 
 		if isSupported {
-			// Should print warning and return nil (no error) on supported platforms
+			// Should return ErrNeedsRoot without a change on supported platforms
 			err := fs.SetImmutable(testFile)
-			if err != nil {
-				t.Errorf("SetImmutable without root should return nil (with warning) on supported platform %s, got: %v", runtime.GOOS, err)
+			if !errors.Is(err, ErrNeedsRoot) {
+				t.Errorf("SetImmutable without root should return ErrNeedsRoot on supported platform %s, got: %v", runtime.GOOS, err)
 			}
 		} else {
 			// Should return "not supported" error on unsupported platforms
@@ -801,14 +802,14 @@ func TestClearImmutableBehavior(t *testing.T) {
 			}
 		}
 	} else {
-		// Running as non-root - test warning behavior
-		t.Log("Running as non-root - testing warning behavior")
+		// Running as non-root - test the ErrNeedsRoot error
+		t.Log("Running as non-root - testing ErrNeedsRoot")
 
 		if isSupported {
-			// Should print warning and return nil (no error) on supported platforms
+			// Should return ErrNeedsRoot without a change on supported platforms
 			err := fs.ClearImmutable(testFile)
-			if err != nil {
-				t.Errorf("ClearImmutable without root should return nil (with warning) on supported platform %s, got: %v", runtime.GOOS, err)
+			if !errors.Is(err, ErrNeedsRoot) {
+				t.Errorf("ClearImmutable without root should return ErrNeedsRoot on supported platform %s, got: %v", runtime.GOOS, err)
 			}
 		} else {
 			// Should return "not supported" error on unsupported platforms
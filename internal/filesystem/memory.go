@@ -0,0 +1,657 @@
+package filesystem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// MemFileSystem is a FileSystem that keeps files, directories and symlinks in memory.
+// It models modes, owners, groups and immutable and append-only flags like the kernel does:
+// such a file cannot be chmod'ed or chown'ed, and only root can change the flags. Owners and
+// groups are plain names, no user database is consulted.
+type MemFileSystem struct {
+	mu    sync.Mutex
+	root  bool
+	nodes map[string]*memNode // absolute, cleaned path -> node
+}
+
+// memNode is a file, directory or symlink of a MemFileSystem
+type memNode struct {
+	dir       bool
+	target    string // symlink target, empty for files and directories
+	content   []byte
+	mode      os.FileMode
+	owner     string
+	group     string
+	immutable bool
+	// appendOnly files refuse writes that replace their content
+	appendOnly bool
+	modTime    time.Time
+}
+
+// locked reports whether the immutable or append-only flag protects the node
+func (n *memNode) locked() bool {
+	return n.immutable || n.appendOnly
+}
+
+// NewMemFileSystem creates an empty MemFileSystem with root privileges.
+func NewMemFileSystem() *MemFileSystem {
+	fs := &MemFileSystem{root: true, nodes: make(map[string]*memNode)}
+	fs.nodes[string(filepath.Separator)] = &memNode{dir: true, mode: 0755, owner: "root", group: "root", modTime: time.Now()}
+	return fs
+}
+
+// SetRootPrivileges sets whether immutable and append-only flags can be changed (default
+// true). Without them the flag setters return ErrNeedsRoot, like the OS backend.
+func (fs *MemFileSystem) SetRootPrivileges(root bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.root = root
+}
+
+// AddDir creates a directory and any missing parents with the given mode, owner and group.
+func (fs *MemFileSystem) AddDir(path string, mode os.FileMode, owner, group string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.mkdirAll(memPath(path), mode, owner, group)
+}
+
+// AddFile creates or replaces a regular file. Missing parents are created with mode 0755.
+func (fs *MemFileSystem) AddFile(path string, content []byte, mode os.FileMode, owner, group string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	path = memPath(path)
+	if err := fs.mkdirAll(filepath.Dir(path), 0755, owner, group); err != nil {
+		return err
+	}
+	if node, ok := fs.nodes[path]; ok && node.dir {
+		return fmt.Errorf("failed to add file %s: %w", path, pathError("open", path, syscall.EISDIR))
+	}
+	fs.nodes[path] = &memNode{
+		content: slices.Clone(content),
+		mode:    mode.Perm(),
+		owner:   owner,
+		group:   group,
+		modTime: time.Now(),
+	}
+	return nil
+}
+
+// AddSymlink creates a symlink at path pointing to target. Relative targets are
+// resolved against the symlink's directory.
+func (fs *MemFileSystem) AddSymlink(path, target string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	path = memPath(path)
+	if err := fs.mkdirAll(filepath.Dir(path), 0755, "root", "root"); err != nil {
+		return err
+	}
+	fs.nodes[path] = &memNode{target: target, mode: 0777, owner: "root", group: "root", modTime: time.Now()}
+	return nil
+}
+
+// ReadFile returns the content of a file.
+func (fs *MemFileSystem) ReadFile(path string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	path, node, err := fs.stat("open", path)
+	if err != nil {
+		return nil, err
+	}
+	if node.dir {
+		return nil, pathError("read", path, syscall.EISDIR)
+	}
+	return slices.Clone(node.content), nil
+}
+
+// HasRootPrivileges returns whether immutable flags can be changed (see SetRootPrivileges).
+func (fs *MemFileSystem) HasRootPrivileges() bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.root
+}
+
+// FileExists checks if a file exists at the given path, following symlinks.
+func (fs *MemFileSystem) FileExists(path string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, _, err := fs.stat("stat", path)
+	return err == nil
+}
+
+// GetFileInfo retrieves the current file mode, owner, and group for a file.
+func (fs *MemFileSystem) GetFileInfo(path string) (mode os.FileMode, owner, group string, err error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, node, err := fs.stat("stat", path)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+	return node.mode, node.owner, node.group, nil
+}
+
+// ApplyPermissions applies the specified mode, owner, and group to a file, in the
+// same order as the OS backend. Empty owner or group strings mean "don't change".
+func (fs *MemFileSystem) ApplyPermissions(path string, mode os.FileMode, owner, group string) error {
+	if err := fs.Chmod(path, mode); err != nil {
+		return err
+	}
+	if owner != "" {
+		if err := fs.Chown(path, owner); err != nil {
+			return err
+		}
+	}
+	if group != "" {
+		if err := fs.Chgrp(path, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestorePermissions is an alias for ApplyPermissions.
+func (fs *MemFileSystem) RestorePermissions(path string, mode os.FileMode, owner, group string) error {
+	return fs.ApplyPermissions(path, mode, owner, group)
+}
+
+// Chmod changes the file mode (permissions) for the specified file.
+func (fs *MemFileSystem) Chmod(path string, mode os.FileMode) error {
+	node, err := fs.modify("chmod", path)
+	if err != nil {
+		return fmt.Errorf("failed to set permissions %o for file %s: %w", mode, path, err)
+	}
+	node.mode = mode.Perm()
+	return nil
+}
+
+// Chown changes the owner of the specified file.
+func (fs *MemFileSystem) Chown(path string, owner string) error {
+	node, err := fs.modify("chown", path)
+	if err != nil {
+		return fmt.Errorf("failed to set owner %s for file %s: %w", owner, path, err)
+	}
+	node.owner = owner
+	return nil
+}
+
+// Chgrp changes the group of the specified file.
+func (fs *MemFileSystem) Chgrp(path string, group string) error {
+	node, err := fs.modify("chown", path)
+	if err != nil {
+		return fmt.Errorf("failed to set group %s for file %s: %w", group, path, err)
+	}
+	node.group = group
+	return nil
+}
+
+// CheckFilesExist checks which files exist and which are missing.
+func (fs *MemFileSystem) CheckFilesExist(paths []string) (existing, missing []string) {
+	for _, path := range paths {
+		if fs.FileExists(path) {
+			existing = append(existing, path)
+		} else {
+			missing = append(missing, path)
+		}
+	}
+	return existing, missing
+}
+
+// ReadDir reads a directory and returns entries sorted with folders first, then alphabetically.
+func (fs *MemFileSystem) ReadDir(path string) ([]DirEntry, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	dir, node, err := fs.stat("open", path)
+	if err == nil && !node.dir {
+		err = pathError("readdirent", dir, syscall.ENOTDIR)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
+	}
+
+	var result []DirEntry
+	for _, name := range fs.children(dir) {
+		fullPath := filepath.Join(path, name)
+		child := fs.nodes[filepath.Join(dir, name)]
+		isDir := child.dir
+		if child.target != "" {
+			if _, target, err := fs.stat("stat", filepath.Join(dir, name)); err == nil {
+				isDir = target.dir
+			}
+		}
+		result = append(result, DirEntry{
+			Name:     name,
+			Path:     fullPath,
+			IsDir:    isDir,
+			IsLink:   child.target != "",
+			FileInfo: child.info(name),
+		})
+	}
+
+	sortDirEntries(result)
+	return result, nil
+}
+
+// Lstat returns file info without following symlinks.
+func (fs *MemFileSystem) Lstat(path string) (os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	node, ok := fs.nodes[memPath(path)]
+	if !ok {
+		return nil, fmt.Errorf("failed to lstat %s: %w", path, pathError("lstat", path, os.ErrNotExist))
+	}
+	return node.info(filepath.Base(path)), nil
+}
+
+// IsSymlink checks if a path is a symbolic link.
+func (fs *MemFileSystem) IsSymlink(path string) (bool, error) {
+	info, err := fs.Lstat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to check symlink %s: %w", path, err)
+	}
+	return info.Mode()&os.ModeSymlink != 0, nil
+}
+
+// IsDir checks if a path is a directory, following symlinks.
+func (fs *MemFileSystem) IsDir(path string) (bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, node, err := fs.stat("stat", path)
+	if err != nil {
+		return false, fmt.Errorf("failed to check directory %s: %w", path, err)
+	}
+	return node.dir, nil
+}
+
+// HashFile returns the hex-encoded SHA-256 of a file's content and its size.
+func (fs *MemFileSystem) HashFile(path string) (string, int64, error) {
+	content, err := fs.ReadFile(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), int64(len(content)), nil
+}
+
+// Stat returns file info, following symlinks.
+func (fs *MemFileSystem) Stat(path string) (os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, node, err := fs.stat("stat", path)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(filepath.Base(path)), nil
+}
+
+// Open opens a file for reading. The reader sees the content at the time of the call.
+func (fs *MemFileSystem) Open(path string) (io.ReadCloser, error) {
+	content, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// WriteFile replaces the content of a file, or creates it with perm and the owner and
+// group of its directory. An immutable or append-only file refuses the write.
+func (fs *MemFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	resolved, node, err := fs.stat("open", path)
+	if err == nil {
+		switch {
+		case node.dir:
+			return pathError("open", path, syscall.EISDIR)
+		case node.locked():
+			return pathError("open", path, os.ErrPermission)
+		}
+		node.content = slices.Clone(data)
+		node.modTime = time.Now()
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	resolved = memPath(path)
+	parent, ok := fs.nodes[filepath.Dir(resolved)]
+	if !ok || !parent.dir {
+		return pathError("open", path, os.ErrNotExist)
+	}
+	fs.nodes[resolved] = &memNode{
+		content: slices.Clone(data),
+		mode:    perm.Perm(),
+		owner:   parent.owner,
+		group:   parent.group,
+		modTime: time.Now(),
+	}
+	return nil
+}
+
+// Rename moves oldpath, and everything below it, to newpath, replacing a file at newpath.
+// Immutable and append-only files can be neither moved nor replaced.
+func (fs *MemFileSystem) Rename(oldpath, newpath string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	from, to := memPath(oldpath), memPath(newpath)
+	linkError := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	node, ok := fs.nodes[from]
+	if !ok {
+		return linkError(os.ErrNotExist)
+	}
+	if parent, ok := fs.nodes[filepath.Dir(to)]; !ok || !parent.dir {
+		return linkError(os.ErrNotExist)
+	}
+	if target, ok := fs.nodes[to]; ok {
+		switch {
+		case target.locked():
+			return linkError(os.ErrPermission)
+		case target.dir != node.dir:
+			return linkError(syscall.EISDIR)
+		}
+	}
+	if node.locked() {
+		return linkError(os.ErrPermission)
+	}
+	if from == to {
+		return nil
+	}
+
+	prefix := from + string(filepath.Separator)
+	for path, child := range fs.nodes {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			delete(fs.nodes, path)
+			fs.nodes[filepath.Join(to, rest)] = child
+		}
+	}
+	delete(fs.nodes, from)
+	fs.nodes[to] = node
+	return nil
+}
+
+// MkdirAll creates a directory and any missing parents, owned like their nearest
+// existing ancestor.
+func (fs *MemFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	dir := memPath(path)
+	ancestor := dir
+	for {
+		if node, ok := fs.nodes[ancestor]; ok {
+			return fs.mkdirAll(dir, perm, node.owner, node.group)
+		}
+		ancestor = filepath.Dir(ancestor)
+	}
+}
+
+// Remove removes a file, symlink or empty directory. Immutable and append-only files refuse it.
+func (fs *MemFileSystem) Remove(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	resolved := memPath(path)
+	node, ok := fs.nodes[resolved]
+	switch {
+	case !ok:
+		return pathError("remove", path, os.ErrNotExist)
+	case node.locked():
+		return pathError("remove", path, os.ErrPermission)
+	case node.dir && len(fs.children(resolved)) > 0:
+		return pathError("remove", path, syscall.ENOTEMPTY)
+	}
+	delete(fs.nodes, resolved)
+	return nil
+}
+
+// CollectImmediateFiles returns the regular files directly in the folder. Excludes symlinks.
+func (fs *MemFileSystem) CollectImmediateFiles(folder string) ([]string, error) {
+	entries, err := fs.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir && !entry.IsLink {
+			files = append(files, entry.Path)
+		}
+	}
+	return files, nil
+}
+
+// CollectFilesRecursive returns all regular files in the folder and its subdirectories,
+// in the lexical order of filepath.WalkDir. Excludes symlinks and does not descend
+// into subdirectories named in skipDirs.
+func (fs *MemFileSystem) CollectFilesRecursive(folder string, skipDirs ...string) ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	dir, node, err := fs.stat("lstat", folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory %s: %w", folder, err)
+	}
+	if !node.dir {
+		return []string{folder}, nil
+	}
+
+	var files []string
+	var walk func(dir, display string)
+	walk = func(dir, display string) {
+		for _, name := range fs.children(dir) {
+			child := fs.nodes[filepath.Join(dir, name)]
+			switch {
+			case child.target != "":
+				// Skip symlinks
+			case child.dir:
+				if !slices.Contains(skipDirs, name) {
+					walk(filepath.Join(dir, name), filepath.Join(display, name))
+				}
+			default:
+				files = append(files, filepath.Join(display, name))
+			}
+		}
+	}
+	walk(dir, folder)
+	return files, nil
+}
+
+// SetImmutable sets the immutable flag on a file.
+// Returns ErrNeedsRoot without a change if the FileSystem has no root privileges.
+func (fs *MemFileSystem) SetImmutable(path string) error {
+	return fs.setImmutable("setimmutable", path, true)
+}
+
+// ClearImmutable removes the immutable flag from a file.
+// Returns ErrNeedsRoot without a change if the FileSystem has no root privileges.
+func (fs *MemFileSystem) ClearImmutable(path string) error {
+	return fs.setImmutable("clearimmutable", path, false)
+}
+
+// IsImmutable checks if a file has the immutable flag set.
+func (fs *MemFileSystem) IsImmutable(path string) (bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, node, err := fs.stat("open", path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file %s for immutable flag check: %w", path, err)
+	}
+	return node.immutable, nil
+}
+
+// SetAppendOnly sets the append-only flag on a file.
+// Returns ErrNeedsRoot without a change if the FileSystem has no root privileges.
+func (fs *MemFileSystem) SetAppendOnly(path string) error {
+	return fs.setAppendOnly("setappendonly", path, true)
+}
+
+// ClearAppendOnly removes the append-only flag from a file.
+// Returns ErrNeedsRoot without a change if the FileSystem has no root privileges.
+func (fs *MemFileSystem) ClearAppendOnly(path string) error {
+	return fs.setAppendOnly("clearappendonly", path, false)
+}
+
+// setAppendOnly sets or clears the append-only flag of a file
+func (fs *MemFileSystem) setAppendOnly(op, path string, appendOnly bool) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if !fs.root {
+		return pathError(op, path, ErrNeedsRoot)
+	}
+	_, node, err := fs.stat("open", path)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s for append-only flag: %w", path, err)
+	}
+	node.appendOnly = appendOnly
+	return nil
+}
+
+// IsAppendOnly checks if a file has the append-only flag set.
+func (fs *MemFileSystem) IsAppendOnly(path string) (bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, node, err := fs.stat("open", path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file %s for append-only flag check: %w", path, err)
+	}
+	return node.appendOnly, nil
+}
+
+// NewWatcher is not supported: nothing changes a MemFileSystem behind guard's back.
+func (fs *MemFileSystem) NewWatcher() (*Watcher, error) {
+	return nil, fmt.Errorf("watching is not supported by the in-memory filesystem")
+}
+
+// setImmutable sets or clears the immutable flag of a file
+func (fs *MemFileSystem) setImmutable(op, path string, immutable bool) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if !fs.root {
+		return pathError(op, path, ErrNeedsRoot)
+	}
+	_, node, err := fs.stat("open", path)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s for immutable flag: %w", path, err)
+	}
+	node.immutable = immutable
+	return nil
+}
+
+// modify returns the node of path for a permission change, which an immutable or
+// append-only file refuses
+func (fs *MemFileSystem) modify(op, path string) (*memNode, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	resolved, node, err := fs.stat(op, path)
+	if err != nil {
+		return nil, err
+	}
+	if node.locked() {
+		return nil, pathError(op, resolved, os.ErrPermission)
+	}
+	node.modTime = time.Now()
+	return node, nil
+}
+
+// stat resolves symlinks and returns the path and node a path refers to.
+// The caller must hold fs.mu.
+func (fs *MemFileSystem) stat(op, path string) (string, *memNode, error) {
+	resolved := memPath(path)
+	for range 40 {
+		node, ok := fs.nodes[resolved]
+		if !ok {
+			return "", nil, pathError(op, path, os.ErrNotExist)
+		}
+		if node.target == "" {
+			return resolved, node, nil
+		}
+		target := node.target
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(resolved), target)
+		}
+		resolved = filepath.Clean(target)
+	}
+	return "", nil, pathError(op, path, syscall.ELOOP)
+}
+
+// mkdirAll creates dir and its missing parents. The caller must hold fs.mu.
+func (fs *MemFileSystem) mkdirAll(dir string, mode os.FileMode, owner, group string) error {
+	if node, ok := fs.nodes[dir]; ok {
+		if !node.dir {
+			return pathError("mkdir", dir, syscall.ENOTDIR)
+		}
+		return nil
+	}
+	if err := fs.mkdirAll(filepath.Dir(dir), mode, owner, group); err != nil {
+		return err
+	}
+	fs.nodes[dir] = &memNode{dir: true, mode: mode.Perm(), owner: owner, group: group, modTime: time.Now()}
+	return nil
+}
+
+// children returns the sorted names of the entries of dir. The caller must hold fs.mu.
+func (fs *MemFileSystem) children(dir string) []string {
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	var names []string
+	for path := range fs.nodes {
+		if rest, ok := strings.CutPrefix(path, prefix); ok && rest != "" && !strings.ContainsRune(rest, filepath.Separator) {
+			names = append(names, rest)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// pathError returns the error the os package returns for op on path
+func pathError(op, path string, err error) error {
+	return &os.PathError{Op: op, Path: path, Err: err}
+}
+
+// memPath returns the key of path: absolute and cleaned
+func memPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// info returns the os.FileInfo of the node
+func (n *memNode) info(name string) os.FileInfo {
+	return &memFileInfo{name: name, node: n}
+}
+
+// memFileInfo is the os.FileInfo of a MemFileSystem node
+type memFileInfo struct {
+	name string
+	node *memNode
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return int64(len(i.node.content)) }
+func (i *memFileInfo) ModTime() time.Time { return i.node.modTime }
+func (i *memFileInfo) IsDir() bool        { return i.node.dir }
+func (i *memFileInfo) Sys() any           { return nil }
+
+// Mode returns the permission bits and the symlink or directory type bit
+func (i *memFileInfo) Mode() os.FileMode {
+	switch {
+	case i.node.target != "":
+		return i.node.mode | os.ModeSymlink
+	case i.node.dir:
+		return i.node.mode | os.ModeDir
+	}
+	return i.node.mode
+}
@@ -0,0 +1,194 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// ============================================================================
+// MemFileSystem Tests
+// ============================================================================
+
+func TestMemFileSystemPermissions(t *testing.T) {
+	fs := NewMemFileSystem()
+	file := "/repo/main.go"
+	if err := fs.AddFile(file, []byte("package main"), 0644, "alice", "staff"); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	if err := fs.ApplyPermissions(file, 0600, "root", "wheel"); err != nil {
+		t.Fatalf("ApplyPermissions failed: %v", err)
+	}
+	mode, owner, group, err := fs.GetFileInfo(file)
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	if mode != 0600 || owner != "root" || group != "wheel" {
+		t.Errorf("Expected 0600 root:wheel, got %o %s:%s", mode, owner, group)
+	}
+
+	// An immutable file refuses permission changes until the flag is cleared
+	if err := fs.SetImmutable(file); err != nil {
+		t.Fatalf("SetImmutable failed: %v", err)
+	}
+	if immutable, _ := fs.IsImmutable(file); !immutable {
+		t.Error("Expected the immutable flag to be set")
+	}
+	if err := fs.Chmod(file, 0644); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected a permission error for chmod of an immutable file, got %v", err)
+	}
+	if err := fs.ClearImmutable(file); err != nil {
+		t.Fatalf("ClearImmutable failed: %v", err)
+	}
+	if err := fs.Chmod(file, 0644); err != nil {
+		t.Errorf("Chmod failed after clearing the immutable flag: %v", err)
+	}
+
+	// An append-only file refuses rewrites until the flag is cleared
+	if err := fs.SetAppendOnly(file); err != nil {
+		t.Fatalf("SetAppendOnly failed: %v", err)
+	}
+	if appendOnly, _ := fs.IsAppendOnly(file); !appendOnly {
+		t.Error("Expected the append-only flag to be set")
+	}
+	if err := fs.WriteFile(file, []byte("rewritten"), 0644); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected a permission error for rewriting an append-only file, got %v", err)
+	}
+	if err := fs.Remove(file); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected a permission error for removing an append-only file, got %v", err)
+	}
+	if err := fs.ClearAppendOnly(file); err != nil {
+		t.Fatalf("ClearAppendOnly failed: %v", err)
+	}
+	if err := fs.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Errorf("WriteFile failed after clearing the append-only flag: %v", err)
+	}
+
+	// Without root privileges the flags are left alone
+	fs.SetRootPrivileges(false)
+	if err := fs.SetImmutable(file); !errors.Is(err, ErrNeedsRoot) || !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("Expected ErrNeedsRoot, got %v", err)
+	}
+	if immutable, _ := fs.IsImmutable(file); immutable {
+		t.Error("Expected the immutable flag to stay cleared without root privileges")
+	}
+	if err := fs.SetAppendOnly(file); !errors.Is(err, ErrNeedsRoot) {
+		t.Fatalf("Expected ErrNeedsRoot, got %v", err)
+	}
+
+	if _, _, _, err := fs.GetFileInfo("/repo/missing.go"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
+
+func TestMemFileSystemTree(t *testing.T) {
+	fs := NewMemFileSystem()
+	for _, file := range []string{"/repo/b.go", "/repo/a.go", "/repo/src/x.go", "/repo/.git/HEAD"} {
+		if err := fs.AddFile(file, []byte(file), 0644, "alice", "staff"); err != nil {
+			t.Fatalf("AddFile failed: %v", err)
+		}
+	}
+	if err := fs.AddSymlink("/repo/link.go", "a.go"); err != nil {
+		t.Fatalf("AddSymlink failed: %v", err)
+	}
+
+	entries, err := fs.ReadDir("/repo")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	if want := []string{".git", "src", "a.go", "b.go", "link.go"}; !slices.Equal(names, want) {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+	if !entries[4].IsLink || entries[4].FileInfo.Mode()&os.ModeSymlink == 0 {
+		t.Error("Expected link.go to be a symlink")
+	}
+	if !fs.FileExists("/repo/link.go") {
+		t.Error("Expected FileExists to follow the symlink")
+	}
+
+	immediate, err := fs.CollectImmediateFiles("/repo")
+	if err != nil {
+		t.Fatalf("CollectImmediateFiles failed: %v", err)
+	}
+	if want := []string{"/repo/a.go", "/repo/b.go"}; !slices.Equal(immediate, want) {
+		t.Errorf("Expected %v, got %v", want, immediate)
+	}
+	recursive, err := fs.CollectFilesRecursive("/repo", ".git")
+	if err != nil {
+		t.Fatalf("CollectFilesRecursive failed: %v", err)
+	}
+	if want := []string{"/repo/a.go", "/repo/b.go", filepath.Join("/repo", "src", "x.go")}; !slices.Equal(recursive, want) {
+		t.Errorf("Expected %v, got %v", want, recursive)
+	}
+
+	hash, size, err := fs.HashFile("/repo/a.go")
+	if err != nil || size != int64(len("/repo/a.go")) || len(hash) != 64 {
+		t.Errorf("Unexpected hash %s, size %d, err %v", hash, size, err)
+	}
+}
+
+// TestMemFileSystemContent tests writing, renaming and removing files, and that an
+// immutable file refuses all three.
+func TestMemFileSystemContent(t *testing.T) {
+	fs := NewMemFileSystem()
+	if err := fs.AddDir("/repo", 0755, "alice", "staff"); err != nil {
+		t.Fatalf("AddDir failed: %v", err)
+	}
+
+	if err := fs.WriteFile("/repo/new.go", []byte("package new"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	mode, owner, group, _ := fs.GetFileInfo("/repo/new.go")
+	if mode != 0600 || owner != "alice" || group != "staff" {
+		t.Errorf("Expected 0600 alice:staff like the directory, got %o %s:%s", mode, owner, group)
+	}
+	if err := fs.WriteFile("/missing/new.go", nil, 0600); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error without a parent directory, got %v", err)
+	}
+
+	if err := fs.Rename("/repo/new.go", "/repo/main.go"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if fs.FileExists("/repo/new.go") {
+		t.Error("Expected new.go to be gone after the rename")
+	}
+	if f, err := fs.Open("/repo/main.go"); err != nil {
+		t.Errorf("Open failed: %v", err)
+	} else {
+		content, _ := io.ReadAll(f)
+		f.Close()
+		if string(content) != "package new" {
+			t.Errorf("Expected the renamed content, got %q", content)
+		}
+	}
+
+	if err := fs.SetImmutable("/repo/main.go"); err != nil {
+		t.Fatalf("SetImmutable failed: %v", err)
+	}
+	if err := fs.WriteFile("/repo/main.go", []byte("changed"), 0644); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected a permission error for writing an immutable file, got %v", err)
+	}
+	if err := fs.Rename("/repo/main.go", "/repo/other.go"); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected a permission error for renaming an immutable file, got %v", err)
+	}
+	if err := fs.Remove("/repo/main.go"); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected a permission error for removing an immutable file, got %v", err)
+	}
+	if err := fs.ClearImmutable("/repo/main.go"); err != nil {
+		t.Fatalf("ClearImmutable failed: %v", err)
+	}
+	if err := fs.Remove("/repo"); err == nil {
+		t.Error("Expected removing a non-empty directory to fail")
+	}
+	if err := fs.Remove("/repo/main.go"); err != nil || fs.FileExists("/repo/main.go") {
+		t.Errorf("Remove failed: %v", err)
+	}
+}
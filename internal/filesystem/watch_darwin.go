@@ -0,0 +1,38 @@
+//go:build darwin
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrWatchOverflow is sent on Errors when the kernel dropped events; watched state must be rescanned.
+var ErrWatchOverflow = errors.New("event queue overflowed")
+
+// Watcher reports changes of entries in watched directories.
+// Watching is only implemented with inotify on Linux.
+type Watcher struct {
+	Events chan string
+	Errors chan error
+}
+
+// NewWatcher is not supported on macOS.
+func (fs *OSFileSystem) NewWatcher() (*Watcher, error) {
+	return nil, fmt.Errorf("watching requires inotify and is only supported on Linux")
+}
+
+// Add starts watching the entries of a directory.
+func (w *Watcher) Add(dir string) error {
+	return nil
+}
+
+// Remove stops watching a directory.
+func (w *Watcher) Remove(dir string) error {
+	return nil
+}
+
+// Close stops the watcher.
+func (w *Watcher) Close() error {
+	return nil
+}
@@ -0,0 +1,168 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchMask selects the inotify events that can change the guard state of a directory entry
+const watchMask = unix.IN_CREATE | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB | unix.IN_MOVED_TO |
+	unix.IN_MOVED_FROM | unix.IN_DELETE | unix.IN_DELETE_SELF
+
+// ErrWatchOverflow is sent on Errors when the kernel dropped events; watched state must be rescanned.
+var ErrWatchOverflow = errors.New("inotify event queue overflowed")
+
+// Watcher reports changes of entries in watched directories (inotify).
+// Events carries the path of every created, written, moved, deleted or chmod'ed entry.
+type Watcher struct {
+	Events chan string
+	Errors chan error
+
+	fd      int
+	mu      sync.Mutex
+	watches map[int]string // watch descriptor -> directory
+	dirs    map[string]int // directory -> watch descriptor
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewWatcher creates a watcher. Directories are added with Add; Close stops it.
+func (fs *OSFileSystem) NewWatcher() (*Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	w := &Watcher{
+		Events:  make(chan string, 256),
+		Errors:  make(chan error, 8),
+		fd:      fd,
+		watches: make(map[int]string),
+		dirs:    make(map[string]int),
+		done:    make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.readEvents()
+	return w, nil
+}
+
+// Add starts watching the entries of a directory.
+func (w *Watcher) Add(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.dirs[dir]; ok {
+		return nil
+	}
+	wd, err := unix.InotifyAddWatch(w.fd, dir, watchMask)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	w.watches[wd] = dir
+	w.dirs[dir] = wd
+	return nil
+}
+
+// Remove stops watching a directory.
+func (w *Watcher) Remove(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wd, ok := w.dirs[dir]
+	if !ok {
+		return nil
+	}
+	delete(w.dirs, dir)
+	delete(w.watches, wd)
+	if _, err := unix.InotifyRmWatch(w.fd, uint32(wd)); err != nil && !errors.Is(err, unix.EINVAL) {
+		return fmt.Errorf("failed to stop watching %s: %w", dir, err)
+	}
+	return nil
+}
+
+// Close stops the watcher and releases the inotify instance.
+func (w *Watcher) Close() error {
+	close(w.done)
+	w.wg.Wait()
+	return unix.Close(w.fd)
+}
+
+// readEvents polls the inotify descriptor until the watcher is closed.
+// The descriptor is non-blocking so Close never waits for the next event.
+func (w *Watcher) readEvents() {
+	defer w.wg.Done()
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	fds := []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+
+		n, err := unix.Poll(fds, 200)
+		if err != nil && !errors.Is(err, unix.EINTR) {
+			w.sendError(fmt.Errorf("failed to poll inotify: %w", err))
+			return
+		}
+		if n <= 0 {
+			continue
+		}
+
+		n, err = unix.Read(w.fd, buf)
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			w.sendError(fmt.Errorf("failed to read inotify events: %w", err))
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&unix.IN_Q_OVERFLOW != 0 {
+				w.sendError(ErrWatchOverflow)
+				continue
+			}
+
+			w.mu.Lock()
+			dir, ok := w.watches[int(event.Wd)]
+			if event.Mask&unix.IN_IGNORED != 0 {
+				delete(w.watches, int(event.Wd))
+				delete(w.dirs, dir)
+			}
+			w.mu.Unlock()
+			if !ok {
+				continue
+			}
+
+			path := dir
+			if name := strings.TrimRight(string(nameBytes), "\x00"); name != "" {
+				path = filepath.Join(dir, name)
+			}
+			select {
+			case w.Events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+func (w *Watcher) sendError(err error) {
+	select {
+	case w.Errors <- err:
+	default:
+	}
+}
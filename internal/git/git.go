@@ -0,0 +1,220 @@
+// Package git runs the local git binary to inspect a working tree and to run git commands.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Repo is a git working tree.
+type Repo struct {
+	root string
+}
+
+// Open returns the working tree containing dir.
+// Returns an error if dir is not inside a git working tree or git is not installed.
+func Open(dir string) (*Repo, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git working tree: %w", err)
+	}
+	return &Repo{root: strings.TrimSpace(out)}, nil
+}
+
+// Root returns the top-level directory of the working tree.
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// TrackedFiles returns the files in the index.
+func (r *Repo) TrackedFiles() ([]string, error) {
+	return r.files("ls-files", "-z")
+}
+
+// UntrackedFiles returns the files git does not track, without ignored files.
+func (r *Repo) UntrackedFiles() ([]string, error) {
+	return r.files("ls-files", "-z", "--others", "--exclude-standard")
+}
+
+// StagedFiles returns the files with changes staged for the next commit.
+func (r *Repo) StagedFiles() ([]string, error) {
+	return r.files("diff", "--cached", "--name-only", "--no-renames", "-z")
+}
+
+// ModifiedFiles returns the tracked files with staged or unstaged changes.
+func (r *Repo) ModifiedFiles() ([]string, error) {
+	return r.files("diff", "HEAD", "--name-only", "--no-renames", "-z")
+}
+
+// ChangedFiles returns the files changed by a commit (e.g. HEAD) or between two commits (e.g. main..HEAD).
+func (r *Repo) ChangedFiles(rev string) ([]string, error) {
+	if err := validateRev(rev); err != nil {
+		return nil, err
+	}
+	if strings.Contains(rev, "..") {
+		return r.files("diff", "--name-only", "--no-renames", "-z", rev, "--")
+	}
+	return r.files("diff-tree", "-r", "--root", "--no-commit-id", "--name-only", "--no-renames", "-z", rev, "--")
+}
+
+// CommittedSince returns the files changed by commits since the given time.
+func (r *Repo) CommittedSince(since time.Time) ([]string, error) {
+	return r.files("log", "--since="+since.Format(time.RFC3339), "--format=", "--name-only", "--no-renames", "-z", "HEAD", "--")
+}
+
+// DiffFiles returns the files that differ between two commits.
+func (r *Repo) DiffFiles(from, to string) ([]string, error) {
+	if err := validateRev(from); err != nil {
+		return nil, err
+	}
+	if err := validateRev(to); err != nil {
+		return nil, err
+	}
+	return r.files("diff", "--name-only", "--no-renames", "-z", from, to, "--")
+}
+
+// ResolveCommit returns the commit a revision names, or false if it names none.
+func (r *Repo) ResolveCommit(rev string) (string, bool) {
+	if validateRev(rev) != nil {
+		return "", false
+	}
+	out, err := run(r.root, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(out), true
+}
+
+// MergeBase returns the best common ancestor of two commits.
+func (r *Repo) MergeBase(a, b string) (string, error) {
+	if err := validateRev(a); err != nil {
+		return "", err
+	}
+	if err := validateRev(b); err != nil {
+		return "", err
+	}
+	out, err := run(r.root, "merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Fetch fetches from a remote, e.g. Fetch("origin", "main"). The fetched commit is FETCH_HEAD.
+func (r *Repo) Fetch(args ...string) error {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("invalid fetch argument '%s'", arg)
+		}
+	}
+	_, err := run(r.root, append([]string{"fetch", "--quiet"}, args...)...)
+	return err
+}
+
+// CurrentBranch returns the name of the checked out branch, or false if HEAD is detached.
+func (r *Repo) CurrentBranch() (string, bool) {
+	out, err := run(r.root, "symbolic-ref", "--short", "--quiet", "HEAD")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(out), true
+}
+
+// HooksDir returns the directory git runs hooks from, honoring core.hooksPath.
+func (r *Repo) HooksDir() (string, error) {
+	out, err := run(r.root, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.root, dir)
+	}
+	return dir, nil
+}
+
+// Trailer is a "Key: value" line at the end of a commit message, e.g. "Signed-off-by: ...".
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// Trailers returns the trailers of the commit message in messageFile.
+func (r *Repo) Trailers(messageFile string) ([]Trailer, error) {
+	out, err := run(r.root, "interpret-trailers", "--parse", messageFile)
+	if err != nil {
+		return nil, err
+	}
+	var trailers []Trailer
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		trailers = append(trailers, Trailer{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return trailers, nil
+}
+
+// Command returns a git command that runs in dir attached to the terminal.
+func Command(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// files runs a git command that prints NUL-separated paths relative to the top-level directory
+// and returns them as absolute paths, without duplicates.
+func (r *Repo) files(args ...string) ([]string, error) {
+	out, err := run(r.root, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, filepath.Join(r.root, filepath.FromSlash(name)))
+	}
+	return files, nil
+}
+
+// validateRev rejects revisions git would parse as an option.
+func validateRev(rev string) error {
+	if rev == "" {
+		return fmt.Errorf("no revision specified")
+	}
+	if strings.HasPrefix(rev, "-") {
+		return fmt.Errorf("invalid revision '%s'", rev)
+	}
+	return nil
+}
+
+// run runs git in dir and returns its standard output.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
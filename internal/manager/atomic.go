@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/security"
+)
+
+// SetAtomic enables all-or-nothing mode for every filesystem phase run by this manager.
+// If any file fails to change, all files changed so far are reverted to their prior
+// state and the .guardfile is restored to the content it had when SetAtomic was called.
+// Call it after LoadRegistry and before the first operation.
+func (m *Manager) SetAtomic(atomic bool) error {
+	m.atomic = atomic
+	m.atomicRegistry = nil
+	if !atomic {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.registryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read .guardfile: %w", err)
+	}
+	m.atomicRegistry = data
+	return nil
+}
+
+// AtomicAborted returns true if an atomic run failed and all its changes were reverted.
+func (m *Manager) AtomicAborted() bool {
+	return m.atomicAborted
+}
+
+// abortAtomic reverts every change of the current atomic run, restores the .guardfile
+// and replaces the collected errors with a single error listing the failures.
+// The failed items are replaced by the files that could not be reverted (see Failure).
+func (m *Manager) abortAtomic() {
+	failures := m.errors
+	m.errors = make([]string, 0)
+	m.atomicAborted = true
+
+	// Revert in reverse order of application
+	var revertFailures, unreverted []string
+	var reverted []AuditFile
+	for i := len(m.atomicApplied) - 1; i >= 0; i-- {
+		entry := m.atomicApplied[i]
+		path := m.journalAbsPath(entry.Path)
+		change, _, err := recoveryChange(path, entry, true)
+		if err != nil {
+			revertFailures = append(revertFailures, fmt.Sprintf("%s: %v", entry.Path, err))
+			unreverted = append(unreverted, path)
+			continue
+		}
+		// A file whose change failed before it started has nothing to revert, and an
+		// immutable one could not be touched without root
+		if m.JournalEntryState(entry) == JournalEntryPending {
+			if immutable, err := m.fs.IsImmutable(path); err == nil && immutable == !entry.Guard {
+				continue
+			}
+		}
+		before := m.journalEntries([]permChange{change})[0]
+		if !m.applyPermChange(change) || m.HasErrors() {
+			revertFailures = append(revertFailures, m.errors...)
+			unreverted = append(unreverted, path)
+			m.errors = make([]string, 0)
+			continue
+		}
+		reverted = append(reverted, m.auditFile(before, change.guard, change.path))
+	}
+	m.atomicApplied = nil
+	m.failedItems = unreverted
+	m.rootFailures = nil
+	if len(reverted) > 0 {
+		m.writeAudit(AuditEntry{Operation: "atomic-revert", Files: reverted})
+	}
+
+	m.dropOpenHistoryEntry()
+	if err := m.restoreAtomicRegistry(); err != nil {
+		revertFailures = append(revertFailures, fmt.Sprintf("Failed to restore .guardfile: %v", err))
+	}
+
+	var msg strings.Builder
+	msg.WriteString("Error: Atomic operation failed, all changes were reverted and the registry was left untouched.\nFailures:")
+	for _, failure := range failures {
+		fmt.Fprintf(&msg, "\n  - %s", strings.TrimPrefix(failure, "Error: "))
+	}
+	if len(revertFailures) > 0 {
+		msg.WriteString("\nReverting failed for (check these files manually):")
+		for _, failure := range revertFailures {
+			fmt.Fprintf(&msg, "\n  - %s", strings.TrimPrefix(failure, "Error: "))
+		}
+	}
+	m.errors = []string{msg.String()}
+}
+
+// restoreAtomicRegistry writes the .guardfile back as it was when the atomic run started
+// and reloads it, discarding all in-memory registry changes of the run.
+func (m *Manager) restoreAtomicRegistry() error {
+	if m.atomicRegistry == nil {
+		return nil
+	}
+	if err := m.clearGuardfileImmutableFlag(); err != nil {
+		return err
+	}
+	// A dry run never wrote the .guardfile, reloading it is enough
+	if !m.DryRun() {
+		if err := os.WriteFile(m.registryPath, m.atomicRegistry, 0644); err != nil {
+			return err
+		}
+	}
+
+	sec, err := security.LoadSecurity(m.registryPath)
+	if err != nil {
+		return err
+	}
+	m.security = sec
+	m.snapshotHistoryState()
+	return nil
+}
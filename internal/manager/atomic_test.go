@@ -0,0 +1,150 @@
+package manager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+	"github.com/florianbuetow/guard/internal/registry"
+	"gopkg.in/yaml.v3"
+)
+
+// TestAtomicDisableRevertsOnFailure tests that a failure on one file reverts all
+// files already changed and leaves the .guardfile untouched.
+func TestAtomicDisableRevertsOnFailure(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0640)
+	file2 := createTestFile(t, tmpDir, "file2.txt", 0640)
+	defer func() {
+		_ = mgr.fs.ClearImmutable(file1)
+		_ = mgr.fs.ClearImmutable(file2)
+	}()
+
+	if err := mgr.EnableFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	// Make restoring file2 fail: its recorded owner does not exist
+	registryPath := filepath.Join(tmpDir, ".guardfile")
+	data, err := registry.ReadRegistryData(registryPath)
+	if err != nil {
+		t.Fatalf("ReadRegistryData failed: %v", err)
+	}
+	for i := range data.Files {
+		if data.Files[i].Path == "file2.txt" {
+			data.Files[i].Owner = "guard-no-such-user"
+		}
+	}
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		t.Fatalf("Failed to marshal registry: %v", err)
+	}
+	if err := mgr.clearGuardfileImmutableFlag(); err != nil {
+		t.Fatalf("Failed to clear .guardfile immutable flag: %v", err)
+	}
+	if err := os.WriteFile(registryPath, content, 0644); err != nil {
+		t.Fatalf("Failed to write registry: %v", err)
+	}
+
+	mgr = NewManager(registryPath, filesystem.NewFileSystem())
+	if err := mgr.LoadRegistry(); err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	if err := mgr.SetAtomic(true); err != nil {
+		t.Fatalf("SetAtomic failed: %v", err)
+	}
+
+	if err := mgr.DisableFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	if !mgr.AtomicAborted() {
+		t.Fatal("Expected the atomic operation to be aborted")
+	}
+	errs := mgr.GetErrors()
+	if len(errs) != 1 || !strings.Contains(errs[0], "Atomic operation failed") || !strings.Contains(errs[0], "guard-no-such-user") {
+		t.Errorf("Expected a single atomic error listing the failure, got %v", errs)
+	}
+
+	// file1 was unguarded first and must be guarded again
+	info, err := os.Stat(file1)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected file1 to be reverted to guard mode 0600, got %04o", info.Mode().Perm())
+	}
+
+	after, err := os.ReadFile(registryPath)
+	if err != nil {
+		t.Fatalf("Failed to read registry: %v", err)
+	}
+	if !bytes.Equal(after, content) {
+		t.Error("Expected the .guardfile to be left untouched")
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(file1); !guard {
+		t.Error("Expected file1 to still be guarded in the registry")
+	}
+}
+
+// TestAtomicFailureAfterRevert tests that a reverted atomic run is reported as a plain
+// failure, not as the needs-root failure that caused it.
+func TestAtomicFailureAfterRevert(t *testing.T) {
+	tmpDir := t.TempDir()
+	mem := filesystem.NewMemFileSystem()
+	mgr := NewManager(filepath.Join(tmpDir, ".guardfile"), mem)
+	if err := mgr.InitializeRegistry("0400", "root", "root", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	file1 := filepath.Join(tmpDir, "file1.txt")
+	file2 := filepath.Join(tmpDir, "file2.txt")
+	for _, path := range []string{file1, file2} {
+		if err := mem.AddFile(path, []byte("content"), 0644, "alice", "staff"); err != nil {
+			t.Fatalf("AddFile failed: %v", err)
+		}
+	}
+
+	// file2 is guarded by root with the immutable flag, file1 without it
+	if err := mgr.EnableFiles([]string{file2}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	mem.SetRootPrivileges(false)
+	if err := mgr.EnableFiles([]string{file1}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	mgr.ClearWarnings()
+
+	if err := mgr.SetAtomic(true); err != nil {
+		t.Fatalf("SetAtomic failed: %v", err)
+	}
+	if err := mgr.DisableFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+	if !mgr.AtomicAborted() {
+		t.Fatal("Expected the atomic operation to be aborted")
+	}
+	if err := mgr.Failure(); KindOf(err) != ErrorOther || len(ItemsOf(err)) != 0 {
+		t.Errorf("Expected a plain failure without items, got %v (%v, %v)", err, KindOf(err), ItemsOf(err))
+	}
+	if mode, _, _, _ := mem.GetFileInfo(file1); mode != 0400 {
+		t.Errorf("Expected file1 to be reverted to guard mode 0400, got %04o", mode)
+	}
+}
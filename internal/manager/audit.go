@@ -0,0 +1,299 @@
+package manager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+const auditFileName = "audit.jsonl"
+
+// AuditFile records the permission change of one file.
+type AuditFile struct {
+	Path        string `json:"path"`
+	Guard       bool   `json:"guard"`
+	BeforeMode  string `json:"before_mode"`
+	AfterMode   string `json:"after_mode"`
+	BeforeOwner string `json:"before_owner,omitempty"`
+	AfterOwner  string `json:"after_owner,omitempty"`
+	BeforeGroup string `json:"before_group,omitempty"`
+	AfterGroup  string `json:"after_group,omitempty"`
+}
+
+// AuditConfigChange records one changed configuration value.
+type AuditConfigChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// AuditEntry is one line of the audit log.
+// User and UID name the real user: the one who ran sudo, if guard runs under sudo.
+type AuditEntry struct {
+	Time      time.Time           `json:"time"`
+	User      string              `json:"user"`
+	UID       string              `json:"uid"`
+	Command   string              `json:"command"`
+	Operation string              `json:"operation"`
+	Files     []AuditFile         `json:"files,omitempty"`
+	Config    []AuditConfigChange `json:"config,omitempty"`
+}
+
+// AuditFilter selects audit entries. Zero values match everything.
+type AuditFilter struct {
+	File  string // Path of a file (relative to the current directory or absolute)
+	User  string // User name or UID
+	Since time.Time
+	Until time.Time
+}
+
+// String renders the entry for syslog and plain output, e.g.
+// "user=alice uid=1000 operation=disable command=\"guard disable a.txt\" a.txt 0600->0644"
+func (e AuditEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "user=%s uid=%s operation=%s command=%q", e.User, e.UID, e.Operation, e.Command)
+	for _, f := range e.Files {
+		fmt.Fprintf(&b, " %s %s->%s", f.Path, f.BeforeMode, f.AfterMode)
+	}
+	for _, c := range e.Config {
+		fmt.Fprintf(&b, " config.%s %s->%s", c.Field, c.Old, c.New)
+	}
+	return b.String()
+}
+
+// AuditLogPath returns the path of the audit log.
+func (m *Manager) AuditLogPath() string {
+	return filepath.Join(m.StateDir(), auditFileName)
+}
+
+// auditPermChanges logs the successfully applied changes of one filesystem phase.
+func (m *Manager) auditPermChanges(operation string, entries []JournalEntry, changes []permChange, succeeded map[string]bool) {
+	var files []AuditFile
+	for i, change := range changes {
+		if !succeeded[change.path] {
+			continue
+		}
+		files = append(files, m.auditFile(entries[i], change.guard, change.path))
+	}
+	if len(files) == 0 {
+		return
+	}
+	m.writeAudit(AuditEntry{Operation: operation, Files: files})
+}
+
+// auditFile describes the change of a journaled file, reading the state after the change from disk.
+func (m *Manager) auditFile(entry JournalEntry, guard bool, path string) AuditFile {
+	file := AuditFile{
+		Path:        entry.Path,
+		Guard:       guard,
+		BeforeMode:  entry.PrevMode,
+		BeforeOwner: entry.PrevOwner,
+		BeforeGroup: entry.PrevGroup,
+		AfterMode:   entry.Mode,
+		AfterOwner:  entry.Owner,
+		AfterGroup:  entry.Group,
+	}
+	if mode, owner, group, err := m.fs.GetFileInfo(path); err == nil {
+		file.AfterMode = formatJournalMode(mode)
+		file.AfterOwner = owner
+		file.AfterGroup = group
+	}
+	return file
+}
+
+// auditConfigChanges logs changed guard defaults between two saved registry states.
+func (m *Manager) auditConfigChanges(oldData, newData *registry.RegistryData) {
+	if oldData == nil || newData == nil {
+		return
+	}
+	diff := registry.DiffRegistryData(
+		&registry.RegistryData{Config: oldData.Config},
+		&registry.RegistryData{Config: newData.Config})
+
+	var changes []AuditConfigChange
+	for _, entry := range diff {
+		for _, field := range entry.Fields {
+			changes = append(changes, AuditConfigChange{Field: field.Field, Old: field.Old, New: field.New})
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+	m.writeAudit(AuditEntry{Operation: "config", Config: changes})
+}
+
+// writeAudit appends an entry to the audit log. A failure is reported as a warning,
+// the operation itself has already happened.
+func (m *Manager) writeAudit(entry AuditEntry) {
+	entry.Time = time.Now().UTC()
+	entry.User, entry.UID = auditUser()
+	entry.Command = auditCommand()
+
+	if err := m.appendAudit(entry); err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to write audit log: %v", err)))
+	}
+}
+
+// appendAudit writes one JSON line. The log is only ever opened for appending;
+// when running as root it is owned by root, closed to other users and append-only
+// (see protectAuditLog).
+func (m *Manager) appendAudit(entry AuditEntry) error {
+	if m.DryRun() {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to serialize audit entry: %w", err)
+	}
+	if _, err := m.ensureStateDir(""); err != nil {
+		return err
+	}
+
+	path := m.AuditLogPath()
+	created := !stateFileExists(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	m.protectAuditLog(path, created)
+	return nil
+}
+
+// protectAuditLog makes the audit log root-owned and append-only (chattr +a, chflags
+// sappnd), so that entries can neither be rewritten nor deleted without root clearing
+// the flag first. Without root privileges the log stays owned by the user, who can
+// rewrite it; that is warned about when the log is created.
+func (m *Manager) protectAuditLog(path string, created bool) {
+	if appendOnly, err := m.fs.IsAppendOnly(path); err == nil && appendOnly {
+		return // Protected by an earlier run as root: owner and mode can no longer change
+	}
+	if !runningAsRoot() {
+		if created {
+			m.AddWarning(NewWarning(WarningGeneric,
+				"The audit log was created without root privileges: it is not append-only and can be rewritten by its owner. Run guard with sudo to protect it"))
+		}
+		return
+	}
+
+	err := os.Chown(path, 0, 0)
+	if err == nil {
+		err = m.fs.SetAppendOnly(path)
+	}
+	if err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("The audit log could not be made root-owned and append-only: %v", err)))
+	}
+}
+
+// ReadAuditLog returns the audit entries matching the filter, oldest first.
+// With a file filter, each entry only lists the matching file.
+func (m *Manager) ReadAuditLog(filter AuditFilter) ([]AuditEntry, error) {
+	f, err := os.Open(m.AuditLogPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	fileFilter := ""
+	if filter.File != "" {
+		fileFilter = filter.File
+		if absPath, err := filepath.Abs(filter.File); err == nil {
+			fileFilter = m.journalRelPath(absPath)
+		}
+	}
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("audit log line %d is corrupted: %w", line, err)
+		}
+
+		if filter.User != "" && filter.User != entry.User && filter.User != entry.UID {
+			continue
+		}
+		if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && entry.Time.After(filter.Until) {
+			continue
+		}
+		if fileFilter != "" {
+			var files []AuditFile
+			for _, file := range entry.Files {
+				if file.Path == fileFilter {
+					files = append(files, file)
+				}
+			}
+			if len(files) == 0 {
+				continue
+			}
+			entry.Files = files
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// SendAuditToSyslog writes the entries to the local syslog daemon (facility auth).
+func SendAuditToSyslog(entries []AuditEntry) error {
+	writer, err := syslog.New(syslog.LOG_AUTH|syslog.LOG_NOTICE, "guard")
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer writer.Close()
+
+	for _, entry := range entries {
+		if err := writer.Notice(entry.Time.Format(time.RFC3339) + " " + entry.String()); err != nil {
+			return fmt.Errorf("failed to write to syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+// auditUser returns the real user behind the current process.
+// Under sudo that is SUDO_USER/SUDO_UID, otherwise the current user.
+func auditUser() (name, uid string) {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser, os.Getenv("SUDO_UID")
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username, current.Uid
+	}
+	return "", strconv.Itoa(os.Getuid())
+}
+
+// auditCommand returns the command line that started guard.
+func auditCommand() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	return strings.Join(append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...), " ")
+}
@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAuditLogRecordsChanges tests that guard state and config changes are logged with the real user.
+func TestAuditLogRecordsChanges(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	t.Setenv("SUDO_USER", "alice")
+	t.Setenv("SUDO_UID", "1234")
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	file2 := createTestFile(t, tmpDir, "file2.txt", 0644)
+	if err := mgr.ToggleFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("ToggleFiles failed: %v", err)
+	}
+	if err := mgr.DisableFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+	if err := mgr.SetConfigMode("0400"); err != nil {
+		t.Fatalf("SetConfigMode failed: %v", err)
+	}
+
+	entries, err := mgr.ReadAuditLog(AuditFilter{})
+	if err != nil {
+		t.Fatalf("ReadAuditLog failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 audit entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Operation != "toggle" || entries[0].User != "alice" || entries[0].UID != "1234" {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if f := entries[0].Files[0]; f.BeforeMode != "0644" || f.AfterMode != "0600" || !f.Guard {
+		t.Errorf("Unexpected file change: %+v", f)
+	}
+	if f := entries[1].Files[0]; f.BeforeMode != "0600" || f.AfterMode != "0644" || f.Guard {
+		t.Errorf("Unexpected file change: %+v", f)
+	}
+	if len(entries[2].Config) != 1 || entries[2].Config[0].Field != "mode" || entries[2].Config[0].New != "0400" {
+		t.Errorf("Expected the config change to be logged, got %+v", entries[2])
+	}
+
+	// Filters
+	entries, _ = mgr.ReadAuditLog(AuditFilter{File: file2})
+	if len(entries) != 2 || len(entries[0].Files) != 1 || entries[0].Files[0].Path != "file2.txt" {
+		t.Errorf("Expected 2 entries limited to file2.txt, got %+v", entries)
+	}
+	entries, _ = mgr.ReadAuditLog(AuditFilter{User: "bob"})
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries for bob, got %d", len(entries))
+	}
+	entries, _ = mgr.ReadAuditLog(AuditFilter{Since: time.Now().Add(time.Hour)})
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries in the future, got %d", len(entries))
+	}
+}
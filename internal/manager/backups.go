@@ -0,0 +1,419 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/registry"
+	"github.com/florianbuetow/guard/internal/security"
+)
+
+const (
+	// StateDirName is the directory next to .guardfile holding guard's internal state
+	StateDirName = ".guard"
+
+	// DefaultBackupGenerations is the number of .guardfile generations kept by SaveRegistry
+	DefaultBackupGenerations = 10
+
+	backupDirName    = "backups"
+	backupFilePrefix = "guardfile-"
+	backupTimeFormat = "20060102T150405.000000000Z"
+)
+
+// RegistryBackup describes one saved generation of the .guardfile.
+// Generation 1 is the most recent backup.
+type RegistryBackup struct {
+	Generation int
+	Path       string
+	Time       time.Time
+}
+
+// StateDir returns the directory holding guard's internal state (backups, journals, ...).
+func (m *Manager) StateDir() string {
+	return filepath.Join(filepath.Dir(m.registryPath), StateDirName)
+}
+
+// IsStatePath returns true if path lies inside guard's state directory.
+// Such paths must never be registered or guarded.
+func (m *Manager) IsStatePath(path string) bool {
+	stateDir, err := filepath.Abs(m.StateDir())
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return absPath == stateDir || strings.HasPrefix(absPath, stateDir+string(filepath.Separator))
+}
+
+// SetBackupGenerations sets how many .guardfile generations SaveRegistry keeps.
+// A value of 0 disables backups.
+func (m *Manager) SetBackupGenerations(n int) {
+	if n < 0 {
+		n = 0
+	}
+	m.backupGenerations = n
+}
+
+// ensureStateDir creates a directory below the state directory and returns its path.
+// When running as root the directories are owned by root and closed to other users,
+// so an unprivileged process cannot tamper with guard's state.
+func (m *Manager) ensureStateDir(sub string) (string, error) {
+	dir := filepath.Join(m.StateDir(), sub)
+	if m.DryRun() {
+		return dir, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	if runningAsRoot() {
+		for _, d := range []string{m.StateDir(), dir} {
+			if err := os.Chown(d, 0, 0); err != nil {
+				return "", fmt.Errorf("failed to change owner of %s: %w", d, err)
+			}
+			if err := os.Chmod(d, 0700); err != nil {
+				return "", fmt.Errorf("failed to change mode of %s: %w", d, err)
+			}
+		}
+	}
+	return dir, nil
+}
+
+// runningAsRoot returns true if guard itself runs as root. The state directory is always
+// on disk, even when the guarded files are simulated with a filesystem.MemFileSystem.
+func runningAsRoot() bool {
+	return os.Geteuid() == 0
+}
+
+// stateFileExists reports whether one of guard's own files, the .guardfile or a file in
+// the state directory, exists. These are always on disk, whatever FileSystem the
+// managed files are on.
+func stateFileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// writeStateFile writes a file inside the state directory, root-owned when running under sudo.
+// A dry run writes nothing; the files users name, like saved states, are recorded by their callers.
+func (m *Manager) writeStateFile(path string, data []byte) error {
+	if m.DryRun() {
+		return nil
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	if runningAsRoot() {
+		return os.Chown(path, 0, 0)
+	}
+	return nil
+}
+
+// backupRegistry copies the current on-disk .guardfile into the backup directory
+// and prunes generations beyond the configured limit.
+// Nothing is written if the .guardfile equals the pending content (a no-op save)
+// or is identical to the newest backup. pending may be nil.
+func (m *Manager) backupRegistry(pending []byte) error {
+	if m.backupGenerations == 0 || !stateFileExists(m.registryPath) {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.registryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read .guardfile: %w", err)
+	}
+	if pending != nil && bytes.Equal(pending, data) {
+		return nil
+	}
+
+	backups, err := m.ListRegistryBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		if latest, err := os.ReadFile(backups[0].Path); err == nil && bytes.Equal(latest, data) {
+			return nil
+		}
+	}
+
+	dir, err := m.ensureStateDir(backupDirName)
+	if err != nil {
+		return err
+	}
+
+	name := backupFilePrefix + time.Now().UTC().Format(backupTimeFormat)
+	if err := m.writeStateFile(filepath.Join(dir, name), data); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return m.pruneRegistryBackups()
+}
+
+// pruneRegistryBackups removes the oldest generations beyond the configured limit.
+func (m *Manager) pruneRegistryBackups() error {
+	backups, err := m.ListRegistryBackups()
+	if err != nil {
+		return err
+	}
+	for i := m.backupGenerations; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %w", backups[i].Path, err)
+		}
+	}
+	return nil
+}
+
+// ListRegistryBackups returns all saved .guardfile generations, newest first.
+func (m *Manager) ListRegistryBackups() ([]RegistryBackup, error) {
+	dir := filepath.Join(m.StateDir(), backupDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []RegistryBackup
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), backupFilePrefix) {
+			continue
+		}
+		stamp, err := time.Parse(backupTimeFormat, strings.TrimPrefix(entry.Name(), backupFilePrefix))
+		if err != nil {
+			continue // Not one of ours
+		}
+		backups = append(backups, RegistryBackup{
+			Path: filepath.Join(dir, entry.Name()),
+			Time: stamp,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	for i := range backups {
+		backups[i].Generation = i + 1
+	}
+	return backups, nil
+}
+
+// getRegistryBackup returns the backup with the given generation number.
+func (m *Manager) getRegistryBackup(generation int) (RegistryBackup, error) {
+	backups, err := m.ListRegistryBackups()
+	if err != nil {
+		return RegistryBackup{}, err
+	}
+	if len(backups) == 0 {
+		return RegistryBackup{}, fmt.Errorf("no registry backups found")
+	}
+	if generation < 1 || generation > len(backups) {
+		return RegistryBackup{}, fmt.Errorf("backup generation %d not found (available: 1-%d)", generation, len(backups))
+	}
+	return backups[generation-1], nil
+}
+
+// ReadRegistryBackup parses the backup with the given generation number.
+func (m *Manager) ReadRegistryBackup(generation int) (*registry.RegistryData, error) {
+	backup, err := m.getRegistryBackup(generation)
+	if err != nil {
+		return nil, err
+	}
+	return registry.ReadRegistryData(backup.Path)
+}
+
+// ReadCurrentRegistryData parses the .guardfile as it is currently stored on disk.
+func (m *Manager) ReadCurrentRegistryData() (*registry.RegistryData, error) {
+	return registry.ReadRegistryData(m.registryPath)
+}
+
+// DiffRegistryBackup returns the changes between two generations, from the older state
+// from to the state to. Generation 0 is the current .guardfile, so DiffRegistryBackup(0, n)
+// lists what restoring generation n would change.
+func (m *Manager) DiffRegistryBackup(from, to int) ([]registry.DiffEntry, error) {
+	fromData, err := m.readRegistryGeneration(from)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := m.readRegistryGeneration(to)
+	if err != nil {
+		return nil, err
+	}
+	return registry.DiffRegistryData(fromData, toData), nil
+}
+
+// readRegistryGeneration parses a backup generation, or the current .guardfile for 0.
+// A missing or corrupted .guardfile is nil: everything in the other generation counts as added.
+func (m *Manager) readRegistryGeneration(generation int) (*registry.RegistryData, error) {
+	if generation != 0 {
+		return m.ReadRegistryBackup(generation)
+	}
+	data, err := m.ReadCurrentRegistryData()
+	if err != nil {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// RestoreRegistryBackup replaces the .guardfile with the given generation.
+// The current .guardfile is backed up first so the restore itself can be undone.
+// File permissions on disk are NOT changed; the returned paths are the registered
+// files whose guard flag differs between the previous and the restored registry,
+// which the caller may pass to ApplyRegisteredState after confirmation.
+func (m *Manager) RestoreRegistryBackup(generation int) ([]string, error) {
+	backup, err := m.getRegistryBackup(generation)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	previous, err := m.ReadCurrentRegistryData()
+	if err != nil {
+		previous = nil // A corrupted .guardfile is exactly what a restore is for
+	}
+
+	// Validate the backup (including tampering checks) before it replaces the .guardfile
+	tmpPath := m.registryPath + ".restore"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to stage backup: %w", err)
+	}
+	if _, err := security.LoadSecurity(tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("backup generation %d is not a valid .guardfile: %w", generation, err)
+	}
+
+	if err := m.backupRegistry(nil); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to back up current .guardfile: %w", err)
+	}
+	if err := m.clearGuardfileImmutableFlag(); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, m.registryPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to restore .guardfile: %w", err)
+	}
+
+	// Nothing on disk may change before the caller confirms ApplyRegisteredState:
+	// the expiry and journal checks of LoadRegistry are left to that step
+	if err := m.LoadRegistryReadOnly(); err != nil {
+		return nil, err
+	}
+	m.snapshotHistoryState()
+
+	restored, err := registry.ParseRegistryData(data)
+	if err != nil {
+		return nil, err
+	}
+	m.warnDroppedGuardedFiles(previous, restored)
+	return m.guardChangedFiles(previous, restored), nil
+}
+
+// warnDroppedGuardedFiles warns about guarded files that the restored registry no longer knows.
+// Their original permissions are only recorded in the backup that was just made.
+func (m *Manager) warnDroppedGuardedFiles(oldData, newData *registry.RegistryData) {
+	if oldData == nil {
+		return
+	}
+	known := make(map[string]bool, len(newData.Files))
+	for _, f := range newData.Files {
+		known[f.Path] = true
+	}
+	var dropped []string
+	for _, f := range oldData.Files {
+		if f.Guard && !known[f.Path] {
+			dropped = append(dropped, f.Path)
+		}
+	}
+	if len(dropped) > 0 {
+		sort.Strings(dropped)
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf(
+			"These files stay guarded but are not in the restored registry (restore generation 1 to undo): %s",
+			strings.Join(dropped, ", "))))
+	}
+}
+
+// guardChangedFiles returns absolute paths of files in newData whose guard flag
+// differs from oldData (files missing from oldData count as unguarded).
+func (m *Manager) guardChangedFiles(oldData, newData *registry.RegistryData) []string {
+	oldGuard := make(map[string]bool)
+	if oldData != nil {
+		for _, f := range oldData.Files {
+			oldGuard[f.Path] = f.Guard
+		}
+	}
+
+	baseDir := filepath.Dir(m.registryPath)
+	var changed []string
+	for _, f := range newData.Files {
+		if oldGuard[f.Path] != f.Guard {
+			abs, err := filepath.Abs(filepath.Join(baseDir, f.Path))
+			if err != nil {
+				continue
+			}
+			changed = append(changed, abs)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ApplyRegisteredState brings the given files on disk in line with their registry entries:
+// guarded files get the guard permissions and the immutable flag, unguarded files get
+// their original permissions back. The registry itself is not modified, except for
+// time-limited guard changes that expired, which are reverted first as by LoadRegistry.
+func (m *Manager) ApplyRegisteredState(paths []string) error {
+	if m.security == nil {
+		return errRegistryNotLoaded
+	}
+
+	// RestoreRegistryBackup loads the registry read-only
+	if err := m.checkPendingJournal(); err != nil {
+		return err
+	}
+	m.ExpireGuards()
+
+	var missingFiles []string
+	var changes []permChange
+	for _, path := range paths {
+		if !m.security.IsRegisteredFile(path) {
+			continue
+		}
+		if !m.fs.FileExists(path) {
+			missingFiles = append(missingFiles, path)
+			continue
+		}
+
+		owner, group, mode, guard, err := m.security.GetRegisteredFileConfig(path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to read registry entry for %s: %v", path, err))
+			continue
+		}
+
+		if guard {
+			changes = append(changes, m.guardPermChange(path))
+		} else {
+			changes = append(changes, unguardPermChange(path, mode, owner, group))
+		}
+	}
+
+	if len(missingFiles) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", m.toDisplayPaths(missingFiles)...))
+	}
+
+	m.applyPermChanges("restore-registry", changes)
+
+	// The restored registry was saved before the filesystem phase
+	m.settleJournal()
+	return nil
+}
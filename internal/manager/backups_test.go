@@ -0,0 +1,254 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestSaveRegistryCreatesBackups tests that each save keeps the previous .guardfile as a generation.
+func TestSaveRegistryCreatesBackups(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0644", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	// AddFiles saves the registry, keeping the initialized .guardfile as generation 1
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.AddFiles([]string{file1}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+
+	// Saving unchanged content must not create a duplicate generation
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	backups, err := mgr.ListRegistryBackups()
+	if err != nil {
+		t.Fatalf("ListRegistryBackups failed: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %d", len(backups))
+	}
+	if backups[0].Generation != 1 {
+		t.Errorf("Expected generation 1, got %d", backups[0].Generation)
+	}
+
+	// The backup holds the freshly initialized registry without the file
+	data, err := mgr.ReadRegistryBackup(1)
+	if err != nil {
+		t.Fatalf("ReadRegistryBackup failed: %v", err)
+	}
+	if len(data.Files) != 0 {
+		t.Errorf("Expected backup without files, got %d", len(data.Files))
+	}
+}
+
+// TestSaveRegistryPrunesBackups tests that only the configured number of generations is kept.
+func TestSaveRegistryPrunesBackups(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0644", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	mgr.SetBackupGenerations(2)
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		path := createTestFile(t, tmpDir, name, 0644)
+		if err := mgr.AddFiles([]string{path}); err != nil {
+			t.Fatalf("AddFiles failed: %v", err)
+		}
+	}
+
+	backups, err := mgr.ListRegistryBackups()
+	if err != nil {
+		t.Fatalf("ListRegistryBackups failed: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups, got %d", len(backups))
+	}
+
+	// Newest backup is the state before d.txt was added
+	data, err := mgr.ReadRegistryBackup(1)
+	if err != nil {
+		t.Fatalf("ReadRegistryBackup failed: %v", err)
+	}
+	if len(data.Files) != 3 {
+		t.Errorf("Expected 3 files in newest backup, got %d", len(data.Files))
+	}
+}
+
+// TestRestoreRegistryBackup tests restoring a generation without touching file permissions.
+func TestRestoreRegistryBackup(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.AddFiles([]string{file1}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+
+	// Guard the file only in the registry, then save (generation 1 = unguarded state)
+	if err := mgr.GetRegistry().SetRegisteredFileGuard(file1, true); err != nil {
+		t.Fatalf("SetRegisteredFileGuard failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	diff, err := mgr.DiffRegistryBackup(0, 1)
+	if err != nil {
+		t.Fatalf("DiffRegistryBackup failed: %v", err)
+	}
+	if len(diff) != 1 || diff[0].String() != "~ file file1.txt: guard true -> false" {
+		t.Fatalf("Unexpected diff: %v", diff)
+	}
+
+	changed, err := mgr.RestoreRegistryBackup(1)
+	if err != nil {
+		t.Fatalf("RestoreRegistryBackup failed: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != file1 {
+		t.Errorf("Expected %s to be reported as changed, got %v", file1, changed)
+	}
+
+	guard, err := mgr.GetRegistry().GetRegisteredFileGuard(file1)
+	if err != nil {
+		t.Fatalf("GetRegisteredFileGuard failed: %v", err)
+	}
+	if guard {
+		t.Error("Expected restored registry to have file unguarded")
+	}
+
+	// File permissions must be untouched by the restore
+	info, err := os.Stat(file1)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected file mode 0644, got %o", info.Mode().Perm())
+	}
+
+	// The restore itself was backed up
+	backups, err := mgr.ListRegistryBackups()
+	if err != nil {
+		t.Fatalf("ListRegistryBackups failed: %v", err)
+	}
+	if len(backups) != 3 {
+		t.Errorf("Expected 3 backups after restore, got %d", len(backups))
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".guardfile.restore")); !os.IsNotExist(err) {
+		t.Error("Expected staging file to be removed after restore")
+	}
+}
+
+// TestRestoreRegistryBackupPendingJournal tests that a pending journal does not fail the
+// restore after the .guardfile was replaced, only the confirmed ApplyRegisteredState.
+func TestRestoreRegistryBackupPendingJournal(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.AddFiles([]string{file1}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.GetRegistry().SetRegisteredFileGuard(file1, true); err != nil {
+		t.Fatalf("SetRegisteredFileGuard failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	writeTestJournal(t, tmpDir, "operation: enable\nentries: []\n")
+
+	changed, err := mgr.RestoreRegistryBackup(1)
+	if err != nil {
+		t.Fatalf("RestoreRegistryBackup failed: %v", err)
+	}
+	if err := mgr.ApplyRegisteredState(changed); KindOf(err) != ErrorPendingJournal {
+		t.Errorf("Expected a pending-journal error from ApplyRegisteredState, got %v", err)
+	}
+}
+
+// TestRestoreRegistryBackupInvalidGeneration tests error handling for unknown generations.
+func TestRestoreRegistryBackupInvalidGeneration(t *testing.T) {
+	mgr, _, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0644", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	if _, err := mgr.RestoreRegistryBackup(1); err == nil {
+		t.Error("Expected error when no backups exist")
+	}
+}
+
+// TestDiffRegistryBackup tests diffing two generations in both directions,
+// with generation 0 standing for the current .guardfile.
+func TestDiffRegistryBackup(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0644", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	// Generation 2 has no files, generation 1 has file1, the .guardfile both files
+	for _, name := range []string{"file1.txt", "file2.txt"} {
+		if err := mgr.AddFiles([]string{createTestFile(t, tmpDir, name, 0644)}); err != nil {
+			t.Fatalf("AddFiles failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		from, to int
+		want     []string
+	}{
+		{2, 1, []string{"+ file file1.txt"}},
+		{1, 2, []string{"- file file1.txt"}},
+		{1, 0, []string{"+ file file2.txt"}},
+		{0, 2, []string{"- file file1.txt", "- file file2.txt"}},
+		{0, 0, nil},
+	}
+	for _, tt := range tests {
+		diff, err := mgr.DiffRegistryBackup(tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("DiffRegistryBackup(%d, %d) failed: %v", tt.from, tt.to, err)
+		}
+		var got []string
+		for _, entry := range diff {
+			got = append(got, entry.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("DiffRegistryBackup(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := mgr.DiffRegistryBackup(3, 0); err == nil {
+		t.Error("Expected error for an unknown generation")
+	}
+}
+
+// TestIsStatePath tests detection of paths inside the state directory.
+func TestIsStatePath(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if !mgr.IsStatePath(filepath.Join(tmpDir, ".guard", "backups", "x")) {
+		t.Error("Expected backup file to be a state path")
+	}
+	if mgr.IsStatePath(filepath.Join(tmpDir, ".guardian")) {
+		t.Error("Expected .guardian not to be a state path")
+	}
+}
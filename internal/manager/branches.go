@@ -0,0 +1,192 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/git"
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+// SetBranchProfile sets the guard state for branches matching pattern, replacing a profile
+// with the same pattern. targets is either the name of one saved state or collection names.
+// The caller saves the registry.
+func (m *Manager) SetBranchProfile(pattern string, targets []string) (*registry.BranchProfile, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if err := validateBranchPattern(pattern); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no saved state or collections specified")
+	}
+
+	profile := registry.BranchProfile{Pattern: pattern}
+	if len(targets) == 1 && validateStateName(targets[0]) == nil && stateFileExists(m.statePath(targets[0])) {
+		if m.security.IsRegisteredCollection(targets[0]) {
+			return nil, fmt.Errorf("'%s' is both a saved state and a collection. Rename one of them", targets[0])
+		}
+		profile.State = targets[0]
+	} else {
+		var unknown []string
+		for _, name := range targets {
+			if !m.security.IsRegisteredCollection(name) {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			return nil, fmt.Errorf("no saved state or collection named %s", strings.Join(unknown, ", "))
+		}
+		profile.Collections = targets
+	}
+
+	m.security.SetBranchProfile(profile)
+	return &profile, nil
+}
+
+// RemoveBranchProfile removes the profile for pattern. The caller saves the registry.
+func (m *Manager) RemoveBranchProfile(pattern string) error {
+	if m.security == nil {
+		return errRegistryNotLoaded
+	}
+	if !m.security.RemoveBranchProfile(pattern) {
+		return newError(ErrorNotFound, []string{pattern}, "branch profile not found: %s", pattern)
+	}
+	return nil
+}
+
+// GetBranchProfiles returns the branch profiles in the order they are matched.
+func (m *Manager) GetBranchProfiles() []registry.BranchProfile {
+	if m.security == nil {
+		return nil
+	}
+	return m.security.GetBranchProfiles()
+}
+
+// BranchProfileFor returns the first profile whose pattern matches branch, or nil if none does.
+func (m *Manager) BranchProfileFor(branch string) *registry.BranchProfile {
+	for _, profile := range m.GetBranchProfiles() {
+		if matched, _ := path.Match(profile.Pattern, branch); matched {
+			return &profile
+		}
+	}
+	return nil
+}
+
+// CurrentBranch returns the checked out git branch, or false if HEAD is detached.
+func (m *Manager) CurrentBranch() (string, bool, error) {
+	repo, err := m.gitRepo()
+	if err != nil {
+		return "", false, err
+	}
+	branch, ok := repo.CurrentBranch()
+	return branch, ok, nil
+}
+
+// BranchProfileChanges returns the profile for branch and the guard state changes applying it
+// would make, without applying them. PrevGuard is the profile's, Guard the current state.
+// The profile is nil if none matches.
+func (m *Manager) BranchProfileChanges(branch string) (*registry.BranchProfile, []HistoryItem, error) {
+	if m.security == nil {
+		return nil, nil, errRegistryNotLoaded
+	}
+	profile := m.BranchProfileFor(branch)
+	if profile == nil {
+		return nil, nil, nil
+	}
+	state, err := m.branchProfileState(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := m.stateChanges(state)
+	return profile, changes, err
+}
+
+// ApplyBranchProfile brings the guard state to the profile for branch.
+// Returns the profile, nil if none matches, and the items that changed; the caller saves the registry.
+func (m *Manager) ApplyBranchProfile(branch string) (*registry.BranchProfile, []HistoryItem, error) {
+	if m.security == nil {
+		return nil, nil, errRegistryNotLoaded
+	}
+	profile := m.BranchProfileFor(branch)
+	if profile == nil {
+		return nil, nil, nil
+	}
+	state, err := m.branchProfileState(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := m.restoreState("branch-profile", state)
+	return profile, changes, err
+}
+
+// branchProfileState returns the guard state a profile asks for.
+// A collections profile guards the files of its collections and unguards everything else.
+func (m *Manager) branchProfileState(profile *registry.BranchProfile) (*SavedState, error) {
+	if profile.State != "" {
+		state, err := m.readSavedState(m.statePath(profile.State))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("state not found: %s", profile.State)
+			}
+			return nil, err
+		}
+		return state, nil
+	}
+
+	state, err := m.currentState(profile.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	for name := range state.Files {
+		state.Files[name] = false
+	}
+	for name := range state.Collections {
+		state.Collections[name] = false
+	}
+	for name := range state.Folders {
+		state.Folders[name] = false
+	}
+
+	var missing []string
+	for _, name := range profile.Collections {
+		files, err := m.security.GetRegisteredCollectionFiles(name)
+		if err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		state.Collections[name] = true
+		for _, file := range files {
+			state.Files[m.security.ToDisplayPath(file)] = true
+		}
+	}
+	if len(missing) > 0 {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf(
+			"Branch profile %s names collections that no longer exist: %s", profile.Pattern, strings.Join(missing, ", "))))
+	}
+	return state, nil
+}
+
+// gitRepo opens the git working tree containing the .guardfile.
+func (m *Manager) gitRepo() (*git.Repo, error) {
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	return git.Open(baseDir)
+}
+
+// validateBranchPattern rejects patterns path.Match cannot use.
+func validateBranchPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("branch pattern must not be empty")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid branch pattern '%s': %w", pattern, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestBranchProfiles tests setting, matching and applying branch profiles.
+func TestBranchProfiles(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	api := createTestFile(t, tmpDir, "api.yaml", 0644)
+	code := createTestFile(t, tmpDir, "main.go", 0644)
+	changelog := createTestFile(t, tmpDir, "CHANGELOG.md", 0644)
+	if err := mgr.AddFiles([]string{api, code, changelog}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.AddFilesToCollections([]string{api}, []string{"contract"}); err != nil {
+		t.Fatalf("AddFilesToCollections failed: %v", err)
+	}
+	if err := mgr.EnableFiles([]string{api, code}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if _, err := mgr.SaveState("frozen"); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	if err := mgr.DisableFiles([]string{api, code}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+
+	if _, err := mgr.SetBranchProfile("release/*", []string{"frozen"}); err != nil {
+		t.Fatalf("SetBranchProfile failed: %v", err)
+	}
+	if profile, err := mgr.SetBranchProfile("*", []string{"contract"}); err != nil || len(profile.Collections) != 1 {
+		t.Fatalf("SetBranchProfile failed: %v (%+v)", err, profile)
+	}
+	if _, err := mgr.SetBranchProfile("x", []string{"unknown"}); err == nil {
+		t.Error("Expected an error for an unknown state or collection")
+	}
+	if _, err := mgr.SetBranchProfile("[", []string{"contract"}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+
+	// Profiles persist in the registry
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	if err := mgr.LoadRegistry(); err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+
+	// The first matching profile applies; "*" does not match "/"
+	if profile := mgr.BranchProfileFor("release/1.0"); profile == nil || profile.State != "frozen" {
+		t.Errorf("Expected the frozen profile for release/1.0, got %+v", profile)
+	}
+	if profile := mgr.BranchProfileFor("main"); profile == nil || profile.Pattern != "*" {
+		t.Errorf("Expected the * profile for main, got %+v", profile)
+	}
+	if profile := mgr.BranchProfileFor("feature/x"); profile != nil {
+		t.Errorf("Expected no profile for feature/x, got %+v", profile)
+	}
+
+	// The dry run changes nothing
+	if _, changes, err := mgr.BranchProfileChanges("release/1.0"); err != nil || len(changes) != 2 {
+		t.Fatalf("BranchProfileChanges failed: %v (%+v)", err, changes)
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(api); guard {
+		t.Error("Expected the dry run to leave api.yaml unguarded")
+	}
+
+	if _, _, err := mgr.ApplyBranchProfile("release/1.0"); err != nil {
+		t.Fatalf("ApplyBranchProfile failed: %v", err)
+	}
+	for path, want := range map[string]bool{api: true, code: true, changelog: false} {
+		if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); guard != want {
+			t.Errorf("release: expected guard %v for %s, got %v", want, filepath.Base(path), guard)
+		}
+	}
+
+	// A collections profile guards only the collection's files
+	if _, _, err := mgr.ApplyBranchProfile("main"); err != nil {
+		t.Fatalf("ApplyBranchProfile failed: %v", err)
+	}
+	for path, want := range map[string]bool{api: true, code: false, changelog: false} {
+		if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(path); guard != want {
+			t.Errorf("main: expected guard %v for %s, got %v", want, filepath.Base(path), guard)
+		}
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredCollectionGuard("contract"); !guard {
+		t.Error("Expected collection contract to be guarded")
+	}
+	if _, changes, _ := mgr.BranchProfileChanges("main"); len(changes) != 0 {
+		t.Errorf("Expected the guard state to match the profile, got %+v", changes)
+	}
+
+	if err := mgr.RemoveBranchProfile("*"); err != nil {
+		t.Fatalf("RemoveBranchProfile failed: %v", err)
+	}
+	if err := mgr.RemoveBranchProfile("*"); err == nil {
+		t.Error("Expected an error when removing a missing profile")
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.DisableFiles([]string{api}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+}
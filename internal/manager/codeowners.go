@@ -0,0 +1,209 @@
+package manager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// codeownersLocations are the places GitHub looks for a CODEOWNERS file, in its order
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeownersCollectionPrefix starts the names of collections imported from CODEOWNERS
+const codeownersCollectionPrefix = "codeowners-"
+
+// codeownersRule is one "pattern owner..." line of a CODEOWNERS file
+type codeownersRule struct {
+	line    int
+	pattern string
+	owners  []string
+}
+
+// CodeownersImport reports the collections an import created, updated or removed.
+type CodeownersImport struct {
+	File    string                        // the CODEOWNERS file, relative to the .guardfile
+	Created []string                      // collections of owners new to the registry
+	Updated []string                      // collections of owners that were imported before
+	Removed []string                      // collections of owners no longer in CODEOWNERS
+	Synced  map[string]*PatternSyncResult // member changes by collection
+}
+
+// ImportCodeowners turns the rules of the project's CODEOWNERS file into one pattern
+// collection per owner, named "codeowners-<owner>". Since the last matching rule owns a
+// file, a later rule for other owners becomes a "!" pattern of earlier owners.
+// Re-running the import updates the patterns, syncs the members and removes the
+// collections of owners that left the file. With team set, only that owner is imported.
+// New collections are not guarded. The caller saves the registry.
+func (m *Manager) ImportCodeowners(team string) (*CodeownersImport, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	file, rules, err := m.readCodeowners()
+	if err != nil {
+		return nil, err
+	}
+	owners := codeownersOwners(rules)
+	if team != "" {
+		if !slices.Contains(owners, team) {
+			return nil, fmt.Errorf("no CODEOWNERS rule in %s is owned by %s", file, team)
+		}
+		owners = []string{team}
+	}
+
+	result := &CodeownersImport{File: file, Synced: make(map[string]*PatternSyncResult)}
+	imported := make(map[string]bool, len(owners))
+	for _, owner := range owners {
+		name := codeownersCollectionName(owner)
+		imported[name] = true
+		patterns, lines := codeownersPatterns(rules, owner)
+
+		if m.security.IsRegisteredCollection(name) {
+			if !m.isCodeownersCollection(name) {
+				m.AddError(fmt.Sprintf("Error: Collection %s exists and was not imported from CODEOWNERS, skipping %s", name, owner))
+				continue
+			}
+			result.Updated = append(result.Updated, name)
+		} else {
+			if err := m.security.RegisterCollection(name, []string{}); err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to create collection %s: %v", name, err))
+				continue
+			}
+			result.Created = append(result.Created, name)
+		}
+
+		if err := m.security.SetRegisteredCollectionPatterns(name, patterns, codeownersSource(file, lines, owner)); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to set patterns of collection %s: %v", name, err))
+			continue
+		}
+		synced, err := m.syncPatternCollection(name)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to sync collection %s: %v", name, err))
+			continue
+		}
+		result.Synced[name] = synced
+	}
+
+	// Owners removed from CODEOWNERS, unless only one team was imported
+	if team == "" {
+		for _, name := range m.security.GetRegisteredCollections() {
+			if imported[name] || !m.isCodeownersCollection(name) {
+				continue
+			}
+			// The members stay registered with their guard state
+			if err := m.security.UnregisterCollection(name, false); err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to remove collection %s: %v", name, err))
+				continue
+			}
+			result.Removed = append(result.Removed, name)
+		}
+	}
+
+	slices.Sort(result.Created)
+	slices.Sort(result.Updated)
+	slices.Sort(result.Removed)
+	return result, nil
+}
+
+// isCodeownersCollection returns true if the collection was imported from CODEOWNERS
+func (m *Manager) isCodeownersCollection(name string) bool {
+	_, source, err := m.security.GetRegisteredCollectionPatterns(name)
+	return err == nil && strings.HasPrefix(name, codeownersCollectionPrefix) && strings.Contains(source, "CODEOWNERS:")
+}
+
+// readCodeowners finds and parses the CODEOWNERS file next to the .guardfile.
+// Returns the file relative to the .guardfile and its rules.
+func (m *Manager) readCodeowners() (string, []codeownersRule, error) {
+	baseDir := filepath.Dir(m.registryPath)
+	for _, location := range codeownersLocations {
+		f, err := os.Open(filepath.Join(baseDir, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		defer f.Close()
+
+		rules, err := parseCodeowners(f)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		return location, rules, nil
+	}
+	return "", nil, fmt.Errorf("no CODEOWNERS file found (looked for %s)", strings.Join(codeownersLocations, ", "))
+}
+
+// parseCodeowners returns the rules of a CODEOWNERS file. Comments and blank lines are
+// skipped; a rule without owners is kept, it takes the files it matches from everyone.
+func parseCodeowners(r io.Reader) ([]codeownersRule, error) {
+	var rules []codeownersRule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		rules = append(rules, codeownersRule{
+			line:    line,
+			pattern: strings.ReplaceAll(fields[0], `\#`, "#"),
+			owners:  fields[1:],
+		})
+	}
+	return rules, scanner.Err()
+}
+
+// codeownersOwners returns every owner named in the rules, in order of first appearance
+func codeownersOwners(rules []codeownersRule) []string {
+	var owners []string
+	for _, rule := range rules {
+		for _, owner := range rule.owners {
+			if !slices.Contains(owners, owner) {
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// codeownersPatterns returns the collection patterns of an owner and the lines of the rules
+// they came from. Rules for other owners after the owner's first rule are excluded again.
+func codeownersPatterns(rules []codeownersRule, owner string) ([]string, []int) {
+	var patterns []string
+	var lines []int
+	for _, rule := range rules {
+		switch {
+		case slices.Contains(rule.owners, owner):
+			patterns = append(patterns, rule.pattern)
+			lines = append(lines, rule.line)
+		case len(patterns) > 0:
+			patterns = append(patterns, "!"+rule.pattern)
+		}
+	}
+	return patterns, lines
+}
+
+// codeownersCollectionName returns the collection name of an owner,
+// e.g. "@org/platform" becomes "codeowners-org-platform"
+func codeownersCollectionName(owner string) string {
+	return codeownersCollectionPrefix + strings.ReplaceAll(strings.TrimPrefix(owner, "@"), "/", "-")
+}
+
+// codeownersSource describes the rules a collection was imported from,
+// e.g. ".github/CODEOWNERS:3,7 @org/platform"
+func codeownersSource(file string, lines []int, owner string) string {
+	numbers := make([]string, len(lines))
+	for i, line := range lines {
+		numbers[i] = strconv.Itoa(line)
+	}
+	return fmt.Sprintf("%s:%s %s", file, strings.Join(numbers, ","), owner)
+}
@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestMatchCollectionPattern tests the CODEOWNERS semantics of collection patterns.
+func TestMatchCollectionPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*", "src/main.go", true},
+		{"*.go", "src/main.go", true},
+		{"*.go", "README.md", false},
+		{"/src/api/", "src/api/handler.go", true},
+		{"/src/api/", "src/apis/handler.go", false},
+		{"apps/", "web/apps/index.js", true},
+		{"apps/", "apps", false},
+		{"/docs/*", "docs/guide.md", true},
+		{"/docs/*", "docs/sub/deep.md", false},
+		{"docs/**/*.md", "docs/sub/deep.md", true},
+		{"/build/logs", "build/logs/today.log", true},
+		{"/build/logs", "other/build/logs/today.log", false},
+	}
+	for _, tt := range tests {
+		if got := matchCollectionPattern(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchCollectionPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+
+	// The last matching pattern wins
+	patterns := []string{"*", "!/src/api/", "/src/api/public.go"}
+	if !matchCollectionPatterns(patterns, "src/main.go") || matchCollectionPatterns(patterns, "src/api/handler.go") ||
+		!matchCollectionPatterns(patterns, "src/api/public.go") {
+		t.Errorf("Unexpected matches for %v", patterns)
+	}
+}
+
+// TestImportCodeowners tests that CODEOWNERS rules become one pattern collection per owner
+// and that re-running the import syncs patterns, members and removed owners.
+func TestImportCodeowners(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	for _, dir := range []string{".github", filepath.Join("src", "api"), "docs"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	createTestFile(t, tmpDir, "main.go", 0644)
+	handlerFile := createTestFile(t, filepath.Join(tmpDir, "src", "api"), "handler.go", 0644)
+	createTestFile(t, filepath.Join(tmpDir, "docs"), "guide.md", 0644)
+
+	codeowners := filepath.Join(tmpDir, ".github", "CODEOWNERS")
+	writeCodeowners := func(content string) {
+		if err := os.WriteFile(codeowners, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	writeCodeowners("# Owners\n*.go @org/platform\n/src/api/ @org/api # API team\n/docs/ @org/docs\n")
+
+	result, err := mgr.ImportCodeowners("")
+	if err != nil {
+		t.Fatalf("ImportCodeowners failed: %v", err)
+	}
+	if !slices.Equal(result.Created, []string{"codeowners-org-api", "codeowners-org-docs", "codeowners-org-platform"}) {
+		t.Errorf("Unexpected collections: %v", result.Created)
+	}
+	if added := result.Synced["codeowners-org-platform"].Added; !slices.Equal(added, []string{"main.go"}) {
+		t.Errorf("Expected the API handler to belong to the API team only, got %v", added)
+	}
+	patterns, source, err := mgr.security.GetRegisteredCollectionPatterns("codeowners-org-platform")
+	if err != nil {
+		t.Fatalf("GetRegisteredCollectionPatterns failed: %v", err)
+	}
+	if !slices.Equal(patterns, []string{"*.go", "!/src/api/", "!/docs/"}) || source != ".github/CODEOWNERS:2 @org/platform" {
+		t.Errorf("Unexpected patterns %v from %q", patterns, source)
+	}
+
+	// Guarded pattern collections guard files that start matching
+	if err := mgr.EnableCollections([]string{"codeowners-org-platform"}); err != nil {
+		t.Fatalf("EnableCollections failed: %v", err)
+	}
+	writeCodeowners("*.go @org/platform\n/docs/ @org/docs\n")
+	result, err = mgr.ImportCodeowners("")
+	if err != nil {
+		t.Fatalf("ImportCodeowners failed: %v", err)
+	}
+	if !slices.Equal(result.Removed, []string{"codeowners-org-api"}) {
+		t.Errorf("Expected the API team's collection to be removed, got %v", result.Removed)
+	}
+	if added := result.Synced["codeowners-org-platform"].Added; !slices.Equal(added, []string{"src/api/handler.go"}) {
+		t.Errorf("Expected the handler to move to the platform team, got %v", added)
+	}
+	if guard, _ := mgr.security.GetRegisteredFileGuard(handlerFile); !guard {
+		t.Error("Expected the handler to be guarded with its new collection")
+	}
+
+	// Only collections imported from CODEOWNERS are updated
+	if _, err := mgr.ImportCodeowners("@org/unknown"); err == nil {
+		t.Error("Expected an error for a team without rules")
+	}
+	if err := mgr.security.UnregisterCollection("codeowners-org-docs", false); err != nil {
+		t.Fatalf("UnregisterCollection failed: %v", err)
+	}
+	if err := mgr.AddCollections([]string{"codeowners-org-docs"}); err != nil {
+		t.Fatalf("AddCollections failed: %v", err)
+	}
+	if _, err := mgr.ImportCodeowners("@org/docs"); err != nil {
+		t.Fatalf("ImportCodeowners failed: %v", err)
+	}
+	if !mgr.HasErrors() {
+		t.Error("Expected an error for a hand-made collection with an imported name")
+	}
+}
@@ -112,38 +112,7 @@ func (m *Manager) RemoveCollections(names []string) error {
 	}
 
 	// Disable guard for all existing files (like guard remove file)
-	for _, path := range existing {
-		if !m.security.IsRegisteredFile(path) {
-			continue
-		}
-
-		// Get file config
-		owner, group, mode, guard, err := m.security.GetRegisteredFileConfig(path)
-		if err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", path, err))
-			continue
-		}
-
-		// Only restore if guard is enabled
-		if guard {
-			// Clear immutable flag first (must be done before chmod)
-			if err := m.fs.ClearImmutable(path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", path, err))
-				continue
-			}
-
-			if err := m.fs.RestorePermissions(path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to restore permissions for %s: %v", path, err))
-				continue
-			}
-
-			// Set guard flag to false
-			if err := m.security.SetRegisteredFileGuard(path, false); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to disable guard for %s: %v", path, err))
-				continue
-			}
-		}
-	}
+	m.disableGuardedFiles("destroy", existing)
 
 	// Remove collections from registry
 	for _, name := range names {
@@ -225,6 +194,9 @@ func (m *Manager) ToggleCollections(names []string) error {
 		}
 	}
 
+	// Pick up files created since pattern collections were last synced
+	m.syncPatternCollections(names)
+
 	// Conflict detection (Requirement 3.5)
 	// Conflict occurs only when: (1) multiple collections, (2) share files, AND (3) different guard states
 	if len(names) > 1 {
@@ -323,7 +295,7 @@ func (m *Manager) ToggleCollections(names []string) error {
 				}
 			}
 
-			return errors.New(strings.TrimSuffix(errMsg.String(), "\n"))
+			return &Error{Kind: ErrorConflict, Items: conflictingFiles, Err: errors.New(strings.TrimSuffix(errMsg.String(), "\n"))}
 		}
 	}
 
@@ -377,56 +349,29 @@ func (m *Manager) ToggleCollections(names []string) error {
 	}
 
 	// Toggle guard for all existing files - sync ALL files to the collection's new guard state
+	var changes []permChange
 	for _, path := range existing {
 		if !m.security.IsRegisteredFile(path) {
 			continue
 		}
 
+		if newCollectionGuardState {
+			changes = append(changes, m.guardPermChange(path))
+			continue
+		}
+
 		// Get current file config (needed for restore permissions)
 		owner, group, mode, _, err := m.security.GetRegisteredFileConfig(path)
 		if err != nil {
 			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", path, err))
 			continue
 		}
-
-		// Sync file to collection's new guard state (not individual toggle)
-		newGuardState := newCollectionGuardState
-
-		if newGuardState {
-			// Enable guard: apply guard permissions, then set immutable
-			guardMode := m.security.GetDefaultFileMode()
-			guardOwner := m.security.GetDefaultFileOwner()
-			guardGroup := m.security.GetDefaultFileGroup()
-
-			if err := m.fs.ApplyPermissions(path, guardMode, guardOwner, guardGroup); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to enable guard for %s: %v", path, err))
-				continue
-			}
-
-			// Set immutable flag (auto-skips if not root)
-			if err := m.fs.SetImmutable(path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to set immutable flag for %s: %v", path, err))
-			}
-		} else {
-			// Disable guard: clear immutable first, then restore permissions
-			if err := m.fs.ClearImmutable(path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", path, err))
-				continue
-			}
-
-			if err := m.fs.RestorePermissions(path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to disable guard for %s: %v", path, err))
-				continue
-			}
-		}
-
-		// Set new guard flag
-		if err := m.security.SetRegisteredFileGuard(path, newGuardState); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", path, err))
-			continue
-		}
+		changes = append(changes, unguardPermChange(path, mode, owner, group))
 	}
 
+	// Sync file guard flags to the collection's new state (not individual toggle)
+	m.setGuardFlags(m.applyPermChanges("toggle", changes), changes, newCollectionGuardState)
+
 	// Toggle guard for all collections
 	for _, name := range names {
 		if !m.security.IsRegisteredCollection(name) {
@@ -443,6 +388,9 @@ func (m *Manager) ToggleCollections(names []string) error {
 			m.AddError(fmt.Sprintf("Error: Failed to toggle guard for collection %s: %v", name, err))
 			continue
 		}
+
+		// Remember for 'guard toggle' without arguments
+		m.security.SetLastToggle(name, "collection")
 	}
 
 	return nil
@@ -461,6 +409,9 @@ func (m *Manager) EnableCollections(names []string) error {
 		return err
 	}
 
+	// Pick up files created since pattern collections were last synced
+	m.syncPatternCollections(names)
+
 	// Collect all files from all collections (deduplicated)
 	allFiles := make(map[string]bool)
 	for _, name := range names {
@@ -497,33 +448,13 @@ func (m *Manager) EnableCollections(names []string) error {
 	}
 
 	// Enable guard for all existing files
+	var changes []permChange
 	for _, path := range existing {
-		if !m.security.IsRegisteredFile(path) {
-			continue
-		}
-
-		// Get guard permissions
-		guardMode := m.security.GetDefaultFileMode()
-		guardOwner := m.security.GetDefaultFileOwner()
-		guardGroup := m.security.GetDefaultFileGroup()
-
-		// Apply guard permissions
-		if err := m.fs.ApplyPermissions(path, guardMode, guardOwner, guardGroup); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to enable guard for %s: %v", path, err))
-			continue
-		}
-
-		// Set immutable flag (auto-skips if not root)
-		if err := m.fs.SetImmutable(path); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set immutable flag for %s: %v", path, err))
-		}
-
-		// Set guard flag to true
-		if err := m.security.SetRegisteredFileGuard(path, true); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", path, err))
-			continue
+		if m.security.IsRegisteredFile(path) {
+			changes = append(changes, m.guardPermChange(path))
 		}
 	}
+	m.setGuardFlags(m.applyPermChanges("enable", changes), changes, true)
 
 	// Enable guard for all collections
 	for _, name := range names {
@@ -588,7 +519,28 @@ func (m *Manager) DisableCollections(names []string) error {
 	}
 
 	// Disable guard for all existing files
-	for _, path := range existing {
+	m.disableGuardedFiles("disable", existing)
+
+	// Disable guard for all collections
+	for _, name := range names {
+		if !m.security.IsRegisteredCollection(name) {
+			continue
+		}
+
+		if err := m.security.SetRegisteredCollectionGuard(name, false); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to disable guard for collection %s: %v", name, err))
+			continue
+		}
+	}
+
+	return nil
+}
+
+// disableGuardedFiles restores the original permissions of all guarded files among paths
+// and clears their guard flag. Unregistered and unguarded files are skipped.
+func (m *Manager) disableGuardedFiles(operation string, paths []string) {
+	var changes []permChange
+	for _, path := range paths {
 		if !m.security.IsRegisteredFile(path) {
 			continue
 		}
@@ -602,38 +554,11 @@ func (m *Manager) DisableCollections(names []string) error {
 
 		// Only restore if guard is enabled
 		if guard {
-			// Clear immutable flag first (must be done before chmod)
-			if err := m.fs.ClearImmutable(path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", path, err))
-				continue
-			}
-
-			if err := m.fs.RestorePermissions(path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to restore permissions for %s: %v", path, err))
-				continue
-			}
-
-			// Set guard flag to false
-			if err := m.security.SetRegisteredFileGuard(path, false); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to disable guard for %s: %v", path, err))
-				continue
-			}
+			changes = append(changes, unguardPermChange(path, mode, owner, group))
 		}
 	}
 
-	// Disable guard for all collections
-	for _, name := range names {
-		if !m.security.IsRegisteredCollection(name) {
-			continue
-		}
-
-		if err := m.security.SetRegisteredCollectionGuard(name, false); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to disable guard for collection %s: %v", name, err))
-			continue
-		}
-	}
-
-	return nil
+	m.setGuardFlags(m.applyPermChanges(operation, changes), changes, false)
 }
 
 // AddFilesToCollections adds files to collections.
@@ -883,10 +808,28 @@ func (m *Manager) RemoveCollectionsFromCollections(sourceNames []string, targetN
 	return nil
 }
 
-// ShowCollections displays the guard status and file count for collections.
+// CollectionInfo is the guard status of a collection and its files.
+type CollectionInfo struct {
+	Name     string           `json:"name"`
+	Guard    bool             `json:"guard"`
+	Files    []CollectionFile `json:"files"`
+	Patterns []string         `json:"patterns,omitempty"` // Set for collections imported from pattern rules
+	Source   string           `json:"source,omitempty"`   // The rule a pattern collection was imported from
+}
+
+// CollectionFile is the guard status of a file in a collection.
+type CollectionFile struct {
+	Path  string `json:"path"`
+	Guard bool   `json:"guard"`
+}
+
+// ShowCollections returns the guard status and files of collections.
 // Per Requirement 6.3-6.4: Shows all collections if none specified, warnings for missing.
-// Per CLI-INTERFACE-SPECS.md lines 162-167: Format: G/- collection: name (n files).
-func (m *Manager) ShowCollections(names []string) error {
+func (m *Manager) ShowCollections(names []string) ([]CollectionInfo, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
 	var collectionsToShow []string
 
 	// If no collections specified, show all
@@ -896,19 +839,13 @@ func (m *Manager) ShowCollections(names []string) error {
 		collectionsToShow = names
 	}
 
-	// Track counts for summary
-	guarded := 0
-	displayed := 0
-
-	// Display each collection
+	infos := []CollectionInfo{}
 	for _, name := range collectionsToShow {
 		if !m.security.IsRegisteredCollection(name) {
 			m.AddWarning(NewWarning(WarningCollectionNotFound, "", name))
 			continue
 		}
 
-		displayed++
-
 		// Get guard status
 		guard, err := m.security.GetRegisteredCollectionGuard(name)
 		if err != nil {
@@ -916,10 +853,6 @@ func (m *Manager) ShowCollections(names []string) error {
 			continue
 		}
 
-		if guard {
-			guarded++
-		}
-
 		// Get files
 		files, err := m.security.GetRegisteredCollectionFiles(name)
 		if err != nil {
@@ -927,42 +860,24 @@ func (m *Manager) ShowCollections(names []string) error {
 			continue
 		}
 
-		// Format: G/- collection: name (n files)
-		guardFlag := "-"
-		if guard {
-			guardFlag = "G"
+		// Pattern collections name the rule they were imported from
+		patterns, source, err := m.security.GetRegisteredCollectionPatterns(name)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to get patterns for collection %s: %v", name, err))
+			continue
 		}
 
-		// If showing all collections (no names specified), don't list individual files
-		if len(names) == 0 {
-			fmt.Printf("%s collection: %s (%d files)\n", guardFlag, name, len(files))
-		} else {
-			// If specific collections requested, show detailed view with files
-			fmt.Printf("%s collection: %s (%d files)\n", guardFlag, name, len(files))
-			for _, file := range files {
-				// Get file guard status
-				_, _, _, fileGuard, err := m.security.GetRegisteredFileConfig(file)
-				if err != nil {
-					continue
-				}
-
-				fileGuardFlag := "-"
-				if fileGuard {
-					fileGuardFlag = "G"
-				}
-
-				// Display relative path
-				displayPath := m.security.ToDisplayPath(file)
-				fmt.Printf("  %s %s\n", fileGuardFlag, displayPath)
+		info := CollectionInfo{Name: name, Guard: guard, Files: []CollectionFile{}, Patterns: patterns, Source: source}
+		for _, file := range files {
+			// Get file guard status
+			_, _, _, fileGuard, err := m.security.GetRegisteredFileConfig(file)
+			if err != nil {
+				continue
 			}
+			info.Files = append(info.Files, CollectionFile{Path: m.security.ToDisplayPath(file), Guard: fileGuard})
 		}
+		infos = append(infos, info)
 	}
 
-	// Print summary when showing all collections (no names specified)
-	if len(names) == 0 && displayed > 0 {
-		unguarded := displayed - guarded
-		fmt.Printf("\n%d collection(s) total: %d guarded, %d unguarded\n", displayed, guarded, unguarded)
-	}
-
-	return nil
+	return infos, nil
 }
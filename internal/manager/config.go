@@ -6,30 +6,32 @@ import (
 	"strconv"
 )
 
-// ShowConfig displays the current configuration from the registry
-func (m *Manager) ShowConfig() error {
+// Config is the guard configuration: the permissions guarded files get.
+// Empty owner or group means the file's owner or group is kept.
+type Config struct {
+	Mode  string `json:"mode"` // Octal, e.g. "0600"
+	Owner string `json:"owner"`
+	Group string `json:"group"`
+}
+
+// GetConfig returns the current configuration from the registry
+func (m *Manager) GetConfig() (*Config, error) {
 	if m.security == nil {
-		return fmt.Errorf(".guardfile not found. Run 'guard init' first")
+		return nil, errGuardfileNotFound
 	}
 
-	mode := m.security.GetDefaultFileMode()
-	owner := m.security.GetDefaultFileOwner()
-	group := m.security.GetDefaultFileGroup()
-
-	// Format output per CLI-INTERFACE-SPECS.md
-	fmt.Println("Configuration:")
-	fmt.Printf("  Mode:  %04o\n", mode.Perm())
-	fmt.Printf("  Owner: %s\n", formatConfigValue(owner))
-	fmt.Printf("  Group: %s\n", formatConfigValue(group))
-
-	return nil
+	return &Config{
+		Mode:  fmt.Sprintf("%04o", m.security.GetDefaultFileMode().Perm()),
+		Owner: m.security.GetDefaultFileOwner(),
+		Group: m.security.GetDefaultFileGroup(),
+	}, nil
 }
 
 // SetConfig updates guard configuration with one or more values
 // Parameters with non-nil pointers are updated, nil means "don't change"
 func (m *Manager) SetConfig(modeStr *string, owner *string, group *string) error {
 	if m.security == nil {
-		return fmt.Errorf(".guardfile not found. Run 'guard init' first")
+		return errGuardfileNotFound
 	}
 
 	// Check that at least one parameter is provided
@@ -37,9 +39,6 @@ func (m *Manager) SetConfig(modeStr *string, owner *string, group *string) error
 		return fmt.Errorf("no configuration values provided")
 	}
 
-	// Track what we're updating
-	var updates []string
-
 	// Check if any files/collections are guarded (warning only)
 	m.checkAndWarnGuardedFiles()
 
@@ -53,50 +52,30 @@ func (m *Manager) SetConfig(modeStr *string, owner *string, group *string) error
 		if err := m.security.SetDefaultFileMode(mode); err != nil {
 			return fmt.Errorf("failed to set mode: %w", err)
 		}
-
-		updates = append(updates, fmt.Sprintf("Mode:  %04o", mode.Perm()))
 	}
 
 	// Update owner if provided (can be empty string to clear)
 	if owner != nil {
 		m.security.SetDefaultFileOwner(*owner)
-		if *owner == "" {
-			updates = append(updates, "Owner: (cleared)")
-		} else {
-			updates = append(updates, fmt.Sprintf("Owner: %s", *owner))
-		}
 	}
 
 	// Update group if provided (can be empty string to clear)
 	if group != nil {
 		m.security.SetDefaultFileGroup(*group)
-		if *group == "" {
-			updates = append(updates, "Group: (cleared)")
-		} else {
-			updates = append(updates, fmt.Sprintf("Group: %s", *group))
-		}
 	}
 
 	// Save registry
-	if err := m.security.Save(); err != nil {
+	if err := m.SaveRegistry(); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	// Print what was updated
-	if len(updates) > 0 {
-		fmt.Println("Config updated:")
-		for _, update := range updates {
-			fmt.Printf("  %s\n", update)
-		}
-	}
-
 	return nil
 }
 
 // SetConfigMode updates guard_mode configuration
 func (m *Manager) SetConfigMode(modeStr string) error {
 	if m.security == nil {
-		return fmt.Errorf(".guardfile not found. Run 'guard init' first")
+		return errGuardfileNotFound
 	}
 
 	// Parse octal string to os.FileMode
@@ -114,19 +93,17 @@ func (m *Manager) SetConfigMode(modeStr string) error {
 	}
 
 	// Save registry
-	if err := m.security.Save(); err != nil {
+	if err := m.SaveRegistry(); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Println("Config updated:")
-	fmt.Printf("  Mode: %04o\n", mode.Perm())
 	return nil
 }
 
 // SetConfigOwner updates guard_owner configuration
 func (m *Manager) SetConfigOwner(owner string) error {
 	if m.security == nil {
-		return fmt.Errorf(".guardfile not found. Run 'guard init' first")
+		return errGuardfileNotFound
 	}
 
 	// Check if any files/collections are guarded (warning only)
@@ -136,23 +113,17 @@ func (m *Manager) SetConfigOwner(owner string) error {
 	m.security.SetDefaultFileOwner(owner)
 
 	// Save registry
-	if err := m.security.Save(); err != nil {
+	if err := m.SaveRegistry(); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Println("Config updated:")
-	if owner == "" {
-		fmt.Println("  Owner: (cleared)")
-	} else {
-		fmt.Printf("  Owner: %s\n", owner)
-	}
 	return nil
 }
 
 // SetConfigGroup updates guard_group configuration
 func (m *Manager) SetConfigGroup(group string) error {
 	if m.security == nil {
-		return fmt.Errorf(".guardfile not found. Run 'guard init' first")
+		return errGuardfileNotFound
 	}
 
 	// Check if any files/collections are guarded (warning only)
@@ -162,16 +133,10 @@ func (m *Manager) SetConfigGroup(group string) error {
 	m.security.SetDefaultFileGroup(group)
 
 	// Save registry
-	if err := m.security.Save(); err != nil {
+	if err := m.SaveRegistry(); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Println("Config updated:")
-	if group == "" {
-		fmt.Println("  Group: (cleared)")
-	} else {
-		fmt.Printf("  Group: %s\n", group)
-	}
 	return nil
 }
 
@@ -206,14 +171,6 @@ func (m *Manager) checkAndWarnGuardedFiles() {
 	}
 }
 
-// formatConfigValue formats a config value for display
-func formatConfigValue(value string) string {
-	if value == "" {
-		return "(empty)"
-	}
-	return value
-}
-
 // parseOctalMode parses an octal mode string and returns os.FileMode
 func parseOctalMode(modeStr string) (os.FileMode, error) {
 	// Parse as uint32 in base 8
@@ -0,0 +1,256 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const hashCacheFileName = "hashcache.yaml"
+
+// fileHash is the result of hashing one file.
+type fileHash struct {
+	sha256 string
+	size   int64
+	err    error
+}
+
+// hashCacheEntry remembers the hash of a file for as long as its mtime and size stay the same.
+type hashCacheEntry struct {
+	ModTime int64  `yaml:"mtime"`
+	Size    int64  `yaml:"size"`
+	SHA256  string `yaml:"sha256"`
+}
+
+// hashCache is stored in .guard/hashcache.yaml, keyed by the path relative to the .guardfile.
+type hashCache struct {
+	Files map[string]hashCacheEntry `yaml:"files"`
+}
+
+// hashFiles hashes the files in parallel, keyed by the given paths.
+// Files whose mtime and size match the cache are not read again.
+func (m *Manager) hashFiles(paths []string) map[string]fileHash {
+	results := make(map[string]fileHash, len(paths))
+	cache := m.readHashCache()
+	cacheChanged := false
+
+	type job struct {
+		path string
+		key  string
+		stat os.FileInfo
+	}
+	var jobs []job
+	for _, path := range paths {
+		info, err := m.fs.Stat(path)
+		if err != nil {
+			results[path] = fileHash{err: err}
+			continue
+		}
+		key := path
+		if absPath, err := filepath.Abs(path); err == nil {
+			key = m.journalRelPath(absPath)
+		}
+		if cached, ok := cache.Files[key]; ok && cached.ModTime == info.ModTime().UnixNano() && cached.Size == info.Size() {
+			results[path] = fileHash{sha256: cached.SHA256, size: cached.Size}
+			continue
+		}
+		jobs = append(jobs, job{path: path, key: key, stat: info})
+	}
+
+	hashes := make([]fileHash, len(jobs))
+	workers := min(runtime.NumCPU(), len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				sum, size, err := m.fs.HashFile(jobs[i].path)
+				hashes[i] = fileHash{sha256: sum, size: size, err: err}
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i, j := range jobs {
+		results[j.path] = hashes[i]
+		if hashes[i].err != nil {
+			continue
+		}
+		// The mtime from before hashing: a file modified while being read is hashed again next time
+		cache.Files[j.key] = hashCacheEntry{ModTime: j.stat.ModTime().UnixNano(), Size: hashes[i].size, SHA256: hashes[i].sha256}
+		cacheChanged = true
+	}
+
+	// The cache only saves time, a user who cannot write .guard/ simply hashes again next time
+	if cacheChanged {
+		_ = m.writeHashCache(cache)
+	}
+	return results
+}
+
+// contentBaselines hashes the files that are about to be guarded and have no baseline yet,
+// and stores a snapshot of their content. Both happen before the guard permissions are applied,
+// which may make a file unreadable.
+func (m *Manager) contentBaselines(changes []permChange) map[string]fileHash {
+	var paths []string
+	for _, change := range changes {
+		if !change.guard || !m.security.IsRegisteredFile(change.path) {
+			continue
+		}
+		if sum, _, err := m.security.GetRegisteredFileContent(change.path); err == nil && sum == "" {
+			paths = append(paths, change.path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	m.snapshotFiles(paths)
+	return m.hashFiles(paths)
+}
+
+// setContentBaselines stores the baselines of newly guarded files and clears the baselines
+// of unguarded files, for every successfully applied change.
+func (m *Manager) setContentBaselines(baselines map[string]fileHash, changes []permChange, succeeded map[string]bool) {
+	for _, change := range changes {
+		if !succeeded[change.path] || !m.security.IsRegisteredFile(change.path) {
+			continue
+		}
+		var err error
+		if !change.guard {
+			err = m.security.SetRegisteredFileContent(change.path, "", 0)
+		} else if hash, ok := baselines[change.path]; ok && hash.err == nil {
+			err = m.security.SetRegisteredFileContent(change.path, hash.sha256, hash.size)
+		}
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to set content baseline for %s: %v", change.path, err))
+		}
+	}
+}
+
+// contentChanges compares the given registered files against their content baselines.
+// Files without a baseline are skipped. Returns the changed files and the files that could not be read.
+func (m *Manager) contentChanges(paths []string) (changed map[string]bool, failed map[string]error) {
+	changed = make(map[string]bool)
+	failed = make(map[string]error)
+
+	baselines := make(map[string]fileHash)
+	var toHash []string
+	for _, path := range paths {
+		sum, size, err := m.security.GetRegisteredFileContent(path)
+		if err != nil || sum == "" {
+			continue
+		}
+		baselines[path] = fileHash{sha256: sum, size: size}
+		toHash = append(toHash, path)
+	}
+	if len(toHash) == 0 {
+		return changed, failed
+	}
+
+	for path, hash := range m.hashFiles(toHash) {
+		if hash.err != nil {
+			failed[path] = hash.err
+			continue
+		}
+		if hash.sha256 != baselines[path].sha256 || hash.size != baselines[path].size {
+			changed[path] = true
+		}
+	}
+	return changed, failed
+}
+
+// Rebaseline accepts the current content of guarded files as their new baseline
+// and stores a snapshot of it.
+// Returns the paths that were rebaselined.
+func (m *Manager) Rebaseline(paths []string) ([]string, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files specified")
+	}
+
+	var toHash, unregistered, unguarded, missing []string
+	for _, path := range paths {
+		if !m.security.IsRegisteredFile(path) {
+			unregistered = append(unregistered, path)
+			continue
+		}
+		if guard, err := m.security.GetRegisteredFileGuard(path); err != nil || !guard {
+			unguarded = append(unguarded, path)
+			continue
+		}
+		if !m.fs.FileExists(path) {
+			missing = append(missing, path)
+			continue
+		}
+		toHash = append(toHash, path)
+	}
+
+	if len(unregistered) > 0 {
+		m.AddWarning(NewWarning(WarningFileNotInRegistry, "", unregistered...))
+	}
+	if len(missing) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", missing...))
+	}
+	if len(unguarded) > 0 {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf(
+			"Only guarded files have a content baseline, skipped: %s", strings.Join(unguarded, ", "))))
+	}
+
+	hashes := m.hashFiles(toHash)
+	var rebaselined []string
+	for _, path := range toHash {
+		hash := hashes[path]
+		if hash.err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to hash %s: %v", path, hash.err))
+			continue
+		}
+		if err := m.security.SetRegisteredFileContent(path, hash.sha256, hash.size); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to set content baseline for %s: %v", path, err))
+			continue
+		}
+		rebaselined = append(rebaselined, path)
+	}
+	m.snapshotFiles(rebaselined)
+	return rebaselined, nil
+}
+
+func (m *Manager) hashCachePath() string {
+	return filepath.Join(m.StateDir(), hashCacheFileName)
+}
+
+// readHashCache loads the hash cache. A missing or unreadable cache is empty.
+func (m *Manager) readHashCache() *hashCache {
+	cache := &hashCache{}
+	if data, err := os.ReadFile(m.hashCachePath()); err == nil {
+		_ = yaml.Unmarshal(data, cache)
+	}
+	if cache.Files == nil {
+		cache.Files = make(map[string]hashCacheEntry)
+	}
+	return cache
+}
+
+func (m *Manager) writeHashCache(cache *hashCache) error {
+	if _, err := m.ensureStateDir(""); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to serialize hash cache: %w", err)
+	}
+	return m.writeStateFile(m.hashCachePath(), data)
+}
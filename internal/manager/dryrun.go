@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+// DryRunPlan is what an operation would have changed. Paths are relative to the .guardfile.
+type DryRunPlan struct {
+	Operations []filesystem.Operation `json:"operations"` // permission, immutable flag and file changes in order
+	Registry   []registry.DiffEntry   `json:"registry"`   // .guardfile entries registered, removed or changed
+}
+
+// Empty returns true if the operation would change nothing.
+func (p *DryRunPlan) Empty() bool {
+	return len(p.Operations) == 0 && len(p.Registry) == 0
+}
+
+// SetDryRun makes the manager run its full logic against a filesystem that records changes
+// instead of making them. Nothing is written: not the .guardfile, nor anything in .guard/.
+// Call it before loading the registry; DryRunPlan returns what would have changed.
+func (m *Manager) SetDryRun() {
+	if m.dryRun == nil {
+		m.dryRun = filesystem.NewDryRunFileSystem(m.fs)
+		m.fs = m.dryRun
+	}
+}
+
+// DryRun returns true if the manager only records changes.
+func (m *Manager) DryRun() bool {
+	return m.dryRun != nil
+}
+
+// DryRunPlan returns the recorded changes and the difference between the .guardfile on disk
+// and the registry in memory.
+func (m *Manager) DryRunPlan() (*DryRunPlan, error) {
+	plan := &DryRunPlan{}
+	if m.dryRun != nil {
+		plan.Operations = m.dryRun.Operations()
+	}
+	for i, op := range plan.Operations {
+		if abs, err := filepath.Abs(op.Path); err == nil && m.security != nil {
+			plan.Operations[i].Path = m.security.ToDisplayPath(abs)
+		}
+	}
+	if m.security == nil {
+		return plan, nil
+	}
+
+	saved := &registry.RegistryData{}
+	if stateFileExists(m.registryPath) {
+		data, err := registry.ReadRegistryData(m.registryPath)
+		if err != nil {
+			return nil, err
+		}
+		saved = data
+	}
+	pending, err := m.security.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	data, err := registry.ParseRegistryData(pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pending registry: %w", err)
+	}
+	plan.Registry = registry.DiffRegistryData(saved, data)
+	return plan, nil
+}
+
+// removeFile removes a file guard created, or records the removal in a dry run
+func (m *Manager) removeFile(path string) error {
+	if m.DryRun() {
+		m.dryRun.Record("remove", path, "")
+		return nil
+	}
+	return os.Remove(path)
+}
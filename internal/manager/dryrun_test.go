@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+// TestDryRun tests that a dry run records the permission and registry changes of an
+// operation without changing the file, the .guardfile or the state directory.
+func TestDryRun(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	testFile := createTestFile(t, tmpDir, "main.go", 0644)
+	saved, err := os.ReadFile(mgr.registryPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	dry := NewManager(mgr.registryPath, filesystem.NewFileSystem())
+	dry.SetDryRun()
+	if err := dry.LoadRegistry(); err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	if err := dry.EnableFiles([]string{testFile}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if err := dry.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	// The dry run sees its own changes
+	if mode, _, _, _ := dry.fs.GetFileInfo(testFile); mode.Perm() != 0600 {
+		t.Errorf("Expected the dry run to report mode 0600, got %o", mode.Perm())
+	}
+	if mode, _, _, _ := mgr.fs.GetFileInfo(testFile); mode.Perm() != 0644 {
+		t.Errorf("Expected main.go to keep mode 0644, got %o", mode.Perm())
+	}
+	if data, _ := os.ReadFile(mgr.registryPath); string(data) != string(saved) {
+		t.Error("Expected the .guardfile to be unchanged")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, StateDirName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no state directory, got %v", err)
+	}
+
+	plan, err := dry.DryRunPlan()
+	if err != nil {
+		t.Fatalf("DryRunPlan failed: %v", err)
+	}
+	if len(plan.Operations) == 0 || plan.Operations[0].String() != "chmod 0600 main.go" {
+		t.Errorf("Expected a chmod of main.go first, got %v", plan.Operations)
+	}
+	if len(plan.Registry) != 1 || plan.Registry[0].Kind != registry.ChangeAdded || plan.Registry[0].Name != "main.go" {
+		t.Errorf("Expected main.go to be registered, got %v", plan.Registry)
+	}
+}
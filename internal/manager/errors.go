@@ -0,0 +1,136 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrorKind classifies the errors of the manager, so callers can tell them apart.
+type ErrorKind int
+
+const (
+	// ErrorOther is any error without a more specific kind
+	ErrorOther ErrorKind = iota
+	// ErrorNotInitialized indicates a missing .guardfile or an unloaded registry
+	ErrorNotInitialized
+	// ErrorCorrupted indicates a .guardfile or state file that cannot be parsed
+	ErrorCorrupted
+	// ErrorTampering indicates a .guardfile with paths outside the repository
+	ErrorTampering
+	// ErrorNeedsRoot indicates permission changes that only root can make
+	ErrorNeedsRoot
+	// ErrorPartialFailure indicates an operation that failed for some of its items
+	ErrorPartialFailure
+	// ErrorConflict indicates items that cannot be changed together
+	ErrorConflict
+	// ErrorNotFound indicates a file, folder, collection or other item that does not exist
+	ErrorNotFound
+	// ErrorPendingJournal indicates an interrupted operation that 'guard recover' has to finish first
+	ErrorPendingJournal
+)
+
+// errorKindNames are the names of the error kinds in JSON and YAML output
+var errorKindNames = map[ErrorKind]string{
+	ErrorOther:          "error",
+	ErrorNotInitialized: "not-initialized",
+	ErrorCorrupted:      "corrupted",
+	ErrorTampering:      "tampering",
+	ErrorNeedsRoot:      "needs-root",
+	ErrorPartialFailure: "partial-failure",
+	ErrorConflict:       "conflict",
+	ErrorNotFound:       "not-found",
+	ErrorPendingJournal: "pending-journal",
+}
+
+// String returns the name of the error kind, e.g. "not-found".
+func (k ErrorKind) String() string {
+	if name, ok := errorKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("error-%d", int(k))
+}
+
+// MarshalText renders the error kind by name in JSON and YAML output.
+func (k ErrorKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Error is a manager error of a known kind, with the items it affects,
+// e.g. the paths of the files that failed or the name that was not found.
+type Error struct {
+	Kind  ErrorKind
+	Items []string
+	Err   error
+}
+
+// Error returns the message of the wrapped error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError creates an Error of the given kind with a formatted message, like fmt.Errorf.
+func newError(kind ErrorKind, items []string, format string, args ...any) *Error {
+	return &Error{Kind: kind, Items: items, Err: fmt.Errorf(format, args...)}
+}
+
+// errRegistryNotLoaded is returned by operations that run before LoadRegistry
+var errRegistryNotLoaded = newError(ErrorNotInitialized, nil, "registry not loaded")
+
+// errGuardfileNotFound is returned when there is no .guardfile to operate on
+var errGuardfileNotFound = newError(ErrorNotInitialized, nil, ".guardfile not found. Run 'guard init' first")
+
+// KindOf returns the kind of the first Error in the chain of err, or ErrorOther.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ErrorOther
+}
+
+// ItemsOf returns the items of the first Error in the chain of err.
+func ItemsOf(err error) []string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Items
+	}
+	return nil
+}
+
+// Failure returns the errors collected during an operation as a single Error, or nil if
+// there were none. It is an ErrorNeedsRoot if every failed permission change was refused
+// for lack of root privileges, otherwise an ErrorPartialFailure listing the failed items.
+// A reverted atomic run changed nothing and is an ErrorOther, unless files could not be
+// reverted: those are listed in an ErrorPartialFailure.
+func (m *Manager) Failure() error {
+	if len(m.errors) == 0 {
+		return nil
+	}
+	if m.atomicAborted {
+		if len(m.failedItems) == 0 {
+			return newError(ErrorOther, nil, "atomic operation failed, all changes were reverted")
+		}
+		return newError(ErrorPartialFailure, m.failedItems, "%d file(s) could not be reverted", len(m.failedItems))
+	}
+	if len(m.rootFailures) == len(m.errors) {
+		return newError(ErrorNeedsRoot, m.rootFailures,
+			"%d file(s) need root privileges (run with sudo)", len(m.rootFailures))
+	}
+	return newError(ErrorPartialFailure, m.failedItems, "%d error(s) occurred", len(m.errors))
+}
+
+// addFileError records an error of a permission change of path. Changes refused for
+// lack of root privileges are kept apart, they have their own exit code.
+func (m *Manager) addFileError(path, msg string, err error) {
+	m.AddError(msg)
+	m.failedItems = append(m.failedItems, path)
+	if errors.Is(err, os.ErrPermission) && !m.fs.HasRootPrivileges() {
+		m.rootFailures = append(m.rootFailures, path)
+	}
+}
@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestErrorKinds tests that load and lookup errors carry their kind and affected items.
+func TestErrorKinds(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	// No .guardfile yet
+	if err := mgr.LoadRegistry(); KindOf(err) != ErrorNotInitialized {
+		t.Errorf("Expected a not-initialized error, got %v (%s)", err, KindOf(err))
+	}
+
+	// Paths outside the project are tampering, anything unparsable is corruption
+	tampered := "config:\n    guard_mode: \"0600\"\nfiles:\n    - path: ../outside.txt\n      mode: \"0644\"\n"
+	if err := os.WriteFile(mgr.registryPath, []byte(tampered), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := mgr.LoadRegistry(); KindOf(err) != ErrorTampering {
+		t.Errorf("Expected a tampering error, got %v (%s)", err, KindOf(err))
+	}
+	if err := os.WriteFile(mgr.registryPath, []byte("files: [unclosed"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := mgr.LoadRegistry(); KindOf(err) != ErrorCorrupted {
+		t.Errorf("Expected a corrupted error, got %v (%s)", err, KindOf(err))
+	}
+
+	if err := os.Remove(mgr.registryPath); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	t.Chdir(tmpDir)
+
+	_, _, _, err := mgr.ResolveArguments([]string{"missing.txt"})
+	if KindOf(err) != ErrorNotFound {
+		t.Errorf("Expected a not-found error, got %v (%s)", err, KindOf(err))
+	}
+	if items := ItemsOf(err); len(items) != 1 || items[0] != "missing.txt" {
+		t.Errorf("Expected missing.txt as the item, got %v", items)
+	}
+
+	if err := mgr.Failure(); err != nil {
+		t.Errorf("Expected no failure without errors, got %v", err)
+	}
+	mgr.AddError("Error: something failed")
+	if err := mgr.Failure(); KindOf(err) != ErrorPartialFailure {
+		t.Errorf("Expected a partial failure, got %v (%s)", err, KindOf(err))
+	}
+}
+
+// TestToggleCollectionsConflictKind tests that toggling collections with shared files
+// in different guard states is a conflict listing the shared files.
+func TestToggleCollectionsConflictKind(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	createTestFile(t, tmpDir, "shared.txt", 0644)
+	if err := mgr.AddFilesToCollections([]string{"shared.txt"}, []string{"a", "b"}); err != nil {
+		t.Fatalf("AddFilesToCollections failed: %v", err)
+	}
+	if err := mgr.EnableCollections([]string{"a"}); err != nil {
+		t.Fatalf("EnableCollections failed: %v", err)
+	}
+	if err := mgr.GetRegistry().SetRegisteredCollectionGuard("b", false); err != nil {
+		t.Fatalf("SetRegisteredCollectionGuard failed: %v", err)
+	}
+
+	err := mgr.ToggleCollections([]string{"a", "b"})
+	if KindOf(err) != ErrorConflict {
+		t.Fatalf("Expected a conflict error, got %v (%s)", err, KindOf(err))
+	}
+	if items := ItemsOf(err); len(items) != 1 || filepath.Base(items[0]) != "shared.txt" {
+		t.Errorf("Expected shared.txt as the item, got %v", items)
+	}
+}
@@ -0,0 +1,231 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+// ExpiringItem is a file, collection or folder whose guard state is time-limited.
+// Name is the registry name: a path relative to the .guardfile for files, @path for folders.
+type ExpiringItem struct {
+	Type  string    `json:"type"` // HistoryItemFile, HistoryItemCollection or HistoryItemFolder
+	Name  string    `json:"name"`
+	Until time.Time `json:"until"`
+	Guard bool      `json:"guard"` // guard state restored once Until has passed
+}
+
+// Label describes the item in one line, e.g. "main.go" or "folder src".
+func (e ExpiringItem) Label() string {
+	switch e.Type {
+	case HistoryItemFile:
+		return e.Name
+	case HistoryItemFolder:
+		return e.Type + " " + strings.TrimPrefix(e.Name, "@")
+	default:
+		return e.Type + " " + e.Name
+	}
+}
+
+// SetExpiry makes the guard changes of the following operations time-limited.
+// Once until has passed, the next guard invocation (or 'guard watch') restores the previous guard state.
+func (m *Manager) SetExpiry(until time.Time) error {
+	if !until.After(time.Now()) {
+		return fmt.Errorf("expiry time %s is not in the future", until.Local().Format("2006-01-02 15:04:05"))
+	}
+	m.expiryUntil = until.Truncate(time.Second)
+	return nil
+}
+
+// ListExpiries returns all time-limited guard changes, soonest first.
+func (m *Manager) ListExpiries() ([]ExpiringItem, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	saved, err := m.security.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	data, err := registry.ParseRegistryData(saved)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ExpiringItem
+	add := func(itemType, name string, expiry *registry.Expiry) {
+		if expiry != nil {
+			items = append(items, ExpiringItem{Type: itemType, Name: name, Until: expiry.Until, Guard: expiry.Guard})
+		}
+	}
+	for _, f := range data.Files {
+		add(HistoryItemFile, f.Path, f.Expiry)
+	}
+	for _, c := range data.Collections {
+		add(HistoryItemCollection, c.Name, c.Expiry)
+	}
+	for _, f := range data.Folders {
+		add(HistoryItemFolder, f.Name, f.Expiry)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Until.Before(items[j].Until)
+	})
+	return items, nil
+}
+
+// NextExpiry returns when the next time-limited guard change expires, false if there is none.
+func (m *Manager) NextExpiry() (time.Time, bool) {
+	items, err := m.ListExpiries()
+	if err != nil || len(items) == 0 {
+		return time.Time{}, false
+	}
+	return items[0].Until, true
+}
+
+// ExpireGuards reverts the time-limited guard changes whose time has passed and saves the registry.
+// LoadRegistry calls it, so an expiry is honored by the next guard invocation even if nothing
+// was running at the moment it passed. Reverted items are reported as a WarningGuardExpired.
+func (m *Manager) ExpireGuards() {
+	items, err := m.ListExpiries()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	var expired []ExpiringItem
+	for _, item := range items {
+		if !item.Until.After(now) {
+			expired = append(expired, item)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	// Folders and collections first: their files follow them
+	var enable, disable [3][]string
+	order := map[string]int{HistoryItemFolder: 0, HistoryItemCollection: 1, HistoryItemFile: 2}
+	descriptions := make([]string, 0, len(expired))
+	for _, item := range expired {
+		m.setItemExpiry(item.Type, item.Name, nil)
+
+		target := item.Name
+		switch item.Type {
+		case HistoryItemFile:
+			target = m.journalAbsPath(item.Name)
+		case HistoryItemFolder:
+			folder := m.security.GetFolder(item.Name)
+			if folder == nil {
+				continue
+			}
+			target = folder.Path
+		}
+
+		state := "disabled"
+		if item.Guard {
+			enable[order[item.Type]] = append(enable[order[item.Type]], target)
+			state = "enabled"
+		} else {
+			disable[order[item.Type]] = append(disable[order[item.Type]], target)
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s (guard %s)", item.Label(), state))
+	}
+
+	operations := []struct {
+		names []string
+		apply func([]string) error
+	}{
+		{enable[0], m.EnableFolders}, {disable[0], m.DisableFolders},
+		{enable[1], m.EnableCollections}, {disable[1], m.DisableCollections},
+		{enable[2], m.EnableFiles}, {disable[2], m.DisableFiles},
+	}
+	for _, op := range operations {
+		if len(op.names) == 0 {
+			continue
+		}
+		if err := op.apply(op.names); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to revert expired guard change: %v", err))
+		}
+	}
+
+	if err := m.SaveRegistry(); err != nil {
+		m.AddError(fmt.Sprintf("Error: Failed to save registry: %v", err))
+	}
+	m.EndHistoryEntry()
+
+	m.AddWarning(NewWarning(WarningGuardExpired, "", descriptions...))
+}
+
+// updateExpiries is called by SaveRegistry before writing. Guard state changes since the last save
+// get the expiry set with SetExpiry, or become permanent without one. An item that is switched
+// back to the state its expiry would restore loses the expiry.
+func (m *Manager) updateExpiries() {
+	saved, err := m.security.Marshal()
+	if err != nil {
+		return
+	}
+	data, err := registry.ParseRegistryData(saved)
+	if err != nil {
+		return
+	}
+
+	previous := make(map[string]*registry.Expiry)
+	if m.historySnapshot != nil {
+		for _, f := range m.historySnapshot.Files {
+			previous[HistoryItemFile+"\x00"+f.Path] = f.Expiry
+		}
+		for _, c := range m.historySnapshot.Collections {
+			previous[HistoryItemCollection+"\x00"+c.Name] = c.Expiry
+		}
+		for _, f := range m.historySnapshot.Folders {
+			previous[HistoryItemFolder+"\x00"+f.Name] = f.Expiry
+		}
+	}
+
+	for _, item := range guardStateChanges(m.historySnapshot, data) {
+		var expiry *registry.Expiry
+		if !m.expiryUntil.IsZero() {
+			restore := item.PrevGuard
+			if prev := previous[item.Type+"\x00"+item.Name]; prev != nil {
+				restore = prev.Guard
+			}
+			if restore != item.Guard {
+				expiry = &registry.Expiry{Until: m.expiryUntil, Guard: restore}
+			}
+		}
+		m.setItemExpiry(item.Type, item.Name, expiry)
+	}
+}
+
+// setItemExpiry sets the expiry of a file, collection or folder by its registry name.
+func (m *Manager) setItemExpiry(itemType, name string, expiry *registry.Expiry) {
+	switch itemType {
+	case HistoryItemFile:
+		_ = m.security.SetRegisteredFileExpiry(m.journalAbsPath(name), expiry)
+	case HistoryItemCollection:
+		_ = m.security.SetRegisteredCollectionExpiry(name, expiry)
+	case HistoryItemFolder:
+		_ = m.security.SetFolderExpiry(name, expiry)
+	}
+}
+
+// FormatRemaining renders the time left until t compactly, e.g. "1h05m", "20m" or "45s".
+func FormatRemaining(t time.Time) string {
+	remaining := time.Until(t).Round(time.Second)
+	switch {
+	case remaining <= 0:
+		return "expired"
+	case remaining < time.Minute:
+		return fmt.Sprintf("%ds", int(remaining.Seconds()))
+	case remaining < time.Hour:
+		return fmt.Sprintf("%dm", int(remaining.Minutes()))
+	case remaining < 48*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(remaining.Hours()), int(remaining.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd", int(remaining.Hours()/24))
+	}
+}
@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+// TestExpiryRevertsGuardChange tests that a time-limited unguard records the state to restore
+// and that the next LoadRegistry guards the file again once the expiry has passed.
+func TestExpiryRevertsGuardChange(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.EnableFiles([]string{file1}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	if expiry, _ := mgr.GetRegistry().GetRegisteredFileExpiry(file1); expiry != nil {
+		t.Fatalf("Expected a permanent guard without --for, got %+v", expiry)
+	}
+
+	// Unguard for an hour
+	if err := mgr.SetExpiry(time.Now().Add(-time.Minute)); err == nil {
+		t.Error("Expected an error for an expiry in the past")
+	}
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := mgr.SetExpiry(until); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+	if err := mgr.DisableFiles([]string{file1}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	expiry, _ := mgr.GetRegistry().GetRegisteredFileExpiry(file1)
+	if expiry == nil || !expiry.Guard || !expiry.Until.Equal(until) {
+		t.Fatalf("Expected an expiry restoring guard at %v, got %+v", until, expiry)
+	}
+
+	// Not expired yet: loading leaves the file unguarded
+	mgr2 := NewManager(mgr.registryPath, filesystem.NewFileSystem())
+	if err := mgr2.LoadRegistry(); err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	if guard, _ := mgr2.GetRegistry().GetRegisteredFileGuard(file1); guard {
+		t.Fatal("Expected file1 to stay unguarded before the expiry")
+	}
+
+	// Let the expiry pass while nothing is running
+	past := &registry.Expiry{Until: time.Now().Add(-time.Second), Guard: true}
+	if err := mgr2.GetRegistry().SetRegisteredFileExpiry(file1, past); err != nil {
+		t.Fatalf("SetRegisteredFileExpiry failed: %v", err)
+	}
+	if err := mgr2.GetRegistry().Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	mgr3 := NewManager(mgr.registryPath, filesystem.NewFileSystem())
+	if err := mgr3.LoadRegistry(); err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	if guard, _ := mgr3.GetRegistry().GetRegisteredFileGuard(file1); !guard {
+		t.Error("Expected file1 to be guarded again after the expiry")
+	}
+	if mode, _, _, _ := mgr3.fs.GetFileInfo(file1); mode.Perm() != 0600 {
+		t.Errorf("Expected mode 0600 after the expiry, got %04o", mode.Perm())
+	}
+	if expiry, _ := mgr3.GetRegistry().GetRegisteredFileExpiry(file1); expiry != nil {
+		t.Errorf("Expected the expiry to be cleared, got %+v", expiry)
+	}
+	if len(mgr3.GetWarnings()) != 1 || mgr3.GetWarnings()[0].Type != WarningGuardExpired {
+		t.Errorf("Expected one expiry warning, got %+v", mgr3.GetWarnings())
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr3.DisableFiles([]string{file1}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+}
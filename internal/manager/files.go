@@ -2,16 +2,19 @@ package manager
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+
+	"github.com/florianbuetow/guard/internal/registry"
 )
 
 // FileInfo contains display information for a registered file.
 // Used by ShowFiles to return data instead of printing directly.
 type FileInfo struct {
-	Path        string
-	Guard       bool
-	Collections []string
+	Path           string           `json:"path"`
+	Guard          bool             `json:"guard"`
+	Collections    []string         `json:"collections"`
+	ContentChanged bool             `json:"content_changed"`  // Content differs from the baseline recorded when the file was guarded
+	Expiry         *registry.Expiry `json:"expiry,omitempty"` // Set while the guard state is time-limited
 }
 
 // AddFiles registers files in the registry if they don't already exist.
@@ -19,7 +22,7 @@ type FileInfo struct {
 // Per Requirement 2.4: Idempotent - ignores files already registered (no warning).
 func (m *Manager) AddFiles(paths []string) error {
 	if m.security == nil {
-		return fmt.Errorf("registry not loaded")
+		return errRegistryNotLoaded
 	}
 
 	if len(paths) == 0 {
@@ -79,13 +82,16 @@ func (m *Manager) AddFiles(paths []string) error {
 // Per Requirement 11.6: Lenient - allows removal even if file deleted outside guard (with warning).
 func (m *Manager) RemoveFiles(paths []string) error {
 	if m.security == nil {
-		return fmt.Errorf("registry not loaded")
+		return errRegistryNotLoaded
 	}
 
 	if len(paths) == 0 {
 		return fmt.Errorf("no files specified")
 	}
 
+	// Step 1: Collect files to remove and the permissions to restore for guarded ones
+	var toRemove []string
+	var changes []permChange
 	for _, path := range paths {
 		// Check if file is registered
 		if !m.security.IsRegisteredFile(path) {
@@ -93,10 +99,6 @@ func (m *Manager) RemoveFiles(paths []string) error {
 			continue
 		}
 
-		// Step 1: Remove from all collections
-		m.security.RemoveRegisteredFileFromAllRegisteredCollections(path)
-
-		// Step 2: Restore original permissions if file is guarded
 		// Get original metadata first to check if restoration is needed
 		owner, group, mode, guard, err := m.security.GetRegisteredFileConfig(path)
 		if err != nil {
@@ -106,22 +108,30 @@ func (m *Manager) RemoveFiles(paths []string) error {
 
 		// Only restore if currently guarded
 		if guard {
-			// Clear immutable flag first (must be done before chmod)
-			if err := m.fs.ClearImmutable(path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", path, err))
-				continue
-			}
-
-			if err := m.fs.RestorePermissions(path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to restore permissions for %s: %v", path, err))
-				continue
-			}
+			changes = append(changes, unguardPermChange(path, mode, owner, group))
 		} else if !m.fs.FileExists(path) {
 			// File not guarded and missing on disk - warn but continue
 			m.AddWarning(NewWarning(WarningFileMissing, "", path))
 		}
+		toRemove = append(toRemove, path)
+	}
+
+	// Step 2: Restore original permissions of guarded files
+	restored := m.applyPermChanges("remove", changes)
+	needsRestore := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		needsRestore[change.path] = true
+	}
+
+	// Step 3: Remove from all collections and from the registry
+	for _, path := range toRemove {
+		// Keep the entry if its original permissions could not be restored
+		if needsRestore[path] && !restored[path] {
+			continue
+		}
+
+		m.security.RemoveRegisteredFileFromAllRegisteredCollections(path)
 
-		// Step 3: Remove from registry
 		if err := m.security.UnregisterFile(path, false); err != nil {
 			m.AddError(fmt.Sprintf("Error: Failed to unregister %s: %v", path, err))
 			continue
@@ -140,7 +150,7 @@ func (m *Manager) RemoveFiles(paths []string) error {
 // Per Requirement 2.7: Adds missing files to registry first, then toggles.
 func (m *Manager) ToggleFiles(paths []string) error {
 	if m.security == nil {
-		return fmt.Errorf("registry not loaded")
+		return errRegistryNotLoaded
 	}
 
 	if len(paths) == 0 {
@@ -195,6 +205,13 @@ func (m *Manager) ToggleFiles(paths []string) error {
 		toggles = append(toggles, fileToggle{path: path, newGuard: newGuard})
 	}
 
+	// Remember the last file for 'guard toggle' without arguments
+	if len(toggles) > 0 {
+		if absPath, err := filepath.Abs(toggles[len(toggles)-1].path); err == nil {
+			m.security.SetLastToggle(m.security.ToDisplayPath(absPath), "file")
+		}
+	}
+
 	// Phase 2: Save registry BEFORE applying filesystem permissions
 	// This ensures the registry is saved while it's still writable
 	if err := m.SaveRegistry(); err != nil {
@@ -202,41 +219,24 @@ func (m *Manager) ToggleFiles(paths []string) error {
 	}
 
 	// Phase 3: Apply filesystem permissions
+	changes := make([]permChange, 0, len(toggles))
 	for _, toggle := range toggles {
 		if toggle.newGuard {
-			// Enabling guard: apply guard permissions, then set immutable
-			mode := m.security.GetDefaultFileMode()
-			owner := m.security.GetDefaultFileOwner()
-			group := m.security.GetDefaultFileGroup()
-
-			if err := m.fs.ApplyPermissions(toggle.path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to apply guard permissions to %s: %v", toggle.path, err))
-				continue
-			}
-
-			// Set immutable flag (auto-skips if not root)
-			if err := m.fs.SetImmutable(toggle.path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to set immutable flag for %s: %v", toggle.path, err))
-			}
-		} else {
-			// Disabling guard: clear immutable first, then restore permissions
-			if err := m.fs.ClearImmutable(toggle.path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", toggle.path, err))
-				continue
-			}
-
-			owner, group, mode, _, err := m.security.GetRegisteredFileConfig(toggle.path)
-			if err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to get original config for %s: %v", toggle.path, err))
-				continue
-			}
+			changes = append(changes, m.guardPermChange(toggle.path))
+			continue
+		}
 
-			if err := m.fs.RestorePermissions(toggle.path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to restore permissions for %s: %v", toggle.path, err))
-				continue
-			}
+		owner, group, mode, _, err := m.security.GetRegisteredFileConfig(toggle.path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to get original config for %s: %v", toggle.path, err))
+			continue
 		}
+		changes = append(changes, unguardPermChange(toggle.path, mode, owner, group))
 	}
+	m.applyPermChanges("toggle", changes)
+
+	// The registry was saved before the filesystem phase
+	m.settleJournal()
 
 	return nil
 }
@@ -246,7 +246,7 @@ func (m *Manager) ToggleFiles(paths []string) error {
 // Per Requirement 2b: Warns if files are missing on disk, does NOT change guard flag for missing files.
 func (m *Manager) EnableFiles(paths []string) error {
 	if m.security == nil {
-		return fmt.Errorf("registry not loaded")
+		return errRegistryNotLoaded
 	}
 
 	if len(paths) == 0 {
@@ -296,21 +296,14 @@ func (m *Manager) EnableFiles(paths []string) error {
 	}
 
 	// Phase 3: Apply filesystem permissions
+	changes := make([]permChange, 0, len(filesToEnable))
 	for _, path := range filesToEnable {
-		mode := m.security.GetDefaultFileMode()
-		owner := m.security.GetDefaultFileOwner()
-		group := m.security.GetDefaultFileGroup()
-
-		if err := m.fs.ApplyPermissions(path, mode, owner, group); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to apply guard permissions to %s: %v", path, err))
-			continue
-		}
-
-		// Set immutable flag (auto-skips if not root)
-		if err := m.fs.SetImmutable(path); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set immutable flag for %s: %v", path, err))
-		}
+		changes = append(changes, m.guardPermChange(path))
 	}
+	m.applyPermChanges("enable", changes)
+
+	// The registry was saved before the filesystem phase
+	m.settleJournal()
 
 	return nil
 }
@@ -319,7 +312,7 @@ func (m *Manager) EnableFiles(paths []string) error {
 // Per Requirement 5.2: Warns if files are missing on disk or not in registry.
 func (m *Manager) DisableFiles(paths []string) error {
 	if m.security == nil {
-		return fmt.Errorf("registry not loaded")
+		return errRegistryNotLoaded
 	}
 
 	if len(paths) == 0 {
@@ -334,7 +327,9 @@ func (m *Manager) DisableFiles(paths []string) error {
 		m.AddWarning(NewWarning(WarningFileMissing, "", missing...))
 	}
 
-	// Process existing files
+	// Phase 1: Collect registered files and the permissions to restore
+	var toDisable []string
+	var changes []permChange
 	for _, path := range existing {
 		// Check if registered
 		if !m.security.IsRegisteredFile(path) {
@@ -351,19 +346,23 @@ func (m *Manager) DisableFiles(paths []string) error {
 
 		// Only restore if currently guarded
 		if guard {
-			// Clear immutable flag first (must be done before chmod)
-			if err := m.fs.ClearImmutable(path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", path, err))
-				continue
-			}
-
-			if err := m.fs.RestorePermissions(path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to restore permissions for %s: %v", path, err))
-				continue
-			}
+			changes = append(changes, unguardPermChange(path, mode, owner, group))
 		}
+		toDisable = append(toDisable, path)
+	}
 
-		// Set guard flag to false
+	// Phase 2: Restore original permissions
+	restored := m.applyPermChanges("disable", changes)
+	needsRestore := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		needsRestore[change.path] = true
+	}
+
+	// Phase 3: Set guard flag to false for files that are no longer guarded on disk
+	for _, path := range toDisable {
+		if needsRestore[path] && !restored[path] {
+			continue
+		}
 		if err := m.security.SetRegisteredFileGuard(path, false); err != nil {
 			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", path, err))
 			continue
@@ -382,7 +381,7 @@ func (m *Manager) DisableFiles(paths []string) error {
 // If no paths are specified, shows all registered files.
 func (m *Manager) ShowFiles(paths []string) ([]FileInfo, error) {
 	if m.security == nil {
-		return nil, fmt.Errorf("registry not loaded")
+		return nil, errRegistryNotLoaded
 	}
 
 	// Initialize slice to collect file information
@@ -431,10 +430,12 @@ func (m *Manager) ShowFiles(paths []string) ([]FileInfo, error) {
 		}
 
 		// Collect file information
+		expiry, _ := m.security.GetRegisteredFileExpiry(path)
 		fileInfos = append(fileInfos, FileInfo{
 			Path:        path,
 			Guard:       guard,
 			Collections: memberOf,
+			Expiry:      expiry,
 		})
 
 		// Check if file exists on disk
@@ -443,6 +444,18 @@ func (m *Manager) ShowFiles(paths []string) ([]FileInfo, error) {
 		}
 	}
 
+	// Compare guarded files against their content baselines (unreadable files are skipped)
+	var guarded []string
+	for _, info := range fileInfos {
+		if info.Guard && m.fs.FileExists(info.Path) {
+			guarded = append(guarded, info.Path)
+		}
+	}
+	changed, _ := m.contentChanges(guarded)
+	for i := range fileInfos {
+		fileInfos[i].ContentChanged = changed[fileInfos[i].Path]
+	}
+
 	return fileInfos, nil
 }
 
@@ -450,13 +463,13 @@ func (m *Manager) ShowFiles(paths []string) ([]FileInfo, error) {
 // Per Requirement 8.1.
 // CleanupResult contains the results of a cleanup operation.
 type CleanupResult struct {
-	FilesRemoved       int
-	CollectionsRemoved int
+	FilesRemoved       int `json:"files_removed"`
+	CollectionsRemoved int `json:"collections_removed"`
 }
 
 func (m *Manager) Cleanup() (*CleanupResult, error) {
 	if m.security == nil {
-		return nil, fmt.Errorf("registry not loaded")
+		return nil, errRegistryNotLoaded
 	}
 
 	result := &CleanupResult{}
@@ -500,21 +513,23 @@ func (m *Manager) Cleanup() (*CleanupResult, error) {
 
 // ResetResult contains the results of a reset operation.
 type ResetResult struct {
-	FilesDisabled       int
-	CollectionsDisabled int
+	FilesDisabled       int `json:"files_disabled"`
+	CollectionsDisabled int `json:"collections_disabled"`
 }
 
 // Reset disables guard for all files and collections.
 // Per Requirement 8.2: Warns for missing files and recommends cleanup.
 func (m *Manager) Reset() (*ResetResult, error) {
 	if m.security == nil {
-		return nil, fmt.Errorf("registry not loaded")
+		return nil, errRegistryNotLoaded
 	}
 
 	result := &ResetResult{}
 
-	// Disable guard for all files
+	// Collect the permissions to restore for all guarded files
 	files := m.security.GetRegisteredFiles()
+	var toReset []string
+	var changes []permChange
 	for _, path := range files {
 		// Get config
 		owner, group, mode, guard, err := m.security.GetRegisteredFileConfig(path)
@@ -525,22 +540,26 @@ func (m *Manager) Reset() (*ResetResult, error) {
 
 		// Restore permissions if guarded
 		if guard {
-			// Clear immutable flag first (must be done before chmod)
-			if err := m.fs.ClearImmutable(path); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", path, err))
-				continue
-			}
-
-			if err := m.fs.RestorePermissions(path, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to restore permissions for %s: %v", path, err))
-				continue
-			}
-			result.FilesDisabled++
+			changes = append(changes, unguardPermChange(path, mode, owner, group))
 		} else if !m.fs.FileExists(path) {
 			// File not guarded and missing - warn but continue
 			m.AddWarning(NewWarning(WarningFileMissing, "", path))
 		}
+		toReset = append(toReset, path)
+	}
 
+	// Disable guard for all files
+	restored := m.applyPermChanges("reset", changes)
+	result.FilesDisabled = len(restored)
+	needsRestore := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		needsRestore[change.path] = true
+	}
+
+	for _, path := range toReset {
+		if needsRestore[path] && !restored[path] {
+			continue
+		}
 		// Set guard flag to false
 		if err := m.security.SetRegisteredFileGuard(path, false); err != nil {
 			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", path, err))
@@ -569,51 +588,41 @@ func (m *Manager) Reset() (*ResetResult, error) {
 	return result, nil
 }
 
+// DestroyResult contains the results of the steps of a destroy operation.
+// Steps that did not run are nil.
+type DestroyResult struct {
+	Reset   *ResetResult   `json:"reset,omitempty"`
+	Cleanup *CleanupResult `json:"cleanup,omitempty"`
+	Removed bool           `json:"removed"` // The .guardfile was deleted
+}
+
 // Destroy runs reset, cleanup, verifies all permissions restored, and deletes .guardfile.
 // Per Requirement 8.3: Only deletes .guardfile if verification succeeds.
-func (m *Manager) Destroy() error {
+// On failure the result describes the steps that completed.
+func (m *Manager) Destroy() (*DestroyResult, error) {
 	if m.security == nil {
-		return fmt.Errorf("registry not loaded")
+		return nil, errRegistryNotLoaded
 	}
+	result := &DestroyResult{}
 
 	// Step 1: Reset (disable all guards)
 	resetResult, err := m.Reset()
 	if err != nil {
-		return fmt.Errorf("reset failed: %w", err)
-	}
-
-	// Print reset output per CLI-INTERFACE-SPECS.md
-	fmt.Println("Reset complete:")
-	if resetResult.FilesDisabled > 0 || resetResult.CollectionsDisabled > 0 {
-		if resetResult.FilesDisabled > 0 {
-			fmt.Printf("  Guard disabled for %d file(s)\n", resetResult.FilesDisabled)
-		}
-		if resetResult.CollectionsDisabled > 0 {
-			fmt.Printf("  Guard disabled for %d collection(s)\n", resetResult.CollectionsDisabled)
-		}
-	} else {
-		fmt.Println("  No guarded files or collections found")
+		return result, fmt.Errorf("reset failed: %w", err)
 	}
+	result.Reset = resetResult
 
 	// Check for reset errors and abort if any
 	if m.HasErrors() {
-		return fmt.Errorf("uninstall aborted. Fix errors and try again")
+		return result, fmt.Errorf("uninstall aborted. Fix errors and try again")
 	}
 
 	// Step 2: Cleanup (remove empty collections and missing files)
 	cleanupResult, err := m.Cleanup()
 	if err != nil {
-		return fmt.Errorf("cleanup failed: %w", err)
-	}
-
-	// Print cleanup output per CLI-INTERFACE-SPECS.md
-	fmt.Println("Cleanup complete:")
-	if cleanupResult.FilesRemoved > 0 || cleanupResult.CollectionsRemoved > 0 {
-		fmt.Printf("  Removed %d file(s) (file not found)\n", cleanupResult.FilesRemoved)
-		fmt.Printf("  Removed %d collection(s) (empty)\n", cleanupResult.CollectionsRemoved)
-	} else {
-		fmt.Println("  No stale entries found")
+		return result, fmt.Errorf("cleanup failed: %w", err)
 	}
+	result.Cleanup = cleanupResult
 
 	// Step 3: Verify all existing files have restored permissions
 	files := m.security.GetRegisteredFiles()
@@ -626,7 +635,7 @@ func (m *Manager) Destroy() error {
 		// Get expected (original) permissions
 		expectedOwner, expectedGroup, expectedMode, guard, err := m.security.GetRegisteredFileConfig(path)
 		if err != nil {
-			return fmt.Errorf("verification failed: cannot get config for %s: %w", path, err)
+			return result, fmt.Errorf("verification failed: cannot get config for %s: %w", path, err)
 		}
 
 		// Guard should be false after reset
@@ -638,7 +647,7 @@ func (m *Manager) Destroy() error {
 		// Verify actual permissions match expected
 		actualMode, actualOwner, actualGroup, err := m.fs.GetFileInfo(path)
 		if err != nil {
-			return fmt.Errorf("verification failed: cannot get file info for %s: %w", path, err)
+			return result, fmt.Errorf("verification failed: cannot get file info for %s: %w", path, err)
 		}
 
 		if actualMode != expectedMode || actualOwner != expectedOwner || actualGroup != expectedGroup {
@@ -650,15 +659,14 @@ func (m *Manager) Destroy() error {
 
 	// Step 4: Only delete .guardfile if verification succeeded
 	if verificationFailed || m.HasErrors() {
-		return fmt.Errorf("destroy verification failed - .guardfile preserved. Fix errors and try again")
+		return result, fmt.Errorf("destroy verification failed - .guardfile preserved. Fix errors and try again")
 	}
 
 	// Delete .guardfile
-	if err := os.Remove(m.registryPath); err != nil {
-		return fmt.Errorf("failed to delete .guardfile: %w", err)
+	if err := m.removeFile(m.registryPath); err != nil {
+		return result, fmt.Errorf("failed to delete .guardfile: %w", err)
 	}
-	fmt.Println("Removed .guardfile")
-	fmt.Println("Uninstall complete")
+	result.Removed = true
 
-	return nil
+	return result, nil
 }
@@ -0,0 +1,431 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/git"
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+// focusSkipDirs are directories never scanned for project files in focus mode
+var focusSkipDirs = []string{".git", StateDirName}
+
+// FocusResult reports the files a focus operation changed, relative to the .guardfile.
+type FocusResult struct {
+	Guarded   []string // files guarded because they are outside the focus set
+	Unguarded []string // files released because they joined the focus set or focus mode ended
+}
+
+// Focus starts focus mode: every file in the project except the focus set is registered
+// and guarded. paths are files, folders or glob patterns ("**" matches any number of folders).
+// Files that are already guarded are left alone and stay guarded after Unfocus.
+// The caller saves the registry.
+func (m *Manager) Focus(paths []string) (*FocusResult, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if m.security.GetFocus() != nil {
+		return nil, fmt.Errorf("focus mode is already active. Adjust it with 'guard focus add/remove' or end it with 'guard unfocus'")
+	}
+
+	patterns, err := m.focusPatterns(paths)
+	if err != nil {
+		return nil, err
+	}
+	return m.applyFocus(&registry.Focus{Paths: patterns})
+}
+
+// AddFocusPaths adds paths to the focus set and releases the files focus mode guarded among them.
+// Files created since focus mode started are guarded unless they are in the focus set.
+func (m *Manager) AddFocusPaths(paths []string) (*FocusResult, error) {
+	focus, err := m.requireFocus()
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := m.focusPatterns(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range patterns {
+		if !slices.Contains(focus.Paths, pattern) {
+			focus.Paths = append(focus.Paths, pattern)
+		}
+	}
+	return m.applyFocus(focus)
+}
+
+// RemoveFocusPaths removes paths from the focus set and guards the files that leave it.
+func (m *Manager) RemoveFocusPaths(paths []string) (*FocusResult, error) {
+	focus, err := m.requireFocus()
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := m.focusPatterns(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for _, pattern := range patterns {
+		if !slices.Contains(focus.Paths, pattern) {
+			unknown = append(unknown, pattern)
+		}
+	}
+	if len(unknown) > 0 {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Not in the focus set: %s", strings.Join(unknown, ", "))))
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(focus.Paths), func(pattern string) bool {
+		return slices.Contains(patterns, pattern)
+	})
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("cannot remove every path from the focus set. End focus mode with 'guard unfocus'")
+	}
+	focus.Paths = kept
+	return m.applyFocus(focus)
+}
+
+// Unfocus ends focus mode. Files guarded by focus mode get their original permissions back
+// and files registered by it are unregistered. Everything else is left as it is.
+// The caller saves the registry.
+func (m *Manager) Unfocus() (*FocusResult, error) {
+	focus, err := m.requireFocus()
+	if err != nil {
+		return nil, err
+	}
+
+	m.pruneFocus(focus)
+	files := slices.Clone(focus.Guarded)
+	for _, rel := range focus.Registered {
+		if !slices.Contains(files, rel) {
+			files = append(files, rel)
+		}
+	}
+
+	result := &FocusResult{}
+	m.releaseFocusFiles("unfocus", focus, files, result)
+
+	// Keep what could not be released so 'guard unfocus' can be retried
+	if len(focus.Guarded) == 0 && len(focus.Registered) == 0 {
+		m.security.SetFocus(nil)
+	} else {
+		m.security.SetFocus(focus)
+	}
+	return result, nil
+}
+
+// GetFocus returns the active focus, or nil if focus mode is not active.
+func (m *Manager) GetFocus() *registry.Focus {
+	if m.security == nil {
+		return nil
+	}
+	return m.security.GetFocus()
+}
+
+// InFocus returns true if focus mode is active and path is in the focus set.
+func (m *Manager) InFocus(path string) bool {
+	focus := m.GetFocus()
+	if focus == nil {
+		return false
+	}
+	rel, err := m.relativeToRegistry(path)
+	if err != nil {
+		return false
+	}
+	return focusMatches(focus.Paths, rel)
+}
+
+// requireFocus returns the active focus or an error if focus mode is not active.
+func (m *Manager) requireFocus() (*registry.Focus, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	focus := m.security.GetFocus()
+	if focus == nil {
+		return nil, fmt.Errorf("focus mode is not active. Start it with 'guard focus <paths>...'")
+	}
+	return focus, nil
+}
+
+// focusFiles returns the project files focus mode guards outside the focus set. Inside a
+// git working tree these are the tracked files and the untracked files git does not ignore,
+// so that dependencies and build output are left alone; otherwise every file below baseDir.
+func (m *Manager) focusFiles(baseDir string) ([]string, error) {
+	if _, err := git.Open(baseDir); err != nil {
+		return m.fs.CollectFilesRecursive(baseDir, focusSkipDirs...)
+	}
+	selected, err := m.SelectGitFiles(GitSelector{Tracked: true, Untracked: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// Regular files only, as in the directory scan: no symlinks or submodules
+	files := selected[:0]
+	for _, path := range selected {
+		if info, err := m.fs.Lstat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// applyFocus guards every project file outside the focus set that is not guarded yet
+// and releases the files focus mode guarded or registered inside it.
+func (m *Manager) applyFocus(focus *registry.Focus) (*FocusResult, error) {
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	files, err := m.focusFiles(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+
+	m.pruneFocus(focus)
+	matched := make(map[string]bool, len(focus.Paths))
+	var release []string
+	var changes []permChange
+	for _, path := range files {
+		if m.isRegistryPath(path) {
+			continue
+		}
+		rel, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		patterns := matchingFocusPatterns(focus.Paths, rel)
+		for _, pattern := range patterns {
+			matched[pattern] = true
+		}
+		if len(patterns) > 0 {
+			if slices.Contains(focus.Guarded, rel) || slices.Contains(focus.Registered, rel) {
+				release = append(release, rel)
+			}
+			continue
+		}
+
+		// Already guarded by focus mode, or released by the user since
+		if slices.Contains(focus.Guarded, rel) {
+			continue
+		}
+		if m.security.IsRegisteredFile(path) {
+			// Guarded on its own: not focus mode's to change
+			if guard, err := m.security.GetRegisteredFileGuard(path); err != nil || guard {
+				continue
+			}
+		} else {
+			if !m.registerFolderFile(path) {
+				continue
+			}
+			focus.Registered = append(focus.Registered, rel)
+		}
+		changes = append(changes, m.guardPermChange(path))
+	}
+
+	var unmatched []string
+	for _, pattern := range focus.Paths {
+		if !matched[pattern] {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	if len(unmatched) > 0 {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Focus paths match no files: %s", strings.Join(unmatched, ", "))))
+	}
+
+	result := &FocusResult{}
+	m.releaseFocusFiles("focus", focus, release, result)
+
+	succeeded := m.applyPermChanges("focus", changes)
+	m.setGuardFlags(succeeded, changes, true)
+	for _, change := range changes {
+		if !succeeded[change.path] {
+			continue
+		}
+		rel, err := m.relativeToRegistry(change.path)
+		if err != nil {
+			continue
+		}
+		focus.Guarded = append(focus.Guarded, rel)
+		result.Guarded = append(result.Guarded, rel)
+	}
+
+	sort.Strings(focus.Guarded)
+	sort.Strings(focus.Registered)
+	m.security.SetFocus(focus)
+	return result, nil
+}
+
+// releaseFocusFiles restores the original permissions of the given files guarded by focus mode
+// and unregisters the ones it registered. Released files are removed from the focus lists.
+func (m *Manager) releaseFocusFiles(operation string, focus *registry.Focus, files []string, result *FocusResult) {
+	baseDir := filepath.Dir(m.registryPath)
+	done := make(map[string]bool, len(files))
+	var missing []string
+	var changes []permChange
+	for _, rel := range files {
+		path, err := filepath.Abs(filepath.Join(baseDir, rel))
+		if err != nil {
+			continue
+		}
+		if !slices.Contains(focus.Guarded, rel) {
+			done[rel] = true
+			continue
+		}
+
+		owner, group, mode, guard, err := m.security.GetRegisteredFileConfig(path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", rel, err))
+			continue
+		}
+		if !guard {
+			done[rel] = true
+			continue
+		}
+		if !m.fs.FileExists(path) {
+			missing = append(missing, rel)
+			if err := m.security.SetRegisteredFileGuard(path, false); err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", rel, err))
+				continue
+			}
+			done[rel] = true
+			continue
+		}
+		changes = append(changes, unguardPermChange(path, mode, owner, group))
+	}
+	if len(missing) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", missing...))
+	}
+
+	succeeded := m.applyPermChanges(operation, changes)
+	m.setGuardFlags(succeeded, changes, false)
+	for _, change := range changes {
+		if !succeeded[change.path] {
+			continue
+		}
+		rel, err := m.relativeToRegistry(change.path)
+		if err != nil {
+			continue
+		}
+		done[rel] = true
+		result.Unguarded = append(result.Unguarded, rel)
+	}
+
+	for _, rel := range focus.Registered {
+		if !done[rel] {
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(baseDir, rel))
+		if err != nil {
+			continue
+		}
+		m.security.RemoveRegisteredFileFromAllRegisteredCollections(path)
+		if err := m.security.UnregisterFile(path, true); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to unregister %s: %v", rel, err))
+			delete(done, rel)
+		}
+	}
+
+	isDone := func(rel string) bool { return done[rel] }
+	focus.Guarded = slices.DeleteFunc(focus.Guarded, isDone)
+	focus.Registered = slices.DeleteFunc(focus.Registered, isDone)
+}
+
+// pruneFocus drops files from the focus lists that were unregistered since.
+func (m *Manager) pruneFocus(focus *registry.Focus) {
+	baseDir := filepath.Dir(m.registryPath)
+	unregistered := func(rel string) bool {
+		path, err := filepath.Abs(filepath.Join(baseDir, rel))
+		return err != nil || !m.security.IsRegisteredFile(path)
+	}
+	focus.Guarded = slices.DeleteFunc(focus.Guarded, unregistered)
+	focus.Registered = slices.DeleteFunc(focus.Registered, unregistered)
+}
+
+// focusPatterns converts focus paths given on the command line to patterns relative to the .guardfile.
+func (m *Manager) focusPatterns(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no focus paths specified")
+	}
+
+	patterns := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, err := m.relativeToRegistry(path)
+		if err != nil {
+			return nil, err
+		}
+		if rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("focus path outside guardfile directory: %s", path)
+		}
+		if _, err := filepath.Match(rel, ""); err != nil {
+			return nil, fmt.Errorf("invalid focus pattern '%s': %w", path, err)
+		}
+		if !slices.Contains(patterns, rel) {
+			patterns = append(patterns, rel)
+		}
+	}
+	return patterns, nil
+}
+
+// relativeToRegistry returns path relative to the .guardfile directory, with forward slashes.
+func (m *Manager) relativeToRegistry(path string) (string, error) {
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	rel, err := filepath.Rel(baseDir, absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// focusMatches returns true if rel is in the focus set described by patterns.
+func focusMatches(patterns []string, rel string) bool {
+	return len(matchingFocusPatterns(patterns, rel)) > 0
+}
+
+// matchingFocusPatterns returns the patterns that match rel.
+// A pattern matches a path and everything below it; "**" matches any number of folders.
+func matchingFocusPatterns(patterns []string, rel string) []string {
+	segments := strings.Split(rel, "/")
+	var matching []string
+	for _, pattern := range patterns {
+		if pattern == "." || matchFocusSegments(strings.Split(pattern, "/"), segments) {
+			matching = append(matching, pattern)
+		}
+	}
+	return matching
+}
+
+func matchFocusSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		// The pattern matched a parent folder of path
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchFocusSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchFocusSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestFocusAndUnfocus tests that focus mode guards everything outside the focus set,
+// leaves files guarded on their own alone, and that unfocus reverses exactly its own changes.
+func TestFocusAndUnfocus(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	apiDir := filepath.Join(tmpDir, "src", "api")
+	if err := os.MkdirAll(apiDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	mainFile := createTestFile(t, tmpDir, "main.go", 0644)
+	libFile := createTestFile(t, tmpDir, "lib.go", 0644)
+	handlerFile := createTestFile(t, apiDir, "handler.go", 0644)
+	otherFile := createTestFile(t, filepath.Join(tmpDir, "src"), "other.go", 0644)
+	if err := mgr.EnableFiles([]string{mainFile}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+
+	result, err := mgr.Focus([]string{"src/api", "*.md"})
+	if err != nil {
+		t.Fatalf("Focus failed: %v", err)
+	}
+	if !slices.Equal(result.Guarded, []string{"lib.go", "src/other.go"}) {
+		t.Errorf("Expected lib.go and src/other.go to be guarded, got %v", result.Guarded)
+	}
+	if _, err := mgr.Focus([]string{"src"}); err == nil {
+		t.Error("Expected an error when focus mode is already active")
+	}
+	if mgr.IsRegisteredFile(handlerFile) {
+		t.Error("Expected the focus set to stay unregistered")
+	}
+	if !mgr.InFocus(handlerFile) || mgr.InFocus(otherFile) {
+		t.Error("Expected only src/api to be in focus")
+	}
+	if len(mgr.GetWarnings()) != 1 {
+		t.Errorf("Expected a warning for *.md matching no files, got %+v", mgr.GetWarnings())
+	}
+
+	// Widen the focus set: src/other.go is released and unregistered again
+	if _, err := mgr.AddFocusPaths([]string{"src"}); err != nil {
+		t.Fatalf("AddFocusPaths failed: %v", err)
+	}
+	if mgr.IsRegisteredFile(otherFile) {
+		t.Error("Expected src/other.go to be unregistered after joining the focus set")
+	}
+	if mode, _, _, _ := mgr.fs.GetFileInfo(otherFile); mode.Perm() != 0644 {
+		t.Errorf("Expected mode 0644 for src/other.go, got %04o", mode.Perm())
+	}
+	if _, err := mgr.RemoveFocusPaths([]string{"src/api", "src", "*.md"}); err == nil {
+		t.Error("Expected an error when removing every focus path")
+	}
+
+	result, err = mgr.Unfocus()
+	if err != nil {
+		t.Fatalf("Unfocus failed: %v", err)
+	}
+	if !slices.Equal(result.Unguarded, []string{"lib.go"}) {
+		t.Errorf("Expected lib.go to be unguarded, got %v", result.Unguarded)
+	}
+	if mgr.IsRegisteredFile(libFile) || mgr.GetFocus() != nil {
+		t.Error("Expected unfocus to unregister lib.go and end focus mode")
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(mainFile); !guard {
+		t.Error("Expected main.go to stay guarded")
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.DisableFiles([]string{mainFile}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+}
+
+// TestFocusInGitRepo tests that inside a git working tree focus mode only guards the files
+// git tracks or does not ignore.
+func TestFocusInGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	runGit(t, tmpDir, "init", "-q")
+	createTestFile(t, tmpDir, "main.go", 0644)
+	runGit(t, tmpDir, "add", "main.go")
+	createTestFile(t, tmpDir, "new.go", 0644)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte(".guard/\nbuild/\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "build"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	outFile := createTestFile(t, filepath.Join(tmpDir, "build"), "out.bin", 0644)
+
+	result, err := mgr.Focus([]string{"main.go"})
+	if err != nil {
+		t.Fatalf("Focus failed: %v", err)
+	}
+	if !slices.Equal(result.Guarded, []string{".gitignore", "new.go"}) {
+		t.Errorf("Expected .gitignore and new.go to be guarded, got %v", result.Guarded)
+	}
+	if mgr.IsRegisteredFile(outFile) {
+		t.Error("Expected the ignored build output to be left alone")
+	}
+
+	if _, err := mgr.Unfocus(); err != nil {
+		t.Fatalf("Unfocus failed: %v", err)
+	}
+}
+
+// TestFocusMatches tests matching of focus paths and glob patterns.
+func TestFocusMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"src", "src/a/b.go", true},
+		{"src", "srcfoo/b.go", false},
+		{"src/a", "src", false},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/README.md", false},
+		{"**/*.md", "docs/README.md", true},
+		{"**/*.md", "README.md", true},
+		{"src/**/*_test.go", "src/a/b/c_test.go", true},
+		{"src/*", "src/a/b.go", true},
+		{".", "anything.go", true},
+	}
+	for _, tt := range tests {
+		if got := focusMatches([]string{tt.pattern}, tt.path); got != tt.want {
+			t.Errorf("focusMatches(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -164,7 +164,7 @@ func (m *Manager) toggleFolder(path string) error {
 	// Validate the folder exists and is a directory
 	isDir, err := m.fs.IsDir(path)
 	if err != nil {
-		return fmt.Errorf("folder not found: %s", path)
+		return newError(ErrorNotFound, []string{path}, "folder not found: %s", path)
 	}
 	if !isDir {
 		return fmt.Errorf("not a directory: %s", path)
@@ -201,26 +201,16 @@ func (m *Manager) toggleFolder(path string) error {
 		m.AddWarning(NewWarning(WarningFolderEmpty, "", path))
 	}
 
-	// Get default guard config
-	guardMode := m.security.GetDefaultFileMode()
-	guardOwner := m.security.GetDefaultFileOwner()
-	guardGroup := m.security.GetDefaultFileGroup()
-
-	// Process each file in the folder
+	// Register files and collect the permission changes
+	var changes []permChange
 	for _, filePath := range files {
-		// Get file info for registration
-		mode, owner, group, err := m.fs.GetFileInfo(filePath)
-		if err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to get file info for %s: %v", filePath, err))
+		if !m.registerFolderFile(filePath) {
 			continue
 		}
 
-		// Register file if not already registered
-		if !m.security.IsRegisteredFile(filePath) {
-			if err := m.security.RegisterFile(filePath, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to register %s: %v", filePath, err))
-				continue
-			}
+		if newGuardState {
+			changes = append(changes, m.guardPermChange(filePath))
+			continue
 		}
 
 		// Get current file config (needed for restore permissions)
@@ -229,44 +219,20 @@ func (m *Manager) toggleFolder(path string) error {
 			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", filePath, err))
 			continue
 		}
-
-		// Apply guard state
-		if newGuardState {
-			// Enable guard: apply guard permissions, then set immutable
-			if err := m.fs.ApplyPermissions(filePath, guardMode, guardOwner, guardGroup); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to enable guard for %s: %v", filePath, err))
-				continue
-			}
-
-			// Set immutable flag (auto-skips if not root)
-			if err := m.fs.SetImmutable(filePath); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to set immutable flag for %s: %v", filePath, err))
-			}
-		} else {
-			// Disable guard: clear immutable first, then restore permissions
-			if err := m.fs.ClearImmutable(filePath); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", filePath, err))
-				continue
-			}
-
-			if err := m.fs.RestorePermissions(filePath, storedMode, storedOwner, storedGroup); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to disable guard for %s: %v", filePath, err))
-				continue
-			}
-		}
-
-		// Set new guard flag
-		if err := m.security.SetRegisteredFileGuard(filePath, newGuardState); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", filePath, err))
-			continue
-		}
+		changes = append(changes, unguardPermChange(filePath, storedMode, storedOwner, storedGroup))
 	}
 
+	// Apply guard state and set new guard flags
+	m.setGuardFlags(m.applyPermChanges("toggle", changes), changes, newGuardState)
+
 	// Update folder guard state
 	if err := m.security.SetFolderGuard(folderName, newGuardState); err != nil {
 		return fmt.Errorf("failed to set folder guard state: %w", err)
 	}
 
+	// Remember for 'guard toggle' without arguments
+	m.security.SetLastToggle(normalizedPath, "folder")
+
 	return nil
 }
 
@@ -293,7 +259,7 @@ func (m *Manager) enableFolder(path string) error {
 	// Validate the folder exists and is a directory
 	isDir, err := m.fs.IsDir(path)
 	if err != nil {
-		return fmt.Errorf("folder not found: %s", path)
+		return newError(ErrorNotFound, []string{path}, "folder not found: %s", path)
 	}
 	if !isDir {
 		return fmt.Errorf("not a directory: %s", path)
@@ -322,44 +288,14 @@ func (m *Manager) enableFolder(path string) error {
 		m.AddWarning(NewWarning(WarningFolderEmpty, "", path))
 	}
 
-	// Get default guard config
-	guardMode := m.security.GetDefaultFileMode()
-	guardOwner := m.security.GetDefaultFileOwner()
-	guardGroup := m.security.GetDefaultFileGroup()
-
-	// Process each file
+	// Register files and enable guard
+	var changes []permChange
 	for _, filePath := range files {
-		mode, owner, group, err := m.fs.GetFileInfo(filePath)
-		if err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to get file info for %s: %v", filePath, err))
-			continue
-		}
-
-		// Register file if not already registered
-		if !m.security.IsRegisteredFile(filePath) {
-			if err := m.security.RegisterFile(filePath, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to register %s: %v", filePath, err))
-				continue
-			}
-		}
-
-		// Enable guard: apply guard permissions
-		if err := m.fs.ApplyPermissions(filePath, guardMode, guardOwner, guardGroup); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to enable guard for %s: %v", filePath, err))
-			continue
-		}
-
-		// Set immutable flag
-		if err := m.fs.SetImmutable(filePath); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set immutable flag for %s: %v", filePath, err))
-		}
-
-		// Set guard flag
-		if err := m.security.SetRegisteredFileGuard(filePath, true); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", filePath, err))
-			continue
+		if m.registerFolderFile(filePath) {
+			changes = append(changes, m.guardPermChange(filePath))
 		}
 	}
+	m.setGuardFlags(m.applyPermChanges("enable", changes), changes, true)
 
 	// Set folder guard state to true
 	if err := m.security.SetFolderGuard(folderName, true); err != nil {
@@ -392,7 +328,7 @@ func (m *Manager) disableFolder(path string) error {
 	// Validate the folder exists and is a directory
 	isDir, err := m.fs.IsDir(path)
 	if err != nil {
-		return fmt.Errorf("folder not found: %s", path)
+		return newError(ErrorNotFound, []string{path}, "folder not found: %s", path)
 	}
 	if !isDir {
 		return fmt.Errorf("not a directory: %s", path)
@@ -421,46 +357,22 @@ func (m *Manager) disableFolder(path string) error {
 		m.AddWarning(NewWarning(WarningFolderEmpty, "", path))
 	}
 
-	// Process each file
+	// Register files and collect the permissions to restore
+	var changes []permChange
 	for _, filePath := range files {
-		mode, owner, group, err := m.fs.GetFileInfo(filePath)
-		if err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to get file info for %s: %v", filePath, err))
+		if !m.registerFolderFile(filePath) {
 			continue
 		}
 
-		// Register file if not already registered
-		if !m.security.IsRegisteredFile(filePath) {
-			if err := m.security.RegisterFile(filePath, mode, owner, group); err != nil {
-				m.AddError(fmt.Sprintf("Error: Failed to register %s: %v", filePath, err))
-				continue
-			}
-		}
-
 		// Get current file config for restoring permissions
 		storedOwner, storedGroup, storedMode, _, err := m.security.GetRegisteredFileConfig(filePath)
 		if err != nil {
 			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", filePath, err))
 			continue
 		}
-
-		// Disable guard: clear immutable, then restore permissions
-		if err := m.fs.ClearImmutable(filePath); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", filePath, err))
-			continue
-		}
-
-		if err := m.fs.RestorePermissions(filePath, storedMode, storedOwner, storedGroup); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to disable guard for %s: %v", filePath, err))
-			continue
-		}
-
-		// Set guard flag to false
-		if err := m.security.SetRegisteredFileGuard(filePath, false); err != nil {
-			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", filePath, err))
-			continue
-		}
+		changes = append(changes, unguardPermChange(filePath, storedMode, storedOwner, storedGroup))
 	}
+	m.setGuardFlags(m.applyPermChanges("disable", changes), changes, false)
 
 	// Set folder guard state to false
 	if err := m.security.SetFolderGuard(folderName, false); err != nil {
@@ -470,6 +382,26 @@ func (m *Manager) disableFolder(path string) error {
 	return nil
 }
 
+// registerFolderFile registers a file found in a folder scan with its current permissions.
+// Returns false (and records an error) if the file could not be registered.
+func (m *Manager) registerFolderFile(filePath string) bool {
+	if m.security.IsRegisteredFile(filePath) {
+		return true
+	}
+
+	mode, owner, group, err := m.fs.GetFileInfo(filePath)
+	if err != nil {
+		m.AddError(fmt.Sprintf("Error: Failed to get file info for %s: %v", filePath, err))
+		return false
+	}
+
+	if err := m.security.RegisterFile(filePath, mode, owner, group); err != nil {
+		m.AddError(fmt.Sprintf("Error: Failed to register %s: %v", filePath, err))
+		return false
+	}
+	return true
+}
+
 // deduplicatePaths returns a slice with duplicate paths removed.
 // Paths are normalized before comparison to handle variations like "./folder" vs "folder".
 func deduplicatePaths(paths []string) []string {
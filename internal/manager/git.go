@@ -0,0 +1,145 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/git"
+)
+
+// GitSelector selects files by their git status. The selected sets are combined.
+type GitSelector struct {
+	Tracked        bool      // all tracked files
+	Untracked      bool      // untracked files, without ignored files
+	Staged         bool      // files with staged changes
+	ExceptStaged   bool      // leave staged files out (of all tracked files if nothing else is selected)
+	Changed        string    // files changed by a commit or range, e.g. "HEAD" or "main..HEAD"
+	UnchangedSince time.Time // tracked files without commits or uncommitted changes since then
+}
+
+// IsEmpty returns true if the selector selects nothing.
+func (s GitSelector) IsEmpty() bool {
+	return !s.Tracked && !s.Untracked && !s.Staged && !s.ExceptStaged && s.Changed == "" && s.UnchangedSince.IsZero()
+}
+
+// SelectGitFiles runs git and returns the selected files that exist below the .guardfile directory,
+// as sorted absolute paths. The .guardfile and guard's state directory are never selected.
+func (m *Manager) SelectGitFiles(sel GitSelector) ([]string, error) {
+	if sel.IsEmpty() {
+		return nil, nil
+	}
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	repo, err := git.Open(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	add := func(files []string, err error) error {
+		if err != nil {
+			return err
+		}
+		selected = append(selected, files...)
+		return nil
+	}
+
+	if sel.Tracked || (sel.ExceptStaged && !sel.Untracked && !sel.Staged && sel.Changed == "" && sel.UnchangedSince.IsZero()) {
+		if err := add(repo.TrackedFiles()); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Untracked {
+		if err := add(repo.UntrackedFiles()); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Staged {
+		if err := add(repo.StagedFiles()); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Changed != "" {
+		if err := add(repo.ChangedFiles(sel.Changed)); err != nil {
+			return nil, err
+		}
+	}
+	if !sel.UnchangedSince.IsZero() {
+		unchanged, err := unchangedFiles(repo, sel.UnchangedSince)
+		if err := add(unchanged, err); err != nil {
+			return nil, err
+		}
+	}
+
+	// Staged files with ExceptStaged, then every file already selected
+	skip := make(map[string]bool)
+	if sel.ExceptStaged {
+		staged, err := repo.StagedFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range staged {
+			skip[path] = true
+		}
+	}
+
+	// git reports paths below its resolved top-level directory
+	realBase, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		realBase = baseDir
+	}
+
+	var files []string
+	for _, path := range selected {
+		if skip[path] {
+			continue
+		}
+		skip[path] = true
+
+		rel, err := filepath.Rel(realBase, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		path = filepath.Join(baseDir, rel)
+		// Deleted files show up in git's output but cannot be guarded
+		if m.isRegistryPath(path) || m.IsStatePath(path) || !m.fs.FileExists(path) {
+			continue
+		}
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// unchangedFiles returns the tracked files without commits since the given time and without uncommitted changes.
+func unchangedFiles(repo *git.Repo, since time.Time) ([]string, error) {
+	tracked, err := repo.TrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	committed, err := repo.CommittedSince(since)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := repo.ModifiedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]bool, len(committed)+len(modified))
+	for _, path := range append(committed, modified...) {
+		changed[path] = true
+	}
+	var unchanged []string
+	for _, path := range tracked {
+		if !changed[path] {
+			unchanged = append(unchanged, path)
+		}
+	}
+	return unchanged, nil
+}
@@ -0,0 +1,82 @@
+package manager
+
+import (
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// runGit runs git in dir and fails the test on error.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+// TestSelectGitFiles tests selecting files by their git status.
+func TestSelectGitFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	runGit(t, tmpDir, "init", "-q")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "test")
+	old := createTestFile(t, tmpDir, "old.txt", 0644)
+	runGit(t, tmpDir, "add", "old.txt", ".guardfile")
+	runGit(t, tmpDir, "commit", "-q", "-m", "one")
+	recent := createTestFile(t, tmpDir, "recent.txt", 0644)
+	runGit(t, tmpDir, "add", "recent.txt")
+	runGit(t, tmpDir, "commit", "-q", "-m", "two")
+	staged := createTestFile(t, tmpDir, "staged.txt", 0644)
+	runGit(t, tmpDir, "add", "staged.txt")
+	untracked := createTestFile(t, tmpDir, "untracked.txt", 0644)
+
+	// SelectGitFiles returns absolute paths
+	abs := func(paths ...string) []string {
+		for i, path := range paths {
+			paths[i], _ = filepath.Abs(path)
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name string
+		sel  GitSelector
+		want []string
+	}{
+		// The committed .guardfile is never selected
+		{"tracked", GitSelector{Tracked: true}, abs(old, recent, staged)},
+		{"untracked", GitSelector{Untracked: true}, abs(untracked)},
+		{"staged", GitSelector{Staged: true}, abs(staged)},
+		{"except staged", GitSelector{ExceptStaged: true}, abs(old, recent)},
+		{"changed", GitSelector{Changed: "HEAD"}, abs(recent)},
+		{"changed range", GitSelector{Changed: "HEAD~1..HEAD", Untracked: true}, abs(recent, untracked)},
+		{"unchanged since", GitSelector{UnchangedSince: time.Now().Add(time.Hour)}, abs(old, recent)},
+	}
+	for _, tt := range tests {
+		got, err := mgr.SelectGitFiles(tt.sel)
+		if err != nil {
+			t.Errorf("%s: SelectGitFiles failed: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := mgr.SelectGitFiles(GitSelector{Changed: "--output=x"}); err == nil {
+		t.Error("Expected an error for a revision that looks like an option")
+	}
+}
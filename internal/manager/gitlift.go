@@ -0,0 +1,262 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/florianbuetow/guard/internal/git"
+)
+
+// gitReadOnlyCommands never write files in the working tree
+var gitReadOnlyCommands = []string{
+	"add", "blame", "branch", "commit", "config", "describe", "diff", "fetch", "grep", "help",
+	"log", "ls-files", "push", "reflog", "remote", "rev-parse", "shortlog", "show", "status", "tag", "version",
+}
+
+// gitPullValueOptions are the options of git pull that take their value as the next argument
+var gitPullValueOptions = []string{
+	"--depth", "--deepen", "--shallow-since", "--shallow-exclude", "-s", "--strategy", "-X", "--strategy-option",
+	"-j", "--jobs", "-o", "--server-option", "--upload-pack", "--negotiation-tip", "--refmap", "--cleanup",
+}
+
+// GitAffectedFiles returns the guarded files the git command given by args may write.
+// Commands guard cannot predict (stash, cherry-pick, rebase --continue, ...) affect every guarded file.
+// For pull, the remote is fetched first to know what will be merged; if that fails, every
+// guarded file is affected.
+func (m *Manager) GitAffectedFiles(args []string) ([]string, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no git command specified")
+	}
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	repo, err := git.Open(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, all, err := gitWriteSet(repo, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// git reports paths below its resolved top-level directory
+	realBase, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		realBase = baseDir
+	}
+	writes := make(map[string]bool, len(candidates))
+	for _, path := range candidates {
+		if rel, err := filepath.Rel(realBase, path); err == nil {
+			writes[filepath.Join(baseDir, rel)] = true
+		}
+	}
+
+	var affected []string
+	for _, path := range m.security.GetRegisteredFiles() {
+		if guard, err := m.security.GetRegisteredFileGuard(path); err != nil || !guard {
+			continue
+		}
+		if all || writes[path] {
+			affected = append(affected, path)
+		}
+	}
+	return affected, nil
+}
+
+// LiftGuards gives guarded files their original permissions back so another program can write them.
+// The registry keeps them guarded; RestoreGuards guards them again. Returns the files lifted.
+func (m *Manager) LiftGuards(paths []string) []string {
+	var changes []permChange
+	var missing []string
+	for _, path := range paths {
+		owner, group, mode, guard, err := m.security.GetRegisteredFileConfig(path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", path, err))
+			continue
+		}
+		if !guard {
+			continue
+		}
+		if !m.fs.FileExists(path) {
+			missing = append(missing, path)
+			continue
+		}
+		changes = append(changes, unguardPermChange(path, mode, owner, group))
+	}
+	if len(missing) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", missing...))
+	}
+
+	succeeded := m.applyPermChanges("git", changes)
+	var lifted []string
+	for _, change := range changes {
+		if succeeded[change.path] {
+			lifted = append(lifted, change.path)
+		}
+	}
+	return lifted
+}
+
+// RestoreGuards guards files lifted by LiftGuards again. The mode a file has now becomes its
+// original mode, so a mode change written by another program (e.g. git's executable bit) is kept.
+// The content baseline is recorded anew. Files deleted in the meantime are reported as missing,
+// files no longer guarded in the registry (e.g. by a git hook) are left alone.
+func (m *Manager) RestoreGuards(paths []string) {
+	var changes []permChange
+	var missing []string
+	for _, path := range paths {
+		if guard, err := m.security.GetRegisteredFileGuard(path); err != nil || !guard {
+			continue
+		}
+		if !m.fs.FileExists(path) {
+			missing = append(missing, path)
+			continue
+		}
+		if mode, _, _, err := m.fs.GetFileInfo(path); err == nil {
+			if err := m.security.SetRegisteredFileMode(path, mode); err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to update original mode of %s: %v", path, err))
+			}
+		}
+		changes = append(changes, m.guardPermChange(path))
+	}
+	if len(missing) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", missing...))
+	}
+	m.applyPermChanges("git", changes)
+
+	// The registry kept the files guarded while they were lifted
+	m.settleJournal()
+}
+
+// ReloadRegistry loads the registry again after another program, such as git or one of its
+// hooks, may have changed the .guardfile. If it cannot be loaded, the registry loaded before
+// is kept, so that lifted files can still be guarded again, and the error is returned.
+func (m *Manager) ReloadRegistry() error {
+	previous := m.security
+	if err := m.LoadRegistry(); err != nil {
+		m.security = previous
+		return err
+	}
+	return nil
+}
+
+// gitWriteSet returns the files the git command may write, or all=true if that cannot be predicted.
+func gitWriteSet(repo *git.Repo, args []string) (files []string, all bool, err error) {
+	command, rest := args[0], args[1:]
+	if slices.Contains(gitReadOnlyCommands, command) {
+		return nil, false, nil
+	}
+
+	// Operations in progress continue where git stopped, which guard cannot see
+	for _, arg := range rest {
+		if arg == "--continue" || arg == "--abort" || arg == "--skip" || arg == "--quit" || arg == "--" {
+			return nil, true, nil
+		}
+	}
+
+	switch command {
+	case "checkout", "switch":
+		target := gitTargetCommit(repo, rest, "")
+		if target == "" {
+			// "checkout -b new" writes nothing, "checkout <paths>" may write anything
+			for _, arg := range rest {
+				if arg == "-b" || arg == "-B" || arg == "-c" || arg == "-C" || arg == "--orphan" {
+					return nil, false, nil
+				}
+			}
+			return nil, true, nil
+		}
+		files, err := gitChangedFiles(repo, target, false)
+		return files, false, err
+
+	case "reset":
+		if !slices.Contains(rest, "--hard") && !slices.Contains(rest, "--merge") && !slices.Contains(rest, "--keep") {
+			// Only the index is reset
+			return nil, false, nil
+		}
+		files, err := gitChangedFiles(repo, gitTargetCommit(repo, rest, "HEAD"), false)
+		return files, false, err
+
+	case "merge", "rebase":
+		if slices.Contains(rest, "--onto") {
+			return nil, true, nil
+		}
+		target := gitTargetCommit(repo, rest, "@{upstream}")
+		if target == "" {
+			return nil, true, nil
+		}
+		files, err := gitChangedFiles(repo, target, true)
+		return files, false, err
+
+	case "pull":
+		var remote []string
+		for i := 0; i < len(rest); i++ {
+			switch {
+			case slices.Contains(gitPullValueOptions, rest[i]):
+				i++ // Skip the option's value
+			case !strings.HasPrefix(rest[i], "-"):
+				remote = append(remote, rest[i])
+			}
+		}
+		if err := repo.Fetch(remote...); err != nil {
+			// The remote could not be predicted from the arguments
+			return nil, true, nil
+		}
+		files, err := gitChangedFiles(repo, "FETCH_HEAD", true)
+		return files, false, err
+	}
+	return nil, true, nil
+}
+
+// gitTargetCommit returns the first argument that names a commit, or fallback if it names one.
+// "-" is the previously checked out branch.
+func gitTargetCommit(repo *git.Repo, args []string, fallback string) string {
+	for _, arg := range args {
+		if arg == "-" {
+			arg = "@{-1}"
+		}
+		if commit, ok := repo.ResolveCommit(arg); ok {
+			return commit
+		}
+	}
+	if fallback == "" {
+		return ""
+	}
+	commit, _ := repo.ResolveCommit(fallback)
+	return commit
+}
+
+// gitChangedFiles returns the files that differ between HEAD and target, plus uncommitted changes.
+// With ownSide, the files changed on HEAD's side since the merge base are added: a rebase
+// writes them again when it replays the commits.
+func gitChangedFiles(repo *git.Repo, target string, ownSide bool) ([]string, error) {
+	files, err := repo.DiffFiles("HEAD", target)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := repo.ModifiedFiles()
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, modified...)
+
+	if ownSide {
+		base, err := repo.MergeBase("HEAD", target)
+		if err != nil {
+			return nil, err
+		}
+		own, err := repo.DiffFiles(base, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, own...)
+	}
+	return files, nil
+}
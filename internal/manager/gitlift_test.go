@@ -0,0 +1,177 @@
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestGitLiftAndRestore tests lifting the guards a git checkout needs and guarding the files again.
+func TestGitLiftAndRestore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	runGit(t, tmpDir, "init", "-q", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "test")
+	changed := createTestFile(t, tmpDir, "changed.txt", 0644)
+	same := createTestFile(t, tmpDir, "same.txt", 0644)
+	runGit(t, tmpDir, "add", "changed.txt", "same.txt")
+	runGit(t, tmpDir, "commit", "-q", "-m", "one")
+	runGit(t, tmpDir, "checkout", "-q", "-b", "other")
+	if err := os.WriteFile(changed, []byte("other content"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", changed, err)
+	}
+	if err := os.Chmod(changed, 0755); err != nil {
+		t.Fatalf("Failed to chmod %s: %v", changed, err)
+	}
+	runGit(t, tmpDir, "commit", "-q", "-a", "-m", "two")
+	runGit(t, tmpDir, "checkout", "-q", "main")
+
+	if err := mgr.AddFiles([]string{changed, same}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.EnableFiles([]string{changed, same}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	defer func() { _ = mgr.DisableFiles([]string{changed, same}) }()
+
+	// Read-only commands lift nothing
+	affected, err := mgr.GitAffectedFiles([]string{"status"})
+	if err != nil {
+		t.Fatalf("GitAffectedFiles failed: %v", err)
+	}
+	if len(affected) != 0 {
+		t.Errorf("Expected no affected files for status, got %v", affected)
+	}
+
+	// A checkout only writes the files that differ between the branches
+	affected, err = mgr.GitAffectedFiles([]string{"checkout", "other"})
+	if err != nil {
+		t.Fatalf("GitAffectedFiles failed: %v", err)
+	}
+	want, _ := filepath.Abs(changed)
+	if !slices.Equal(affected, []string{want}) {
+		t.Fatalf("Expected %v to be affected, got %v", []string{want}, affected)
+	}
+
+	// A pull fetches first; option values are not taken for the remote
+	runGit(t, tmpDir, "remote", "add", "origin", ".")
+	pulled, err := mgr.GitAffectedFiles([]string{"pull", "--depth", "1", "-X", "theirs", "origin", "other"})
+	if err != nil {
+		t.Fatalf("GitAffectedFiles failed: %v", err)
+	}
+	if !slices.Equal(pulled, []string{want}) {
+		t.Errorf("Expected %v to be affected by the pull, got %v", []string{want}, pulled)
+	}
+
+	// A pull whose remote cannot be fetched may write every guarded file
+	pulled, err = mgr.GitAffectedFiles([]string{"pull", "nowhere"})
+	if err != nil {
+		t.Fatalf("GitAffectedFiles failed: %v", err)
+	}
+	if len(pulled) != 2 {
+		t.Errorf("Expected both guarded files to be affected by the pull, got %v", pulled)
+	}
+
+	lifted := mgr.LiftGuards(affected)
+	if len(lifted) != 1 {
+		t.Fatalf("Expected 1 lifted file, got %v", lifted)
+	}
+	if info, _ := os.Stat(changed); info.Mode().Perm() != 0644 {
+		t.Errorf("Expected lifted file to have mode 0644, got %o", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(same); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected untouched file to stay guarded, got mode %o", info.Mode().Perm())
+	}
+
+	runGit(t, tmpDir, "checkout", "-q", "other")
+	mgr.RestoreGuards(lifted)
+	if mgr.HasErrors() {
+		t.Fatalf("RestoreGuards reported errors: %v", mgr.GetErrors())
+	}
+
+	if info, _ := os.Stat(changed); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected restored file to have mode 0600, got %o", info.Mode().Perm())
+	}
+	_, _, mode, guard, err := mgr.security.GetRegisteredFileConfig(want)
+	if err != nil {
+		t.Fatalf("GetRegisteredFileConfig failed: %v", err)
+	}
+	if !guard {
+		t.Error("Expected file to stay guarded in the registry")
+	}
+	// git's executable bit becomes the original mode
+	if mode != 0755 {
+		t.Errorf("Expected original mode 0755, got %o", mode)
+	}
+}
+
+// TestGitRestoreAfterGuardfileChange tests that lifted files are guarded again when git
+// leaves a .guardfile that cannot be loaded.
+func TestGitRestoreAfterGuardfileChange(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	// Branch other changes the file and commits a .guardfile with a conflict in it
+	runGit(t, tmpDir, "init", "-q", "-b", "main")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "test")
+	file := createTestFile(t, tmpDir, "file.txt", 0644)
+	runGit(t, tmpDir, "add", "file.txt")
+	runGit(t, tmpDir, "commit", "-q", "-m", "one")
+	runGit(t, tmpDir, "checkout", "-q", "-b", "other")
+	if err := os.WriteFile(file, []byte("other content"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", file, err)
+	}
+	if err := os.WriteFile(mgr.registryPath, []byte("<<<<<<< HEAD\nfiles: [\n=======\n>>>>>>> main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .guardfile: %v", err)
+	}
+	runGit(t, tmpDir, "add", "file.txt", ".guardfile")
+	runGit(t, tmpDir, "commit", "-q", "-m", "two")
+	runGit(t, tmpDir, "checkout", "-q", "main")
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	if err := mgr.EnableFiles([]string{file}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	defer func() { _ = mgr.DisableFiles([]string{file}) }()
+
+	affected, err := mgr.GitAffectedFiles([]string{"checkout", "other"})
+	if err != nil {
+		t.Fatalf("GitAffectedFiles failed: %v", err)
+	}
+	lifted := mgr.LiftGuards(affected)
+	if len(lifted) != 1 {
+		t.Fatalf("Expected 1 lifted file, got %v", lifted)
+	}
+	runGit(t, tmpDir, "checkout", "-q", "-f", "other")
+
+	if err := mgr.ReloadRegistry(); KindOf(err) != ErrorCorrupted {
+		t.Fatalf("Expected a corrupted .guardfile, got %v", err)
+	}
+	if !mgr.IsRegisteredFile(file) {
+		t.Fatal("Expected the registry from before git to be kept")
+	}
+	mgr.RestoreGuards(lifted)
+	if mgr.HasErrors() {
+		t.Fatalf("Unexpected errors: %v", mgr.GetErrors())
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file to be guarded again, got mode %o", info.Mode().Perm())
+	}
+}
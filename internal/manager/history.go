@@ -0,0 +1,406 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/registry"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultHistoryLimit is the number of operations kept in the history
+	DefaultHistoryLimit = 100
+
+	historyFileName = "history.yaml"
+
+	// History item types
+	HistoryItemFile       = "file"
+	HistoryItemCollection = "collection"
+	HistoryItemFolder     = "folder"
+)
+
+// HistoryItem records the guard state change of one file, collection or folder.
+// Name is the registry name: a path relative to the .guardfile for files.
+type HistoryItem struct {
+	Type      string `yaml:"type" json:"type"`
+	Name      string `yaml:"name" json:"name"`
+	PrevGuard bool   `yaml:"prev_guard" json:"prev_guard"`
+	Guard     bool   `yaml:"guard" json:"guard"`
+}
+
+// HistoryEntry is one recorded operation.
+type HistoryEntry struct {
+	Operation string        `yaml:"operation"`
+	Time      time.Time     `yaml:"time"`
+	Items     []HistoryItem `yaml:"items"`
+}
+
+// History is the operation history stored in .guard/history.yaml.
+// Entries before Position are applied, entries from Position on were undone and can be redone.
+type History struct {
+	Position int            `yaml:"position"`
+	Entries  []HistoryEntry `yaml:"entries"`
+}
+
+// Label names the item, e.g. "a.txt" or "collection config".
+func (i HistoryItem) Label() string {
+	if i.Type == HistoryItemFile {
+		return i.Name
+	}
+	return i.Type + " " + i.Name
+}
+
+// Summary describes the entry in one line, e.g. "enable a.txt, b.txt".
+func (e HistoryEntry) Summary() string {
+	names := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		names = append(names, item.Label())
+	}
+	return e.Operation + " " + strings.Join(names, ", ")
+}
+
+// EndHistoryEntry closes the current history entry.
+// All registry saves of one Manager are recorded as a single operation until this is called,
+// so long-lived callers like the TUI call it after every user action.
+func (m *Manager) EndHistoryEntry() {
+	m.historyOpen = false
+}
+
+// ReadHistory loads the operation history. A missing history is empty.
+func (m *Manager) ReadHistory() (*History, error) {
+	data, err := os.ReadFile(m.historyPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &History{}, nil
+		}
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var history History
+	if err := yaml.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse history: %w", err)
+	}
+	if history.Position < 0 || history.Position > len(history.Entries) {
+		history.Position = len(history.Entries)
+	}
+	return &history, nil
+}
+
+// Undo reverts the most recent applied operation and returns it.
+func (m *Manager) Undo() (*HistoryEntry, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	history, err := m.ReadHistory()
+	if err != nil {
+		return nil, err
+	}
+	if history.Position == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	entry := history.Entries[history.Position-1]
+	if err := m.applyHistoryEntry(entry, true); err != nil {
+		return nil, err
+	}
+	history.Position--
+	return &entry, m.writeHistory(history)
+}
+
+// Redo re-applies the most recently undone operation and returns it.
+func (m *Manager) Redo() (*HistoryEntry, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	history, err := m.ReadHistory()
+	if err != nil {
+		return nil, err
+	}
+	if history.Position == len(history.Entries) {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+
+	entry := history.Entries[history.Position]
+	if err := m.applyHistoryEntry(entry, false); err != nil {
+		return nil, err
+	}
+	history.Position++
+	return &entry, m.writeHistory(history)
+}
+
+// applyHistoryEntry brings every item of the entry to its previous (undo) or new (redo) guard state.
+func (m *Manager) applyHistoryEntry(entry HistoryEntry, undo bool) error {
+	operation := "redo"
+	if undo {
+		operation = "undo"
+	}
+	m.applyHistoryItems(operation, entry.Items, undo)
+
+	// Undo and redo move through the history instead of adding to it
+	m.historyPaused = true
+	defer func() { m.historyPaused = false }()
+	return m.SaveRegistry()
+}
+
+// applyHistoryItems brings every item to its previous (undo) or new guard state without saving.
+// Items that are no longer registered or missing on disk are skipped with a warning.
+func (m *Manager) applyHistoryItems(operation string, items []HistoryItem, undo bool) {
+	baseDir := filepath.Dir(m.registryPath)
+	var guardChanges, unguardChanges []permChange
+	var missingFiles, unknown []string
+	for _, item := range items {
+		target := item.Guard
+		if undo {
+			target = item.PrevGuard
+		}
+
+		switch item.Type {
+		case HistoryItemFile:
+			path, err := filepath.Abs(filepath.Join(baseDir, item.Name))
+			if err != nil || !m.security.IsRegisteredFile(path) {
+				unknown = append(unknown, item.Name)
+				continue
+			}
+			if !m.fs.FileExists(path) {
+				missingFiles = append(missingFiles, item.Name)
+				continue
+			}
+			if target {
+				guardChanges = append(guardChanges, m.guardPermChange(path))
+				continue
+			}
+			owner, group, mode, _, err := m.security.GetRegisteredFileConfig(path)
+			if err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", item.Name, err))
+				continue
+			}
+			unguardChanges = append(unguardChanges, unguardPermChange(path, mode, owner, group))
+
+		case HistoryItemCollection:
+			if err := m.security.SetRegisteredCollectionGuard(item.Name, target); err != nil {
+				unknown = append(unknown, item.Type+" "+item.Name)
+			}
+
+		case HistoryItemFolder:
+			if err := m.security.SetFolderGuard(item.Name, target); err != nil {
+				unknown = append(unknown, item.Type+" "+item.Name)
+			}
+		}
+	}
+
+	if len(missingFiles) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", missingFiles...))
+	}
+	if len(unknown) > 0 {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf(
+			"Skipped items that are no longer registered: %s", strings.Join(unknown, ", "))))
+	}
+
+	m.setGuardFlags(m.applyPermChanges(operation, guardChanges), guardChanges, true)
+	m.setGuardFlags(m.applyPermChanges(operation, unguardChanges), unguardChanges, false)
+}
+
+// snapshotHistoryState remembers the guard state of the loaded registry,
+// which the next SaveRegistry compares against.
+func (m *Manager) snapshotHistoryState() {
+	m.historySnapshot = nil
+	data, err := m.security.Marshal()
+	if err != nil {
+		return
+	}
+	m.historySnapshot, _ = registry.ParseRegistryData(data)
+}
+
+// recordHistory records the guard state changes between the last snapshot and the saved content,
+// and audits changed configuration values. Called by SaveRegistry after a successful save.
+func (m *Manager) recordHistory(saved []byte) {
+	data, err := registry.ParseRegistryData(saved)
+	if err != nil {
+		return
+	}
+	m.auditConfigChanges(m.historySnapshot, data)
+	items := guardStateChanges(m.historySnapshot, data)
+	m.historySnapshot = data
+	if len(items) == 0 || m.historyPaused {
+		return
+	}
+
+	if err := m.appendHistory(items); err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to record history: %v", err)))
+	}
+}
+
+// appendHistory adds the items as a new entry, or merges them into the entry this Manager
+// opened earlier. Undone entries are dropped: a new operation ends the redo chain.
+func (m *Manager) appendHistory(items []HistoryItem) error {
+	history, err := m.ReadHistory()
+	if err != nil {
+		return err
+	}
+
+	if m.historyOpen && history.Position > 0 {
+		entry := &history.Entries[history.Position-1]
+		entry.Items = mergeHistoryItems(entry.Items, items)
+		entry.Operation = historyOperation(entry.Items)
+		if len(entry.Items) == 0 {
+			history.Entries = history.Entries[:history.Position-1]
+			m.historyOpen = false
+		}
+	} else {
+		history.Entries = append(history.Entries[:history.Position], HistoryEntry{
+			Operation: historyOperation(items),
+			Time:      time.Now(),
+			Items:     items,
+		})
+		if len(history.Entries) > DefaultHistoryLimit {
+			history.Entries = history.Entries[len(history.Entries)-DefaultHistoryLimit:]
+		}
+		m.historyOpen = true
+	}
+	history.Position = len(history.Entries)
+
+	return m.writeHistory(history)
+}
+
+// dropOpenHistoryEntry removes the entry recorded by this Manager, used when an atomic run is reverted.
+func (m *Manager) dropOpenHistoryEntry() {
+	if !m.historyOpen {
+		return
+	}
+	m.historyOpen = false
+
+	history, err := m.ReadHistory()
+	if err != nil || history.Position == 0 {
+		return
+	}
+	history.Entries = append(history.Entries[:history.Position-1], history.Entries[history.Position:]...)
+	history.Position--
+	if err := m.writeHistory(history); err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to update history: %v", err)))
+	}
+}
+
+func (m *Manager) historyPath() string {
+	return filepath.Join(m.StateDir(), historyFileName)
+}
+
+func (m *Manager) writeHistory(history *History) error {
+	if _, err := m.ensureStateDir(""); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to serialize history: %w", err)
+	}
+	if err := m.writeStateFile(m.historyPath(), data); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// guardStateChanges returns the files, collections and folders whose guard flag differs.
+// Entries missing on one side count as unguarded there.
+func guardStateChanges(oldData, newData *registry.RegistryData) []HistoryItem {
+	if oldData == nil {
+		oldData = &registry.RegistryData{}
+	}
+
+	var items []HistoryItem
+	add := func(itemType string, oldGuard, newGuard map[string]bool) {
+		var names []string
+		for name := range oldGuard {
+			names = append(names, name)
+		}
+		for name := range newGuard {
+			if _, ok := oldGuard[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if oldGuard[name] != newGuard[name] {
+				items = append(items, HistoryItem{Type: itemType, Name: name, PrevGuard: oldGuard[name], Guard: newGuard[name]})
+			}
+		}
+	}
+
+	oldFiles, newFiles := make(map[string]bool), make(map[string]bool)
+	for _, f := range oldData.Files {
+		oldFiles[f.Path] = f.Guard
+	}
+	for _, f := range newData.Files {
+		newFiles[f.Path] = f.Guard
+	}
+	add(HistoryItemFile, oldFiles, newFiles)
+
+	oldColls, newColls := make(map[string]bool), make(map[string]bool)
+	for _, c := range oldData.Collections {
+		oldColls[c.Name] = c.Guard
+	}
+	for _, c := range newData.Collections {
+		newColls[c.Name] = c.Guard
+	}
+	add(HistoryItemCollection, oldColls, newColls)
+
+	oldFolders, newFolders := make(map[string]bool), make(map[string]bool)
+	for _, f := range oldData.Folders {
+		oldFolders[f.Name] = f.Guard
+	}
+	for _, f := range newData.Folders {
+		newFolders[f.Name] = f.Guard
+	}
+	add(HistoryItemFolder, oldFolders, newFolders)
+
+	return items
+}
+
+// mergeHistoryItems combines two consecutive changes: the earlier previous state is kept,
+// the later new state wins, and items that end up unchanged are dropped.
+func mergeHistoryItems(earlier, later []HistoryItem) []HistoryItem {
+	merged := make([]HistoryItem, 0, len(earlier)+len(later))
+	index := make(map[string]int)
+	for _, item := range earlier {
+		index[item.Type+"\x00"+item.Name] = len(merged)
+		merged = append(merged, item)
+	}
+	for _, item := range later {
+		if i, ok := index[item.Type+"\x00"+item.Name]; ok {
+			merged[i].Guard = item.Guard
+			continue
+		}
+		merged = append(merged, item)
+	}
+
+	result := merged[:0]
+	for _, item := range merged {
+		if item.PrevGuard != item.Guard {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// historyOperation names an entry after its changes: "enable", "disable" or "toggle" if mixed.
+func historyOperation(items []HistoryItem) string {
+	enabled, disabled := false, false
+	for _, item := range items {
+		if item.Guard {
+			enabled = true
+		} else {
+			disabled = true
+		}
+	}
+	switch {
+	case enabled && disabled:
+		return "toggle"
+	case enabled:
+		return "enable"
+	default:
+		return "disable"
+	}
+}
@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"testing"
+)
+
+// TestUndoRedoToggle tests that a toggle is recorded and can be undone and redone.
+func TestUndoRedoToggle(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.ToggleFiles([]string{file1}); err != nil {
+		t.Fatalf("ToggleFiles failed: %v", err)
+	}
+	mgr.EndHistoryEntry()
+
+	history, err := mgr.ReadHistory()
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+	if len(history.Entries) != 1 || history.Position != 1 {
+		t.Fatalf("Expected 1 applied history entry, got %+v", history)
+	}
+	item := history.Entries[0].Items[0]
+	if item.Type != HistoryItemFile || item.Name != "file1.txt" || item.PrevGuard || !item.Guard {
+		t.Errorf("Unexpected history item: %+v", item)
+	}
+	if name, toggleType := mgr.GetRegistry().GetLastToggle(); name != "file1.txt" || toggleType != "file" {
+		t.Errorf("Expected last toggle file1.txt/file, got %s/%s", name, toggleType)
+	}
+
+	// Undo restores the original permissions and guard flag
+	if _, err := mgr.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(file1); guard {
+		t.Error("Expected file1 to be unguarded after undo")
+	}
+	if mode, _, _, _ := mgr.fs.GetFileInfo(file1); mode.Perm() != 0644 {
+		t.Errorf("Expected mode 0644 after undo, got %04o", mode.Perm())
+	}
+
+	// Undo does not add a history entry of its own
+	history, _ = mgr.ReadHistory()
+	if len(history.Entries) != 1 || history.Position != 0 {
+		t.Fatalf("Expected the entry to be marked undone, got %+v", history)
+	}
+	if _, err := mgr.Undo(); err == nil {
+		t.Error("Expected 'nothing to undo' error")
+	}
+
+	// Redo guards the file again
+	if _, err := mgr.Redo(); err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(file1); !guard {
+		t.Error("Expected file1 to be guarded after redo")
+	}
+	if mode, _, _, _ := mgr.fs.GetFileInfo(file1); mode.Perm() != 0600 {
+		t.Errorf("Expected mode 0600 after redo, got %04o", mode.Perm())
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if _, err := mgr.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+}
+
+// TestHistoryNewOperationDropsRedo tests that a new operation after an undo ends the redo chain,
+// and that the saves of one Manager are merged until EndHistoryEntry.
+func TestHistoryNewOperationDropsRedo(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	file2 := createTestFile(t, tmpDir, "file2.txt", 0644)
+
+	// Two saves, one entry
+	if err := mgr.EnableFiles([]string{file1}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if err := mgr.EnableFiles([]string{file2}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	mgr.EndHistoryEntry()
+
+	history, _ := mgr.ReadHistory()
+	if len(history.Entries) != 1 || len(history.Entries[0].Items) != 2 || history.Entries[0].Operation != "enable" {
+		t.Fatalf("Expected one merged enable entry with 2 items, got %+v", history)
+	}
+
+	if _, err := mgr.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if err := mgr.ToggleFiles([]string{file1}); err != nil {
+		t.Fatalf("ToggleFiles failed: %v", err)
+	}
+	mgr.EndHistoryEntry()
+
+	history, _ = mgr.ReadHistory()
+	if len(history.Entries) != 1 || history.Position != 1 || len(history.Entries[0].Items) != 1 {
+		t.Fatalf("Expected the undone entry to be replaced, got %+v", history)
+	}
+	if _, err := mgr.Redo(); err == nil {
+		t.Error("Expected 'nothing to redo' error")
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.DisableFiles([]string{file1}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// hookMarker identifies git hooks written by guard
+const hookMarker = "# Installed by guard"
+
+// gitHook is a git hook guard installs. setup runs where git starts the hook,
+// commands run in the project directory with $guard set to the guard executable.
+type gitHook struct {
+	name     string
+	setup    string
+	commands string
+}
+
+// branchProfileHooks run when git changes the checked out branch.
+// post-checkout gets "1" as third argument for branch checkouts, "0" for file checkouts.
+var branchProfileHooks = []gitHook{
+	{name: "post-checkout", commands: "[ \"$3\" = \"1\" ] || exit 0\n\"$guard\" branch-profile apply"},
+	{name: "post-merge", commands: "\"$guard\" branch-profile apply"},
+}
+
+// commitCheckHooks reject commits that touch guarded paths. The check runs in commit-msg,
+// the first hook before the commit is created that sees the message and its override trailer.
+// The message file is passed relative to the working tree root.
+var commitCheckHooks = []gitHook{
+	{
+		name:     "commit-msg",
+		setup:    "case \"$1\" in /*) ;; *) set -- \"$PWD/$1\" ;; esac",
+		commands: "\"$guard\" check-staged --message-file \"$1\"",
+	},
+}
+
+// InstallHooks writes git hooks that apply the branch profile whenever the checked out
+// branch changes or, with commitCheck, a hook that runs 'guard check-staged' before every commit.
+// The hooks run the guard executable at guardPath and fail if it is gone.
+// A hook not written by guard is only replaced with force. Returns the paths of the installed hooks.
+func (m *Manager) InstallHooks(guardPath string, commitCheck, force bool) ([]string, error) {
+	repo, err := m.gitRepo()
+	if err != nil {
+		return nil, err
+	}
+	hooksDir, err := repo.HooksDir()
+	if err != nil {
+		return nil, err
+	}
+	projectDir, err := m.hookProjectDir(repo.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := branchProfileHooks
+	if commitCheck {
+		hooks = commitCheckHooks
+	}
+	for _, hook := range hooks {
+		if !force && isForeignHook(filepath.Join(hooksDir, hook.name)) {
+			return nil, fmt.Errorf("git hook %s already exists and was not installed by guard. Use --force to replace it", hook.name)
+		}
+	}
+
+	if m.DryRun() {
+		var planned []string
+		for _, hook := range hooks {
+			hookPath := filepath.Join(hooksDir, hook.name)
+			m.dryRun.Record("write", hookPath, "")
+			planned = append(planned, hookPath)
+		}
+		return planned, nil
+	}
+
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	var installed []string
+	for _, hook := range hooks {
+		hookPath := filepath.Join(hooksDir, hook.name)
+		if err := os.WriteFile(hookPath, []byte(hookScript(guardPath, projectDir, hook)), 0755); err != nil {
+			return installed, fmt.Errorf("failed to write git hook %s: %w", hook.name, err)
+		}
+		// WriteFile keeps the mode of an existing file
+		if err := os.Chmod(hookPath, 0755); err != nil {
+			return installed, fmt.Errorf("failed to make git hook %s executable: %w", hook.name, err)
+		}
+		installed = append(installed, hookPath)
+	}
+	return installed, nil
+}
+
+// UninstallHooks removes the git hooks written by guard and returns their paths.
+func (m *Manager) UninstallHooks() ([]string, error) {
+	repo, err := m.gitRepo()
+	if err != nil {
+		return nil, err
+	}
+	hooksDir, err := repo.HooksDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, hook := range slices.Concat(branchProfileHooks, commitCheckHooks) {
+		hookPath := filepath.Join(hooksDir, hook.name)
+		if !isGuardHook(hookPath) {
+			continue
+		}
+		if err := m.removeFile(hookPath); err != nil {
+			return removed, fmt.Errorf("failed to remove git hook %s: %w", hook.name, err)
+		}
+		removed = append(removed, hookPath)
+	}
+	return removed, nil
+}
+
+// hookProjectDir returns the .guardfile directory relative to the working tree root,
+// where git runs hooks.
+func (m *Manager) hookProjectDir(root string) (string, error) {
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	if realBase, err := filepath.EvalSymlinks(baseDir); err == nil {
+		baseDir = realBase
+	}
+	rel, err := filepath.Rel(root, baseDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// hookScript writes the script of a hook that runs its commands in the project directory
+// with the guard executable at guardPath. The hook fails if guard cannot run, so a missing
+// guard never lets a commit through unchecked.
+func hookScript(guardPath, projectDir string, hook gitHook) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(hookMarker + ". Remove with 'guard hooks uninstall'.\n")
+	b.WriteString("guard=" + shellQuote(guardPath) + "\n")
+	b.WriteString("if [ ! -x \"$guard\" ]; then\n")
+	b.WriteString("\techo \"guard: $guard not found. Reinstall the hook with 'guard hooks install' or remove it with 'guard hooks uninstall'\" >&2\n")
+	b.WriteString("\texit 1\n")
+	b.WriteString("fi\n")
+	if hook.setup != "" {
+		b.WriteString(hook.setup + "\n")
+	}
+	if projectDir != "." {
+		b.WriteString("cd " + shellQuote(projectDir) + " || exit 1\n")
+	}
+	b.WriteString(hook.commands + "\n")
+	return b.String()
+}
+
+// shellQuote quotes s as a single word for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isGuardHook returns true if the hook at path was written by guard.
+func isGuardHook(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), hookMarker)
+}
+
+// isForeignHook returns true if a hook exists at path that guard did not write.
+func isForeignHook(path string) bool {
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	return !isGuardHook(path)
+}
@@ -0,0 +1,86 @@
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInstallHooks tests installing and uninstalling the git hooks.
+func TestInstallHooks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	runGit(t, tmpDir, "init", "-q")
+
+	// A stand-in for the guard executable that records its arguments
+	guardPath := filepath.Join(t.TempDir(), "guard")
+	argsPath := guardPath + ".args"
+	if err := os.WriteFile(guardPath, []byte("#!/bin/sh\necho \"$@\" > '"+argsPath+"'\n"), 0755); err != nil {
+		t.Fatalf("Failed to write guard stand-in: %v", err)
+	}
+
+	hookPath := filepath.Join(tmpDir, ".git", "hooks", "post-checkout")
+	if err := os.WriteFile(hookPath, []byte("#!/bin/sh\necho mine\n"), 0755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if _, err := mgr.InstallHooks(guardPath, false, false); err == nil {
+		t.Fatal("Expected an error when a foreign hook exists")
+	}
+
+	installed, err := mgr.InstallHooks(guardPath, false, true)
+	if err != nil || len(installed) != 2 {
+		t.Fatalf("InstallHooks failed: %v (%v)", err, installed)
+	}
+	data, err := os.ReadFile(hookPath)
+	if err != nil || !strings.Contains(string(data), "\"$guard\" branch-profile apply") {
+		t.Errorf("Expected the hook to apply the branch profile, got %q (%v)", data, err)
+	}
+	if info, _ := os.Stat(hookPath); info.Mode().Perm()&0100 == 0 {
+		t.Error("Expected the hook to be executable")
+	}
+	// Installing again updates guard's own hooks
+	if _, err := mgr.InstallHooks(guardPath, false, false); err != nil {
+		t.Errorf("InstallHooks failed to update its hooks: %v", err)
+	}
+
+	// The commit check is installed on its own
+	installed, err = mgr.InstallHooks(guardPath, true, false)
+	if err != nil || len(installed) != 1 || filepath.Base(installed[0]) != "commit-msg" {
+		t.Fatalf("InstallHooks failed for the commit check: %v (%v)", err, installed)
+	}
+	hook := exec.Command(installed[0], "COMMIT_EDITMSG")
+	hook.Dir = tmpDir
+	if output, err := hook.CombinedOutput(); err != nil {
+		t.Fatalf("commit-msg hook failed: %v (%s)", err, output)
+	}
+	if args, err := os.ReadFile(argsPath); err != nil || !strings.Contains(string(args), "check-staged --message-file "+filepath.Join(tmpDir, "COMMIT_EDITMSG")) {
+		t.Errorf("Expected the hook to run guard check-staged, got %q (%v)", args, err)
+	}
+
+	// Without the guard executable the hook fails instead of letting the commit through
+	if err := os.Remove(guardPath); err != nil {
+		t.Fatalf("Failed to remove guard stand-in: %v", err)
+	}
+	hook = exec.Command(installed[0], "COMMIT_EDITMSG")
+	hook.Dir = tmpDir
+	if output, err := hook.CombinedOutput(); err == nil || !strings.Contains(string(output), "not found") {
+		t.Errorf("Expected the commit-msg hook to fail without guard, got %v (%s)", err, output)
+	}
+
+	removed, err := mgr.UninstallHooks()
+	if err != nil || len(removed) != 3 {
+		t.Fatalf("UninstallHooks failed: %v (%v)", err, removed)
+	}
+	if _, err := os.Stat(hookPath); !os.IsNotExist(err) {
+		t.Error("Expected the hook to be removed")
+	}
+}
@@ -0,0 +1,496 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+	"gopkg.in/yaml.v3"
+)
+
+const journalFileName = "journal.yaml"
+
+// permChange is one step of an operation's filesystem phase.
+// guard=true applies the guard permissions and sets the immutable flag,
+// guard=false clears the immutable flag and restores the given permissions.
+type permChange struct {
+	path  string
+	guard bool
+	mode  os.FileMode
+	owner string
+	group string
+}
+
+// JournalEntry records the intended and the previous on-disk state of one file.
+type JournalEntry struct {
+	Path          string `yaml:"path"`
+	Guard         bool   `yaml:"guard"`
+	Mode          string `yaml:"mode"`
+	Owner         string `yaml:"owner"`
+	Group         string `yaml:"group"`
+	PrevMode      string `yaml:"prev_mode"`
+	PrevOwner     string `yaml:"prev_owner"`
+	PrevGroup     string `yaml:"prev_group"`
+	PrevImmutable bool   `yaml:"prev_immutable"`
+	PrevGuard     bool   `yaml:"prev_guard"`
+}
+
+// Journal is the write-ahead intent log of an operation's filesystem phase.
+// It is written before any permission is changed and removed once all changes were attempted.
+// A journal left on disk means the operation was interrupted.
+type Journal struct {
+	Operation string         `yaml:"operation"`
+	Started   time.Time      `yaml:"started"`
+	Entries   []JournalEntry `yaml:"entries"`
+}
+
+// JournalEntryStatus describes how far an interrupted change got on disk
+type JournalEntryStatus string
+
+const (
+	JournalEntryDone    JournalEntryStatus = "done"
+	JournalEntryPending JournalEntryStatus = "pending"
+	JournalEntryPartial JournalEntryStatus = "partial"
+	JournalEntryMissing JournalEntryStatus = "missing"
+)
+
+// guardPermChange returns a change that applies the default guard permissions to path.
+func (m *Manager) guardPermChange(path string) permChange {
+	return permChange{
+		path:  path,
+		guard: true,
+		mode:  m.security.GetDefaultFileMode(),
+		owner: m.security.GetDefaultFileOwner(),
+		group: m.security.GetDefaultFileGroup(),
+	}
+}
+
+// unguardPermChange returns a change that restores the given original permissions of path.
+func unguardPermChange(path string, mode os.FileMode, owner, group string) permChange {
+	return permChange{path: path, guard: false, mode: mode, owner: owner, group: group}
+}
+
+// FileResult is the outcome of the permission change of one file.
+// The modes, owners and groups are those after the change, or after the failed attempt.
+type FileResult struct {
+	Operation string `json:"operation"`
+	OK        bool   `json:"ok"`
+	AuditFile
+}
+
+// applyPermChanges runs the filesystem phase of an operation.
+// The intent journal is written before the first change and kept until SaveRegistry
+// records the outcome (see settleJournal), so a run interrupted before the registry
+// matches the files can be finished or rolled back by 'guard recover'.
+// Guarding a file records its content baseline, unguarding clears it.
+// Returns the set of paths whose change succeeded; failures are recorded as errors.
+// Every change is reported by GetFileResults.
+// In atomic mode the first failure reverts every change of the run and nothing succeeds.
+func (m *Manager) applyPermChanges(operation string, changes []permChange) map[string]bool {
+	succeeded := make(map[string]bool, len(changes))
+	if len(changes) == 0 || m.atomicAborted {
+		return succeeded
+	}
+
+	baselines := m.contentBaselines(changes)
+	entries := m.journalEntries(changes)
+	journaled := true
+	if err := m.writeJournal(operation, entries); err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to write intent journal: %v", err)))
+		journaled = false
+	}
+
+	for i, change := range changes {
+		errorsBefore := len(m.errors)
+		ok := m.applyPermChange(change)
+		if m.atomic && len(m.errors) > errorsBefore {
+			// Any error counts, including a failed immutable flag on an otherwise applied change.
+			// The failing file is reverted too, a failed chown may follow a successful chmod.
+			m.atomicApplied = append(m.atomicApplied, entries[i])
+			m.abortAtomic()
+			succeeded = make(map[string]bool)
+			break
+		}
+		if ok {
+			succeeded[change.path] = true
+			if m.atomic {
+				m.atomicApplied = append(m.atomicApplied, entries[i])
+			}
+		}
+	}
+
+	// A reverted atomic run leaves the files as the registry has them
+	if journaled && m.atomicAborted {
+		m.settleJournal()
+	}
+	m.invalidateStatusIndex()
+	m.setContentBaselines(baselines, changes, succeeded)
+	m.auditPermChanges(operation, entries, changes, succeeded)
+	for i, change := range changes {
+		m.fileResults = append(m.fileResults, FileResult{
+			Operation: operation,
+			OK:        succeeded[change.path],
+			AuditFile: m.auditFile(entries[i], change.guard, change.path),
+		})
+	}
+	return succeeded
+}
+
+// setGuardFlags sets the registry guard flag of every successfully applied change.
+func (m *Manager) setGuardFlags(succeeded map[string]bool, changes []permChange, guard bool) {
+	for _, change := range changes {
+		if !succeeded[change.path] {
+			continue
+		}
+		if err := m.security.SetRegisteredFileGuard(change.path, guard); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", change.path, err))
+		}
+	}
+}
+
+// applyPermChange applies a single change and reports whether it succeeded.
+func (m *Manager) applyPermChange(change permChange) bool {
+	if change.guard {
+		// A file that is still immutable from an earlier guard cannot be chmod'ed
+		if immutable, err := m.fs.IsImmutable(change.path); err == nil && immutable {
+			if err := m.clearImmutable(change.path); err != nil {
+				m.addFileError(change.path, fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", change.path, err), err)
+				return false
+			}
+		}
+
+		// Enabling guard: apply guard permissions, then set immutable
+		if err := m.fs.ApplyPermissions(change.path, change.mode, change.owner, change.group); err != nil {
+			m.addFileError(change.path, fmt.Sprintf("Error: Failed to apply guard permissions to %s: %v", change.path, err), err)
+			return false
+		}
+
+		// Set immutable flag (skipped with a warning if not root)
+		if err := m.setImmutable(change.path); err != nil {
+			m.addFileError(change.path, fmt.Sprintf("Error: Failed to set immutable flag for %s: %v", change.path, err), err)
+		}
+		return true
+	}
+
+	// Disabling guard: clear immutable first (must be done before chmod), then restore permissions
+	if err := m.clearImmutable(change.path); err != nil {
+		m.addFileError(change.path, fmt.Sprintf("Error: Failed to clear immutable flag for %s: %v", change.path, err), err)
+		return false
+	}
+	if err := m.fs.RestorePermissions(change.path, change.mode, change.owner, change.group); err != nil {
+		m.addFileError(change.path, fmt.Sprintf("Error: Failed to restore permissions for %s: %v", change.path, err), err)
+		return false
+	}
+	return true
+}
+
+// setImmutable sets the immutable flag of a managed file. Without root privileges the
+// flag is skipped with a warning; the guard permissions still protect the file.
+func (m *Manager) setImmutable(path string) error {
+	err := m.fs.SetImmutable(path)
+	if errors.Is(err, filesystem.ErrNeedsRoot) {
+		m.AddWarning(NewWarning(WarningImmutableSkipped, "", m.toDisplayPaths([]string{path})...))
+		return nil
+	}
+	return err
+}
+
+// clearImmutable clears the immutable flag of a managed file. Without root privileges
+// only a file that has the flag is an error; there is nothing to clear otherwise.
+func (m *Manager) clearImmutable(path string) error {
+	err := m.fs.ClearImmutable(path)
+	if errors.Is(err, filesystem.ErrNeedsRoot) {
+		if immutable, checkErr := m.fs.IsImmutable(path); checkErr == nil && !immutable {
+			return nil
+		}
+	}
+	return err
+}
+
+func (m *Manager) journalPath() string {
+	return filepath.Join(m.StateDir(), journalFileName)
+}
+
+// journalEntries records the intended changes together with the current on-disk state of each file.
+func (m *Manager) journalEntries(changes []permChange) []JournalEntry {
+	entries := make([]JournalEntry, 0, len(changes))
+	for _, change := range changes {
+		entry := JournalEntry{
+			Path:  m.journalRelPath(change.path),
+			Guard: change.guard,
+			Mode:  formatJournalMode(change.mode),
+			Owner: change.owner,
+			Group: change.group,
+		}
+		if mode, owner, group, err := m.fs.GetFileInfo(change.path); err == nil {
+			entry.PrevMode = formatJournalMode(mode)
+			entry.PrevOwner = owner
+			entry.PrevGroup = group
+			entry.PrevGuard = m.hasGuardPermissions(mode, owner, group)
+		}
+		if immutable, err := m.fs.IsImmutable(change.path); err == nil {
+			entry.PrevImmutable = immutable
+			entry.PrevGuard = entry.PrevGuard || immutable
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// writeJournal persists the intent journal of an operation.
+// The entries of earlier filesystem phases whose outcome is not saved yet are kept.
+func (m *Manager) writeJournal(operation string, entries []JournalEntry) error {
+	if m.journal == nil {
+		m.journal = &Journal{
+			Operation: operation,
+			Started:   time.Now().UTC(),
+		}
+	}
+	m.journal.Entries = append(m.journal.Entries, entries...)
+
+	data, err := yaml.Marshal(m.journal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	if _, err := m.ensureStateDir(""); err != nil {
+		return err
+	}
+	return m.writeStateFile(m.journalPath(), data)
+}
+
+// hasGuardPermissions reports whether the given on-disk state equals the configured guard permissions.
+func (m *Manager) hasGuardPermissions(mode os.FileMode, owner, group string) bool {
+	guardOwner := m.security.GetDefaultFileOwner()
+	guardGroup := m.security.GetDefaultFileGroup()
+	return mode.Perm() == m.security.GetDefaultFileMode().Perm() &&
+		(guardOwner == "" || owner == guardOwner) &&
+		(guardGroup == "" || group == guardGroup)
+}
+
+// settleJournal removes the intent journal of the filesystem phases once the registry
+// records their outcome, e.g. after SaveRegistry.
+func (m *Manager) settleJournal() {
+	if m.journal == nil {
+		return
+	}
+	m.journal = nil
+	if err := m.removeJournal(); err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to remove intent journal: %v", err)))
+	}
+}
+
+func (m *Manager) removeJournal() error {
+	if err := os.Remove(m.journalPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ReadJournal returns the journal of an interrupted operation, or nil if there is none.
+func (m *Manager) ReadJournal() (*Journal, error) {
+	data, err := os.ReadFile(m.journalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read intent journal: %w", err)
+	}
+
+	var journal Journal
+	if err := yaml.Unmarshal(data, &journal); err != nil {
+		return nil, newError(ErrorCorrupted, []string{m.journalPath()}, "intent journal is corrupted: %w", err)
+	}
+	return &journal, nil
+}
+
+// checkPendingJournal returns an ErrorPendingJournal listing the journaled files if an
+// interrupted operation still has to be recovered.
+func (m *Manager) checkPendingJournal() error {
+	journal, err := m.ReadJournal()
+	if err != nil {
+		return err
+	}
+	if journal == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(journal.Entries))
+	for _, entry := range journal.Entries {
+		paths = append(paths, entry.Path)
+	}
+	return newError(ErrorPendingJournal, paths, "an interrupted '%s' operation from %s left %d file(s) in an unknown state. Run 'guard recover' to finish it or 'guard recover --rollback' to undo it",
+		journal.Operation, journal.Started.Local().Format("2006-01-02 15:04:05"), len(journal.Entries))
+}
+
+// journalRelPath stores paths relative to the .guardfile directory, like the registry does.
+func (m *Manager) journalRelPath(path string) string {
+	if m.security == nil {
+		return path
+	}
+	return m.security.ToDisplayPath(path)
+}
+
+// journalAbsPath resolves a journaled path against the .guardfile directory.
+func (m *Manager) journalAbsPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(m.registryPath), path)
+}
+
+func formatJournalMode(mode os.FileMode) string {
+	return fmt.Sprintf("%04o", mode.Perm())
+}
+
+func parseJournalMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q in intent journal", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// JournalEntryState inspects the file on disk and reports whether the journaled change
+// was applied (done), not yet started (pending) or only partially applied.
+func (m *Manager) JournalEntryState(entry JournalEntry) JournalEntryStatus {
+	path := m.journalAbsPath(entry.Path)
+	mode, owner, group, err := m.fs.GetFileInfo(path)
+	if err != nil {
+		return JournalEntryMissing
+	}
+	current := formatJournalMode(mode)
+
+	matchesTarget := current == entry.Mode &&
+		(entry.Owner == "" || owner == entry.Owner) &&
+		(entry.Group == "" || group == entry.Group)
+	if immutable, err := m.fs.IsImmutable(path); err == nil && m.fs.HasRootPrivileges() {
+		matchesTarget = matchesTarget && immutable == entry.Guard
+	}
+	if matchesTarget {
+		return JournalEntryDone
+	}
+
+	matchesPrevious := current == entry.PrevMode && owner == entry.PrevOwner && group == entry.PrevGroup
+	if matchesPrevious {
+		return JournalEntryPending
+	}
+	return JournalEntryPartial
+}
+
+// RecoverResult summarizes what Recover did per file
+type RecoverResult struct {
+	Finished   []string `json:"finished"`
+	RolledBack []string `json:"rolled_back"`
+}
+
+// Recover completes or reverts an interrupted operation file by file.
+// Files listed in rollbackPaths (or all files if rollbackAll is set) are returned to the
+// state they had before the operation; all other files are brought to the intended state.
+// The registry guard flags are updated to match and the journal is removed.
+func (m *Manager) Recover(rollbackAll bool, rollbackPaths []string) (*RecoverResult, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	journal, err := m.ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+	if journal == nil {
+		return nil, fmt.Errorf("no interrupted operation found")
+	}
+
+	rollbackSet := make(map[string]bool, len(rollbackPaths))
+	for _, path := range rollbackPaths {
+		rollbackSet[m.journalRelPath(path)] = true
+	}
+
+	result := &RecoverResult{}
+	var missingFiles []string
+	var audited []AuditFile
+	for _, entry := range journal.Entries {
+		path := m.journalAbsPath(entry.Path)
+		if !m.fs.FileExists(path) {
+			missingFiles = append(missingFiles, entry.Path)
+			continue
+		}
+		// The journal is data from disk: apply the same checks as for registry paths
+		if err := m.security.ValidatePaths([]string{path}); err != nil {
+			m.AddError(fmt.Sprintf("Error: Refusing to recover %s: %v", entry.Path, err))
+			continue
+		}
+
+		rollback := rollbackAll || rollbackSet[entry.Path]
+		status := m.JournalEntryState(entry)
+		alreadyThere := (!rollback && status == JournalEntryDone) || (rollback && status == JournalEntryPending)
+
+		change, guard, err := recoveryChange(path, entry, rollback)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to recover %s: %v", entry.Path, err))
+			continue
+		}
+		if !alreadyThere {
+			before := m.journalEntries([]permChange{change})[0]
+			if !m.applyPermChange(change) {
+				continue
+			}
+			audited = append(audited, m.auditFile(before, guard, path))
+		}
+
+		if m.security.IsRegisteredFile(path) {
+			if err := m.security.SetRegisteredFileGuard(path, guard); err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to set guard flag for %s: %v", entry.Path, err))
+				continue
+			}
+		}
+
+		if rollback {
+			result.RolledBack = append(result.RolledBack, entry.Path)
+		} else {
+			result.Finished = append(result.Finished, entry.Path)
+		}
+	}
+
+	if len(missingFiles) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", missingFiles...))
+	}
+	if len(audited) > 0 {
+		m.writeAudit(AuditEntry{Operation: "recover", Files: audited})
+	}
+
+	if err := m.SaveRegistry(); err != nil {
+		return nil, fmt.Errorf("failed to save registry: %w", err)
+	}
+
+	// Keep the journal if anything failed so recovery can be retried
+	if !m.HasErrors() {
+		if err := m.removeJournal(); err != nil {
+			return nil, fmt.Errorf("failed to remove intent journal: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// recoveryChange returns the change that finishes or rolls back a journal entry,
+// together with the guard flag the registry should record afterwards.
+func recoveryChange(path string, entry JournalEntry, rollback bool) (permChange, bool, error) {
+	if !rollback {
+		mode, err := parseJournalMode(entry.Mode)
+		if err != nil {
+			return permChange{}, false, err
+		}
+		return permChange{path: path, guard: entry.Guard, mode: mode, owner: entry.Owner, group: entry.Group}, entry.Guard, nil
+	}
+
+	if entry.PrevMode == "" {
+		return permChange{}, false, fmt.Errorf("previous state was not recorded")
+	}
+	mode, err := parseJournalMode(entry.PrevMode)
+	if err != nil {
+		return permChange{}, false, err
+	}
+	return permChange{path: path, guard: entry.PrevGuard, mode: mode, owner: entry.PrevOwner, group: entry.PrevGroup}, entry.PrevGuard, nil
+}
@@ -0,0 +1,229 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestJournal leaves an intent journal behind as an interrupted operation would.
+func writeTestJournal(t *testing.T, tmpDir string, content string) {
+	stateDir := filepath.Join(tmpDir, StateDirName)
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		t.Fatalf("Failed to create state dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, journalFileName), []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+}
+
+// TestJournalRemovedAfterOperation tests that a completed operation leaves no journal behind.
+func TestJournalRemovedAfterOperation(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.ToggleFiles([]string{file1}); err != nil {
+		t.Fatalf("ToggleFiles failed: %v", err)
+	}
+
+	journal, err := mgr.ReadJournal()
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	if journal != nil {
+		t.Errorf("Expected no journal after a completed operation, got %+v", journal)
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.ToggleFiles([]string{file1}); err != nil {
+		t.Fatalf("ToggleFiles failed: %v", err)
+	}
+}
+
+// TestJournalKeptUntilSave tests that the journal of a filesystem phase whose registry
+// changes follow it is only removed once the registry is saved.
+func TestJournalKeptUntilSave(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	file2 := createTestFile(t, tmpDir, "file2.txt", 0644)
+
+	// Two phases before the save: the journal covers both
+	mgr.applyPermChanges("enable", []permChange{mgr.guardPermChange(file1)})
+	mgr.applyPermChanges("enable", []permChange{mgr.guardPermChange(file2)})
+	journal, err := mgr.ReadJournal()
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	if journal == nil || len(journal.Entries) != 2 {
+		t.Fatalf("Expected a journal with 2 entries before the save, got %+v", journal)
+	}
+
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	if journal, _ := mgr.ReadJournal(); journal != nil {
+		t.Errorf("Expected no journal after the save, got %+v", journal)
+	}
+
+	// Clean up guard so the temp dir can be removed
+	mgr.applyPermChanges("disable", []permChange{
+		unguardPermChange(file1, 0644, "", ""),
+		unguardPermChange(file2, 0644, "", ""),
+	})
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+}
+
+// TestLoadRegistryDetectsPendingJournal tests that commands refuse to run on top of an interrupted operation.
+func TestLoadRegistryDetectsPendingJournal(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	writeTestJournal(t, tmpDir, "operation: enable\nentries: []\n")
+
+	err := mgr.LoadRegistry()
+	if err == nil || !strings.Contains(err.Error(), "guard recover") {
+		t.Fatalf("Expected pending journal error, got %v", err)
+	}
+	if KindOf(err) != ErrorPendingJournal {
+		t.Errorf("Expected a pending-journal error, got %s", KindOf(err))
+	}
+
+	if err := mgr.LoadRegistryForRecovery(); err != nil {
+		t.Fatalf("LoadRegistryForRecovery failed: %v", err)
+	}
+}
+
+// TestLoadRegistryReadOnly tests that the read-only load ignores a pending journal
+// and reports a missing .guardfile like LoadRegistry.
+func TestLoadRegistryReadOnly(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	reader := NewManager(mgr.registryPath, mgr.fs)
+	if err := reader.LoadRegistryReadOnly(); KindOf(err) != ErrorNotInitialized {
+		t.Fatalf("Expected a not-initialized error, got %v", err)
+	}
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	if err := mgr.AddFilesToCollections([]string{createTestFile(t, tmpDir, "file1.txt", 0644)}, []string{"docs"}); err != nil {
+		t.Fatalf("AddFilesToCollections failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	writeTestJournal(t, tmpDir, "operation: enable\nentries: []\n")
+
+	if err := reader.LoadRegistryReadOnly(); err != nil {
+		t.Fatalf("LoadRegistryReadOnly failed: %v", err)
+	}
+	if !reader.GetRegistry().IsRegisteredCollection("docs") {
+		t.Error("Expected collection docs in the registry")
+	}
+	if journal, err := reader.ReadJournal(); err != nil || journal == nil {
+		t.Errorf("Expected the journal to be left alone, got %v (%v)", journal, err)
+	}
+}
+
+// TestRecoverRollback tests that an interrupted disable is rolled back per file.
+func TestRecoverRollback(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0640)
+	file2 := createTestFile(t, tmpDir, "file2.txt", 0640)
+	if err := mgr.AddFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+
+	// Simulate an enable that was interrupted after file1 got its guard permissions
+	if err := os.Chmod(file1, 0600); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	_, owner, group, err := mgr.GetFileSystem().GetFileInfo(file1)
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	writeTestJournal(t, tmpDir, `operation: enable
+entries:
+  - path: file1.txt
+    guard: true
+    mode: "0600"
+    prev_mode: "0640"
+    prev_owner: `+owner+`
+    prev_group: `+group+`
+  - path: file2.txt
+    guard: true
+    mode: "0600"
+    prev_mode: "0640"
+    prev_owner: `+owner+`
+    prev_group: `+group+`
+`)
+
+	if err := mgr.LoadRegistryForRecovery(); err != nil {
+		t.Fatalf("LoadRegistryForRecovery failed: %v", err)
+	}
+
+	journal, err := mgr.ReadJournal()
+	if err != nil || journal == nil {
+		t.Fatalf("Expected pending journal, got %v, %v", journal, err)
+	}
+	if status := mgr.JournalEntryState(journal.Entries[1]); status != JournalEntryPending {
+		t.Errorf("Expected file2 to be pending, got %s", status)
+	}
+
+	// Roll back file1 only, finish file2
+	result, err := mgr.Recover(false, []string{file1})
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if len(result.RolledBack) != 1 || len(result.Finished) != 1 {
+		t.Fatalf("Expected 1 rolled back and 1 finished file, got %+v", result)
+	}
+
+	info, err := os.Stat(file1)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected file1 rolled back to 0640, got %o", info.Mode().Perm())
+	}
+
+	guard, err := mgr.GetRegistry().GetRegisteredFileGuard(file2)
+	if err != nil {
+		t.Fatalf("GetRegisteredFileGuard failed: %v", err)
+	}
+	if !guard {
+		t.Error("Expected file2 to be guarded after finishing")
+	}
+
+	if journal, _ := mgr.ReadJournal(); journal != nil {
+		t.Error("Expected journal to be removed after recovery")
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.DisableFiles([]string{file2}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+}
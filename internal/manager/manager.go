@@ -1,9 +1,12 @@
 package manager
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/florianbuetow/guard/internal/filesystem"
+	"github.com/florianbuetow/guard/internal/registry"
 	"github.com/florianbuetow/guard/internal/security"
 )
 
@@ -12,19 +15,52 @@ import (
 type Manager struct {
 	registryPath string
 	security     *security.Security
-	fs           *filesystem.FileSystem
+	fs           filesystem.FileSystem
 	warnings     []Warning
 	errors       []string
+	fileResults  []FileResult
+
+	// Items of failed permission changes (see Failure)
+	failedItems  []string
+	rootFailures []string
+
+	backupGenerations int
+	snapshotLimit     int64
+
+	// All-or-nothing mode (see SetAtomic)
+	atomic         bool
+	atomicAborted  bool
+	atomicRegistry []byte
+	atomicApplied  []JournalEntry
+
+	// Intent journal of the filesystem phases not saved yet (see applyPermChanges)
+	journal *Journal
+
+	// Operation history (see history.go)
+	historySnapshot *registry.RegistryData
+	historyOpen     bool
+	historyPaused   bool
+
+	// Time-limited guard changes (see SetExpiry)
+	expiryUntil time.Time
+
+	// Dry run (see SetDryRun): fs wraps the real filesystem and records changes
+	dryRun *filesystem.DryRunFileSystem
 }
 
 // NewManager creates a new Manager instance with the specified registry path.
+// fs is filesystem.NewFileSystem() for the real files, or a filesystem.MemFileSystem
+// to simulate guard without root.
 // The registry is NOT loaded automatically - call LoadRegistry() explicitly.
-func NewManager(registryPath string) *Manager {
+func NewManager(registryPath string, fs filesystem.FileSystem) *Manager {
 	return &Manager{
 		registryPath: registryPath,
-		fs:           filesystem.NewFileSystem(),
+		fs:           fs,
 		warnings:     make([]Warning, 0),
 		errors:       make([]string, 0),
+
+		backupGenerations: DefaultBackupGenerations,
+		snapshotLimit:     DefaultSnapshotLimit,
 	}
 }
 
@@ -34,33 +70,95 @@ func (m *Manager) LoadRegistry() error {
 	sec, err := security.LoadSecurity(m.registryPath)
 	if err != nil {
 		// Check if file doesn't exist (specific error message per Requirement 11.7)
-		if !m.fs.FileExists(m.registryPath) {
-			return fmt.Errorf(".guardfile not found in current directory. Run 'guard init <mode> <owner> <group>' to initialize")
+		if !stateFileExists(m.registryPath) {
+			return newError(ErrorNotInitialized, nil, ".guardfile not found in current directory. Run 'guard init <mode> <owner> <group>' to initialize")
 		}
 		// Otherwise it's corrupted (Requirement 11.8)
-		return fmt.Errorf(".guardfile is corrupted: %w. Suggested recovery: restore from backup or run 'guard init' to reinitialize", err)
+		kind := ErrorCorrupted
+		if errors.Is(err, security.ErrTampering) {
+			kind = ErrorTampering
+		}
+		return newError(kind, []string{m.registryPath}, ".guardfile is corrupted: %w. Suggested recovery: restore from backup or run 'guard init' to reinitialize", err)
 	}
 
+	m.security = sec
+	m.snapshotHistoryState()
+
+	// Refuse to work on top of an interrupted operation (see 'guard recover')
+	if err := m.checkPendingJournal(); err != nil {
+		return err
+	}
+
+	// Revert time-limited guard changes that expired while nothing was running
+	m.ExpireGuards()
+	return nil
+}
+
+// LoadRegistryForRecovery loads the registry even if an interrupted operation is pending.
+// Only 'guard recover' should use this.
+func (m *Manager) LoadRegistryForRecovery() error {
+	if err := m.LoadRegistry(); err != nil && m.security == nil {
+		return err
+	}
+	return nil
+}
+
+// LoadRegistryReadOnly loads the registry without checking for an interrupted operation
+// or reverting expired guard changes, so nothing on disk changes.
+// For callers that only look up names, such as shell completion.
+func (m *Manager) LoadRegistryReadOnly() error {
+	sec, err := security.LoadSecurity(m.registryPath)
+	if err != nil {
+		if !stateFileExists(m.registryPath) {
+			return newError(ErrorNotInitialized, nil, ".guardfile not found in current directory. Run 'guard init <mode> <owner> <group>' to initialize")
+		}
+		return newError(ErrorCorrupted, []string{m.registryPath}, ".guardfile is corrupted: %w", err)
+	}
 	m.security = sec
 	return nil
 }
 
 // SaveRegistry saves the registry to disk.
+// The previous .guardfile is kept as a rolling backup generation first.
 // If the .guardfile has an immutable flag set, it will be cleared before writing.
 func (m *Manager) SaveRegistry() error {
 	if m.security == nil {
-		return fmt.Errorf("registry not loaded")
+		return errRegistryNotLoaded
+	}
+	// A reverted atomic run leaves the .guardfile as it was
+	if m.atomicAborted {
+		m.settleJournal()
+		return nil
+	}
+	m.updateExpiries()
+	if m.DryRun() {
+		m.settleJournal()
+		return nil
+	}
+
+	// A failed backup must not block the save, but the user should know about it
+	pending, err := m.security.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := m.backupRegistry(pending); err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to back up .guardfile: %v", err)))
 	}
 	if err := m.clearGuardfileImmutableFlag(); err != nil {
 		return err
 	}
-	return m.security.Save()
+	if err := m.security.Save(); err != nil {
+		return err
+	}
+	m.settleJournal()
+	m.recordHistory(pending)
+	return nil
 }
 
 // clearGuardfileImmutableFlag removes the immutable flag from .guardfile if set.
 // This must be called before any write operation to .guardfile.
 func (m *Manager) clearGuardfileImmutableFlag() error {
-	if !m.fs.FileExists(m.registryPath) {
+	if !stateFileExists(m.registryPath) {
 		return nil // File doesn't exist yet, nothing to clear
 	}
 
@@ -100,11 +198,14 @@ func (m *Manager) InitializeRegistry(mode, owner, group string, overwrite bool)
 	}
 
 	// Save immediately
-	if err := sec.Save(); err != nil {
-		return fmt.Errorf("failed to save new registry: %w", err)
+	if !m.DryRun() {
+		if err := sec.Save(); err != nil {
+			return fmt.Errorf("failed to save new registry: %w", err)
+		}
 	}
 
 	m.security = sec
+	m.snapshotHistoryState()
 	return nil
 }
 
@@ -114,7 +215,7 @@ func (m *Manager) GetRegistry() *security.Security {
 }
 
 // GetFileSystem returns the underlying filesystem (for testing).
-func (m *Manager) GetFileSystem() *filesystem.FileSystem {
+func (m *Manager) GetFileSystem() filesystem.FileSystem {
 	return m.fs
 }
 
@@ -129,7 +230,7 @@ func (m *Manager) IsRegisteredFile(path string) bool {
 // CountFilesInCollection returns the number of files in a collection.
 func (m *Manager) CountFilesInCollection(collectionName string) (int, error) {
 	if m.security == nil {
-		return 0, fmt.Errorf("registry not loaded")
+		return 0, errRegistryNotLoaded
 	}
 	return m.security.CountFilesInCollection(collectionName)
 }
@@ -154,6 +255,11 @@ func (m *Manager) GetErrors() []string {
 	return m.errors
 }
 
+// GetFileResults returns the outcome of every permission change, in order.
+func (m *Manager) GetFileResults() []FileResult {
+	return m.fileResults
+}
+
 // ClearWarnings clears all collected warnings.
 func (m *Manager) ClearWarnings() {
 	m.warnings = make([]Warning, 0)
@@ -171,6 +277,8 @@ func (m *Manager) toDisplayPaths(paths []string) []string {
 // ClearErrors clears all collected errors.
 func (m *Manager) ClearErrors() {
 	m.errors = make([]string, 0)
+	m.failedItems = nil
+	m.rootFailures = nil
 }
 
 // HasErrors returns true if any errors have been collected.
@@ -203,7 +311,7 @@ func (m *Manager) IsRegisteredCollection(name string) bool {
 // 6. None of the above → error
 func (m *Manager) ResolveArgument(arg string) (string, error) {
 	if m.security == nil {
-		return "", fmt.Errorf("registry not loaded")
+		return "", errRegistryNotLoaded
 	}
 
 	// Priority 1: Directory on disk
@@ -234,7 +342,7 @@ func (m *Manager) ResolveArgument(arg string) (string, error) {
 	}
 
 	// Priority 6: Not found
-	return "", fmt.Errorf("'%s' not found", arg)
+	return "", newError(ErrorNotFound, []string{arg}, "'%s' not found", arg)
 }
 
 // ResolveArguments categorizes a list of arguments into files, folders, and collections.
@@ -242,7 +350,7 @@ func (m *Manager) ResolveArgument(arg string) (string, error) {
 // Returns an error if any argument is not found.
 func (m *Manager) ResolveArguments(args []string) (files []string, folders []string, collections []string, err error) {
 	if m.security == nil {
-		return nil, nil, nil, fmt.Errorf("registry not loaded")
+		return nil, nil, nil, errRegistryNotLoaded
 	}
 
 	files = make([]string, 0)
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
 )
 
 // setupTestManager creates a temporary directory and Manager for testing.
@@ -18,10 +20,12 @@ func setupTestManager(t *testing.T) (*Manager, string, func()) {
 	registryPath := filepath.Join(tmpDir, ".guardfile")
 
 	// Create manager
-	mgr := NewManager(registryPath)
+	mgr := NewManager(registryPath, filesystem.NewFileSystem())
 
 	// Cleanup function
 	cleanup := func() {
+		// The audit log is made append-only when running as root
+		mgr.fs.ClearAppendOnly(mgr.AuditLogPath())
 		os.RemoveAll(tmpDir)
 	}
 
@@ -323,6 +327,37 @@ func TestWarningAggregationSilentDuplicateFiles(t *testing.T) {
 	}
 }
 
+// TestGroupWarnings tests that warnings are grouped by type for structured output.
+func TestGroupWarnings(t *testing.T) {
+	warnings := []Warning{
+		NewWarning(WarningFileMissing, "", "file1.txt"),
+		NewWarning(WarningGeneric, "first"),
+		NewWarning(WarningFileAlreadyInRegistry, "", "file2.txt"),
+		NewWarning(WarningFileMissing, "", "file3.txt"),
+		NewWarning(WarningGeneric, "second"),
+	}
+
+	grouped := GroupWarnings(warnings)
+
+	// Missing files are grouped, generic warnings stay separate, duplicates are silent
+	if len(grouped) != 3 {
+		t.Fatalf("Expected 3 grouped warnings, got %d: %v", len(grouped), grouped)
+	}
+	missing := grouped[0]
+	if missing.Type != WarningFileMissing || len(missing.Items) != 2 || missing.Items[1] != "file3.txt" {
+		t.Errorf("Expected both missing files in the first group, got %+v", missing)
+	}
+	if strings.HasPrefix(missing.Message, "Warning: ") || strings.Contains(missing.Message, "\n") {
+		t.Errorf("Expected a single line message without prefix, got %q", missing.Message)
+	}
+	if grouped[1].Message != "first" || grouped[2].Message != "second" {
+		t.Errorf("Expected the generic warnings in order, got %+v", grouped[1:])
+	}
+	if text, _ := missing.Type.MarshalText(); string(text) != "file-missing" {
+		t.Errorf("Expected type file-missing, got %s", text)
+	}
+}
+
 // TestAddFilesToCollections tests adding files to collections.
 func TestAddFilesToCollections(t *testing.T) {
 	mgr, tmpDir, cleanup := setupTestManager(t)
@@ -627,10 +662,13 @@ func TestUninstallVerification(t *testing.T) {
 	}
 
 	// Run uninstall
-	err = mgr.Destroy()
+	result, err := mgr.Destroy()
 	if err != nil {
 		t.Fatalf("Uninstall failed: %v", err)
 	}
+	if !result.Removed || result.Reset.FilesDisabled != 1 {
+		t.Errorf("Unexpected uninstall result: %+v", result)
+	}
 
 	// Verify .guardfile was deleted
 	if _, err := os.Stat(registryPath); !os.IsNotExist(err) {
@@ -946,3 +984,94 @@ func TestDestroyCollectionWithMissingFilesWarning(t *testing.T) {
 		t.Errorf("Expected warning about missing file with cleanup suggestion, got: %v", aggregated)
 	}
 }
+
+// TestMemFileSystem tests that the manager guards files of an in-memory filesystem,
+// including the immutable flag, without root.
+func TestMemFileSystem(t *testing.T) {
+	tmpDir := t.TempDir()
+	mem := filesystem.NewMemFileSystem()
+	mgr := NewManager(filepath.Join(tmpDir, ".guardfile"), mem)
+	if err := mgr.InitializeRegistry("0400", "root", "root", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	testFile := filepath.Join(tmpDir, "main.go")
+	if err := mem.AddFile(testFile, []byte("package main"), 0644, "alice", "staff"); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	if err := mgr.EnableFiles([]string{testFile}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	mode, owner, group, _ := mem.GetFileInfo(testFile)
+	if immutable, _ := mem.IsImmutable(testFile); mode != 0400 || owner != "root" || group != "root" || !immutable {
+		t.Errorf("Expected an immutable 0400 root:root file, got %o %s:%s immutable=%v", mode, owner, group, immutable)
+	}
+
+	if err := mgr.DisableFiles([]string{testFile}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+	mode, owner, group, _ = mem.GetFileInfo(testFile)
+	if immutable, _ := mem.IsImmutable(testFile); mode != 0644 || owner != "alice" || group != "staff" || immutable {
+		t.Errorf("Expected the original 0644 alice:staff, got %o %s:%s immutable=%v", mode, owner, group, immutable)
+	}
+	if mgr.HasErrors() {
+		t.Errorf("Unexpected errors: %v", mgr.GetErrors())
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("Expected main.go to exist only in memory")
+	}
+}
+
+// TestMemFileSystemContent tests that content baselines, snapshots and restores work on
+// files that only exist in memory, and that guarding without root skips the immutable
+// flag with a warning.
+func TestMemFileSystemContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	mem := filesystem.NewMemFileSystem()
+	mgr := NewManager(filepath.Join(tmpDir, ".guardfile"), mem)
+	if err := mgr.InitializeRegistry("0400", "root", "root", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	testFile := filepath.Join(tmpDir, "src", "main.go")
+	if err := mem.AddFile(testFile, []byte("package main"), 0644, "alice", "staff"); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	mem.SetRootPrivileges(false)
+	if err := mgr.EnableFiles([]string{testFile}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if mgr.HasErrors() {
+		t.Fatalf("Unexpected errors: %v", mgr.GetErrors())
+	}
+	if sum, size, err := mgr.GetRegistry().GetRegisteredFileContent(testFile); err != nil || sum == "" || size != int64(len("package main")) {
+		t.Errorf("Expected a content baseline, got %q %d (%v)", sum, size, err)
+	}
+	if snapshots, err := mgr.ListSnapshots(testFile); err != nil || len(snapshots) != 1 {
+		t.Fatalf("Expected 1 snapshot after guarding, got %v (%v)", snapshots, err)
+	}
+	skipped := false
+	for _, w := range mgr.GetWarnings() {
+		skipped = skipped || w.Type == WarningImmutableSkipped
+	}
+	if immutable, _ := mem.IsImmutable(testFile); immutable || !skipped {
+		t.Errorf("Expected the immutable flag to be skipped with a warning, got immutable=%v warnings=%v", immutable, mgr.GetWarnings())
+	}
+
+	// Overwrite the file behind guard's back and bring it back from the snapshot
+	if err := mem.AddFile(testFile, []byte("corrupted"), 0644, "alice", "staff"); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	if _, err := mgr.RestoreFiles([]string{testFile}, ""); err != nil || mgr.HasErrors() {
+		t.Fatalf("RestoreFiles failed: %v (errors: %v)", err, mgr.GetErrors())
+	}
+	if content, err := mem.ReadFile(testFile); err != nil || string(content) != "package main" {
+		t.Errorf("Expected the restored content, got %q (%v)", content, err)
+	}
+	if entries, _ := mem.ReadDir(filepath.Dir(testFile)); len(entries) != 1 {
+		t.Errorf("Expected no temporary file left behind, got %v", entries)
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("Expected main.go to exist only in memory")
+	}
+}
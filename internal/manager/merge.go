@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/florianbuetow/guard/internal/registry"
+)
+
+// MergeRegistryFiles merges the .guardfile revisions ours and theirs with their common base
+// and writes the result to ours, like a git merge driver called with %O %A %B.
+// An empty base means both sides added the .guardfile. Conflicting fields keep our value;
+// the conflicts are returned.
+func MergeRegistryFiles(basePath, oursPath, theirsPath string) ([]registry.MergeConflict, error) {
+	var base *registry.RegistryData
+	data, err := os.ReadFile(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base registry: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if base, err = registry.ParseRegistryData(data); err != nil {
+			return nil, fmt.Errorf("base registry: %w", err)
+		}
+	}
+	ours, err := registry.ReadRegistryData(oursPath)
+	if err != nil {
+		return nil, fmt.Errorf("our registry: %w", err)
+	}
+	theirs, err := registry.ReadRegistryData(theirsPath)
+	if err != nil {
+		return nil, fmt.Errorf("their registry: %w", err)
+	}
+
+	merged, conflicts := registry.MergeRegistryData(base, ours, theirs)
+	out, err := registry.MarshalRegistryData(merged)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(oursPath, out, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write merged registry: %w", err)
+	}
+	return conflicts, nil
+}
+
+// DiffRegistryFiles returns the changes from the .guardfile at oldPath to the one at newPath.
+func DiffRegistryFiles(oldPath, newPath string) ([]registry.DiffEntry, error) {
+	oldData, err := registry.ReadRegistryData(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", oldPath, err)
+	}
+	newData, err := registry.ReadRegistryData(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", newPath, err)
+	}
+	return registry.DiffRegistryData(oldData, newData), nil
+}
@@ -0,0 +1,166 @@
+package manager
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PatternSyncResult reports how syncing a pattern collection changed its members,
+// relative to the .guardfile.
+type PatternSyncResult struct {
+	Added   []string // files that started matching the patterns
+	Removed []string // members that no longer match
+}
+
+// syncPatternCollection makes the members of a pattern collection the project files its
+// patterns match. New matches are registered and, if the collection is guarded, guarded.
+// Members that no longer match leave the collection but stay registered as they are.
+// Collections without patterns are left alone.
+func (m *Manager) syncPatternCollection(name string) (*PatternSyncResult, error) {
+	patterns, _, err := m.security.GetRegisteredCollectionPatterns(name)
+	if err != nil {
+		return nil, err
+	}
+	result := &PatternSyncResult{}
+	if len(patterns) == 0 {
+		return result, nil
+	}
+
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	files, err := m.fs.CollectFilesRecursive(baseDir, focusSkipDirs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+	members, err := m.security.GetRegisteredCollectionFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make(map[string]bool)
+	var added []string
+	for _, file := range files {
+		if m.isRegistryPath(file) {
+			continue
+		}
+		rel, err := filepath.Rel(baseDir, file)
+		if err != nil || !matchCollectionPatterns(patterns, filepath.ToSlash(rel)) {
+			continue
+		}
+		matched[file] = true
+		if !slices.Contains(members, file) && m.registerFolderFile(file) {
+			added = append(added, file)
+		}
+	}
+	var removed []string
+	for _, member := range members {
+		if !matched[member] {
+			removed = append(removed, member)
+		}
+	}
+
+	if len(added) > 0 {
+		if err := m.security.AddRegisteredFilesToRegisteredCollections([]string{name}, added); err != nil {
+			return nil, err
+		}
+		if guard, err := m.security.GetRegisteredCollectionGuard(name); err == nil && guard {
+			var changes []permChange
+			for _, file := range added {
+				changes = append(changes, m.guardPermChange(file))
+			}
+			m.setGuardFlags(m.applyPermChanges("sync", changes), changes, true)
+		}
+	}
+	if len(removed) > 0 {
+		if err := m.security.RemoveRegisteredFilesFromRegisteredCollections([]string{name}, removed); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Added = m.toDisplayPaths(added)
+	result.Removed = m.toDisplayPaths(removed)
+	return result, nil
+}
+
+// syncPatternCollections syncs the pattern collections among names before they are
+// enabled or toggled, so files created since the last sync are included.
+func (m *Manager) syncPatternCollections(names []string) {
+	for _, name := range names {
+		if !m.security.IsRegisteredCollection(name) {
+			continue
+		}
+		if _, err := m.syncPatternCollection(name); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to sync collection %s with its patterns: %v", name, err))
+		}
+	}
+}
+
+// matchCollectionPatterns reports whether the slash-separated path rel matches the patterns
+// of a collection. As in .gitignore and CODEOWNERS, the last matching pattern decides and
+// a pattern starting with "!" excludes the paths it matches.
+func matchCollectionPatterns(patterns []string, rel string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		if matchCollectionPattern(strings.TrimPrefix(pattern, "!"), rel) {
+			matched = !negate
+		}
+	}
+	return matched
+}
+
+// matchCollectionPattern matches one pattern with CODEOWNERS semantics: a pattern
+// without a slash matches at any depth, a leading or inner slash anchors it to the
+// .guardfile directory, a trailing slash matches folders only and "**" matches any
+// number of folders. A pattern that matches a folder matches everything below it,
+// except that "docs/*" matches only the files directly in docs.
+func matchCollectionPattern(pattern, rel string) bool {
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return false
+	}
+	segments := strings.Split(trimmed, "/")
+	if !strings.HasPrefix(pattern, "/") && len(segments) == 1 {
+		segments = append([]string{"**"}, segments...)
+	}
+
+	folderOnly := strings.HasSuffix(pattern, "/")
+	directOnly := !folderOnly && len(segments) > 1 && segments[len(segments)-1] == "*"
+	return matchPatternSegments(segments, strings.Split(rel, "/"), func(rest []string) bool {
+		switch {
+		case folderOnly:
+			return len(rest) > 0
+		case directOnly:
+			return len(rest) == 0
+		}
+		return true
+	})
+}
+
+// matchPatternSegments matches pattern segments against the leading path segments;
+// accept decides whether the path segments left over after a full match are allowed.
+func matchPatternSegments(pattern, segments []string, accept func(rest []string) bool) bool {
+	if len(pattern) == 0 {
+		return accept(segments)
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchPatternSegments(pattern[1:], segments[i:], accept) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchPatternSegments(pattern[1:], segments[1:], accept)
+}
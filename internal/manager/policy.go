@@ -0,0 +1,357 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyFileName is the reviewed file holding the desired protections, next to the .guardfile
+const PolicyFileName = "guard.policy.yaml"
+
+// policySource marks collections whose patterns come from the policy file
+const policySource = PolicyFileName
+
+// builtinProfiles are available in every policy without being defined
+var builtinProfiles = map[string]PolicyProfile{
+	"guarded":   {Guard: true},
+	"unguarded": {Guard: false},
+}
+
+// Policy is the desired guard state of a project, committed as guard.policy.yaml.
+// Rules map file patterns to profiles; collections and folders name the profile they get.
+// Files, collections and folders the policy does not cover keep their guard state.
+type Policy struct {
+	Profiles    map[string]PolicyProfile `yaml:"profiles,omitempty"`
+	Rules       []PolicyRule             `yaml:"rules,omitempty"`
+	Collections []PolicyCollection       `yaml:"collections,omitempty"`
+	Folders     []PolicyFolder           `yaml:"folders,omitempty"`
+}
+
+// PolicyProfile is a named set of protections.
+type PolicyProfile struct {
+	Guard bool `yaml:"guard"`
+}
+
+// PolicyRule applies a profile to the files matching a pattern (CODEOWNERS syntax).
+// The last matching rule decides, and rules decide over collections and folders.
+type PolicyRule struct {
+	Pattern string `yaml:"pattern"`
+	Profile string `yaml:"profile"`
+}
+
+// PolicyCollection is a pattern collection with a profile.
+type PolicyCollection struct {
+	Name     string   `yaml:"name"`
+	Patterns []string `yaml:"patterns"`
+	Profile  string   `yaml:"profile"`
+}
+
+// PolicyFolder applies a profile to a folder and the files directly in it.
+type PolicyFolder struct {
+	Path    string `yaml:"path"`
+	Profile string `yaml:"profile"`
+}
+
+// SyncPlan lists what reconciling the registry and filesystem with the policy changes.
+// Paths are relative to the .guardfile.
+type SyncPlan struct {
+	RegisterFiles     []string      `json:"register_files"`     // files the policy guards that are not registered yet
+	CreateCollections []string      `json:"create_collections"` // policy collections missing from the registry
+	UpdateCollections []string      `json:"update_collections"` // collections whose patterns differ from the policy
+	RegisterFolders   []string      `json:"register_folders"`   // policy folders missing from the registry, by @name
+	Changes           []HistoryItem `json:"changes"`            // guard state changes of files, collections and folders
+	Repair            []FileDrift   `json:"repair"`             // files in the right guard state whose permissions drifted
+}
+
+// Empty returns true if the registry and filesystem already match the policy.
+func (p *SyncPlan) Empty() bool {
+	return len(p.RegisterFiles) == 0 && len(p.CreateCollections) == 0 && len(p.UpdateCollections) == 0 &&
+		len(p.RegisterFolders) == 0 && len(p.Changes) == 0 && len(p.Repair) == 0
+}
+
+// LoadPolicy reads and validates guard.policy.yaml next to the .guardfile.
+func (m *Manager) LoadPolicy() (*Policy, error) {
+	path := m.policyPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no %s found next to the .guardfile", PolicyFileName)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", PolicyFileName, err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", PolicyFileName, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", PolicyFileName, err)
+	}
+	return &policy, nil
+}
+
+// PlanSync returns what Sync would change to reconcile the project with the policy.
+func (m *Manager) PlanSync(policy *Policy) (*SyncPlan, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	plan := &SyncPlan{}
+	for _, c := range policy.Collections {
+		if !m.security.IsRegisteredCollection(c.Name) {
+			plan.CreateCollections = append(plan.CreateCollections, c.Name)
+			continue
+		}
+		patterns, source, err := m.security.GetRegisteredCollectionPatterns(c.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Equal(patterns, c.Patterns) || source != policySource {
+			plan.UpdateCollections = append(plan.UpdateCollections, c.Name)
+		}
+	}
+	for _, f := range policy.Folders {
+		if name := folderNameFromPath(normalizeFolderPath(f.Path)); !m.security.IsRegisteredFolder(name) {
+			plan.RegisterFolders = append(plan.RegisterFolders, name)
+		}
+	}
+
+	current, err := m.currentState("")
+	if err != nil {
+		return nil, err
+	}
+	desired, err := m.policyState(policy, current)
+	if err != nil {
+		return nil, err
+	}
+	for rel, guard := range desired.Files {
+		if _, registered := current.Files[rel]; !registered && guard {
+			plan.RegisterFiles = append(plan.RegisterFiles, rel)
+		}
+	}
+	sort.Strings(plan.RegisterFiles)
+	plan.Changes = guardStateChanges(current.registryData(), desired.registryData())
+
+	// Files keeping their guard state must also have the permissions it implies
+	changing := make(map[string]bool, len(plan.Changes))
+	for _, item := range plan.Changes {
+		if item.Type == HistoryItemFile {
+			changing[item.Name] = true
+		}
+	}
+	for _, path := range m.security.GetRegisteredFiles() {
+		rel := m.security.ToDisplayPath(path)
+		if changing[rel] || !m.fs.FileExists(path) {
+			continue
+		}
+		drift, err := m.CheckFileDrift(path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to check %s: %v", rel, err))
+			continue
+		}
+		if drift != nil {
+			plan.Repair = append(plan.Repair, *drift)
+		}
+	}
+	sort.Slice(plan.Repair, func(i, j int) bool { return plan.Repair[i].Path < plan.Repair[j].Path })
+	return plan, nil
+}
+
+// Sync reconciles the registry and filesystem with the policy: it registers what the policy
+// names, sets the patterns of policy collections and guards or unguards every file,
+// collection and folder the policy covers until they match. Returns the plan it carried out; the caller
+// saves the registry.
+func (m *Manager) Sync(policy *Policy) (*SyncPlan, error) {
+	plan, err := m.PlanSync(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := filepath.Dir(m.registryPath)
+	for _, f := range policy.Folders {
+		normalized := normalizeFolderPath(f.Path)
+		name := folderNameFromPath(normalized)
+		if !slices.Contains(plan.RegisterFolders, name) {
+			continue
+		}
+		if isDir, err := m.fs.IsDir(filepath.Join(baseDir, normalized)); err != nil || !isDir {
+			m.AddError(fmt.Sprintf("Error: Policy folder not found: %s", f.Path))
+			continue
+		}
+		if err := m.security.RegisterFolder(name, normalized); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to register folder %s: %v", f.Path, err))
+		}
+	}
+	for _, c := range policy.Collections {
+		if slices.Contains(plan.CreateCollections, c.Name) {
+			if err := m.security.RegisterCollection(c.Name, []string{}); err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to create collection %s: %v", c.Name, err))
+				continue
+			}
+		}
+		if err := m.security.SetRegisteredCollectionPatterns(c.Name, c.Patterns, policySource); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to set patterns of collection %s: %v", c.Name, err))
+			continue
+		}
+		if _, err := m.syncPatternCollection(c.Name); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to sync collection %s: %v", c.Name, err))
+		}
+	}
+	for _, rel := range plan.RegisterFiles {
+		m.registerFolderFile(filepath.Join(baseDir, rel))
+	}
+
+	// Syncing collection members may have guarded files already: plan the guard changes again
+	replanned, err := m.PlanSync(policy)
+	if err != nil {
+		return nil, err
+	}
+	m.applyHistoryItems("sync", replanned.Changes, false)
+	if _, err := m.RepairDrift(replanned.Repair); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// policyState returns the guard state the policy asks for. Files are those of the project
+// and those registered; files no rule, collection or folder of the policy covers, and
+// registered collections and folders the policy does not name, keep their guard state.
+func (m *Manager) policyState(policy *Policy, current *SavedState) (*SavedState, error) {
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	files, err := m.fs.CollectFilesRecursive(baseDir, focusSkipDirs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+
+	state := &SavedState{
+		Files:       make(map[string]bool, len(files)),
+		Collections: make(map[string]bool, len(current.Collections)),
+		Folders:     make(map[string]bool, len(current.Folders)),
+	}
+	for name, guard := range current.Collections {
+		state.Collections[name] = guard
+	}
+	for name, guard := range current.Folders {
+		state.Folders[name] = guard
+	}
+	// Registered files that no longer exist or that the policy does not cover keep their state
+	for rel, guard := range current.Files {
+		state.Files[rel] = guard
+	}
+
+	for _, c := range policy.Collections {
+		state.Collections[c.Name] = policy.profile(c.Profile).Guard
+	}
+	for _, f := range policy.Folders {
+		state.Folders[folderNameFromPath(normalizeFolderPath(f.Path))] = policy.profile(f.Profile).Guard
+	}
+
+	for _, file := range files {
+		if m.isRegistryPath(file) {
+			continue
+		}
+		rel, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if guard, covered := policy.fileGuard(rel); covered {
+			state.Files[rel] = guard
+		}
+	}
+	return state, nil
+}
+
+// fileGuard returns whether the policy guards the file at the slash-separated path rel,
+// and whether a rule, collection or folder of the policy covers it at all
+func (p *Policy) fileGuard(rel string) (guard, covered bool) {
+	for _, c := range p.Collections {
+		if matchCollectionPatterns(c.Patterns, rel) {
+			covered = true
+			guard = guard || p.profile(c.Profile).Guard
+		}
+	}
+	for _, f := range p.Folders {
+		folder := strings.TrimPrefix(normalizeFolderPath(f.Path), "./")
+		if path := filepath.ToSlash(filepath.Dir(rel)); path == folder {
+			covered = true
+			guard = guard || p.profile(f.Profile).Guard
+		}
+	}
+	for _, rule := range p.Rules {
+		if matchCollectionPattern(rule.Pattern, rel) {
+			covered = true
+			guard = p.profile(rule.Profile).Guard
+		}
+	}
+	return guard, covered
+}
+
+// profile returns a validated profile by name
+func (p *Policy) profile(name string) PolicyProfile {
+	if profile, ok := p.Profiles[name]; ok {
+		return profile
+	}
+	return builtinProfiles[name]
+}
+
+// validate rejects unknown profiles, duplicate names and entries missing required fields
+func (p *Policy) validate() error {
+	known := func(name string) error {
+		if _, ok := p.Profiles[name]; ok {
+			return nil
+		}
+		if _, ok := builtinProfiles[name]; ok {
+			return nil
+		}
+		return fmt.Errorf("unknown profile '%s'", name)
+	}
+
+	for i, rule := range p.Rules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("rule %d has no pattern", i+1)
+		}
+		if err := known(rule.Profile); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Pattern, err)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, c := range p.Collections {
+		if err := validateCollectionNames([]string{c.Name}); err != nil || c.Name == "" {
+			return fmt.Errorf("invalid collection name '%s'", c.Name)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("collection %s is defined twice", c.Name)
+		}
+		seen[c.Name] = true
+		if len(c.Patterns) == 0 {
+			return fmt.Errorf("collection %s has no patterns", c.Name)
+		}
+		if err := known(c.Profile); err != nil {
+			return fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+	}
+	for _, f := range p.Folders {
+		if f.Path == "" {
+			return fmt.Errorf("folder without path")
+		}
+		if err := known(f.Profile); err != nil {
+			return fmt.Errorf("folder %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// policyPath returns the path of guard.policy.yaml next to the .guardfile
+func (m *Manager) policyPath() string {
+	return filepath.Join(filepath.Dir(m.registryPath), PolicyFileName)
+}
@@ -0,0 +1,141 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSyncPolicy tests that sync plans and applies the guard state of guard.policy.yaml,
+// that files the policy does not cover keep their guard state, and that a synced project
+// has an empty plan.
+func TestSyncPolicy(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	for _, dir := range []string{filepath.Join("vendor", "patches"), filepath.Join("db", "migrations"), "config"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	mainFile := createTestFile(t, tmpDir, "main.go", 0644)
+	createTestFile(t, tmpDir, "go.lock", 0644)
+	createTestFile(t, filepath.Join(tmpDir, "vendor"), "lib.go", 0644)
+	patchFile := createTestFile(t, filepath.Join(tmpDir, "vendor", "patches"), "fix.diff", 0644)
+	createTestFile(t, filepath.Join(tmpDir, "db", "migrations"), "001.sql", 0644)
+	createTestFile(t, filepath.Join(tmpDir, "config"), "app.yml", 0644)
+
+	policy := `profiles:
+  frozen:
+    guard: true
+rules:
+  - pattern: "*.lock"
+    profile: guarded
+  - pattern: /vendor/
+    profile: frozen
+  - pattern: /vendor/patches/
+    profile: unguarded
+collections:
+  - name: migrations
+    patterns: [/db/migrations/]
+    profile: guarded
+folders:
+  - path: config
+    profile: guarded
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, PolicyFileName), []byte(policy), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	// Guarded outside the policy: sync leaves it alone
+	if err := mgr.EnableFiles([]string{mainFile}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+
+	loaded, err := mgr.LoadPolicy()
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	plan, err := mgr.PlanSync(loaded)
+	if err != nil {
+		t.Fatalf("PlanSync failed: %v", err)
+	}
+	want := []string{"config/app.yml", "db/migrations/001.sql", "go.lock", "vendor/lib.go"}
+	if !slices.Equal(plan.RegisterFiles, want) {
+		t.Errorf("Expected to register %v, got %v", want, plan.RegisterFiles)
+	}
+	if !slices.Equal(plan.CreateCollections, []string{"migrations"}) || !slices.Equal(plan.RegisterFolders, []string{"@config"}) {
+		t.Errorf("Unexpected registrations: %+v", plan)
+	}
+	if mgr.IsRegisteredFile(patchFile) {
+		t.Error("Expected planning to change nothing")
+	}
+
+	if _, err := mgr.Sync(loaded); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	for _, rel := range want {
+		if guard, err := mgr.security.GetRegisteredFileGuard(filepath.Join(tmpDir, rel)); err != nil || !guard {
+			t.Errorf("Expected %s to be guarded", rel)
+		}
+	}
+	if guard, _ := mgr.security.GetRegisteredFileGuard(mainFile); !guard {
+		t.Error("Expected main.go, which the policy does not cover, to stay guarded")
+	}
+	if mgr.IsRegisteredFile(patchFile) {
+		t.Error("Expected the unguarded rule to keep vendor/patches unregistered")
+	}
+	if guard, _ := mgr.security.GetRegisteredCollectionGuard("migrations"); !guard {
+		t.Error("Expected the migrations collection to be guarded")
+	}
+
+	plan, err = mgr.PlanSync(loaded)
+	if err != nil {
+		t.Fatalf("PlanSync failed: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("Expected an empty plan after sync, got %+v", plan)
+	}
+
+	// Once a rule covers it, sync unguards it
+	policy = strings.Replace(policy, "collections:\n", "  - pattern: /main.go\n    profile: unguarded\ncollections:\n", 1)
+	if err := os.WriteFile(filepath.Join(tmpDir, PolicyFileName), []byte(policy), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	loaded, err = mgr.LoadPolicy()
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	plan, err = mgr.Sync(loaded)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Name != "main.go" || plan.Changes[0].Guard {
+		t.Errorf("Expected only main.go to be unguarded, got %+v", plan.Changes)
+	}
+	if guard, _ := mgr.security.GetRegisteredFileGuard(mainFile); guard {
+		t.Error("Expected main.go to be unguarded by its rule")
+	}
+}
+
+// TestLoadPolicyValidation tests that policies naming unknown profiles are rejected.
+func TestLoadPolicyValidation(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if _, err := mgr.LoadPolicy(); err == nil {
+		t.Error("Expected an error without a policy file")
+	}
+	policy := "rules:\n  - pattern: \"*.go\"\n    profile: frozen\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, PolicyFileName), []byte(policy), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := mgr.LoadPolicy(); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
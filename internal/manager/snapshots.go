@@ -0,0 +1,489 @@
+package manager
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultSnapshotLimit is the size in bytes the snapshot store is pruned to
+	// after new snapshots were added
+	DefaultSnapshotLimit int64 = 256 << 20
+
+	snapshotDirName       = "snapshots"
+	snapshotIndexFileName = "index.yaml"
+	snapshotObjectSuffix  = ".gz"
+)
+
+// Snapshot is one stored version of a guarded file's content.
+// Mode, Owner and Group are the file's original (unguarded) permissions.
+type Snapshot struct {
+	Path   string    `yaml:"path"` // Relative to the .guardfile
+	SHA256 string    `yaml:"sha256"`
+	Size   int64     `yaml:"size"`
+	Mode   string    `yaml:"mode"`
+	Owner  string    `yaml:"owner"`
+	Group  string    `yaml:"group"`
+	Time   time.Time `yaml:"time"`
+}
+
+// ID returns the short form of the content hash used to select a snapshot.
+func (s Snapshot) ID() string {
+	return s.SHA256[:12]
+}
+
+// snapshotIndex is stored in .guard/snapshots/index.yaml, oldest snapshot first.
+// The content lives next to it in gzip-compressed files named after its SHA-256.
+type snapshotIndex struct {
+	Snapshots []Snapshot `yaml:"snapshots"`
+}
+
+// SnapshotPruneResult summarizes a prune run.
+type SnapshotPruneResult struct {
+	Removed    int   // Snapshot entries removed
+	FreedBytes int64 // Bytes of content files deleted
+	TotalBytes int64 // Bytes of content files left
+}
+
+// SetSnapshotLimit sets the size in bytes the snapshot store is pruned to when snapshots are added.
+// A value of 0 disables the limit.
+func (m *Manager) SetSnapshotLimit(limit int64) {
+	if limit < 0 {
+		limit = 0
+	}
+	m.snapshotLimit = limit
+}
+
+// snapshotFiles stores the current content of the given registered files in the snapshot store.
+// Failures are reported as warnings, the store only adds to the protection of a guard.
+func (m *Manager) snapshotFiles(paths []string) {
+	if len(paths) == 0 || m.DryRun() {
+		return
+	}
+	index, err := m.readSnapshotIndex()
+	if err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to snapshot files: %v", err)))
+		return
+	}
+
+	added := false
+	for _, path := range paths {
+		snapshot, err := m.storeSnapshot(path)
+		if err != nil {
+			m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to snapshot %s: %v", path, err)))
+			continue
+		}
+		// Consecutive snapshots of unchanged content are only stored once
+		if latest := latestSnapshot(index, snapshot.Path); latest != nil && latest.SHA256 == snapshot.SHA256 {
+			continue
+		}
+		index.Snapshots = append(index.Snapshots, *snapshot)
+		added = true
+	}
+	if !added {
+		return
+	}
+
+	if m.snapshotLimit > 0 {
+		m.pruneSnapshotIndex(index, 0, m.snapshotLimit)
+	}
+	if err := m.writeSnapshotIndex(index); err != nil {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Failed to write snapshot index: %v", err)))
+		return
+	}
+	m.removeUnreferencedSnapshots(index)
+}
+
+// storeSnapshot compresses the file into the store under the SHA-256 of its content.
+func (m *Manager) storeSnapshot(path string) (*Snapshot, error) {
+	owner, group, mode, _, err := m.security.GetRegisteredFileConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := m.ensureStateDir(snapshotDirName)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := m.fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	zw := gzip.NewWriter(tmp)
+	size, err := io.Copy(io.MultiWriter(h, zw), src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store content: %w", err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	object := filepath.Join(dir, sum+snapshotObjectSuffix)
+	if !stateFileExists(object) {
+		if runningAsRoot() {
+			if err := os.Chown(tmp.Name(), 0, 0); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.Rename(tmp.Name(), object); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Snapshot{
+		Path:   m.journalRelPath(absPath),
+		SHA256: sum,
+		Size:   size,
+		Mode:   formatJournalMode(mode),
+		Owner:  owner,
+		Group:  group,
+		Time:   time.Now(),
+	}, nil
+}
+
+// ListSnapshots returns the stored snapshots, newest first.
+// With a path, only the snapshots of that file are returned.
+func (m *Manager) ListSnapshots(path string) ([]Snapshot, error) {
+	index, err := m.readSnapshotIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	rel := ""
+	if path != "" {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		rel = m.journalRelPath(absPath)
+	}
+
+	var snapshots []Snapshot
+	for i := len(index.Snapshots) - 1; i >= 0; i-- {
+		if rel == "" || index.Snapshots[i].Path == rel {
+			snapshots = append(snapshots, index.Snapshots[i])
+		}
+	}
+	return snapshots, nil
+}
+
+// RestoreFiles brings files back from the snapshot store: the latest snapshot, or the one whose
+// ID starts with snapshotID. Targets are file paths or collection names; files removed from the
+// registry since their snapshot are registered again with their original permissions.
+// Guarded files get the guard permissions, unguarded files their original permissions.
+// Returns the display paths of the restored files.
+func (m *Manager) RestoreFiles(targets []string, snapshotID string) ([]string, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no files or collections specified")
+	}
+
+	index, err := m.readSnapshotIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, target := range targets {
+		if m.security.IsRegisteredCollection(target) {
+			files, err := m.security.GetRegisteredCollectionFiles(target)
+			if err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to get files of collection %s: %v", target, err))
+				continue
+			}
+			paths = append(paths, files...)
+			continue
+		}
+		absPath, err := filepath.Abs(target)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to resolve path %s: %v", target, err))
+			continue
+		}
+		paths = append(paths, absPath)
+	}
+
+	var restored, noSnapshot []string
+	var changes []permChange
+	for _, path := range paths {
+		rel := m.journalRelPath(path)
+		snapshot := findSnapshot(index, rel, snapshotID)
+		if snapshot == nil {
+			noSnapshot = append(noSnapshot, rel)
+			continue
+		}
+
+		if !m.security.IsRegisteredFile(path) {
+			mode, err := parseJournalMode(snapshot.Mode)
+			if err == nil {
+				err = m.security.RegisterFile(path, mode, snapshot.Owner, snapshot.Group)
+			}
+			if err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to register %s: %v", rel, err))
+				continue
+			}
+		}
+		owner, group, mode, guard, err := m.security.GetRegisteredFileConfig(path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", rel, err))
+			continue
+		}
+
+		if err := m.restoreSnapshotContent(path, snapshot, owner, group); err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to restore %s: %v", rel, err))
+			continue
+		}
+
+		if guard {
+			if err := m.security.SetRegisteredFileContent(path, snapshot.SHA256, snapshot.Size); err != nil {
+				m.AddError(fmt.Sprintf("Error: Failed to set content baseline for %s: %v", rel, err))
+			}
+			changes = append(changes, m.guardPermChange(path))
+		} else {
+			changes = append(changes, unguardPermChange(path, mode, owner, group))
+		}
+		restored = append(restored, rel)
+	}
+
+	if len(noSnapshot) > 0 {
+		m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf(
+			"No matching snapshot for: %s", strings.Join(noSnapshot, ", "))))
+	}
+
+	succeeded := m.applyPermChanges("restore", changes)
+	var result []string
+	for i, change := range changes {
+		if succeeded[change.path] {
+			result = append(result, restored[i])
+		}
+	}
+	return result, nil
+}
+
+// restoreSnapshotContent replaces the file with the snapshot content.
+// The content is written to a temporary file next to it and renamed into place.
+func (m *Manager) restoreSnapshotContent(path string, snapshot *Snapshot, owner, group string) error {
+	if m.DryRun() {
+		m.dryRun.Record("restore", path, snapshot.ID())
+		return nil
+	}
+	src, err := os.Open(filepath.Join(m.StateDir(), snapshotDirName, snapshot.SHA256+snapshotObjectSuffix))
+	if err != nil {
+		return fmt.Errorf("snapshot content is missing: %w", err)
+	}
+	defer src.Close()
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return fmt.Errorf("snapshot content is corrupted: %w", err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("snapshot content is corrupted: %w", err)
+	}
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != snapshot.SHA256 {
+		return fmt.Errorf("snapshot content does not match its hash")
+	}
+
+	if err := m.fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.restore-%d", filepath.Base(path), time.Now().UnixNano()))
+	if err := m.fs.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	defer m.fs.Remove(tmp)
+
+	// The restored file keeps its original owner until the permission phase runs
+	if err := m.fs.ApplyPermissions(tmp, 0600, owner, group); err != nil {
+		return err
+	}
+
+	// An immutable file cannot be replaced
+	if immutable, err := m.fs.IsImmutable(path); err == nil && immutable {
+		if err := m.clearImmutable(path); err != nil {
+			return err
+		}
+	}
+	return m.fs.Rename(tmp, path)
+}
+
+// PruneSnapshots keeps at most keep snapshots per file (0 keeps all) and then removes the oldest
+// snapshots until the store is below maxSize bytes (0 for no limit). The latest snapshot of a file
+// is only removed by keep, never to meet the size limit.
+func (m *Manager) PruneSnapshots(keep int, maxSize int64) (*SnapshotPruneResult, error) {
+	index, err := m.readSnapshotIndex()
+	if err != nil {
+		return nil, err
+	}
+	before := m.snapshotStoreSize(index)
+	count := len(index.Snapshots)
+
+	m.pruneSnapshotIndex(index, keep, maxSize)
+	if err := m.writeSnapshotIndex(index); err != nil {
+		return nil, err
+	}
+	m.removeUnreferencedSnapshots(index)
+
+	after := m.snapshotStoreSize(index)
+	return &SnapshotPruneResult{
+		Removed:    count - len(index.Snapshots),
+		FreedBytes: before - after,
+		TotalBytes: after,
+	}, nil
+}
+
+// pruneSnapshotIndex drops index entries as described by PruneSnapshots.
+func (m *Manager) pruneSnapshotIndex(index *snapshotIndex, keep int, maxSize int64) {
+	if keep > 0 {
+		perPath := make(map[string]int)
+		kept := index.Snapshots[:0]
+		for i := len(index.Snapshots) - 1; i >= 0; i-- {
+			s := index.Snapshots[i]
+			perPath[s.Path]++
+			if perPath[s.Path] <= keep {
+				kept = append(kept, s)
+			}
+		}
+		slices.Reverse(kept)
+		index.Snapshots = kept
+	}
+
+	if maxSize <= 0 {
+		return
+	}
+	latest := make(map[string]int)
+	refs := make(map[string]int)
+	for i, s := range index.Snapshots {
+		latest[s.Path] = i
+		refs[s.SHA256]++
+	}
+	size := m.snapshotStoreSize(index)
+	var kept []Snapshot
+	for i, s := range index.Snapshots {
+		if size <= maxSize || latest[s.Path] == i {
+			kept = append(kept, s)
+			continue
+		}
+		// Content shared with a kept snapshot stays on disk
+		refs[s.SHA256]--
+		if refs[s.SHA256] == 0 {
+			size -= m.snapshotObjectSize(s.SHA256)
+		}
+	}
+	index.Snapshots = kept
+}
+
+// snapshotStoreSize returns the bytes on disk of the content files referenced by the index.
+func (m *Manager) snapshotStoreSize(index *snapshotIndex) int64 {
+	seen := make(map[string]bool)
+	var total int64
+	for _, s := range index.Snapshots {
+		if !seen[s.SHA256] {
+			seen[s.SHA256] = true
+			total += m.snapshotObjectSize(s.SHA256)
+		}
+	}
+	return total
+}
+
+// snapshotObjectSize returns the compressed size of a content file, 0 if it is missing.
+func (m *Manager) snapshotObjectSize(sum string) int64 {
+	info, err := os.Stat(filepath.Join(m.StateDir(), snapshotDirName, sum+snapshotObjectSuffix))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// removeUnreferencedSnapshots deletes content files no index entry refers to.
+func (m *Manager) removeUnreferencedSnapshots(index *snapshotIndex) {
+	dir := filepath.Join(m.StateDir(), snapshotDirName)
+	referenced := make(map[string]bool)
+	for _, s := range index.Snapshots {
+		referenced[s.SHA256+snapshotObjectSuffix] = true
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), snapshotObjectSuffix) && !referenced[entry.Name()] {
+			_ = m.removeFile(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+func (m *Manager) snapshotIndexPath() string {
+	return filepath.Join(m.StateDir(), snapshotDirName, snapshotIndexFileName)
+}
+
+// readSnapshotIndex loads the snapshot index. A missing index is empty.
+func (m *Manager) readSnapshotIndex() (*snapshotIndex, error) {
+	index := &snapshotIndex{}
+	data, err := os.ReadFile(m.snapshotIndexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot index: %w", err)
+	}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot index: %w", err)
+	}
+	return index, nil
+}
+
+func (m *Manager) writeSnapshotIndex(index *snapshotIndex) error {
+	if _, err := m.ensureStateDir(snapshotDirName); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot index: %w", err)
+	}
+	return m.writeStateFile(m.snapshotIndexPath(), data)
+}
+
+// latestSnapshot returns the newest snapshot of the file, or nil.
+func latestSnapshot(index *snapshotIndex, rel string) *Snapshot {
+	return findSnapshot(index, rel, "")
+}
+
+// findSnapshot returns the newest snapshot of the file whose ID starts with id (any if empty), or nil.
+func findSnapshot(index *snapshotIndex, rel, id string) *Snapshot {
+	for i := len(index.Snapshots) - 1; i >= 0; i-- {
+		s := &index.Snapshots[i]
+		if s.Path == rel && strings.HasPrefix(s.SHA256, id) {
+			return s
+		}
+	}
+	return nil
+}
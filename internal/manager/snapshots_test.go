@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"os"
+	"testing"
+)
+
+// TestRestoreDeletedGuardedFile tests that a guarded file deleted via its directory
+// and forgotten by cleanup is restored from its snapshot.
+func TestRestoreDeletedGuardedFile(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	original, _ := os.ReadFile(file1)
+	if err := mgr.EnableFiles([]string{file1}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+
+	snapshots, err := mgr.ListSnapshots(file1)
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("Expected 1 snapshot after guarding, got %v (%v)", snapshots, err)
+	}
+
+	// Delete the file behind guard's back and let cleanup forget it
+	_ = mgr.fs.ClearImmutable(file1)
+	if err := os.Remove(file1); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := mgr.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	if mgr.IsRegisteredFile(file1) {
+		t.Fatal("Expected cleanup to unregister the deleted file")
+	}
+
+	restored, err := mgr.RestoreFiles([]string{file1}, snapshots[0].ID())
+	if err != nil || len(restored) != 1 {
+		t.Fatalf("RestoreFiles failed: %v (%v, errors: %v)", restored, err, mgr.GetErrors())
+	}
+
+	content, err := os.ReadFile(file1)
+	if err != nil || string(content) != string(original) {
+		t.Errorf("Expected restored content %q, got %q (%v)", original, content, err)
+	}
+	// Re-registered unguarded with its original permissions
+	if mode, _, _, _ := mgr.fs.GetFileInfo(file1); mode.Perm() != 0644 {
+		t.Errorf("Expected original mode 0644 after restore, got %04o", mode.Perm())
+	}
+	if guard, err := mgr.GetRegistry().GetRegisteredFileGuard(file1); err != nil || guard {
+		t.Errorf("Expected file1 to be registered unguarded, got guard=%v (%v)", guard, err)
+	}
+
+	result, err := mgr.PruneSnapshots(1, 0)
+	if err != nil || result.Removed != 0 {
+		t.Errorf("Expected nothing to prune, got %+v (%v)", result, err)
+	}
+}
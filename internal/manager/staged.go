@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// OverrideTrailer is the commit message trailer that lets a commit touch guarded paths,
+// e.g. "Guard-Override: regenerate API client".
+const OverrideTrailer = "Guard-Override"
+
+// StagedViolation is a staged change to a guarded path.
+type StagedViolation struct {
+	Path    string   // relative to the .guardfile directory
+	Reasons []string // e.g. "guarded file", "guarded folder @docs", "guarded collection api"
+}
+
+// CheckStaged returns the staged changes that touch a guarded file, a file in a guarded folder
+// or a file in a guarded collection. Deleted and renamed files count with their old path,
+// so deleting and recreating a guarded file is caught too.
+func (m *Manager) CheckStaged() ([]StagedViolation, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	baseDir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	repo, err := m.gitRepo()
+	if err != nil {
+		return nil, err
+	}
+	staged, err := repo.StagedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Files of guarded collections, by absolute path
+	collections := make(map[string][]string)
+	for _, name := range m.security.GetRegisteredCollections() {
+		if guard, err := m.security.GetRegisteredCollectionGuard(name); err != nil || !guard {
+			continue
+		}
+		files, err := m.security.GetRegisteredCollectionFiles(name)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			collections[file] = append(collections[file], name)
+		}
+	}
+
+	// git reports paths below its resolved top-level directory
+	realBase, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		realBase = baseDir
+	}
+
+	folders := m.security.ListFolders()
+	var violations []StagedViolation
+	for _, path := range staged {
+		rel, err := filepath.Rel(realBase, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		path = filepath.Join(baseDir, rel)
+
+		var reasons []string
+		if guard, err := m.security.GetRegisteredFileGuard(path); err == nil && guard {
+			reasons = append(reasons, "guarded file")
+		}
+		for _, folder := range folders {
+			folderPath := filepath.Join(baseDir, folder.Path)
+			if folder.Guard && strings.HasPrefix(path, folderPath+string(filepath.Separator)) {
+				reasons = append(reasons, "guarded folder "+folder.Name)
+			}
+		}
+		for _, name := range collections[path] {
+			reasons = append(reasons, "guarded collection "+name)
+		}
+		if len(reasons) > 0 {
+			violations = append(violations, StagedViolation{Path: filepath.ToSlash(rel), Reasons: reasons})
+		}
+	}
+	return violations, nil
+}
+
+// CommitOverride returns the reason given in the override trailer of the commit message
+// in messageFile, or "" if the message has none.
+func (m *Manager) CommitOverride(messageFile string) (string, error) {
+	repo, err := m.gitRepo()
+	if err != nil {
+		return "", err
+	}
+	messageFile, err = filepath.Abs(messageFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve commit message file: %w", err)
+	}
+	trailers, err := repo.Trailers(messageFile)
+	if err != nil {
+		return "", err
+	}
+	for _, trailer := range trailers {
+		if strings.EqualFold(trailer.Key, OverrideTrailer) && trailer.Value != "" {
+			return trailer.Value, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestCheckStaged tests finding staged changes to guarded paths and the override trailer.
+func TestCheckStaged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	runGit(t, tmpDir, "init", "-q")
+	runGit(t, tmpDir, "config", "user.email", "test@example.com")
+	runGit(t, tmpDir, "config", "user.name", "test")
+	guarded := createTestFile(t, tmpDir, "guarded.txt", 0644)
+	member := createTestFile(t, tmpDir, "member.txt", 0644)
+	free := createTestFile(t, tmpDir, "free.txt", 0644)
+	runGit(t, tmpDir, "add", "guarded.txt", "member.txt", "free.txt")
+	runGit(t, tmpDir, "commit", "-q", "-m", "one")
+
+	if err := mgr.AddFiles([]string{guarded, member, free}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.AddFilesToCollections([]string{member}, []string{"contract"}); err != nil {
+		t.Fatalf("AddFilesToCollections failed: %v", err)
+	}
+	if err := mgr.EnableFiles([]string{guarded}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	// Only the collection is guarded, not its file
+	if err := mgr.security.SetRegisteredCollectionGuard("contract", true); err != nil {
+		t.Fatalf("SetRegisteredCollectionGuard failed: %v", err)
+	}
+	defer func() { _ = mgr.DisableFiles([]string{guarded}) }()
+
+	// Deleting a guarded file from the index, changing a collection file and a free file
+	runGit(t, tmpDir, "rm", "-q", "--cached", "guarded.txt")
+	for _, path := range []string{member, free} {
+		if err := os.WriteFile(path, []byte("changed"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	runGit(t, tmpDir, "add", "member.txt", "free.txt")
+
+	violations, err := mgr.CheckStaged()
+	if err != nil {
+		t.Fatalf("CheckStaged failed: %v", err)
+	}
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %+v", violations)
+	}
+	if violations[0].Path != "guarded.txt" || !slices.Equal(violations[0].Reasons, []string{"guarded file"}) {
+		t.Errorf("Unexpected violation: %+v", violations[0])
+	}
+	if violations[1].Path != "member.txt" || !slices.Equal(violations[1].Reasons, []string{"guarded collection contract"}) {
+		t.Errorf("Unexpected violation: %+v", violations[1])
+	}
+
+	messageFile := filepath.Join(tmpDir, "COMMIT_EDITMSG")
+	if err := os.WriteFile(messageFile, []byte("Change things\n\nGuard-Override: approved in review\n"), 0644); err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if reason, err := mgr.CommitOverride(messageFile); err != nil || reason != "approved in review" {
+		t.Errorf("Expected the override reason, got %q (%v)", reason, err)
+	}
+	if err := os.WriteFile(messageFile, []byte("Change things\n\nGuard-Override is not a trailer here\n"), 0644); err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if reason, err := mgr.CommitOverride(messageFile); err != nil || reason != "" {
+		t.Errorf("Expected no override, got %q (%v)", reason, err)
+	}
+}
@@ -0,0 +1,341 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/registry"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	stateDirName       = "states"
+	sessionFileName    = "session.yaml"
+	stateFileExtension = ".yaml"
+)
+
+// SavedState is the guard state of every registered file, collection and folder at one point in time.
+// Files are keyed by their path relative to the .guardfile, folders by their @path name.
+type SavedState struct {
+	Name        string          `yaml:"name"`
+	Time        time.Time       `yaml:"time"`
+	Files       map[string]bool `yaml:"files"`
+	Collections map[string]bool `yaml:"collections"`
+	Folders     map[string]bool `yaml:"folders"`
+}
+
+// SaveState records the current guard state under name in .guard/states/, replacing a state of that name.
+func (m *Manager) SaveState(name string) (*SavedState, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if err := validateStateName(name); err != nil {
+		return nil, err
+	}
+
+	state, err := m.currentState(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.ensureStateDir(stateDirName); err != nil {
+		return nil, err
+	}
+	if err := m.writeSavedState(m.statePath(name), state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// LoadState brings every file, collection and folder to the guard state saved under name.
+// Returns the items that changed; the caller saves the registry.
+func (m *Manager) LoadState(name string) ([]HistoryItem, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if err := validateStateName(name); err != nil {
+		return nil, err
+	}
+
+	state, err := m.readSavedState(m.statePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, newError(ErrorNotFound, []string{name}, "state not found: %s", name)
+		}
+		return nil, err
+	}
+	return m.restoreState("state", state)
+}
+
+// DiffState returns the guard state changes since the state saved under name.
+// PrevGuard is the saved, Guard the current state.
+func (m *Manager) DiffState(name string) ([]HistoryItem, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if err := validateStateName(name); err != nil {
+		return nil, err
+	}
+
+	state, err := m.readSavedState(m.statePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, newError(ErrorNotFound, []string{name}, "state not found: %s", name)
+		}
+		return nil, err
+	}
+	return m.stateChanges(state)
+}
+
+// ListStates returns the saved states sorted by name.
+func (m *Manager) ListStates() ([]SavedState, error) {
+	entries, err := os.ReadDir(filepath.Join(m.StateDir(), stateDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read saved states: %w", err)
+	}
+
+	var states []SavedState
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), stateFileExtension) {
+			continue
+		}
+		state, err := m.readSavedState(filepath.Join(m.StateDir(), stateDirName, entry.Name()))
+		if err != nil {
+			m.AddWarning(NewWarning(WarningGeneric, fmt.Sprintf("Skipped unreadable state %s: %v", entry.Name(), err)))
+			continue
+		}
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states, nil
+}
+
+// DeleteState removes the state saved under name.
+func (m *Manager) DeleteState(name string) error {
+	if err := validateStateName(name); err != nil {
+		return err
+	}
+	if !stateFileExists(m.statePath(name)) {
+		return newError(ErrorNotFound, []string{name}, "state not found: %s", name)
+	}
+	if err := m.removeFile(m.statePath(name)); err != nil {
+		if os.IsNotExist(err) {
+			return newError(ErrorNotFound, []string{name}, "state not found: %s", name)
+		}
+		return fmt.Errorf("failed to delete state %s: %w", name, err)
+	}
+	return nil
+}
+
+// StartSession records the current guard state so EndSession can restore it.
+// Only one session can be active at a time.
+func (m *Manager) StartSession(name string) (*SavedState, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+	if err := validateStateName(name); err != nil {
+		return nil, err
+	}
+	active, err := m.ActiveSession()
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, fmt.Errorf("session '%s' is already active since %s. End it with 'guard session end'",
+			active.Name, active.Time.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	state, err := m.currentState(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.ensureStateDir(""); err != nil {
+		return nil, err
+	}
+	if err := m.writeSavedState(m.sessionPath(), state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// ActiveSession returns the active session, or nil if there is none.
+func (m *Manager) ActiveSession() (*SavedState, error) {
+	state, err := m.readSavedState(m.sessionPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return state, nil
+}
+
+// SessionDiff returns the guard state changes since the active session started.
+// PrevGuard is the recorded, Guard the current state.
+func (m *Manager) SessionDiff() (*SavedState, []HistoryItem, error) {
+	if m.security == nil {
+		return nil, nil, errRegistryNotLoaded
+	}
+	session, err := m.requireSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := m.stateChanges(session)
+	return session, items, err
+}
+
+// EndSession ends the active session. Unless keep is set, every file, collection and folder
+// returns to the guard state recorded when the session started.
+// Returns the session and the items that were restored; the caller saves the registry.
+func (m *Manager) EndSession(keep bool) (*SavedState, []HistoryItem, error) {
+	if m.security == nil {
+		return nil, nil, errRegistryNotLoaded
+	}
+	session, err := m.requireSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var restored []HistoryItem
+	if !keep {
+		if restored, err = m.restoreState("session", session); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := m.removeFile(m.sessionPath()); err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to end session: %w", err)
+	}
+	return session, restored, nil
+}
+
+// requireSession returns the active session or an error if none is active.
+func (m *Manager) requireSession() (*SavedState, error) {
+	session, err := m.ActiveSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("no active session. Start one with 'guard session start <name>'")
+	}
+	return session, nil
+}
+
+// restoreState applies the guard state changes that lead back to state and returns them.
+// Files registered after the state was recorded are unguarded.
+func (m *Manager) restoreState(operation string, state *SavedState) ([]HistoryItem, error) {
+	changes, err := m.stateChanges(state)
+	if err != nil {
+		return nil, err
+	}
+	m.applyHistoryItems(operation, changes, true)
+	return changes, nil
+}
+
+// stateChanges compares a saved state with the current guard state.
+func (m *Manager) stateChanges(state *SavedState) ([]HistoryItem, error) {
+	current, err := m.currentState("")
+	if err != nil {
+		return nil, err
+	}
+	return guardStateChanges(state.registryData(), current.registryData()), nil
+}
+
+// currentState captures the guard state of the loaded registry.
+func (m *Manager) currentState(name string) (*SavedState, error) {
+	saved, err := m.security.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	data, err := registry.ParseRegistryData(saved)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &SavedState{
+		Name:        name,
+		Time:        time.Now(),
+		Files:       make(map[string]bool, len(data.Files)),
+		Collections: make(map[string]bool, len(data.Collections)),
+		Folders:     make(map[string]bool, len(data.Folders)),
+	}
+	for _, f := range data.Files {
+		state.Files[f.Path] = f.Guard
+	}
+	for _, c := range data.Collections {
+		state.Collections[c.Name] = c.Guard
+	}
+	for _, f := range data.Folders {
+		state.Folders[f.Name] = f.Guard
+	}
+	return state, nil
+}
+
+// registryData converts the state into registry data for guardStateChanges.
+func (s *SavedState) registryData() *registry.RegistryData {
+	data := &registry.RegistryData{}
+	for path, guard := range s.Files {
+		data.Files = append(data.Files, registry.FileEntry{Path: path, Guard: guard})
+	}
+	for name, guard := range s.Collections {
+		data.Collections = append(data.Collections, registry.Collection{Name: name, Guard: guard})
+	}
+	for name, guard := range s.Folders {
+		data.Folders = append(data.Folders, registry.Folder{Name: name, Guard: guard})
+	}
+	return data
+}
+
+// validateStateName rejects names that cannot be used as a file name in the state directory.
+func validateStateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid name '%s': must not contain path separators or start with a dot", name)
+	}
+	return nil
+}
+
+func (m *Manager) statePath(name string) string {
+	return filepath.Join(m.StateDir(), stateDirName, name+stateFileExtension)
+}
+
+func (m *Manager) sessionPath() string {
+	return filepath.Join(m.StateDir(), sessionFileName)
+}
+
+// readSavedState loads a saved state. A missing file returns an os.IsNotExist error.
+func (m *Manager) readSavedState(path string) (*SavedState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state SavedState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return &state, nil
+}
+
+func (m *Manager) writeSavedState(path string, state *SavedState) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to serialize state: %w", err)
+	}
+	if err := m.writeStateFile(path, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if m.DryRun() {
+		m.dryRun.Record("write", path, "")
+	}
+	return nil
+}
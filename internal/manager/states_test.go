@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"testing"
+)
+
+// TestSessionRestoresRecordedState tests that ending a session brings every file
+// back to the guard state recorded when it started.
+func TestSessionRestoresRecordedState(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	file2 := createTestFile(t, tmpDir, "file2.txt", 0644)
+	if err := mgr.EnableFiles([]string{file1}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if err := mgr.AddFiles([]string{file2}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+
+	if _, err := mgr.StartSession("pairing"); err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	if _, err := mgr.StartSession("other"); err == nil {
+		t.Error("Expected an error when starting a second session")
+	}
+
+	// Swap the guards during the session
+	if err := mgr.ToggleFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("ToggleFiles failed: %v", err)
+	}
+	_, changes, err := mgr.SessionDiff()
+	if err != nil {
+		t.Fatalf("SessionDiff failed: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Name != "file1.txt" || !changes[0].PrevGuard || changes[0].Guard {
+		t.Fatalf("Unexpected session diff: %+v", changes)
+	}
+
+	if _, restored, err := mgr.EndSession(false); err != nil || len(restored) != 2 {
+		t.Fatalf("EndSession failed: %v (%+v)", err, restored)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(file1); !guard {
+		t.Error("Expected file1 to be guarded again")
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(file2); guard {
+		t.Error("Expected file2 to be unguarded again")
+	}
+	if mode, _, _, _ := mgr.fs.GetFileInfo(file2); mode.Perm() != 0644 {
+		t.Errorf("Expected mode 0644 for file2, got %04o", mode.Perm())
+	}
+	if session, _ := mgr.ActiveSession(); session != nil {
+		t.Errorf("Expected no active session, got %+v", session)
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.DisableFiles([]string{file1}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+}
+
+// TestSaveAndLoadState tests switching between named guard states.
+func TestSaveAndLoadState(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.AddFiles([]string{file1}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if _, err := mgr.SaveState("open"); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	if _, err := mgr.SaveState("../escape"); err == nil {
+		t.Error("Expected an error for a name with a path separator")
+	}
+
+	if err := mgr.EnableFiles([]string{file1}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if _, err := mgr.SaveState("locked"); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+
+	if changes, err := mgr.LoadState("open"); err != nil || len(changes) != 1 {
+		t.Fatalf("LoadState failed: %v (%+v)", err, changes)
+	}
+	if guard, _ := mgr.GetRegistry().GetRegisteredFileGuard(file1); guard {
+		t.Error("Expected file1 to be unguarded in state 'open'")
+	}
+	if changes, err := mgr.DiffState("locked"); err != nil || len(changes) != 1 {
+		t.Errorf("Expected one difference to state 'locked', got %+v (%v)", changes, err)
+	}
+
+	states, err := mgr.ListStates()
+	if err != nil || len(states) != 2 || states[0].Name != "locked" || states[1].Name != "open" {
+		t.Errorf("Expected states locked and open, got %+v (%v)", states, err)
+	}
+	if _, err := mgr.LoadState("missing"); err == nil {
+		t.Error("Expected an error for a missing state")
+	}
+}
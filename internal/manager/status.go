@@ -0,0 +1,252 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const statusIndexFileName = "status.yaml"
+
+// statusIndexMaxAge is how long StatusSummary trusts the index of an unchanged .guardfile.
+// Permission changes made outside guard show up after at most this long.
+const statusIndexMaxAge = time.Minute
+
+// On-disk states of a registered file (see FileStatus)
+const (
+	FileStateGuarded   = "guarded"   // Guard permissions or the immutable flag are set
+	FileStateUnguarded = "unguarded" // Neither guard permissions nor the immutable flag are set
+	FileStateMissing   = "missing"   // The file does not exist
+)
+
+// FileStatus is the guard flag of a registered file and its state on disk.
+type FileStatus struct {
+	Path        string   `json:"path"`  // Display path
+	Guard       bool     `json:"guard"` // Guard flag in the registry
+	State       string   `json:"state"` // FileStateGuarded, FileStateUnguarded or FileStateMissing
+	Drift       bool     `json:"drift"` // The file is missing or does not match the registry (see CheckFileDrift)
+	Collections []string `json:"collections"`
+}
+
+// StatusSummary counts the registered files by guard flag, and the drifted ones.
+type StatusSummary struct {
+	Guarded   int `json:"guarded" yaml:"guarded"`
+	Unguarded int `json:"unguarded" yaml:"unguarded"`
+	Drift     int `json:"drift" yaml:"drift"`
+}
+
+// statusIndex is stored in .guard/status.yaml (see statusIndexPaths). It is valid for
+// the .guardfile with the recorded modification time and size.
+type statusIndex struct {
+	GuardfileModTime int64         `yaml:"guardfile_mtime"`
+	GuardfileSize    int64         `yaml:"guardfile_size"`
+	Updated          time.Time     `yaml:"updated"`
+	Summary          StatusSummary `yaml:"summary"`
+}
+
+// Status returns the status of every registered file, sorted by path, and refreshes
+// the status index used by StatusSummary.
+func (m *Manager) Status() ([]FileStatus, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	// Collection membership of every file, in one pass over the collections
+	memberOf := make(map[string][]string)
+	for _, coll := range m.security.GetRegisteredCollections() {
+		files, err := m.security.GetRegisteredCollectionFiles(coll)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			memberOf[f] = append(memberOf[f], coll)
+		}
+	}
+
+	statuses := []FileStatus{}
+	for _, path := range m.security.GetRegisteredFiles() {
+		guard, err := m.security.GetRegisteredFileGuard(path)
+		if err != nil {
+			return nil, err
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		status := FileStatus{
+			Path:        m.security.ToDisplayPath(absPath),
+			Guard:       guard,
+			Collections: memberOf[absPath],
+		}
+		if status.Collections == nil {
+			status.Collections = []string{}
+		}
+		status.State, status.Drift = m.fileState(absPath)
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Path < statuses[j].Path })
+
+	// The index only saves time, a user who cannot write .guard/ gets a fresh summary every time
+	_ = m.writeStatusIndex(SummarizeStatus(statuses))
+	return statuses, nil
+}
+
+// fileState returns the on-disk state of a registered file and whether it drifted.
+func (m *Manager) fileState(path string) (state string, drift bool) {
+	if !m.fs.FileExists(path) {
+		return FileStateMissing, true
+	}
+	state = FileStateUnguarded
+	mode, owner, group, err := m.fs.GetFileInfo(path)
+	if err == nil && m.hasGuardPermissions(mode, owner, group) {
+		state = FileStateGuarded
+	}
+	if immutable, err := m.fs.IsImmutable(path); err == nil && immutable {
+		state = FileStateGuarded
+	}
+	fileDrift, err := m.CheckFileDrift(path)
+	return state, err != nil || fileDrift != nil
+}
+
+// SummarizeStatus counts the given file statuses.
+func SummarizeStatus(statuses []FileStatus) StatusSummary {
+	var summary StatusSummary
+	for _, status := range statuses {
+		if status.Guard {
+			summary.Guarded++
+		} else {
+			summary.Unguarded++
+		}
+		if status.Drift {
+			summary.Drift++
+		}
+	}
+	return summary
+}
+
+// StatusSummary returns the counts of the last Status while the .guardfile is unchanged
+// and the index is recent, without loading the registry or looking at any file.
+// Otherwise it loads the registry read-only if needed and runs Status, so it never
+// changes a file or the .guardfile.
+func (m *Manager) StatusSummary() (*StatusSummary, error) {
+	if index := m.readStatusIndex(); index != nil {
+		return &index.Summary, nil
+	}
+
+	if m.security == nil {
+		if err := m.LoadRegistryReadOnly(); err != nil {
+			return nil, err
+		}
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	summary := SummarizeStatus(statuses)
+	return &summary, nil
+}
+
+// statusIndexPaths returns where the status index is stored: .guard/status.yaml, and a
+// file in the user's cache directory for users who cannot write .guard/, such as the
+// owner of a shell prompt in a project guarded under sudo.
+func (m *Manager) statusIndexPaths() []string {
+	paths := []string{filepath.Join(m.StateDir(), statusIndexFileName)}
+	if path := m.userStatusIndexPath(); path != "" {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// userStatusIndexPath returns the status index in the user's cache directory, named after
+// the absolute path of the .guardfile. Returns "" without a cache directory.
+func (m *Manager) userStatusIndexPath() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	absPath, err := filepath.Abs(m.registryPath)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(absPath))
+	return filepath.Join(cacheDir, "guard", hex.EncodeToString(sum[:8])+"-"+statusIndexFileName)
+}
+
+// readStatusIndex loads the first valid status index. Returns nil if there is none: each
+// may be missing, unreadable, older than statusIndexMaxAge or written for another version
+// of the .guardfile.
+func (m *Manager) readStatusIndex() *statusIndex {
+	info, err := os.Stat(m.registryPath)
+	if err != nil {
+		return nil
+	}
+	for _, path := range m.statusIndexPaths() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var index statusIndex
+		if err := yaml.Unmarshal(data, &index); err != nil {
+			continue
+		}
+		if index.GuardfileModTime != info.ModTime().UnixNano() || index.GuardfileSize != info.Size() {
+			continue
+		}
+		if age := time.Since(index.Updated); age < 0 || age > statusIndexMaxAge {
+			continue
+		}
+		return &index
+	}
+	return nil
+}
+
+// writeStatusIndex stores the summary for the current .guardfile in .guard/, or in the
+// user's cache directory if .guard/ cannot be written.
+func (m *Manager) writeStatusIndex(summary StatusSummary) error {
+	if m.DryRun() {
+		return nil
+	}
+	info, err := os.Stat(m.registryPath)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(&statusIndex{
+		GuardfileModTime: info.ModTime().UnixNano(),
+		GuardfileSize:    info.Size(),
+		Updated:          time.Now().UTC(),
+		Summary:          summary,
+	})
+	if err != nil {
+		return err
+	}
+
+	paths := m.statusIndexPaths()
+	if _, err = m.ensureStateDir(""); err == nil {
+		if err = m.writeStateFile(paths[0], data); err == nil {
+			return nil
+		}
+	}
+	if len(paths) < 2 {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(paths[1]), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(paths[1], data, 0600)
+}
+
+// invalidateStatusIndex removes the status indexes after permission changes, which do
+// not necessarily change the .guardfile.
+func (m *Manager) invalidateStatusIndex() {
+	if m.DryRun() {
+		return
+	}
+	for _, path := range m.statusIndexPaths() {
+		_ = os.Remove(path)
+	}
+}
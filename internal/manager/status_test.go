@@ -0,0 +1,173 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStatus tests the guard flag, on-disk state, drift and collections of registered files.
+func TestStatus(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	guarded := createTestFile(t, tmpDir, "guarded.txt", 0644)
+	drifted := createTestFile(t, tmpDir, "drifted.txt", 0644)
+	missing := createTestFile(t, tmpDir, "missing.txt", 0644)
+	if err := mgr.AddFilesToCollections([]string{guarded}, []string{"docs"}); err != nil {
+		t.Fatalf("AddFilesToCollections failed: %v", err)
+	}
+	if err := mgr.AddFiles([]string{drifted, missing}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.EnableFiles([]string{guarded}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if err := os.Chmod(drifted, 0600); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	if err := os.Remove(missing); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	statuses, err := mgr.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	expected := []FileStatus{
+		{Path: "drifted.txt", Guard: false, State: FileStateGuarded, Drift: true},
+		{Path: "guarded.txt", Guard: true, State: FileStateGuarded, Drift: false},
+		{Path: "missing.txt", Guard: false, State: FileStateMissing, Drift: true},
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("Expected %d statuses, got %+v", len(expected), statuses)
+	}
+	for i, want := range expected {
+		got := statuses[i]
+		if got.Path != want.Path || got.Guard != want.Guard || got.State != want.State || got.Drift != want.Drift {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+	if len(statuses[1].Collections) != 1 || statuses[1].Collections[0] != "docs" {
+		t.Errorf("Expected guarded.txt in docs, got %v", statuses[1].Collections)
+	}
+
+	summary := SummarizeStatus(statuses)
+	if summary != (StatusSummary{Guarded: 1, Unguarded: 2, Drift: 2}) {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+}
+
+// TestStatusSummaryUsesIndex tests that the summary comes from the status index until
+// the .guardfile or the file permissions change through guard.
+func TestStatusSummaryUsesIndex(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	file := createTestFile(t, tmpDir, "file.txt", 0644)
+	if err := mgr.AddFiles([]string{file}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	prompt := NewManager(mgr.registryPath, mgr.fs)
+	summary, err := prompt.StatusSummary()
+	if err != nil {
+		t.Fatalf("StatusSummary failed: %v", err)
+	}
+	if *summary != (StatusSummary{Unguarded: 1}) {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+
+	// A change outside guard is not seen while the index is valid
+	if err := os.Remove(file); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	cached := NewManager(mgr.registryPath, mgr.fs)
+	if summary, err := cached.StatusSummary(); err != nil || summary.Drift != 0 {
+		t.Errorf("Expected the cached summary without drift, got %+v (%v)", summary, err)
+	}
+	if cached.GetRegistry() != nil {
+		t.Error("Expected the cached summary not to load the registry")
+	}
+
+	// Saving a changed .guardfile invalidates the index
+	createTestFile(t, tmpDir, "other.txt", 0644)
+	if err := mgr.AddFiles([]string{"other.txt"}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+	fresh := NewManager(mgr.registryPath, mgr.fs)
+	summary, err = fresh.StatusSummary()
+	if err != nil {
+		t.Fatalf("StatusSummary failed: %v", err)
+	}
+	if *summary != (StatusSummary{Unguarded: 2, Drift: 1}) {
+		t.Errorf("Expected a fresh summary, got %+v", summary)
+	}
+}
+
+// TestStatusSummaryWithoutStateDir tests that a user who cannot write .guard/ gets a
+// cached summary from the user cache directory, and that the summary does not need a
+// registry LoadRegistry would refuse.
+func TestStatusSummaryWithoutStateDir(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	file := createTestFile(t, tmpDir, "file.txt", 0644)
+	if err := mgr.AddFiles([]string{file}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+	if err := mgr.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	// An interrupted operation makes LoadRegistry fail, the read-only summary does not care
+	writeTestJournal(t, tmpDir, "operation: enable\nentries: []\n")
+	if _, err := NewManager(mgr.registryPath, mgr.fs).StatusSummary(); err != nil {
+		t.Fatalf("StatusSummary failed with a pending journal: %v", err)
+	}
+
+	// A .guard that is not a writable directory stands in for a root-owned one
+	if err := os.RemoveAll(mgr.StateDir()); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	if err := os.WriteFile(mgr.StateDir(), nil, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := NewManager(mgr.registryPath, mgr.fs).StatusSummary(); err != nil {
+		t.Fatalf("StatusSummary failed: %v", err)
+	}
+	if _, err := os.Stat(mgr.userStatusIndexPath()); err != nil {
+		t.Fatalf("Expected the index in the user cache directory: %v", err)
+	}
+
+	cached := NewManager(mgr.registryPath, mgr.fs)
+	summary, err := cached.StatusSummary()
+	if err != nil || *summary != (StatusSummary{Unguarded: 1}) {
+		t.Errorf("Unexpected summary %+v (%v)", summary, err)
+	}
+	if cached.GetRegistry() != nil {
+		t.Error("Expected the summary from the user cache without loading the registry")
+	}
+}
@@ -0,0 +1,196 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// FileDrift describes a registered file whose permissions on disk no longer match the registry.
+// Guarded files are compared against the guard configuration, unguarded files against
+// their stored original permissions.
+type FileDrift struct {
+	Path           string   `json:"path"`            // Display path
+	Guard          bool     `json:"guard"`           // Guard flag in the registry
+	Issues         []string `json:"issues"`          // One entry per permission mismatch, e.g. "mode is 0644, expected 0600"
+	ContentChanged bool     `json:"content_changed"` // Content differs from the baseline recorded when the file was guarded
+	path           string
+}
+
+// VerifyResult summarizes a verify run.
+type VerifyResult struct {
+	Checked int
+	Drifted []FileDrift
+	Missing []string
+}
+
+// VerifyFiles compares the given registered files (all registered files if paths is empty)
+// against the state the registry expects for them. With checkContent, guarded files are
+// also compared against their content baselines.
+func (m *Manager) VerifyFiles(paths []string, checkContent bool) (*VerifyResult, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	if len(paths) == 0 {
+		paths = m.security.GetRegisteredFiles()
+	}
+	sort.Strings(paths)
+
+	result := &VerifyResult{}
+	var unregistered, checked []string
+	for _, arg := range paths {
+		path, err := filepath.Abs(arg)
+		if err != nil || !m.security.IsRegisteredFile(path) {
+			unregistered = append(unregistered, arg)
+			continue
+		}
+		if !m.fs.FileExists(path) {
+			result.Missing = append(result.Missing, m.security.ToDisplayPath(path))
+			continue
+		}
+
+		result.Checked++
+		drift, err := m.CheckFileDrift(path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to verify %s: %v", path, err))
+			continue
+		}
+		if drift != nil {
+			result.Drifted = append(result.Drifted, *drift)
+		}
+		checked = append(checked, path)
+	}
+
+	if checkContent {
+		m.verifyContent(result, checked)
+	}
+
+	if len(unregistered) > 0 {
+		m.AddWarning(NewWarning(WarningFileNotInRegistry, "", unregistered...))
+	}
+	if len(result.Missing) > 0 {
+		m.AddWarning(NewWarning(WarningFileMissing, "", result.Missing...))
+	}
+	return result, nil
+}
+
+// verifyContent adds the files whose content changed since they were guarded to the result.
+func (m *Manager) verifyContent(result *VerifyResult, paths []string) {
+	changed, failed := m.contentChanges(paths)
+	for _, path := range paths {
+		if err, ok := failed[path]; ok {
+			m.AddError(fmt.Sprintf("Error: Failed to hash %s: %v", path, err))
+			continue
+		}
+		if !changed[path] {
+			continue
+		}
+
+		displayPath := m.security.ToDisplayPath(path)
+		found := false
+		for i := range result.Drifted {
+			if result.Drifted[i].path == path {
+				result.Drifted[i].ContentChanged = true
+				found = true
+			}
+		}
+		if !found {
+			guard, _ := m.security.GetRegisteredFileGuard(path)
+			result.Drifted = append(result.Drifted, FileDrift{Path: displayPath, Guard: guard, ContentChanged: true, path: path})
+		}
+	}
+	sort.Slice(result.Drifted, func(i, j int) bool { return result.Drifted[i].Path < result.Drifted[j].Path })
+}
+
+// CheckFileDrift compares one registered file against its expected state.
+// Returns nil if the file matches.
+func (m *Manager) CheckFileDrift(path string) (*FileDrift, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	storedOwner, storedGroup, storedMode, guard, err := m.security.GetRegisteredFileConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	mode, owner, group, err := m.fs.GetFileInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	immutable, err := m.fs.IsImmutable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedMode, expectedOwner, expectedGroup := storedMode, storedOwner, storedGroup
+	if guard {
+		expectedMode = m.security.GetDefaultFileMode()
+		expectedOwner = m.security.GetDefaultFileOwner()
+		expectedGroup = m.security.GetDefaultFileGroup()
+	}
+
+	var issues []string
+	if mode.Perm() != expectedMode.Perm() {
+		issues = append(issues, fmt.Sprintf("mode is %04o, expected %04o", mode.Perm(), expectedMode.Perm()))
+	}
+	if expectedOwner != "" && owner != expectedOwner {
+		issues = append(issues, fmt.Sprintf("owner is %s, expected %s", owner, expectedOwner))
+	}
+	if expectedGroup != "" && group != expectedGroup {
+		issues = append(issues, fmt.Sprintf("group is %s, expected %s", group, expectedGroup))
+	}
+	// Only root can set the immutable flag, so it is only expected when verifying as root
+	if guard && !immutable && m.fs.HasRootPrivileges() {
+		issues = append(issues, "immutable flag is not set")
+	}
+	if !guard && immutable {
+		issues = append(issues, "immutable flag is set on an unguarded file")
+	}
+
+	if len(issues) == 0 {
+		return nil, nil
+	}
+	return &FileDrift{
+		Path:   m.security.ToDisplayPath(path),
+		Guard:  guard,
+		Issues: issues,
+		path:   path,
+	}, nil
+}
+
+// RepairDrift re-applies the expected permissions to the drifted files.
+// Content changes cannot be repaired, files with only a content change are skipped.
+// Returns the display paths of the repaired files; failures are recorded as errors.
+func (m *Manager) RepairDrift(drifted []FileDrift) ([]string, error) {
+	if m.security == nil {
+		return nil, errRegistryNotLoaded
+	}
+
+	changes := make([]permChange, 0, len(drifted))
+	for _, drift := range drifted {
+		if len(drift.Issues) == 0 {
+			continue
+		}
+		if drift.Guard {
+			changes = append(changes, m.guardPermChange(drift.path))
+			continue
+		}
+		owner, group, mode, _, err := m.security.GetRegisteredFileConfig(drift.path)
+		if err != nil {
+			m.AddError(fmt.Sprintf("Error: Failed to get config for %s: %v", drift.Path, err))
+			continue
+		}
+		changes = append(changes, unguardPermChange(drift.path, mode, owner, group))
+	}
+
+	succeeded := m.applyPermChanges("repair", changes)
+
+	var repaired []string
+	for _, drift := range drifted {
+		if succeeded[drift.path] {
+			repaired = append(repaired, drift.Path)
+		}
+	}
+	return repaired, nil
+}
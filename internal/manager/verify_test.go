@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"os"
+	"testing"
+)
+
+// TestVerifyDetectsAndRepairsDrift tests that a mode changed behind guard's back is reported and repaired.
+func TestVerifyDetectsAndRepairsDrift(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	file2 := createTestFile(t, tmpDir, "file2.txt", 0644)
+	if err := mgr.AddFiles([]string{file1, file2}); err != nil {
+		t.Fatalf("AddFiles failed: %v", err)
+	}
+
+	result, err := mgr.VerifyFiles(nil, false)
+	if err != nil {
+		t.Fatalf("VerifyFiles failed: %v", err)
+	}
+	if result.Checked != 2 || len(result.Drifted) != 0 {
+		t.Fatalf("Expected 2 clean files, got %+v", result)
+	}
+
+	// Unguarded file2 drifts away from its stored mode
+	if err := os.Chmod(file2, 0666); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+
+	result, err = mgr.VerifyFiles(nil, false)
+	if err != nil {
+		t.Fatalf("VerifyFiles failed: %v", err)
+	}
+	if len(result.Drifted) != 1 || result.Drifted[0].Path != "file2.txt" || result.Drifted[0].Guard {
+		t.Fatalf("Expected file2.txt to have drifted, got %+v", result.Drifted)
+	}
+
+	repaired, err := mgr.RepairDrift(result.Drifted)
+	if err != nil {
+		t.Fatalf("RepairDrift failed: %v", err)
+	}
+	if len(repaired) != 1 || mgr.HasErrors() {
+		t.Fatalf("Expected 1 repaired file, got %v (errors: %v)", repaired, mgr.GetErrors())
+	}
+	if mode, _, _, _ := mgr.fs.GetFileInfo(file2); mode.Perm() != 0644 {
+		t.Errorf("Expected mode 0644 after repair, got %04o", mode.Perm())
+	}
+	if drift, err := mgr.CheckFileDrift(file2); err != nil || drift != nil {
+		t.Errorf("Expected no drift after repair, got %+v (%v)", drift, err)
+	}
+}
+
+// TestVerifyContentAndRebaseline tests that guarding records a content baseline,
+// that changed content is reported and that rebaseline accepts it.
+func TestVerifyContentAndRebaseline(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	if err := mgr.InitializeRegistry("0644", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	file1 := createTestFile(t, tmpDir, "file1.txt", 0644)
+	if err := mgr.EnableFiles([]string{file1}); err != nil {
+		t.Fatalf("EnableFiles failed: %v", err)
+	}
+	if sum, size, _ := mgr.GetRegistry().GetRegisteredFileContent(file1); sum == "" || size == 0 {
+		t.Fatalf("Expected a content baseline after guarding, got %q/%d", sum, size)
+	}
+
+	// Clear the immutable flag so the content can be changed behind guard's back
+	_ = mgr.fs.ClearImmutable(file1)
+	if err := os.WriteFile(file1, []byte("changed content"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	_ = mgr.fs.SetImmutable(file1)
+
+	result, err := mgr.VerifyFiles(nil, true)
+	if err != nil {
+		t.Fatalf("VerifyFiles failed: %v", err)
+	}
+	if len(result.Drifted) != 1 || !result.Drifted[0].ContentChanged || len(result.Drifted[0].Issues) != 0 {
+		t.Fatalf("Expected a content-only drift, got %+v", result.Drifted)
+	}
+	infos, _ := mgr.ShowFiles([]string{file1})
+	if len(infos) != 1 || !infos[0].ContentChanged {
+		t.Errorf("Expected show to report the content change, got %+v", infos)
+	}
+
+	if rebaselined, err := mgr.Rebaseline([]string{file1}); err != nil || len(rebaselined) != 1 {
+		t.Fatalf("Rebaseline failed: %v (%v)", rebaselined, err)
+	}
+	result, _ = mgr.VerifyFiles(nil, true)
+	if len(result.Drifted) != 0 {
+		t.Errorf("Expected no drift after rebaseline, got %+v", result.Drifted)
+	}
+
+	// Unguarding clears the baseline
+	if err := mgr.DisableFiles([]string{file1}); err != nil {
+		t.Fatalf("DisableFiles failed: %v", err)
+	}
+	if sum, _, _ := mgr.GetRegistry().GetRegisteredFileContent(file1); sum != "" {
+		t.Errorf("Expected the baseline to be cleared after unguarding, got %q", sum)
+	}
+}
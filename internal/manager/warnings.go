@@ -31,15 +31,50 @@ const (
 	WarningFolderEmpty
 	// WarningFileAlreadyGuarded indicates file has permissions matching guard mode
 	WarningFileAlreadyGuarded
+	// WarningGuardExpired indicates time-limited guard changes that were reverted
+	WarningGuardExpired
+	// WarningImmutableSkipped indicates files guarded without the immutable flag (no root)
+	WarningImmutableSkipped
 	// WarningGeneric is for other warning messages
 	WarningGeneric
 )
 
+// warningTypeNames are the names of the warning types in JSON and YAML output
+var warningTypeNames = map[WarningType]string{
+	WarningFileMissing:               "file-missing",
+	WarningFileNotInRegistry:         "file-not-in-registry",
+	WarningFileAlreadyInRegistry:     "file-already-in-registry",
+	WarningCollectionEmpty:           "collection-empty",
+	WarningCollectionNotFound:        "collection-not-found",
+	WarningCollectionAlreadyExists:   "collection-already-exists",
+	WarningFileNotInCollection:       "file-not-in-collection",
+	WarningCollectionHasMissingFiles: "collection-has-missing-files",
+	WarningCollectionCreated:         "collection-created",
+	WarningFolderEmpty:               "folder-empty",
+	WarningFileAlreadyGuarded:        "file-already-guarded",
+	WarningGuardExpired:              "guard-expired",
+	WarningImmutableSkipped:          "immutable-skipped",
+	WarningGeneric:                   "generic",
+}
+
+// String returns the name of the warning type, e.g. "file-missing".
+func (t WarningType) String() string {
+	if name, ok := warningTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("warning-%d", int(t))
+}
+
+// MarshalText renders the warning type by name in JSON and YAML output.
+func (t WarningType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
 // Warning represents a warning with a type and associated items.
 type Warning struct {
-	Type    WarningType
-	Message string
-	Items   []string // For aggregation (file paths, collection names, etc.)
+	Type    WarningType `json:"type"`
+	Message string      `json:"message,omitempty"`
+	Items   []string    `json:"items,omitempty"` // For aggregation (file paths, collection names, etc.)
 }
 
 // NewWarning creates a new warning with the specified type, message, and items.
@@ -70,41 +105,87 @@ func AggregateWarnings(warnings []Warning) []string {
 
 	// Process each warning type
 	for warnType, warns := range grouped {
-		switch warnType {
-		case WarningFileMissing:
-			result = append(result, aggregateFilesMissing(warns))
-		case WarningFileNotInRegistry:
-			result = append(result, aggregateFilesNotInRegistry(warns))
-		case WarningFileAlreadyInRegistry:
-			// Silent per Requirement 2.4 (idempotent file addition)
-			// Don't add to result
-		case WarningCollectionEmpty:
-			result = append(result, aggregateCollectionsEmpty(warns))
-		case WarningCollectionNotFound:
-			result = append(result, aggregateCollectionsNotFound(warns))
-		case WarningCollectionAlreadyExists:
-			result = append(result, aggregateCollectionsAlreadyExist(warns))
-		case WarningFileNotInCollection:
-			result = append(result, aggregateFilesNotInCollection(warns))
-		case WarningCollectionHasMissingFiles:
-			result = append(result, aggregateCollectionsHaveMissingFiles(warns))
-		case WarningCollectionCreated:
-			result = append(result, aggregateCollectionsCreated(warns))
-		case WarningFolderEmpty:
-			result = append(result, aggregateFoldersEmpty(warns))
-		case WarningFileAlreadyGuarded:
-			result = append(result, aggregateFilesAlreadyGuarded(warns))
-		case WarningGeneric:
-			// Generic warnings are not aggregated
-			for _, w := range warns {
-				result = append(result, fmt.Sprintf("Warning: %s", w.Message))
-			}
+		result = append(result, aggregateWarningsOfType(warnType, warns)...)
+	}
+
+	return result
+}
+
+// GroupWarnings combines the warnings of each type into one warning, in order of first
+// occurrence, with the first line of the message AggregateWarnings prints for them. Generic warnings stay
+// separate and warnings that are never printed are dropped.
+func GroupWarnings(warnings []Warning) []Warning {
+	var order []WarningType
+	grouped := make(map[WarningType][]Warning)
+	for _, w := range warnings {
+		if _, seen := grouped[w.Type]; !seen {
+			order = append(order, w.Type)
 		}
+		grouped[w.Type] = append(grouped[w.Type], w)
 	}
 
+	var result []Warning
+	for _, warnType := range order {
+		warns := grouped[warnType]
+		if warnType == WarningGeneric {
+			result = append(result, warns...)
+			continue
+		}
+		messages := aggregateWarningsOfType(warnType, warns)
+		if len(messages) == 0 || messages[0] == "" {
+			continue
+		}
+		// The items are listed separately, the message keeps the first line
+		message, _, _ := strings.Cut(messages[0], "\n")
+		group := Warning{Type: warnType, Message: strings.TrimPrefix(message, "Warning: ")}
+		for _, w := range warns {
+			group.Items = append(group.Items, w.Items...)
+		}
+		result = append(result, group)
+	}
 	return result
 }
 
+// aggregateWarningsOfType formats the warnings of one type for display
+func aggregateWarningsOfType(warnType WarningType, warns []Warning) []string {
+	var messages []string
+	switch warnType {
+	case WarningFileMissing:
+		messages = append(messages, aggregateFilesMissing(warns))
+	case WarningFileNotInRegistry:
+		messages = append(messages, aggregateFilesNotInRegistry(warns))
+	case WarningFileAlreadyInRegistry:
+		// Silent per Requirement 2.4 (idempotent file addition)
+		// Don't add to result
+	case WarningCollectionEmpty:
+		messages = append(messages, aggregateCollectionsEmpty(warns))
+	case WarningCollectionNotFound:
+		messages = append(messages, aggregateCollectionsNotFound(warns))
+	case WarningCollectionAlreadyExists:
+		messages = append(messages, aggregateCollectionsAlreadyExist(warns))
+	case WarningFileNotInCollection:
+		messages = append(messages, aggregateFilesNotInCollection(warns))
+	case WarningCollectionHasMissingFiles:
+		messages = append(messages, aggregateCollectionsHaveMissingFiles(warns))
+	case WarningCollectionCreated:
+		messages = append(messages, aggregateCollectionsCreated(warns))
+	case WarningFolderEmpty:
+		messages = append(messages, aggregateFoldersEmpty(warns))
+	case WarningFileAlreadyGuarded:
+		messages = append(messages, aggregateFilesAlreadyGuarded(warns))
+	case WarningGuardExpired:
+		messages = append(messages, aggregateGuardsExpired(warns))
+	case WarningImmutableSkipped:
+		messages = append(messages, aggregateImmutableSkipped(warns))
+	case WarningGeneric:
+		// Generic warnings are not aggregated
+		for _, w := range warns {
+			messages = append(messages, fmt.Sprintf("Warning: %s", w.Message))
+		}
+	}
+	return messages
+}
+
 func aggregateFilesMissing(warnings []Warning) string {
 	allFiles := []string{}
 	context := ""
@@ -321,25 +402,40 @@ func aggregateFilesAlreadyGuarded(warnings []Warning) string {
 	return sb.String()
 }
 
-// PrintWarnings formats and prints all aggregated warnings to stdout.
-func PrintWarnings(warnings []Warning) {
-	if len(warnings) == 0 {
-		return
+func aggregateGuardsExpired(warnings []Warning) string {
+	allItems := []string{}
+	for _, w := range warnings {
+		allItems = append(allItems, w.Items...)
 	}
 
-	aggregated := AggregateWarnings(warnings)
-	for _, msg := range aggregated {
-		if msg != "" {
-			fmt.Println(msg)
-		}
+	if len(allItems) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Warning: The following time-limited guard changes expired and were reverted:")
+	for _, item := range allItems {
+		sb.WriteString("\n  - ")
+		sb.WriteString(item)
 	}
+	return sb.String()
 }
 
-// PrintErrors prints all error messages to stdout.
-func PrintErrors(errors []string) {
-	for _, err := range errors {
-		if err != "" {
-			fmt.Println(err)
-		}
+func aggregateImmutableSkipped(warnings []Warning) string {
+	allFiles := []string{}
+	for _, w := range warnings {
+		allFiles = append(allFiles, w.Items...)
 	}
+
+	if len(allFiles) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Warning: Setting the immutable flag requires root privileges (sudo). It was skipped for:")
+	for _, f := range allFiles {
+		sb.WriteString("\n  - ")
+		sb.WriteString(f)
+	}
+	return sb.String()
 }
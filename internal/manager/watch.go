@@ -0,0 +1,355 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florianbuetow/guard/internal/filesystem"
+)
+
+// DefaultWatchDebounce is how long Watch waits for a burst of events to settle before acting
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// Watch keeps the registry's guards enforced until ctx is cancelled.
+// It watches the directories of guarded folders and guarded files, and the whole project while a
+// guarded collection has patterns, and once a burst of events has settled for debounce (editors
+// save through temporary files and renames):
+//   - guards files that appeared in a guarded folder or match the patterns of a guarded collection,
+//   - re-applies the guard permissions of guarded files that were changed out of band,
+//   - reloads the registry when the .guardfile changed (a broken .guardfile keeps the previous one).
+//
+// Time-limited guard changes are reverted as soon as they expire.
+//
+// Every action, warning and error is reported through logf.
+func (m *Manager) Watch(ctx context.Context, debounce time.Duration, logf func(format string, args ...any)) error {
+	if m.security == nil {
+		return errRegistryNotLoaded
+	}
+
+	watcher, err := m.fs.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	watched := make(map[string]bool)
+	m.updateWatchedDirs(watcher, watched, logf)
+	logf("Watching %d director(ies)", len(watched))
+
+	// Catch up on everything that changed while nobody was watching
+	m.enforceGuards(nil, logf)
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	expiry := time.NewTimer(0)
+	m.resetExpiryTimer(expiry)
+	pending := make(map[string]bool)
+	rescan := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-expiry.C:
+			m.ExpireGuards()
+			m.enforceGuards(nil, logf)
+			m.updateWatchedDirs(watcher, watched, logf)
+			m.resetExpiryTimer(expiry)
+
+		case path := <-watcher.Events:
+			pending[path] = true
+			timer.Reset(debounce)
+
+		case err := <-watcher.Errors:
+			if !errors.Is(err, filesystem.ErrWatchOverflow) {
+				return err
+			}
+			logf("Missed events (%v), rescanning", err)
+			rescan = true
+			timer.Reset(debounce)
+
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			pending = make(map[string]bool)
+			sort.Strings(paths)
+
+			if m.containsRegistryPath(paths) {
+				if err := m.LoadRegistry(); err != nil {
+					logf("Failed to reload .guardfile, keeping the previous registry: %v", err)
+				} else {
+					logf("Reloaded .guardfile")
+					rescan = true
+				}
+			}
+			if rescan {
+				paths = nil
+				rescan = false
+			}
+			m.enforceGuards(paths, logf)
+			m.updateWatchedDirs(watcher, watched, logf)
+			m.resetExpiryTimer(expiry)
+		}
+	}
+}
+
+// resetExpiryTimer arms timer for the next time-limited guard change, or stops it if there is none.
+func (m *Manager) resetExpiryTimer(timer *time.Timer) {
+	next, ok := m.NextExpiry()
+	if !ok {
+		timer.Stop()
+		return
+	}
+	timer.Reset(max(time.Until(next), 0))
+}
+
+// enforceGuards guards new files in guarded folders or matching the patterns of guarded
+// collections, and repairs guarded files among paths.
+// nil paths checks every guarded folder, pattern collection and file.
+func (m *Manager) enforceGuards(paths []string, logf func(format string, args ...any)) {
+	folders := m.guardedFolderDirs()
+	patterns := m.guardedCollectionPatterns()
+
+	if paths == nil {
+		for dir := range folders {
+			files, err := m.fs.CollectImmediateFiles(dir)
+			if err != nil {
+				continue
+			}
+			paths = append(paths, files...)
+		}
+		paths = append(paths, m.security.GetRegisteredFiles()...)
+		if len(patterns) > 0 {
+			paths = append(paths, m.projectDir())
+		}
+	}
+	if len(patterns) > 0 {
+		// A new directory is only watched after the fact, so look at the files already in it
+		for _, path := range paths {
+			if info, err := m.fs.Lstat(path); err != nil || !info.IsDir() || !m.isProjectPath(path) {
+				continue
+			}
+			if files, err := m.fs.CollectFilesRecursive(path, focusSkipDirs...); err == nil {
+				paths = append(paths, files...)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var changes []permChange
+	var actions []string
+	for _, path := range paths {
+		if seen[path] || m.IsStatePath(path) || m.isRegistryPath(path) {
+			continue
+		}
+		seen[path] = true
+
+		// Only regular files that (still) exist; editors' temporary files are gone by now
+		info, err := m.fs.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		guard := false
+		if m.security.IsRegisteredFile(path) {
+			guard, _ = m.security.GetRegisteredFileGuard(path)
+		}
+		display := m.security.ToDisplayPath(path)
+
+		if !guard {
+			// Registered files were unguarded on purpose (possibly for a limited time)
+			registered := m.security.IsRegisteredFile(path)
+			collections := m.matchingPatternCollections(path, patterns)
+			if registered || (!folders[filepath.Dir(path)] && len(collections) == 0) || !m.registerFolderFile(path) {
+				continue
+			}
+			if len(collections) > 0 {
+				if err := m.security.AddRegisteredFilesToRegisteredCollections(collections, []string{path}); err != nil {
+					m.AddError(fmt.Sprintf("Error: Failed to add %s to %s: %v", display, strings.Join(collections, ", "), err))
+				}
+			}
+			changes = append(changes, m.guardPermChange(path))
+			actions = append(actions, fmt.Sprintf("Guarded new file %s", display))
+			continue
+		}
+
+		drift, err := m.CheckFileDrift(path)
+		if err != nil || drift == nil {
+			continue
+		}
+		changes = append(changes, m.guardPermChange(path))
+		actions = append(actions, fmt.Sprintf("Re-applied guard to %s (%s)", display, strings.Join(drift.Issues, ", ")))
+	}
+
+	if len(changes) > 0 {
+		succeeded := m.applyPermChanges("watch", changes)
+		m.setGuardFlags(succeeded, changes, true)
+		for i, change := range changes {
+			if succeeded[change.path] {
+				logf("%s", actions[i])
+			}
+		}
+		if err := m.SaveRegistry(); err != nil {
+			logf("Error: Failed to save registry: %v", err)
+		}
+		m.EndHistoryEntry()
+	}
+
+	for _, line := range AggregateWarnings(m.GetWarnings()) {
+		logf("%s", line)
+	}
+	for _, line := range m.GetErrors() {
+		logf("%s", line)
+	}
+	m.ClearWarnings()
+	m.ClearErrors()
+}
+
+// guardedFolderDirs returns the absolute directories of the guarded folders.
+func (m *Manager) guardedFolderDirs() map[string]bool {
+	baseDir := filepath.Dir(m.registryPath)
+	dirs := make(map[string]bool)
+	for _, folder := range m.security.ListFolders() {
+		if !folder.Guard {
+			continue
+		}
+		if dir, err := filepath.Abs(filepath.Join(baseDir, folder.Path)); err == nil {
+			dirs[dir] = true
+		}
+	}
+	return dirs
+}
+
+// guardedCollectionPatterns returns the patterns of the guarded collections that have patterns.
+func (m *Manager) guardedCollectionPatterns() map[string][]string {
+	collections := make(map[string][]string)
+	for _, name := range m.security.GetRegisteredCollections() {
+		if guard, err := m.security.GetRegisteredCollectionGuard(name); err != nil || !guard {
+			continue
+		}
+		if patterns, _, err := m.security.GetRegisteredCollectionPatterns(name); err == nil && len(patterns) > 0 {
+			collections[name] = patterns
+		}
+	}
+	return collections
+}
+
+// matchingPatternCollections returns the sorted names of the collections whose patterns match path.
+func (m *Manager) matchingPatternCollections(path string, collections map[string][]string) []string {
+	if len(collections) == 0 || !m.isProjectPath(path) {
+		return nil
+	}
+	rel, err := filepath.Rel(m.projectDir(), path)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for name, patterns := range collections {
+		if matchCollectionPatterns(patterns, filepath.ToSlash(rel)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// projectDir returns the absolute directory of the .guardfile.
+func (m *Manager) projectDir() string {
+	dir, err := filepath.Abs(filepath.Dir(m.registryPath))
+	if err != nil {
+		return filepath.Dir(m.registryPath)
+	}
+	return dir
+}
+
+// isProjectPath returns true if path lies in the .guardfile directory, outside the
+// directories never scanned for project files.
+func (m *Manager) isProjectPath(path string) bool {
+	rel, err := filepath.Rel(m.projectDir(), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	for _, segment := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if slices.Contains(focusSkipDirs, segment) {
+			return false
+		}
+	}
+	return !slices.Contains(focusSkipDirs, filepath.Base(rel))
+}
+
+// projectDirs returns dir and the directories below it, without symlinks and the
+// directories never scanned for project files.
+func (m *Manager) projectDirs(dir string) []string {
+	dirs := []string{dir}
+	entries, err := m.fs.ReadDir(dir)
+	if err != nil {
+		return dirs
+	}
+	for _, entry := range entries {
+		if entry.IsDir && !entry.IsLink && !slices.Contains(focusSkipDirs, entry.Name) {
+			dirs = append(dirs, m.projectDirs(entry.Path)...)
+		}
+	}
+	return dirs
+}
+
+// updateWatchedDirs watches the .guardfile directory, the guarded folders, the directories
+// of guarded files and, while a guarded collection has patterns, every project directory.
+// It stops watching directories that are no longer needed.
+func (m *Manager) updateWatchedDirs(watcher *filesystem.Watcher, watched map[string]bool, logf func(format string, args ...any)) {
+	wanted := m.guardedFolderDirs()
+	wanted[m.projectDir()] = true
+	if len(m.guardedCollectionPatterns()) > 0 {
+		for _, dir := range m.projectDirs(m.projectDir()) {
+			wanted[dir] = true
+		}
+	}
+	for _, path := range m.security.GetRegisteredFiles() {
+		if guard, _ := m.security.GetRegisteredFileGuard(path); guard {
+			wanted[filepath.Dir(path)] = true
+		}
+	}
+
+	for dir := range watched {
+		if !wanted[dir] {
+			if err := watcher.Remove(dir); err != nil {
+				logf("%v", err)
+			}
+			delete(watched, dir)
+		}
+	}
+	for dir := range wanted {
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			logf("%v", err)
+			continue
+		}
+		watched[dir] = true
+	}
+}
+
+// isRegistryPath returns true if path is the .guardfile.
+func (m *Manager) isRegistryPath(path string) bool {
+	registryPath, err := filepath.Abs(m.registryPath)
+	return err == nil && path == registryPath
+}
+
+// containsRegistryPath returns true if one of the paths is the .guardfile.
+func (m *Manager) containsRegistryPath(paths []string) bool {
+	for _, path := range paths {
+		if m.isRegistryPath(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestEnforceGuards tests that a watch pass guards new files in a guarded folder
+// and re-applies the guard of a file changed out of band.
+func TestEnforceGuards(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	file1 := createTestFile(t, srcDir, "file1.txt", 0644)
+	if err := mgr.EnableFolders([]string{"src"}); err != nil {
+		t.Fatalf("EnableFolders failed: %v", err)
+	}
+
+	// A new file appears and a guarded file is chmod'ed behind guard's back
+	file2 := createTestFile(t, srcDir, "file2.txt", 0644)
+	_ = mgr.fs.ClearImmutable(file1)
+	if err := os.Chmod(file1, 0666); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+
+	var logged []string
+	mgr.enforceGuards([]string{file1, file2}, func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+
+	for _, path := range []string{file1, file2} {
+		if guard, err := mgr.GetRegistry().GetRegisteredFileGuard(path); err != nil || !guard {
+			t.Errorf("Expected %s to be guarded, got %v (%v)", path, guard, err)
+		}
+		if mode, _, _, _ := mgr.fs.GetFileInfo(path); mode.Perm() != 0600 {
+			t.Errorf("Expected mode 0600 for %s, got %04o", path, mode.Perm())
+		}
+	}
+	if len(logged) != 2 {
+		t.Errorf("Expected 2 logged actions, got %v", logged)
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.DisableFolders([]string{"src"}); err != nil {
+		t.Fatalf("DisableFolders failed: %v", err)
+	}
+}
+
+// TestEnforceGuardsPatternCollection tests that a watch pass registers and guards new files
+// matching the patterns of a guarded collection, including files in a new directory.
+func TestEnforceGuardsPatternCollection(t *testing.T) {
+	mgr, tmpDir, cleanup := setupTestManager(t)
+	defer cleanup()
+	t.Chdir(tmpDir)
+
+	if err := mgr.InitializeRegistry("0600", "", "", false); err != nil {
+		t.Fatalf("InitializeRegistry failed: %v", err)
+	}
+	if err := mgr.AddCollections([]string{"secrets"}); err != nil {
+		t.Fatalf("AddCollections failed: %v", err)
+	}
+	if err := mgr.security.SetRegisteredCollectionPatterns("secrets", []string{"*.key"}, ""); err != nil {
+		t.Fatalf("SetRegisteredCollectionPatterns failed: %v", err)
+	}
+	if err := mgr.EnableCollections([]string{"secrets"}); err != nil {
+		t.Fatalf("EnableCollections failed: %v", err)
+	}
+	mgr.ClearWarnings()
+
+	// A matching file appears in a new directory, next to one that does not match
+	certsDir := filepath.Join(tmpDir, "certs")
+	if err := os.Mkdir(certsDir, 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	key := createTestFile(t, certsDir, "server.key", 0644)
+	other := createTestFile(t, certsDir, "README.md", 0644)
+
+	var logged []string
+	mgr.enforceGuards([]string{certsDir}, func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+
+	if guard, err := mgr.GetRegistry().GetRegisteredFileGuard(key); err != nil || !guard {
+		t.Errorf("Expected %s to be guarded, got %v (%v)", key, guard, err)
+	}
+	if mode, _, _, _ := mgr.fs.GetFileInfo(key); mode.Perm() != 0600 {
+		t.Errorf("Expected mode 0600 for %s, got %04o", key, mode.Perm())
+	}
+	if files, _ := mgr.GetRegistry().GetRegisteredCollectionFiles("secrets"); len(files) != 1 || files[0] != key {
+		t.Errorf("Expected %s in collection secrets, got %v", key, files)
+	}
+	if mgr.IsRegisteredFile(other) {
+		t.Errorf("Expected %s to stay unregistered", other)
+	}
+	if len(logged) != 1 {
+		t.Errorf("Expected 1 logged action, got %v", logged)
+	}
+	if dirs := mgr.projectDirs(tmpDir); len(dirs) != 2 || dirs[1] != certsDir {
+		t.Errorf("Expected the project and certs directories to be watched, got %v", dirs)
+	}
+
+	// Clean up guard so the temp dir can be removed
+	if err := mgr.DisableCollections([]string{"secrets"}); err != nil {
+		t.Fatalf("DisableCollections failed: %v", err)
+	}
+}
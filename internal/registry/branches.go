@@ -0,0 +1,60 @@
+package registry
+
+// BranchProfile is the guard state for git branches matching Pattern: either a saved
+// state, or guarding exactly the listed collections.
+type BranchProfile struct {
+	Pattern     string   `yaml:"pattern"`               // branch name glob, e.g. release/*
+	State       string   `yaml:"state,omitempty"`       // saved state to load
+	Collections []string `yaml:"collections,omitempty"` // collections to guard, everything else is unguarded
+}
+
+// GetBranchProfiles returns a copy of the branch profiles in the order they are matched
+func (r *Registry) GetBranchProfiles() []BranchProfile {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return copyBranchProfiles(r.branchProfiles)
+}
+
+// SetBranchProfile replaces the profile with the same pattern, or appends it
+func (r *Registry) SetBranchProfile(profile BranchProfile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	profile.Collections = append([]string(nil), profile.Collections...)
+	for i := range r.branchProfiles {
+		if r.branchProfiles[i].Pattern == profile.Pattern {
+			r.branchProfiles[i] = profile
+			return
+		}
+	}
+	r.branchProfiles = append(r.branchProfiles, profile)
+}
+
+// RemoveBranchProfile removes the profile with the given pattern.
+// Returns false if there is none.
+func (r *Registry) RemoveBranchProfile(pattern string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.branchProfiles {
+		if r.branchProfiles[i].Pattern == pattern {
+			r.branchProfiles = append(r.branchProfiles[:i], r.branchProfiles[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// copyBranchProfiles returns a deep copy of branch profiles so callers never share them with the registry
+func copyBranchProfiles(profiles []BranchProfile) []BranchProfile {
+	if profiles == nil {
+		return nil
+	}
+	copied := make([]BranchProfile, len(profiles))
+	for i, profile := range profiles {
+		copied[i] = profile
+		copied[i].Collections = append([]string(nil), profile.Collections...)
+	}
+	return copied
+}
@@ -0,0 +1,339 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeKind describes how an entry differs between two registry states
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "changed"
+)
+
+// FieldChange records a single field that differs between two entries
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// DiffEntry describes one added, removed or changed registry entry.
+// Section is one of "config", "file", "collection" or "folder".
+type DiffEntry struct {
+	Kind    ChangeKind    `json:"change"`
+	Section string        `json:"section"`
+	Name    string        `json:"name"`
+	Fields  []FieldChange `json:"fields,omitempty"`
+}
+
+// String renders the entry as a single line, e.g. "~ file main.go: guard false -> true"
+func (d DiffEntry) String() string {
+	var prefix string
+	switch d.Kind {
+	case ChangeAdded:
+		prefix = "+"
+	case ChangeRemoved:
+		prefix = "-"
+	default:
+		prefix = "~"
+	}
+
+	line := fmt.Sprintf("%s %s %s", prefix, d.Section, d.Name)
+	if len(d.Fields) == 0 {
+		return line
+	}
+
+	parts := make([]string, 0, len(d.Fields))
+	for _, f := range d.Fields {
+		// Membership changes carry the +/- marker in the value itself
+		if f.Field == "files" {
+			parts = append(parts, f.Old+f.New)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s -> %s", f.Field, f.Old, f.New))
+	}
+	return line + ": " + strings.Join(parts, ", ")
+}
+
+// ParseRegistryData parses and validates serialized registry content without building a Registry.
+// Used to inspect backups and other .guardfile revisions.
+func ParseRegistryData(data []byte) (*RegistryData, error) {
+	var registryData RegistryData
+	if err := yaml.Unmarshal(data, &registryData); err != nil {
+		return nil, fmt.Errorf("failed to parse registry YAML: %w", err)
+	}
+	if err := validateConfig(registryData.Config); err != nil {
+		return nil, err
+	}
+	return &registryData, nil
+}
+
+// ReadRegistryData reads and parses a registry file without building a Registry.
+func ReadRegistryData(path string) (*RegistryData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry file: %w", err)
+	}
+	return ParseRegistryData(data)
+}
+
+// DiffRegistryData compares two registry states and returns the changes needed to go from oldData to newData.
+// Entries are ordered by section (config, files, collections, folders, focus, branch profiles)
+// and then by name.
+func DiffRegistryData(oldData, newData *RegistryData) []DiffEntry {
+	if oldData == nil {
+		oldData = &RegistryData{}
+	}
+	if newData == nil {
+		newData = &RegistryData{}
+	}
+
+	var diff []DiffEntry
+	if fields := diffConfig(oldData.Config, newData.Config); len(fields) > 0 {
+		diff = append(diff, DiffEntry{Kind: ChangeModified, Section: "config", Name: "defaults", Fields: fields})
+	}
+	diff = append(diff, diffFiles(oldData.Files, newData.Files)...)
+	diff = append(diff, diffCollections(oldData.Collections, newData.Collections)...)
+	diff = append(diff, diffFolders(oldData.Folders, newData.Folders)...)
+	diff = append(diff, diffFocus(oldData.Focus, newData.Focus)...)
+	diff = append(diff, diffBranchProfiles(oldData.BranchProfiles, newData.BranchProfiles)...)
+	return diff
+}
+
+func diffConfig(oldConfig, newConfig Config) []FieldChange {
+	var fields []FieldChange
+	fields = appendFieldChange(fields, "mode", oldConfig.GuardFileMode, newConfig.GuardFileMode)
+	fields = appendFieldChange(fields, "owner", oldConfig.GuardOwner, newConfig.GuardOwner)
+	fields = appendFieldChange(fields, "group", oldConfig.GuardGroup, newConfig.GuardGroup)
+	return fields
+}
+
+func diffFiles(oldFiles, newFiles []FileEntry) []DiffEntry {
+	oldByPath := make(map[string]FileEntry, len(oldFiles))
+	for _, f := range oldFiles {
+		oldByPath[f.Path] = f
+	}
+	newByPath := make(map[string]FileEntry, len(newFiles))
+	for _, f := range newFiles {
+		newByPath[f.Path] = f
+	}
+
+	var diff []DiffEntry
+	for _, path := range sortedUnion(keysOf(oldByPath), keysOf(newByPath)) {
+		oldEntry, inOld := oldByPath[path]
+		newEntry, inNew := newByPath[path]
+		switch {
+		case !inOld:
+			diff = append(diff, DiffEntry{Kind: ChangeAdded, Section: "file", Name: path})
+		case !inNew:
+			diff = append(diff, DiffEntry{Kind: ChangeRemoved, Section: "file", Name: path})
+		default:
+			var fields []FieldChange
+			fields = appendFieldChange(fields, "mode", oldEntry.FileMode, newEntry.FileMode)
+			fields = appendFieldChange(fields, "owner", oldEntry.Owner, newEntry.Owner)
+			fields = appendFieldChange(fields, "group", oldEntry.Group, newEntry.Group)
+			fields = appendFieldChange(fields, "guard", strconv.FormatBool(oldEntry.Guard), strconv.FormatBool(newEntry.Guard))
+			fields = appendFieldChange(fields, "sha256", oldEntry.SHA256, newEntry.SHA256)
+			fields = appendFieldChange(fields, "expires", expiryValue(oldEntry.Expiry), expiryValue(newEntry.Expiry))
+			if len(fields) > 0 {
+				diff = append(diff, DiffEntry{Kind: ChangeModified, Section: "file", Name: path, Fields: fields})
+			}
+		}
+	}
+	return diff
+}
+
+func diffCollections(oldCollections, newCollections []Collection) []DiffEntry {
+	oldByName := make(map[string]Collection, len(oldCollections))
+	for _, c := range oldCollections {
+		oldByName[c.Name] = c
+	}
+	newByName := make(map[string]Collection, len(newCollections))
+	for _, c := range newCollections {
+		newByName[c.Name] = c
+	}
+
+	var diff []DiffEntry
+	for _, name := range sortedUnion(keysOf(oldByName), keysOf(newByName)) {
+		oldColl, inOld := oldByName[name]
+		newColl, inNew := newByName[name]
+		switch {
+		case !inOld:
+			diff = append(diff, DiffEntry{Kind: ChangeAdded, Section: "collection", Name: name})
+		case !inNew:
+			diff = append(diff, DiffEntry{Kind: ChangeRemoved, Section: "collection", Name: name})
+		default:
+			var fields []FieldChange
+			fields = appendFieldChange(fields, "guard", strconv.FormatBool(oldColl.Guard), strconv.FormatBool(newColl.Guard))
+			fields = appendFieldChange(fields, "mode", oldColl.GuardFileMode, newColl.GuardFileMode)
+			fields = appendFieldChange(fields, "owner", oldColl.GuardOwner, newColl.GuardOwner)
+			fields = appendFieldChange(fields, "group", oldColl.GuardGroup, newColl.GuardGroup)
+			fields = appendFieldChange(fields, "expires", expiryValue(oldColl.Expiry), expiryValue(newColl.Expiry))
+			fields = appendFieldChange(fields, "patterns", strings.Join(oldColl.Patterns, ","), strings.Join(newColl.Patterns, ","))
+			fields = appendFieldChange(fields, "source", oldColl.Source, newColl.Source)
+			fields = append(fields, diffMembers(oldColl.Files, newColl.Files)...)
+			if len(fields) > 0 {
+				diff = append(diff, DiffEntry{Kind: ChangeModified, Section: "collection", Name: name, Fields: fields})
+			}
+		}
+	}
+	return diff
+}
+
+func diffFolders(oldFolders, newFolders []Folder) []DiffEntry {
+	oldByName := make(map[string]Folder, len(oldFolders))
+	for _, f := range oldFolders {
+		oldByName[f.Name] = f
+	}
+	newByName := make(map[string]Folder, len(newFolders))
+	for _, f := range newFolders {
+		newByName[f.Name] = f
+	}
+
+	var diff []DiffEntry
+	for _, name := range sortedUnion(keysOf(oldByName), keysOf(newByName)) {
+		oldFolder, inOld := oldByName[name]
+		newFolder, inNew := newByName[name]
+		switch {
+		case !inOld:
+			diff = append(diff, DiffEntry{Kind: ChangeAdded, Section: "folder", Name: name})
+		case !inNew:
+			diff = append(diff, DiffEntry{Kind: ChangeRemoved, Section: "folder", Name: name})
+		default:
+			var fields []FieldChange
+			fields = appendFieldChange(fields, "path", oldFolder.Path, newFolder.Path)
+			fields = appendFieldChange(fields, "guard", strconv.FormatBool(oldFolder.Guard), strconv.FormatBool(newFolder.Guard))
+			fields = appendFieldChange(fields, "expires", expiryValue(oldFolder.Expiry), expiryValue(newFolder.Expiry))
+			if len(fields) > 0 {
+				diff = append(diff, DiffEntry{Kind: ChangeModified, Section: "folder", Name: name, Fields: fields})
+			}
+		}
+	}
+	return diff
+}
+
+// diffFocus reports focus mode being started, ended or given other paths
+func diffFocus(oldFocus, newFocus *Focus) []DiffEntry {
+	switch {
+	case oldFocus == nil && newFocus == nil:
+		return nil
+	case oldFocus == nil:
+		return []DiffEntry{{Kind: ChangeAdded, Section: "focus", Name: strings.Join(newFocus.Paths, ",")}}
+	case newFocus == nil:
+		return []DiffEntry{{Kind: ChangeRemoved, Section: "focus", Name: strings.Join(oldFocus.Paths, ",")}}
+	}
+	fields := appendFieldChange(nil, "paths", strings.Join(oldFocus.Paths, ","), strings.Join(newFocus.Paths, ","))
+	if len(fields) == 0 {
+		return nil
+	}
+	return []DiffEntry{{Kind: ChangeModified, Section: "focus", Name: "paths", Fields: fields}}
+}
+
+func diffBranchProfiles(oldProfiles, newProfiles []BranchProfile) []DiffEntry {
+	oldByPattern := make(map[string]BranchProfile, len(oldProfiles))
+	for _, p := range oldProfiles {
+		oldByPattern[p.Pattern] = p
+	}
+	newByPattern := make(map[string]BranchProfile, len(newProfiles))
+	for _, p := range newProfiles {
+		newByPattern[p.Pattern] = p
+	}
+
+	var diff []DiffEntry
+	for _, pattern := range sortedUnion(keysOf(oldByPattern), keysOf(newByPattern)) {
+		oldProfile, inOld := oldByPattern[pattern]
+		newProfile, inNew := newByPattern[pattern]
+		switch {
+		case !inOld:
+			diff = append(diff, DiffEntry{Kind: ChangeAdded, Section: "branch-profile", Name: pattern})
+		case !inNew:
+			diff = append(diff, DiffEntry{Kind: ChangeRemoved, Section: "branch-profile", Name: pattern})
+		default:
+			var fields []FieldChange
+			fields = appendFieldChange(fields, "state", oldProfile.State, newProfile.State)
+			fields = appendFieldChange(fields, "collections", strings.Join(oldProfile.Collections, ","), strings.Join(newProfile.Collections, ","))
+			if len(fields) > 0 {
+				diff = append(diff, DiffEntry{Kind: ChangeModified, Section: "branch-profile", Name: pattern, Fields: fields})
+			}
+		}
+	}
+	return diff
+}
+
+// diffMembers reports collection membership changes as "+file"/"-file" field changes
+func diffMembers(oldFiles, newFiles []string) []FieldChange {
+	oldSet := make(map[string]bool, len(oldFiles))
+	for _, f := range oldFiles {
+		oldSet[f] = true
+	}
+	newSet := make(map[string]bool, len(newFiles))
+	for _, f := range newFiles {
+		newSet[f] = true
+	}
+
+	var fields []FieldChange
+	for _, f := range sortedUnion(keysOf(oldSet), keysOf(newSet)) {
+		switch {
+		case !oldSet[f]:
+			fields = append(fields, FieldChange{Field: "files", Old: "", New: "+" + f})
+		case !newSet[f]:
+			fields = append(fields, FieldChange{Field: "files", Old: "-" + f, New: ""})
+		}
+	}
+	return fields
+}
+
+func appendFieldChange(fields []FieldChange, field, oldValue, newValue string) []FieldChange {
+	if oldValue == newValue {
+		return fields
+	}
+	return append(fields, FieldChange{Field: field, Old: displayValue(oldValue), New: displayValue(newValue)})
+}
+
+// expiryValue renders an expiry for the diff, empty if there is none
+func expiryValue(expiry *Expiry) string {
+	if expiry == nil {
+		return ""
+	}
+	return expiry.Until.Format(time.RFC3339)
+}
+
+func displayValue(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	return value
+}
+
+func keysOf[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func sortedUnion(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	result := make([]string, 0, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
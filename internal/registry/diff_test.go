@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestDiffRegistryData(t *testing.T) {
+	oldData := &RegistryData{
+		Config: Config{GuardFileMode: "0644", GuardOwner: "root"},
+		Files: []FileEntry{
+			{Path: "a.txt", FileMode: "0644", Owner: "u", Group: "g", Guard: false},
+			{Path: "b.txt", FileMode: "0644", Owner: "u", Group: "g", Guard: true},
+		},
+		Collections: []Collection{{Name: "docs", Files: []string{"a.txt"}}},
+	}
+	newData := &RegistryData{
+		Config: Config{GuardFileMode: "0444", GuardOwner: "root"},
+		Files: []FileEntry{
+			{Path: "a.txt", FileMode: "0644", Owner: "u", Group: "g", Guard: true},
+			{Path: "c.txt", FileMode: "0600", Owner: "u", Group: "g"},
+		},
+		Collections: []Collection{{Name: "docs", Files: []string{"a.txt", "c.txt"}}},
+		Folders:     []Folder{{Name: "@src", Path: "src", Guard: true}},
+	}
+
+	diff := DiffRegistryData(oldData, newData)
+
+	expected := []string{
+		"~ config defaults: mode 0644 -> 0444",
+		"~ file a.txt: guard false -> true",
+		"- file b.txt",
+		"+ file c.txt",
+		"~ collection docs: +c.txt",
+		"+ folder @src",
+	}
+	if len(diff) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %v", len(expected), len(diff), diff)
+	}
+	for i, entry := range diff {
+		if entry.String() != expected[i] {
+			t.Errorf("Change %d: expected %q, got %q", i, expected[i], entry.String())
+		}
+	}
+}
+
+func TestDiffRegistryDataIdentical(t *testing.T) {
+	data := &RegistryData{
+		Config: Config{GuardFileMode: "0644"},
+		Files:  []FileEntry{{Path: "a.txt", FileMode: "0644"}},
+	}
+	if diff := DiffRegistryData(data, data); len(diff) != 0 {
+		t.Errorf("Expected no changes, got %v", diff)
+	}
+}
+
+func TestDiffRegistryDataFocusAndProfiles(t *testing.T) {
+	oldData := &RegistryData{
+		BranchProfiles: []BranchProfile{{Pattern: "release/*", State: "frozen"}, {Pattern: "main", State: "open"}},
+	}
+	newData := &RegistryData{
+		Focus:          &Focus{Paths: []string{"src", "docs"}},
+		BranchProfiles: []BranchProfile{{Pattern: "release/*", Collections: []string{"api"}}},
+	}
+
+	expected := []string{
+		"+ focus src,docs",
+		"- branch-profile main",
+		"~ branch-profile release/*: state frozen -> (empty), collections (empty) -> api",
+	}
+	diff := DiffRegistryData(oldData, newData)
+	if len(diff) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %v", len(expected), len(diff), diff)
+	}
+	for i, entry := range diff {
+		if entry.String() != expected[i] {
+			t.Errorf("Change %d: expected %q, got %q", i, expected[i], entry.String())
+		}
+	}
+}
+
+func TestParseRegistryDataInvalid(t *testing.T) {
+	if _, err := ParseRegistryData([]byte("config: [")); err == nil {
+		t.Error("Expected error for invalid YAML")
+	}
+	if _, err := ParseRegistryData([]byte("files: []\n")); err == nil {
+		t.Error("Expected error for missing guard_mode")
+	}
+}
@@ -0,0 +1,37 @@
+package registry
+
+// Focus records an active focus mode: every project file outside Paths is guarded.
+// Guarded and Registered list the files focus mode changed, so it can be reversed exactly.
+type Focus struct {
+	Paths      []string `yaml:"paths"`                // focus set: paths and glob patterns relative to the .guardfile
+	Guarded    []string `yaml:"guarded,omitempty"`    // files guarded by focus mode
+	Registered []string `yaml:"registered,omitempty"` // files registered by focus mode
+}
+
+// GetFocus returns a copy of the active focus, or nil if focus mode is not active
+func (r *Registry) GetFocus() *Focus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return copyFocus(r.focus)
+}
+
+// SetFocus replaces the active focus. nil ends focus mode.
+func (r *Registry) SetFocus(focus *Focus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.focus = copyFocus(focus)
+}
+
+// copyFocus returns a deep copy of a focus so callers never share it with the registry
+func copyFocus(focus *Focus) *Focus {
+	if focus == nil {
+		return nil
+	}
+	return &Focus{
+		Paths:      append([]string(nil), focus.Paths...),
+		Guarded:    append([]string(nil), focus.Guarded...),
+		Registered: append([]string(nil), focus.Registered...),
+	}
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFocusPersistence(t *testing.T) {
+	tmpDir := t.TempDir()
+	registryPath := filepath.Join(tmpDir, ".guardfile")
+
+	defaults := &RegistryDefaults{
+		GuardMode:  "000",
+		GuardOwner: "testuser",
+		GuardGroup: "testgroup",
+	}
+	reg, err := NewRegistry(registryPath, defaults, false)
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	if reg.GetFocus() != nil {
+		t.Fatal("Expected no focus in a new registry")
+	}
+
+	reg.SetFocus(&Focus{Paths: []string{"src/api"}, Guarded: []string{"main.go"}, Registered: []string{"main.go"}})
+
+	// The registry must not share the focus with callers
+	focus := reg.GetFocus()
+	focus.Paths[0] = "changed"
+	if reg.GetFocus().Paths[0] != "src/api" {
+		t.Error("Expected GetFocus to return a copy")
+	}
+
+	if err := reg.Save(); err != nil {
+		t.Fatalf("Failed to save registry: %v", err)
+	}
+	loaded, err := LoadRegistry(registryPath)
+	if err != nil {
+		t.Fatalf("Failed to load registry: %v", err)
+	}
+	focus = loaded.GetFocus()
+	if focus == nil || len(focus.Paths) != 1 || focus.Paths[0] != "src/api" || len(focus.Guarded) != 1 || len(focus.Registered) != 1 {
+		t.Fatalf("Expected the focus to survive a save, got %+v", focus)
+	}
+
+	// Ending focus mode removes the section
+	loaded.SetFocus(nil)
+	if err := loaded.Save(); err != nil {
+		t.Fatalf("Failed to save registry: %v", err)
+	}
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Failed to reload registry: %v", err)
+	}
+	if loaded.GetFocus() != nil {
+		t.Error("Expected no focus after SetFocus(nil)")
+	}
+}
@@ -5,9 +5,10 @@ import "fmt"
 // Folder represents a dynamic folder entry in the registry
 // Unlike collections, folders do not store file lists - files are scanned dynamically from disk
 type Folder struct {
-	Name  string `yaml:"name"`  // @path/to/folder format (with @ prefix)
-	Path  string `yaml:"path"`  // relative path to folder on disk
-	Guard bool   `yaml:"guard"` // guard state
+	Name   string  `yaml:"name"`             // @path/to/folder format (with @ prefix)
+	Path   string  `yaml:"path"`             // relative path to folder on disk
+	Guard  bool    `yaml:"guard"`            // guard state
+	Expiry *Expiry `yaml:"expiry,omitempty"` // set while the guard state is time-limited
 }
 
 // RegisterFolder adds a new folder entry to the registry
@@ -107,6 +108,21 @@ func (r *Registry) GetFolderGuard(name string) (bool, error) {
 	return folder.Guard, nil
 }
 
+// SetFolderExpiry sets the expiry of a folder's guard state
+// A nil expiry makes the current guard state permanent
+func (r *Registry) SetFolderExpiry(name string, expiry *Expiry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	folder, exists := r.folders[name]
+	if !exists {
+		return fmt.Errorf("folder not found: %s", name)
+	}
+
+	folder.Expiry = copyExpiry(expiry)
+	return nil
+}
+
 // ListFolders returns all folder entries
 func (r *Registry) ListFolders() []Folder {
 	r.mu.RLock()
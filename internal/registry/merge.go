@@ -0,0 +1,263 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// MergeConflict records an entry or field both sides changed differently.
+// Field is empty when one side removed an entry the other side changed.
+type MergeConflict struct {
+	Section string
+	Name    string
+	Field   string
+	Base    string
+	Ours    string
+	Theirs  string
+}
+
+// String renders the conflict as a single line, e.g.
+// "! file main.go: mode base 0644, ours 0600, theirs 0640"
+func (c MergeConflict) String() string {
+	line := fmt.Sprintf("! %s %s", c.Section, c.Name)
+	if c.Field == "" {
+		return fmt.Sprintf("%s: %s in ours, %s in theirs", line, c.Ours, c.Theirs)
+	}
+	return fmt.Sprintf("%s: %s base %s, ours %s, theirs %s", line, c.Field, c.Base, c.Ours, c.Theirs)
+}
+
+// MergeRegistryData merges the changes two registry states made since their common base.
+// Registrations are united; a field conflicts only when both sides changed it to different
+// values, and an entry conflicts when one side removed it and the other changed it.
+// Conflicts keep our value and our entry. base may be nil when both sides added the registry.
+func MergeRegistryData(base, ours, theirs *RegistryData) (*RegistryData, []MergeConflict) {
+	m := &registryMerger{}
+	hasBase := base != nil
+	if base == nil {
+		base = &RegistryData{}
+	}
+
+	merged := &RegistryData{}
+	merged.Config = mergeConfig(m.entry("config", "defaults", hasBase), base.Config, ours.Config, theirs.Config)
+	merged.Files = mergeEntries(m, "file", base.Files, ours.Files, theirs.Files,
+		func(f FileEntry) string { return f.Path }, mergeFileEntry)
+	merged.Collections = mergeEntries(m, "collection", base.Collections, ours.Collections, theirs.Collections,
+		func(c Collection) string { return c.Name }, mergeCollection)
+	merged.Folders = mergeEntries(m, "folder", base.Folders, ours.Folders, theirs.Folders,
+		func(f Folder) string { return f.Name }, mergeFolder)
+	merged.Focus = mergeFocus(m.entry("focus", "paths", hasBase), base.Focus, ours.Focus, theirs.Focus)
+	merged.BranchProfiles = mergeBranchProfiles(m, base.BranchProfiles, ours.BranchProfiles, theirs.BranchProfiles)
+	return merged, m.conflicts
+}
+
+// registryMerger collects the conflicts of one merge
+type registryMerger struct {
+	conflicts []MergeConflict
+}
+
+// entryMerger merges the fields of one entry. Without a base, both sides added the entry
+// and every differing field conflicts.
+type entryMerger struct {
+	m       *registryMerger
+	section string
+	name    string
+	hasBase bool
+}
+
+func (m *registryMerger) entry(section, name string, hasBase bool) entryMerger {
+	return entryMerger{m: m, section: section, name: name, hasBase: hasBase}
+}
+
+// mergeField returns the value of the side that changed the field, or ours on a conflict
+func mergeField[T comparable](e entryMerger, field string, base, ours, theirs T) T {
+	return mergeValue(e, field, base, ours, theirs, func(a, b T) bool { return a == b }, func(v T) string {
+		return displayValue(fmt.Sprint(v))
+	})
+}
+
+// mergeValue is mergeField for values compared and rendered by the given functions
+func mergeValue[T any](e entryMerger, field string, base, ours, theirs T, equal func(a, b T) bool, render func(T) string) T {
+	switch {
+	case equal(ours, theirs):
+		return ours
+	case e.hasBase && equal(ours, base):
+		return theirs
+	case e.hasBase && equal(theirs, base):
+		return ours
+	}
+	baseValue := "(none)"
+	if e.hasBase {
+		baseValue = render(base)
+	}
+	e.m.conflicts = append(e.m.conflicts, MergeConflict{
+		Section: e.section, Name: e.name, Field: field,
+		Base: baseValue, Ours: render(ours), Theirs: render(theirs),
+	})
+	return ours
+}
+
+// mergeEntries merges entries keyed by name and returns them sorted by name
+func mergeEntries[E any](m *registryMerger, section string, base, ours, theirs []E,
+	key func(E) string, mergeEntry func(e entryMerger, base, ours, theirs E) E) []E {
+	baseByKey, oursByKey, theirsByKey := entriesByKey(base, key), entriesByKey(ours, key), entriesByKey(theirs, key)
+
+	merged := make([]E, 0, len(ours))
+	for _, name := range sortedUnion(sortedUnion(keysOf(baseByKey), keysOf(oursByKey)), keysOf(theirsByKey)) {
+		b, inBase := baseByKey[name]
+		o, inOurs := oursByKey[name]
+		t, inTheirs := theirsByKey[name]
+		switch {
+		case inOurs && inTheirs:
+			merged = append(merged, mergeEntry(m.entry(section, name, inBase), b, o, t))
+		case inOurs && !inBase:
+			merged = append(merged, o)
+		case inTheirs && !inBase:
+			merged = append(merged, t)
+		case inOurs:
+			// Removed in theirs: keep the entry only if ours changed it
+			if !reflect.DeepEqual(o, b) {
+				m.conflicts = append(m.conflicts, MergeConflict{Section: section, Name: name, Ours: "changed", Theirs: "removed"})
+				merged = append(merged, o)
+			}
+		case inTheirs:
+			if !reflect.DeepEqual(t, b) {
+				m.conflicts = append(m.conflicts, MergeConflict{Section: section, Name: name, Ours: "removed", Theirs: "changed"})
+				merged = append(merged, t)
+			}
+		}
+	}
+	return merged
+}
+
+func mergeConfig(e entryMerger, base, ours, theirs Config) Config {
+	merged := ours
+	merged.GuardFileMode = mergeField(e, "mode", base.GuardFileMode, ours.GuardFileMode, theirs.GuardFileMode)
+	merged.GuardOwner = mergeField(e, "owner", base.GuardOwner, ours.GuardOwner, theirs.GuardOwner)
+	merged.GuardGroup = mergeField(e, "group", base.GuardGroup, ours.GuardGroup, theirs.GuardGroup)
+	// The last toggle is a convenience and never conflicts
+	if reflect.DeepEqual(ours.LastToggle, base.LastToggle) {
+		merged.LastToggle = theirs.LastToggle
+	}
+	return merged
+}
+
+func mergeFileEntry(e entryMerger, base, ours, theirs FileEntry) FileEntry {
+	merged := ours
+	merged.FileMode = mergeField(e, "mode", base.FileMode, ours.FileMode, theirs.FileMode)
+	merged.Owner = mergeField(e, "owner", base.Owner, ours.Owner, theirs.Owner)
+	merged.Group = mergeField(e, "group", base.Group, ours.Group, theirs.Group)
+	merged.Guard = mergeField(e, "guard", base.Guard, ours.Guard, theirs.Guard)
+	merged.Expiry = mergeExpiry(e, base.Expiry, ours.Expiry, theirs.Expiry)
+
+	// The content baseline is one value: hash and size
+	baseline := mergeValue(e, "sha256", base, ours, theirs, func(a, b FileEntry) bool {
+		return a.SHA256 == b.SHA256 && a.Size == b.Size
+	}, func(f FileEntry) string { return displayValue(f.SHA256) })
+	merged.SHA256, merged.Size = baseline.SHA256, baseline.Size
+	return merged
+}
+
+func mergeCollection(e entryMerger, base, ours, theirs Collection) Collection {
+	merged := ours
+	merged.Guard = mergeField(e, "guard", base.Guard, ours.Guard, theirs.Guard)
+	merged.GuardFileMode = mergeField(e, "mode", base.GuardFileMode, ours.GuardFileMode, theirs.GuardFileMode)
+	merged.GuardOwner = mergeField(e, "owner", base.GuardOwner, ours.GuardOwner, theirs.GuardOwner)
+	merged.GuardGroup = mergeField(e, "group", base.GuardGroup, ours.GuardGroup, theirs.GuardGroup)
+	merged.Expiry = mergeExpiry(e, base.Expiry, ours.Expiry, theirs.Expiry)
+	merged.Files = mergeMembers(base.Files, ours.Files, theirs.Files)
+
+	// Patterns and their source are one value: they are synced together
+	rule := mergeValue(e, "patterns", base, ours, theirs, func(a, b Collection) bool {
+		return slices.Equal(a.Patterns, b.Patterns) && a.Source == b.Source
+	}, func(c Collection) string { return displayValue(strings.Join(c.Patterns, ",")) })
+	merged.Patterns, merged.Source = rule.Patterns, rule.Source
+	return merged
+}
+
+func mergeFolder(e entryMerger, base, ours, theirs Folder) Folder {
+	merged := ours
+	merged.Path = mergeField(e, "path", base.Path, ours.Path, theirs.Path)
+	merged.Guard = mergeField(e, "guard", base.Guard, ours.Guard, theirs.Guard)
+	merged.Expiry = mergeExpiry(e, base.Expiry, ours.Expiry, theirs.Expiry)
+	return merged
+}
+
+func mergeExpiry(e entryMerger, base, ours, theirs *Expiry) *Expiry {
+	return mergeValue(e, "expires", base, ours, theirs, func(a, b *Expiry) bool {
+		return reflect.DeepEqual(a, b)
+	}, func(expiry *Expiry) string { return displayValue(expiryValue(expiry)) })
+}
+
+// mergeMembers unites the collection members both sides added and drops those either side
+// removed. Our order is kept, members only theirs added follow.
+func mergeMembers(base, ours, theirs []string) []string {
+	inBase, inOurs, inTheirs := memberSet(base), memberSet(ours), memberSet(theirs)
+	merged := make([]string, 0, len(ours))
+	for _, file := range ours {
+		if inTheirs[file] || !inBase[file] {
+			merged = append(merged, file)
+		}
+	}
+	for _, file := range theirs {
+		if !inOurs[file] && !inBase[file] {
+			merged = append(merged, file)
+		}
+	}
+	return merged
+}
+
+// mergeFocus merges focus mode as one value: a focus set only makes sense as a whole
+func mergeFocus(e entryMerger, base, ours, theirs *Focus) *Focus {
+	return copyFocus(mergeValue(e, "focus", base, ours, theirs, func(a, b *Focus) bool {
+		return reflect.DeepEqual(a, b)
+	}, func(focus *Focus) string {
+		if focus == nil {
+			return "(off)"
+		}
+		return strings.Join(focus.Paths, ",")
+	}))
+}
+
+// mergeBranchProfiles merges profiles by pattern. Profiles are matched in order, so our
+// order is kept and profiles only theirs added follow in their order.
+func mergeBranchProfiles(m *registryMerger, base, ours, theirs []BranchProfile) []BranchProfile {
+	key := func(p BranchProfile) string { return p.Pattern }
+	mergedByPattern := entriesByKey(mergeEntries(m, "branch-profile", base, ours, theirs, key,
+		func(e entryMerger, base, ours, theirs BranchProfile) BranchProfile {
+			merged := ours
+			merged.State = mergeField(e, "state", base.State, ours.State, theirs.State)
+			merged.Collections = mergeValue(e, "collections", base.Collections, ours.Collections, theirs.Collections,
+				slices.Equal[[]string],
+				func(names []string) string { return displayValue(strings.Join(names, ",")) })
+			return merged
+		}), key)
+
+	var merged []BranchProfile
+	for _, list := range [][]BranchProfile{ours, theirs} {
+		for _, profile := range list {
+			if p, ok := mergedByPattern[profile.Pattern]; ok {
+				merged = append(merged, p)
+				delete(mergedByPattern, profile.Pattern)
+			}
+		}
+	}
+	return merged
+}
+
+func entriesByKey[E any](entries []E, key func(E) string) map[string]E {
+	byKey := make(map[string]E, len(entries))
+	for _, entry := range entries {
+		byKey[key(entry)] = entry
+	}
+	return byKey
+}
+
+func memberSet(files []string) map[string]bool {
+	set := make(map[string]bool, len(files))
+	for _, file := range files {
+		set[file] = true
+	}
+	return set
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeRegistryData(t *testing.T) {
+	base := &RegistryData{
+		Config: Config{GuardFileMode: "0600", GuardOwner: "root"},
+		Files: []FileEntry{
+			{Path: "a.txt", FileMode: "0644", Guard: false},
+			{Path: "b.txt", FileMode: "0644", Guard: true},
+			{Path: "gone.txt", FileMode: "0644"},
+		},
+		Collections: []Collection{{Name: "docs", Files: []string{"a.txt", "b.txt"}}},
+	}
+	ours := &RegistryData{
+		Config: Config{GuardFileMode: "0600", GuardOwner: "admin"},
+		Files: []FileEntry{
+			{Path: "a.txt", FileMode: "0644", Guard: true},
+			{Path: "b.txt", FileMode: "0600", Guard: true},
+			{Path: "ours.txt", FileMode: "0644"},
+		},
+		Collections: []Collection{{Name: "docs", Files: []string{"a.txt", "b.txt", "ours.txt"}}},
+	}
+	theirs := &RegistryData{
+		Config: Config{GuardFileMode: "0400", GuardOwner: "root"},
+		Files: []FileEntry{
+			{Path: "a.txt", FileMode: "0640", Guard: false},
+			{Path: "b.txt", FileMode: "0640", Guard: true},
+			{Path: "theirs.txt", FileMode: "0644"},
+		},
+		Collections: []Collection{{Name: "docs", Files: []string{"b.txt", "theirs.txt"}}},
+		Folders:     []Folder{{Name: "@src", Path: "./src", Guard: true}},
+	}
+
+	merged, conflicts := MergeRegistryData(base, ours, theirs)
+
+	// Each side's change to a different field is taken
+	if merged.Config.GuardFileMode != "0400" || merged.Config.GuardOwner != "admin" {
+		t.Errorf("Unexpected config: %+v", merged.Config)
+	}
+	var paths []string
+	for _, f := range merged.Files {
+		paths = append(paths, f.Path)
+	}
+	if !slices.Equal(paths, []string{"a.txt", "b.txt", "ours.txt", "theirs.txt"}) {
+		t.Errorf("Expected the union of registrations without removed files, got %v", paths)
+	}
+	if a := merged.Files[0]; !a.Guard || a.FileMode != "0640" {
+		t.Errorf("Expected a.txt to take guard from ours and mode from theirs, got %+v", a)
+	}
+	if files := merged.Collections[0].Files; !slices.Equal(files, []string{"b.txt", "ours.txt", "theirs.txt"}) {
+		t.Errorf("Unexpected collection members: %v", files)
+	}
+	if len(merged.Folders) != 1 {
+		t.Errorf("Expected their folder, got %+v", merged.Folders)
+	}
+
+	// Both sides changed the mode of b.txt
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %v", conflicts)
+	}
+	if got := conflicts[0].String(); got != "! file b.txt: mode base 0644, ours 0600, theirs 0640" {
+		t.Errorf("Unexpected conflict: %s", got)
+	}
+	if merged.Files[1].FileMode != "0600" {
+		t.Errorf("Expected the conflict to keep our value, got %s", merged.Files[1].FileMode)
+	}
+}
+
+func TestMergeRegistryDataRemovedAndChanged(t *testing.T) {
+	base := &RegistryData{Files: []FileEntry{{Path: "a.txt", Guard: false}}}
+	ours := &RegistryData{Files: []FileEntry{{Path: "a.txt", Guard: true}}}
+	theirs := &RegistryData{}
+
+	merged, conflicts := MergeRegistryData(base, ours, theirs)
+	if len(merged.Files) != 1 {
+		t.Errorf("Expected the changed entry to be kept, got %+v", merged.Files)
+	}
+	if len(conflicts) != 1 || conflicts[0].String() != "! file a.txt: changed in ours, removed in theirs" {
+		t.Errorf("Unexpected conflicts: %v", conflicts)
+	}
+}
+
+func TestMergeRegistryDataWithoutBase(t *testing.T) {
+	ours := &RegistryData{
+		Config:         Config{GuardFileMode: "0600"},
+		Files:          []FileEntry{{Path: "a.txt", Guard: true}},
+		BranchProfiles: []BranchProfile{{Pattern: "main", State: "open"}},
+	}
+	theirs := &RegistryData{
+		Config:         Config{GuardFileMode: "0600"},
+		Files:          []FileEntry{{Path: "a.txt", Guard: false}},
+		BranchProfiles: []BranchProfile{{Pattern: "release/*", State: "frozen"}, {Pattern: "main", State: "open"}},
+	}
+
+	merged, conflicts := MergeRegistryData(nil, ours, theirs)
+	if len(conflicts) != 1 || conflicts[0].Field != "guard" || conflicts[0].Base != "(none)" {
+		t.Errorf("Expected a guard conflict without base, got %v", conflicts)
+	}
+	// Our profile order first, profiles only theirs added follow
+	if len(merged.BranchProfiles) != 2 || merged.BranchProfiles[0].Pattern != "main" || merged.BranchProfiles[1].Pattern != "release/*" {
+		t.Errorf("Unexpected branch profiles: %+v", merged.BranchProfiles)
+	}
+}
@@ -3,9 +3,12 @@ package registry
 import (
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,7 +23,7 @@ type RegistryDefaults struct {
 // LastToggle tracks the last toggled item for quick re-toggle
 type LastToggle struct {
 	Name string `yaml:"name"`
-	Type string `yaml:"type"` // "file" or "collection"
+	Type string `yaml:"type"` // "file", "collection" or "folder"
 }
 
 // Config represents the registry configuration
@@ -31,13 +34,22 @@ type Config struct {
 	LastToggle    *LastToggle `yaml:"last_toggle,omitempty"`
 }
 
+// Expiry marks a time-limited guard change that is reverted once Until has passed
+type Expiry struct {
+	Until time.Time `yaml:"until" json:"until"`
+	Guard bool      `yaml:"guard" json:"guard"` // guard state restored on expiry
+}
+
 // FileEntry represents a registered file in the registry
 type FileEntry struct {
-	Path     string `yaml:"path"`
-	FileMode string `yaml:"mode"`
-	Owner    string `yaml:"owner"`
-	Group    string `yaml:"group"`
-	Guard    bool   `yaml:"guard"`
+	Path     string  `yaml:"path"`
+	FileMode string  `yaml:"mode"`
+	Owner    string  `yaml:"owner"`
+	Group    string  `yaml:"group"`
+	Guard    bool    `yaml:"guard"`
+	SHA256   string  `yaml:"sha256,omitempty"` // Content baseline recorded when the file was guarded
+	Size     int64   `yaml:"size,omitempty"`
+	Expiry   *Expiry `yaml:"expiry,omitempty"`
 }
 
 // Collection represents a group of files that can be toggled together
@@ -48,24 +60,31 @@ type Collection struct {
 	GuardFileMode string   `yaml:"guard_mode,omitempty"`
 	GuardOwner    string   `yaml:"guard_owner,omitempty"`
 	GuardGroup    string   `yaml:"guard_group,omitempty"`
+	Expiry        *Expiry  `yaml:"expiry,omitempty"`
+	Patterns      []string `yaml:"patterns,omitempty"` // gitignore-style; matching files are synced into Files
+	Source        string   `yaml:"source,omitempty"`   // where the patterns came from, e.g. a CODEOWNERS rule
 }
 
 // Registry manages the file tracking system
 type Registry struct {
-	mu           sync.RWMutex
-	registryPath string
-	entries      map[string]*FileEntry  // key is the file path
-	collections  map[string]*Collection // key is the collection name
-	folders      map[string]*Folder     // key is the folder name (@path/to/folder)
-	config       Config
+	mu             sync.RWMutex
+	registryPath   string
+	entries        map[string]*FileEntry  // key is the file path
+	collections    map[string]*Collection // key is the collection name
+	folders        map[string]*Folder     // key is the folder name (@path/to/folder)
+	focus          *Focus                 // nil unless focus mode is active
+	branchProfiles []BranchProfile        // matched in order
+	config         Config
 }
 
 // RegistryData is used for YAML serialization
 type RegistryData struct {
-	Config      Config       `yaml:"config"`
-	Files       []FileEntry  `yaml:"files"`
-	Collections []Collection `yaml:"collections"`
-	Folders     []Folder     `yaml:"folders"`
+	Config         Config          `yaml:"config"`
+	Files          []FileEntry     `yaml:"files"`
+	Collections    []Collection    `yaml:"collections"`
+	Folders        []Folder        `yaml:"folders"`
+	Focus          *Focus          `yaml:"focus,omitempty"`
+	BranchProfiles []BranchProfile `yaml:"branch_profiles,omitempty"`
 }
 
 // NewRegistry creates a new empty registry instance with the given defaults
@@ -169,11 +188,13 @@ func LoadRegistry(registryPath string) (*Registry, error) {
 	}
 
 	return &Registry{
-		registryPath: registryPath,
-		config:       registryData.Config,
-		entries:      entries,
-		collections:  collections,
-		folders:      folders,
+		registryPath:   registryPath,
+		config:         registryData.Config,
+		entries:        entries,
+		collections:    collections,
+		folders:        folders,
+		focus:          registryData.Focus,
+		branchProfiles: registryData.BranchProfiles,
 	}, nil
 }
 
@@ -188,6 +209,8 @@ func (r *Registry) Load() error {
 		r.entries = make(map[string]*FileEntry)
 		r.collections = make(map[string]*Collection)
 		r.folders = make(map[string]*Folder)
+		r.focus = nil
+		r.branchProfiles = nil
 		return nil
 	}
 
@@ -229,13 +252,32 @@ func (r *Registry) Load() error {
 		r.folders[registryData.Folders[i].Name] = &registryData.Folders[i]
 	}
 
+	r.focus = registryData.Focus
+	r.branchProfiles = registryData.BranchProfiles
+
 	return nil
 }
 
 // Save writes the registry from memory to disk
 func (r *Registry) Save() error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	data, err := r.Marshal()
+	if err != nil {
+		return err
+	}
+
+	// Write to file
+	if err := os.WriteFile(r.registryPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write registry file: %w", err)
+	}
+
+	return nil
+}
+
+// Marshal serializes the registry to YAML exactly as Save would write it.
+// Entries are sorted so that unchanged registries always produce identical output.
+func (r *Registry) Marshal() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
 	// Convert map to slice for YAML serialization
 	var registryData RegistryData
@@ -245,31 +287,42 @@ func (r *Registry) Save() error {
 	for _, entry := range r.entries {
 		registryData.Files = append(registryData.Files, *entry)
 	}
+	sort.Slice(registryData.Files, func(i, j int) bool {
+		return registryData.Files[i].Path < registryData.Files[j].Path
+	})
 
 	// Convert collections map to slice
 	registryData.Collections = make([]Collection, 0, len(r.collections))
 	for _, collection := range r.collections {
 		registryData.Collections = append(registryData.Collections, *collection)
 	}
+	sort.Slice(registryData.Collections, func(i, j int) bool {
+		return registryData.Collections[i].Name < registryData.Collections[j].Name
+	})
 
 	// Convert folders map to slice
 	registryData.Folders = make([]Folder, 0, len(r.folders))
 	for _, folder := range r.folders {
 		registryData.Folders = append(registryData.Folders, *folder)
 	}
+	sort.Slice(registryData.Folders, func(i, j int) bool {
+		return registryData.Folders[i].Name < registryData.Folders[j].Name
+	})
 
-	// Marshal to YAML
-	data, err := yaml.Marshal(&registryData)
-	if err != nil {
-		return fmt.Errorf("failed to marshal registry to YAML: %w", err)
-	}
+	registryData.Focus = copyFocus(r.focus)
+	registryData.BranchProfiles = copyBranchProfiles(r.branchProfiles)
 
-	// Write to file
-	if err := os.WriteFile(r.registryPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write registry file: %w", err)
-	}
+	return MarshalRegistryData(&registryData)
+}
 
-	return nil
+// MarshalRegistryData serializes registry data to YAML. Entries must already be sorted,
+// see Marshal.
+func MarshalRegistryData(registryData *RegistryData) ([]byte, error) {
+	data, err := yaml.Marshal(registryData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal registry to YAML: %w", err)
+	}
+	return data, nil
 }
 
 // validateConfig checks that all required config fields are present and valid
@@ -302,8 +355,9 @@ func validateConfig(config Config) error {
 		// Validate type if set
 		if config.LastToggle.Type != "" &&
 			config.LastToggle.Type != "file" &&
-			config.LastToggle.Type != "collection" {
-			return fmt.Errorf("invalid last_toggle type: must be 'file' or 'collection', got '%s'", config.LastToggle.Type)
+			config.LastToggle.Type != "collection" &&
+			config.LastToggle.Type != "folder" {
+			return fmt.Errorf("invalid last_toggle type: must be 'file', 'collection' or 'folder', got '%s'", config.LastToggle.Type)
 		}
 	}
 
@@ -516,6 +570,68 @@ func (r *Registry) SetRegisteredFileConfig(path string, fileMode os.FileMode, ow
 	return nil
 }
 
+// GetRegisteredFileContent returns the content baseline (SHA-256 and size) of a registered file
+// An empty hash means no baseline has been recorded
+func (r *Registry) GetRegisteredFileContent(path string) (string, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.entries[path]
+	if !exists {
+		return "", 0, fmt.Errorf("file not found in registry: %s", path)
+	}
+
+	return entry.SHA256, entry.Size, nil
+}
+
+// SetRegisteredFileContent sets the content baseline of a registered file
+// An empty hash clears the baseline
+func (r *Registry) SetRegisteredFileContent(path string, sha256 string, size int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[path]
+	if !exists {
+		return fmt.Errorf("file not found in registry: %s", path)
+	}
+
+	if sha256 == "" {
+		size = 0
+	}
+	entry.SHA256 = sha256
+	entry.Size = size
+	return nil
+}
+
+// GetRegisteredFileExpiry returns the expiry of a time-limited guard change of a registered file
+// Returns nil if the guard state of the file does not expire
+func (r *Registry) GetRegisteredFileExpiry(path string) (*Expiry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.entries[path]
+	if !exists {
+		return nil, fmt.Errorf("file not found in registry: %s", path)
+	}
+
+	return copyExpiry(entry.Expiry), nil
+}
+
+// SetRegisteredFileExpiry sets the expiry of a registered file's guard state
+// A nil expiry makes the current guard state permanent
+func (r *Registry) SetRegisteredFileExpiry(path string, expiry *Expiry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[path]
+	if !exists {
+		return fmt.Errorf("file not found in registry: %s", path)
+	}
+
+	entry.Expiry = copyExpiry(expiry)
+	return nil
+}
+
 // GetRegisteredFileOwner returns the stored owner for a registered file
 func (r *Registry) GetRegisteredFileOwner(path string) (string, error) {
 	r.mu.RLock()
@@ -661,6 +777,64 @@ func (r *Registry) SetRegisteredCollectionGuard(collectionName string, guard boo
 	return nil
 }
 
+// GetRegisteredCollectionExpiry returns the expiry of a time-limited guard change of a collection
+// Returns nil if the guard state of the collection does not expire
+func (r *Registry) GetRegisteredCollectionExpiry(collectionName string) (*Expiry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	col, exists := r.collections[collectionName]
+	if !exists {
+		return nil, fmt.Errorf("collection not found: %s", collectionName)
+	}
+
+	return copyExpiry(col.Expiry), nil
+}
+
+// SetRegisteredCollectionExpiry sets the expiry of a collection's guard state
+// A nil expiry makes the current guard state permanent
+func (r *Registry) SetRegisteredCollectionExpiry(collectionName string, expiry *Expiry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	col, exists := r.collections[collectionName]
+	if !exists {
+		return fmt.Errorf("collection not found: %s", collectionName)
+	}
+
+	col.Expiry = copyExpiry(expiry)
+	return nil
+}
+
+// GetRegisteredCollectionPatterns returns the file patterns of a collection and where they came from
+// Returns no patterns for collections whose files are managed by hand
+func (r *Registry) GetRegisteredCollectionPatterns(collectionName string) ([]string, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	col, exists := r.collections[collectionName]
+	if !exists {
+		return nil, "", fmt.Errorf("collection not found: %s", collectionName)
+	}
+
+	return slices.Clone(col.Patterns), col.Source, nil
+}
+
+// SetRegisteredCollectionPatterns sets the file patterns of a collection and where they came from
+func (r *Registry) SetRegisteredCollectionPatterns(collectionName string, patterns []string, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	col, exists := r.collections[collectionName]
+	if !exists {
+		return fmt.Errorf("collection not found: %s", collectionName)
+	}
+
+	col.Patterns = slices.Clone(patterns)
+	col.Source = source
+	return nil
+}
+
 // GetRegisteredCollectionFiles returns a copy of the files in a collection
 func (r *Registry) GetRegisteredCollectionFiles(collectionName string) ([]string, error) {
 	r.mu.RLock()
@@ -1087,3 +1261,12 @@ func (r *Registry) GetRegisteredCollectionEffectiveConfig(collectionName string)
 
 	return owner, group, mode, col.Guard, nil
 }
+
+// copyExpiry returns a copy of an expiry so callers never share it with the registry
+func copyExpiry(expiry *Expiry) *Expiry {
+	if expiry == nil {
+		return nil
+	}
+	expiryCopy := *expiry
+	return &expiryCopy
+}
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -39,6 +40,9 @@ func NewSecurity(registryPath string, defaults *registry.RegistryDefaults, overw
 	}, nil
 }
 
+// ErrTampering is wrapped by the errors of a guardfile whose paths fail validation.
+var ErrTampering = errors.New("guardfile tampering detected")
+
 // LoadSecurity loads an existing registry and wraps it with security validation.
 // Validates all paths in the guardfile to detect tampering on load.
 func LoadSecurity(registryPath string) (*Security, error) {
@@ -59,7 +63,7 @@ func LoadSecurity(registryPath string) (*Security, error) {
 
 	// Validate all paths on load (tampering detection)
 	if err := s.validateAllRegisteredPaths(); err != nil {
-		return nil, fmt.Errorf("guardfile tampering detected: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrTampering, err)
 	}
 
 	return s, nil
@@ -187,7 +191,7 @@ func (s *Security) Load() error {
 	}
 	// Validate all paths immediately after loading
 	if err := s.validateAllRegisteredPaths(); err != nil {
-		return fmt.Errorf("guardfile tampering detected: %w", err)
+		return fmt.Errorf("%w: %w", ErrTampering, err)
 	}
 	return nil
 }
@@ -197,6 +201,11 @@ func (s *Security) Save() error {
 	return s.registry.Save()
 }
 
+// Marshal returns the registry content exactly as Save would write it.
+func (s *Security) Marshal() ([]byte, error) {
+	return s.registry.Marshal()
+}
+
 // RegisterFile registers a file in the registry.
 func (s *Security) RegisterFile(path string, fileMode os.FileMode, owner string, group string) error {
 	// Convert to absolute first
@@ -354,6 +363,70 @@ func (s *Security) GetRegisteredFileConfig(path string) (string, string, os.File
 	return s.registry.GetRegisteredFileConfig(relPath)
 }
 
+// GetRegisteredFileContent returns the content baseline of a registered file.
+func (s *Security) GetRegisteredFileContent(path string) (string, int64, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if err := s.validatePath(absPath); err != nil {
+		return "", 0, err
+	}
+	relPath, err := s.toRelativePath(absPath)
+	if err != nil {
+		return "", 0, err
+	}
+	return s.registry.GetRegisteredFileContent(relPath)
+}
+
+// SetRegisteredFileContent sets the content baseline of a registered file.
+func (s *Security) SetRegisteredFileContent(path string, sha256 string, size int64) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if err := s.validatePath(absPath); err != nil {
+		return err
+	}
+	relPath, err := s.toRelativePath(absPath)
+	if err != nil {
+		return err
+	}
+	return s.registry.SetRegisteredFileContent(relPath, sha256, size)
+}
+
+// GetRegisteredFileExpiry returns the expiry of a registered file's guard state, nil if none.
+func (s *Security) GetRegisteredFileExpiry(path string) (*registry.Expiry, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if err := s.validatePath(absPath); err != nil {
+		return nil, err
+	}
+	relPath, err := s.toRelativePath(absPath)
+	if err != nil {
+		return nil, err
+	}
+	return s.registry.GetRegisteredFileExpiry(relPath)
+}
+
+// SetRegisteredFileExpiry sets the expiry of a registered file's guard state.
+func (s *Security) SetRegisteredFileExpiry(path string, expiry *registry.Expiry) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if err := s.validatePath(absPath); err != nil {
+		return err
+	}
+	relPath, err := s.toRelativePath(absPath)
+	if err != nil {
+		return err
+	}
+	return s.registry.SetRegisteredFileExpiry(relPath, expiry)
+}
+
 // SetRegisteredFileConfig sets the configuration for a registered file.
 func (s *Security) SetRegisteredFileConfig(path string, fileMode os.FileMode, owner string, group string, guard bool) error {
 	absPath, err := filepath.Abs(path)
@@ -483,6 +556,27 @@ func (s *Security) SetRegisteredCollectionGuard(collectionName string, guard boo
 	return s.registry.SetRegisteredCollectionGuard(collectionName, guard)
 }
 
+// GetRegisteredCollectionExpiry returns the expiry of a collection's guard state, nil if none.
+func (s *Security) GetRegisteredCollectionExpiry(collectionName string) (*registry.Expiry, error) {
+	return s.registry.GetRegisteredCollectionExpiry(collectionName)
+}
+
+// SetRegisteredCollectionExpiry sets the expiry of a collection's guard state.
+func (s *Security) SetRegisteredCollectionExpiry(collectionName string, expiry *registry.Expiry) error {
+	return s.registry.SetRegisteredCollectionExpiry(collectionName, expiry)
+}
+
+// GetRegisteredCollectionPatterns returns the file patterns of a collection and their source.
+// Patterns are relative to the .guardfile directory.
+func (s *Security) GetRegisteredCollectionPatterns(collectionName string) ([]string, string, error) {
+	return s.registry.GetRegisteredCollectionPatterns(collectionName)
+}
+
+// SetRegisteredCollectionPatterns sets the file patterns of a collection and their source.
+func (s *Security) SetRegisteredCollectionPatterns(collectionName string, patterns []string, source string) error {
+	return s.registry.SetRegisteredCollectionPatterns(collectionName, patterns, source)
+}
+
 // GetRegisteredCollectionFiles returns the files in a collection.
 func (s *Security) GetRegisteredCollectionFiles(collectionName string) ([]string, error) {
 	relPaths, err := s.registry.GetRegisteredCollectionFiles(collectionName)
@@ -729,6 +823,11 @@ func (s *Security) GetFolderGuard(name string) (bool, error) {
 	return s.registry.GetFolderGuard(name)
 }
 
+// SetFolderExpiry sets the expiry of a folder's guard state.
+func (s *Security) SetFolderExpiry(name string, expiry *registry.Expiry) error {
+	return s.registry.SetFolderExpiry(name, expiry)
+}
+
 // ListFolders returns all folder entries.
 func (s *Security) ListFolders() []registry.Folder {
 	return s.registry.ListFolders()
@@ -762,3 +861,31 @@ func (s *Security) IsRegisteredFolderByPath(path string) bool {
 func (s *Security) CountFolders() int {
 	return s.registry.CountFolders()
 }
+
+// GetFocus returns the active focus, or nil if focus mode is not active.
+// Paths in the focus are relative to the guardfile directory.
+func (s *Security) GetFocus() *registry.Focus {
+	return s.registry.GetFocus()
+}
+
+// SetFocus replaces the active focus. nil ends focus mode.
+// Paths in the focus must be relative to the guardfile directory.
+func (s *Security) SetFocus(focus *registry.Focus) {
+	s.registry.SetFocus(focus)
+}
+
+// GetBranchProfiles returns the branch profiles in the order they are matched.
+func (s *Security) GetBranchProfiles() []registry.BranchProfile {
+	return s.registry.GetBranchProfiles()
+}
+
+// SetBranchProfile replaces the branch profile with the same pattern, or appends it.
+func (s *Security) SetBranchProfile(profile registry.BranchProfile) {
+	s.registry.SetBranchProfile(profile)
+}
+
+// RemoveBranchProfile removes the branch profile with the given pattern.
+// Returns false if there is none.
+func (s *Security) RemoveBranchProfile(pattern string) bool {
+	return s.registry.RemoveBranchProfile(pattern)
+}
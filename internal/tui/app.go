@@ -2,6 +2,8 @@ package tui
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,28 +25,30 @@ type App struct {
 	styles      *Styles
 	keys        KeyMap
 	mgr         *manager.Manager
-	fs          *filesystem.FileSystem
+	fs          filesystem.FileSystem
+	drift       *DriftCache
 	rootPath    string
 
 	quitting bool
 }
 
 // NewApp creates a new App model
-func NewApp(rootPath string, mgr *manager.Manager, fs *filesystem.FileSystem) (App, error) {
+func NewApp(rootPath string, mgr *manager.Manager, fs filesystem.FileSystem) (App, error) {
 	styles := DefaultStyles()
 	keys := DefaultKeyMap()
 
 	// Build the file tree
-	root, err := BuildFileTree(rootPath, fs, mgr)
+	drift := NewDriftCache()
+	root, err := BuildFileTree(rootPath, fs, mgr, drift)
 	if err != nil {
 		return App{}, fmt.Errorf("failed to build file tree: %w", err)
 	}
 
 	// Update guard states for all nodes (including collapsed folders)
-	UpdateGuardStates(root, mgr, fs)
+	UpdateGuardStates(root, mgr, fs, drift)
 
 	app := App{
-		filesPanel:       NewFilesPanel(root, fs, mgr, styles, keys),
+		filesPanel:       NewFilesPanel(root, fs, mgr, drift, styles, keys),
 		collectionsPanel: NewCollectionsPanel(mgr, styles, keys),
 		statusBar:        NewStatusBar(styles, keys),
 		errorModal:       NewErrorModal(styles),
@@ -53,6 +57,7 @@ func NewApp(rootPath string, mgr *manager.Manager, fs *filesystem.FileSystem) (A
 		keys:             keys,
 		mgr:              mgr,
 		fs:               fs,
+		drift:            drift,
 		rootPath:         rootPath,
 	}
 
@@ -70,9 +75,18 @@ func (a App) Init() tea.Cmd {
 		a.collectionsPanel.Init(),
 		a.statusBar.Init(),
 		a.errorModal.Init(),
+		expiryTick(),
 	)
 }
 
+// expiryTickInterval is how often remaining times are updated and expired guard changes reverted
+const expiryTickInterval = 15 * time.Second
+
+// expiryTick schedules the next ExpiryTickMsg
+func expiryTick() tea.Cmd {
+	return tea.Tick(expiryTickInterval, func(time.Time) tea.Msg { return ExpiryTickMsg{} })
+}
+
 // Update handles messages
 func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -116,6 +130,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.collectionsPanel, _ = a.collectionsPanel.Update(refreshMsg)
 			a.statusBar, _ = a.statusBar.Update(refreshMsg)
 			return a, nil
+
+		case matchAppKey(msg, a.keys.Undo):
+			return a, a.undo()
 		}
 
 		// Forward to active panel
@@ -132,12 +149,48 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.errorModal, _ = a.errorModal.Update(msg)
 
 	case GuardToggledMsg:
+		// Each toggle is its own history entry, so 'u' undoes exactly one
+		if a.mgr != nil {
+			a.mgr.EndHistoryEntry()
+		}
+		// The files tree already invalidated the files it toggled
+		if msg.IsCollection {
+			a.drift.Invalidate()
+		}
+
 		// Update status bar
 		a.statusBar, _ = a.statusBar.Update(msg)
 		// Refresh both panels
 		a.filesPanel.Refresh()
 		a.collectionsPanel.Refresh()
 
+	case UndoneMsg:
+		a.drift.Invalidate()
+		a.statusBar, _ = a.statusBar.Update(msg)
+		a.filesPanel.Refresh()
+		a.collectionsPanel.Refresh()
+
+	case ExpiryTickMsg:
+		cmds = append(cmds, expiryTick())
+		if a.mgr == nil {
+			break
+		}
+		if _, ok := a.mgr.NextExpiry(); !ok {
+			break
+		}
+		a.mgr.ExpireGuards()
+		if slices.ContainsFunc(a.mgr.GetWarnings(), func(w manager.Warning) bool { return w.Type == manager.WarningGuardExpired }) {
+			a.drift.Invalidate()
+		}
+		a.mgr.ClearWarnings()
+		if a.mgr.HasErrors() {
+			errs := a.mgr.GetErrors()
+			a.mgr.ClearErrors()
+			cmds = append(cmds, func() tea.Msg { return ErrorMsg{Err: fmt.Errorf("%s", errs[0])} })
+		}
+		a.filesPanel.Refresh()
+		a.collectionsPanel.Refresh()
+
 	case RefreshMsg:
 		a.filesPanel, _ = a.filesPanel.Update(msg)
 		a.collectionsPanel, _ = a.collectionsPanel.Update(msg)
@@ -230,8 +283,10 @@ func (a *App) switchPanel() {
 	}
 }
 
-// refresh reloads the registry and refreshes both panels
+// refresh reloads the registry, checks every file for drift again and refreshes both panels
 func (a *App) refresh() {
+	a.drift.Invalidate()
+
 	// Reload registry
 	if a.mgr != nil {
 		_ = a.mgr.LoadRegistry()
@@ -242,6 +297,25 @@ func (a *App) refresh() {
 	a.collectionsPanel.Refresh()
 }
 
+// undo reverts the last recorded operation (usually the last toggle)
+func (a *App) undo() tea.Cmd {
+	if a.mgr == nil {
+		return nil
+	}
+
+	entry, err := a.mgr.Undo()
+	if err != nil {
+		return func() tea.Msg { return ErrorMsg{Err: err} }
+	}
+	if a.mgr.HasErrors() {
+		errs := a.mgr.GetErrors()
+		a.mgr.ClearErrors()
+		return func() tea.Msg { return ErrorMsg{Err: fmt.Errorf("%s", errs[0])} }
+	}
+
+	return func() tea.Msg { return UndoneMsg{Summary: entry.Summary()} }
+}
+
 // updateLayout updates the layout based on current dimensions
 func (a *App) updateLayout() {
 	panelWidth := a.width / 2
@@ -122,9 +122,15 @@ func (ct CollectionTree) renderNode(node CollectionNode, selected bool) string {
 		EquivalentTo: node.EquivalentTo,
 	})
 
+	// Remaining time of a time-limited guard change
+	expiry := ""
+	if label := CollectionExpiryLabel(ct.mgr, node.Name); label != "" {
+		expiry = " " + label
+	}
+
 	// Calculate available width for name
 	prefixWidth := StringWidth(prefix) + 3 + 1 // prefix + guard (3 chars) + space
-	availableWidth := ct.width - prefixWidth - 2
+	availableWidth := ct.width - prefixWidth - 2 - StringWidth(expiry)
 	if availableWidth < 10 {
 		availableWidth = 10
 	}
@@ -144,6 +150,7 @@ func (ct CollectionTree) renderNode(node CollectionNode, selected bool) string {
 	}
 
 	sb.WriteString(nameStyle.Render(name))
+	sb.WriteString(ct.styles.Expiry.Render(expiry))
 
 	// Pad to fill width
 	line := sb.String()
@@ -205,7 +212,7 @@ func (ct *CollectionTree) toggleGuard() tea.Cmd {
 	}
 
 	// Save registry
-	if err := reg.Save(); err != nil {
+	if err := ct.mgr.SaveRegistry(); err != nil {
 		return func() tea.Msg { return ErrorMsg{Err: err} }
 	}
 
@@ -44,7 +44,7 @@ func NewFileNode(name, path string, isDir, isSymlink bool, depth int, parent *Fi
 }
 
 // BuildFileTree builds a tree of FileNodes from a root directory
-func BuildFileTree(rootPath string, fs *filesystem.FileSystem, mgr *manager.Manager) (*FileNode, error) {
+func BuildFileTree(rootPath string, fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache) (*FileNode, error) {
 	// Get the base name of the root
 	absPath, err := filepath.Abs(rootPath)
 	if err != nil {
@@ -56,7 +56,7 @@ func BuildFileTree(rootPath string, fs *filesystem.FileSystem, mgr *manager.Mana
 	root.Expanded = true // Root is always expanded
 
 	// Populate children
-	if err := populateChildren(root, fs, mgr); err != nil {
+	if err := populateChildren(root, fs, mgr, drift); err != nil {
 		return nil, err
 	}
 
@@ -64,7 +64,7 @@ func BuildFileTree(rootPath string, fs *filesystem.FileSystem, mgr *manager.Mana
 }
 
 // populateChildren populates the children of a directory node
-func populateChildren(node *FileNode, fs *filesystem.FileSystem, mgr *manager.Manager) error {
+func populateChildren(node *FileNode, fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache) error {
 	if !node.IsDir {
 		return nil
 	}
@@ -74,6 +74,17 @@ func populateChildren(node *FileNode, fs *filesystem.FileSystem, mgr *manager.Ma
 		return err
 	}
 
+	// Hide guard's own state directory (registry backups etc.)
+	if mgr != nil {
+		visible := entries[:0]
+		for _, entry := range entries {
+			if !mgr.IsStatePath(entry.Path) {
+				visible = append(visible, entry)
+			}
+		}
+		entries = visible
+	}
+
 	for i, entry := range entries {
 		child := NewFileNode(entry.Name, entry.Path, entry.IsDir, entry.IsLink, node.Depth+1, node)
 		child.IsLastChild = i == len(entries)-1
@@ -86,7 +97,7 @@ func populateChildren(node *FileNode, fs *filesystem.FileSystem, mgr *manager.Ma
 
 		// Compute guard state for files
 		if !entry.IsDir && !entry.IsLink {
-			child.GuardState = ComputeFileGuardState(mgr, entry.Path)
+			child.GuardState = ComputeFileGuardState(mgr, drift, entry.Path)
 		}
 
 		node.Children = append(node.Children, child)
@@ -96,19 +107,19 @@ func populateChildren(node *FileNode, fs *filesystem.FileSystem, mgr *manager.Ma
 }
 
 // RefreshChildren refreshes the children of a node while preserving expansion state
-func (n *FileNode) RefreshChildren(fs *filesystem.FileSystem, mgr *manager.Manager) error {
+func (n *FileNode) RefreshChildren(fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache) error {
 	// Save expansion state of the ENTIRE subtree before refreshing
 	expansionState := make(map[string]bool)
 	collectExpansionState(n, expansionState)
 
 	// Clear and repopulate children
 	n.Children = nil
-	if err := populateChildren(n, fs, mgr); err != nil {
+	if err := populateChildren(n, fs, mgr, drift); err != nil {
 		return err
 	}
 
 	// Restore expansion state for all directories in the subtree
-	restoreExpansionState(n, expansionState, fs, mgr)
+	restoreExpansionState(n, expansionState, fs, mgr, drift)
 
 	return nil
 }
@@ -124,22 +135,22 @@ func collectExpansionState(node *FileNode, state map[string]bool) {
 }
 
 // restoreExpansionState recursively restores expansion state and populates children
-func restoreExpansionState(node *FileNode, state map[string]bool, fs *filesystem.FileSystem, mgr *manager.Manager) {
+func restoreExpansionState(node *FileNode, state map[string]bool, fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache) {
 	for _, child := range node.Children {
 		if child.IsDir {
 			if state[child.Path] {
 				child.Expanded = true
 				// Populate this child's children
-				_ = populateChildren(child, fs, mgr)
+				_ = populateChildren(child, fs, mgr, drift)
 				// Recursively restore expansion for grandchildren
-				restoreExpansionState(child, state, fs, mgr)
+				restoreExpansionState(child, state, fs, mgr, drift)
 			}
 		}
 	}
 }
 
 // Expand expands the node if it's a directory
-func (n *FileNode) Expand(fs *filesystem.FileSystem, mgr *manager.Manager) error {
+func (n *FileNode) Expand(fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache) error {
 	if !n.IsDir || n.IsSymlink {
 		return nil
 	}
@@ -148,7 +159,7 @@ func (n *FileNode) Expand(fs *filesystem.FileSystem, mgr *manager.Manager) error
 		n.Expanded = true
 		// Load children if not already loaded
 		if len(n.Children) == 0 {
-			return populateChildren(n, fs, mgr)
+			return populateChildren(n, fs, mgr, drift)
 		}
 	}
 
@@ -161,12 +172,12 @@ func (n *FileNode) Collapse() {
 }
 
 // Toggle toggles the expansion state
-func (n *FileNode) Toggle(fs *filesystem.FileSystem, mgr *manager.Manager) error {
+func (n *FileNode) Toggle(fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache) error {
 	if n.Expanded {
 		n.Collapse()
 		return nil
 	}
-	return n.Expand(fs, mgr)
+	return n.Expand(fs, mgr, drift)
 }
 
 // FlattenedNode represents a node in the flattened list with rendering info
@@ -250,15 +261,15 @@ func collectVisible(node *FileNode, result *[]*FileNode) {
 }
 
 // UpdateGuardStates updates the guard states for all nodes
-func UpdateGuardStates(root *FileNode, mgr *manager.Manager, fs *filesystem.FileSystem) {
+func UpdateGuardStates(root *FileNode, mgr *manager.Manager, fs filesystem.FileSystem, drift *DriftCache) {
 	if root == nil {
 		return
 	}
 
-	updateNodeGuardState(root, mgr, fs)
+	updateNodeGuardState(root, mgr, fs, drift)
 }
 
-func updateNodeGuardState(node *FileNode, mgr *manager.Manager, fs *filesystem.FileSystem) {
+func updateNodeGuardState(node *FileNode, mgr *manager.Manager, fs filesystem.FileSystem, drift *DriftCache) {
 	if node.IsDir {
 		// Compute folder guard state based on immediate children
 		var files []string
@@ -278,11 +289,11 @@ func updateNodeGuardState(node *FileNode, mgr *manager.Manager, fs *filesystem.F
 		}
 		node.GuardState = ComputeEffectiveFolderGuardState(mgr, files, "")
 	} else if !node.IsSymlink {
-		node.GuardState = ComputeFileGuardState(mgr, node.Path)
+		node.GuardState = ComputeFileGuardState(mgr, drift, node.Path)
 	}
 
 	// Recurse into children
 	for _, child := range node.Children {
-		updateNodeGuardState(child, mgr, fs)
+		updateNodeGuardState(child, mgr, fs, drift)
 	}
 }
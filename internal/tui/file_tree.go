@@ -21,13 +21,14 @@ type FileTree struct {
 	height    int
 	styles    *Styles
 	keys      KeyMap
-	fs        *filesystem.FileSystem
+	fs        filesystem.FileSystem
 	mgr       *manager.Manager
+	drift     *DriftCache
 	focused   bool
 }
 
 // NewFileTree creates a new FileTree model
-func NewFileTree(root *FileNode, fs *filesystem.FileSystem, mgr *manager.Manager, styles *Styles, keys KeyMap) FileTree {
+func NewFileTree(root *FileNode, fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache, styles *Styles, keys KeyMap) FileTree {
 	ft := FileTree{
 		root:   root,
 		scroll: NewScrollState(10),
@@ -35,6 +36,7 @@ func NewFileTree(root *FileNode, fs *filesystem.FileSystem, mgr *manager.Manager
 		keys:   keys,
 		fs:     fs,
 		mgr:    mgr,
+		drift:  drift,
 	}
 	ft.refreshFlatNodes()
 	return ft
@@ -139,9 +141,17 @@ func (ft FileTree) renderNode(fn FlattenedNode, selected bool) string {
 		name += "/"
 	}
 
+	// Remaining time of a time-limited guard change
+	expiry := ""
+	if !node.IsDir && !node.IsSymlink {
+		if label := FileExpiryLabel(ft.mgr, node.Path); label != "" {
+			expiry = " " + label
+		}
+	}
+
 	// Calculate available width for name
-	prefixWidth := StringWidth(fn.TreePrefix) + 2 + 3 + 1 // prefix + indicator (2 chars) + guard (3 chars) + space
-	availableWidth := ft.width - prefixWidth - 2          // Account for padding
+	prefixWidth := StringWidth(fn.TreePrefix) + 2 + 3 + 1              // prefix + indicator (2 chars) + guard (3 chars) + space
+	availableWidth := ft.width - prefixWidth - 2 - StringWidth(expiry) // Account for padding
 	if availableWidth < 10 {
 		availableWidth = 10
 	}
@@ -158,11 +168,17 @@ func (ft FileTree) renderNode(fn FlattenedNode, selected bool) string {
 		nameStyle = ft.styles.ItemFile
 	}
 
+	// Highlight the focus set while focus mode is active (guard focus)
+	if !node.IsSymlink && ft.mgr != nil && ft.mgr.InFocus(node.Path) {
+		nameStyle = ft.styles.ItemFocus
+	}
+
 	if selected {
 		nameStyle = ft.styles.ItemSelected
 	}
 
 	sb.WriteString(nameStyle.Render(name))
+	sb.WriteString(ft.styles.Expiry.Render(expiry))
 
 	// Pad to fill width
 	line := sb.String()
@@ -223,7 +239,7 @@ func (ft *FileTree) handleRight() {
 	if node.IsDir && !node.IsSymlink {
 		if !node.Expanded {
 			// Expand the folder
-			_ = node.Expand(ft.fs, ft.mgr)
+			_ = node.Expand(ft.fs, ft.mgr, ft.drift)
 			ft.refreshFlatNodes()
 		} else if len(node.Children) > 0 {
 			// Move to first child
@@ -288,12 +304,14 @@ func (ft *FileTree) toggleFileGuard(node *FileNode) tea.Cmd {
 	}
 
 	// Use manager's ToggleFiles to toggle guard and apply filesystem permissions
-	if err := ft.mgr.ToggleFiles([]string{node.Path}); err != nil {
+	err := ft.mgr.ToggleFiles([]string{node.Path})
+	ft.drift.Invalidate(node.Path)
+	if err != nil {
 		return func() tea.Msg { return ErrorMsg{Err: err} }
 	}
 
 	// Update node state
-	node.GuardState = ComputeFileGuardState(ft.mgr, node.Path)
+	node.GuardState = ComputeFileGuardState(ft.mgr, ft.drift, node.Path)
 
 	return func() tea.Msg {
 		return GuardToggledMsg{
@@ -327,6 +345,15 @@ func (ft *FileTree) toggleFolderGuard(node *FileNode, recursive bool) tea.Cmd {
 		}
 	}
 
+	// Never guard guard's own state files
+	guardable := files[:0]
+	for _, file := range files {
+		if !ft.mgr.IsStatePath(file) {
+			guardable = append(guardable, file)
+		}
+	}
+	files = guardable
+
 	if len(files) == 0 {
 		return nil
 	}
@@ -351,7 +378,9 @@ func (ft *FileTree) toggleFolderGuard(node *FileNode, recursive bool) tea.Cmd {
 	newGuard := guardedCount <= len(files)/2
 
 	// Use manager's ToggleFiles to toggle guard and apply filesystem permissions
-	if err := ft.mgr.ToggleFiles(files); err != nil {
+	err := ft.mgr.ToggleFiles(files)
+	ft.drift.Invalidate(files...)
+	if err != nil {
 		return func() tea.Msg { return ErrorMsg{Err: err} }
 	}
 
@@ -375,8 +404,8 @@ func (ft *FileTree) refresh() {
 	}
 
 	// Reload children
-	_ = ft.root.RefreshChildren(ft.fs, ft.mgr)
-	UpdateGuardStates(ft.root, ft.mgr, ft.fs)
+	_ = ft.root.RefreshChildren(ft.fs, ft.mgr, ft.drift)
+	UpdateGuardStates(ft.root, ft.mgr, ft.fs, ft.drift)
 	ft.refreshFlatNodes()
 }
 
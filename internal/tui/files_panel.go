@@ -21,9 +21,9 @@ type FilesPanel struct {
 }
 
 // NewFilesPanel creates a new FilesPanel
-func NewFilesPanel(root *FileNode, fs *filesystem.FileSystem, mgr *manager.Manager, styles *Styles, keys KeyMap) FilesPanel {
+func NewFilesPanel(root *FileNode, fs filesystem.FileSystem, mgr *manager.Manager, drift *DriftCache, styles *Styles, keys KeyMap) FilesPanel {
 	return FilesPanel{
-		tree:   NewFileTree(root, fs, mgr, styles, keys),
+		tree:   NewFileTree(root, fs, mgr, drift, styles, keys),
 		styles: styles,
 		title:  "Files",
 	}
@@ -25,10 +25,86 @@ func GetFileRegistryGuardState(mgr *manager.Manager, path string) GuardState {
 	return GuardStateUnguarded
 }
 
+// DriftCache remembers per file whether its permissions on disk have drifted from what
+// the registry expects, so that refreshing the tree does not stat every file again.
+// Entries are computed on first use and must be invalidated after guard operations.
+type DriftCache struct {
+	drifted map[string]bool
+}
+
+// NewDriftCache creates an empty DriftCache
+func NewDriftCache() *DriftCache {
+	return &DriftCache{drifted: make(map[string]bool)}
+}
+
+// Drifted returns whether a registered file has drifted, checking it on first use.
+// A nil cache checks every time.
+func (c *DriftCache) Drifted(mgr *manager.Manager, path string) bool {
+	if c != nil {
+		if drifted, ok := c.drifted[path]; ok {
+			return drifted
+		}
+	}
+	drift, err := mgr.CheckFileDrift(path)
+	drifted := err == nil && drift != nil
+	if c != nil {
+		c.drifted[path] = drifted
+	}
+	return drifted
+}
+
+// Invalidate forgets the given files, or every file if none are given
+func (c *DriftCache) Invalidate(paths ...string) {
+	if c == nil {
+		return
+	}
+	if len(paths) == 0 {
+		clear(c.drifted)
+		return
+	}
+	for _, path := range paths {
+		delete(c.drifted, path)
+	}
+}
+
 // ComputeFileGuardState computes the guard state for a file.
-// For files, this is simply the direct registry state - files do NOT have implicit guard.
-func ComputeFileGuardState(mgr *manager.Manager, path string) GuardState {
-	return GetFileRegistryGuardState(mgr, path)
+// For files, this is the direct registry state - files do NOT have implicit guard -
+// unless the permissions on disk have drifted from what the registry expects.
+func ComputeFileGuardState(mgr *manager.Manager, drift *DriftCache, path string) GuardState {
+	state := GetFileRegistryGuardState(mgr, path)
+	if state == GuardStateNotRegistered {
+		return state
+	}
+	if drift.Drifted(mgr, path) {
+		return GuardStateDrift
+	}
+	return state
+}
+
+// FileExpiryLabel returns the remaining time of a file's time-limited guard state, e.g. "20m".
+// Returns "" if the guard state of the file does not expire.
+func FileExpiryLabel(mgr *manager.Manager, path string) string {
+	if mgr == nil || !mgr.IsRegisteredFile(path) {
+		return ""
+	}
+	expiry, err := mgr.GetRegistry().GetRegisteredFileExpiry(path)
+	if err != nil || expiry == nil {
+		return ""
+	}
+	return manager.FormatRemaining(expiry.Until)
+}
+
+// CollectionExpiryLabel returns the remaining time of a collection's time-limited guard state.
+// Returns "" if the guard state of the collection does not expire.
+func CollectionExpiryLabel(mgr *manager.Manager, name string) string {
+	if mgr == nil || mgr.GetRegistry() == nil {
+		return ""
+	}
+	expiry, err := mgr.GetRegistry().GetRegisteredCollectionExpiry(name)
+	if err != nil || expiry == nil {
+		return ""
+	}
+	return manager.FormatRemaining(expiry.Until)
 }
 
 // ComputeEffectiveFolderGuardState computes the effective guard state for a folder
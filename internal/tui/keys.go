@@ -17,6 +17,7 @@ type KeyMap struct {
 	ToggleAll   key.Binding // Shift+Space - toggle recursively (folders only)
 	SwitchPanel key.Binding // Tab - switch between Files and Collections
 	Refresh     key.Binding // R - refresh/reload
+	Undo        key.Binding // U - undo last toggle
 
 	// Exit
 	Quit key.Binding // Q or Esc - quit
@@ -57,6 +58,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("r", "R"),
 			key.WithHelp("r", "refresh"),
 		),
+		Undo: key.NewBinding(
+			key.WithKeys("u", "U"),
+			key.WithHelp("u", "undo"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("q", "Q", "esc", "ctrl+c"),
 			key.WithHelp("q/Esc", "quit"),
@@ -76,11 +81,11 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right},
 		{k.Toggle, k.ToggleAll, k.SwitchPanel},
-		{k.Refresh, k.Quit},
+		{k.Refresh, k.Undo, k.Quit},
 	}
 }
 
 // StatusBarHelp returns the help text for the status bar
 func (k KeyMap) StatusBarHelp() string {
-	return "↑↓:Navigate  ←→:Expand/Collapse  Space:Toggle  Tab:Switch  R:Refresh  Q:Quit"
+	return "↑↓:Navigate  ←→:Expand/Collapse  Space:Toggle  Tab:Switch  U:Undo  R:Refresh  Q:Quit"
 }
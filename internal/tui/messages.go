@@ -16,6 +16,14 @@ type GuardToggledMsg struct {
 	AffectedFiles int // Number of files affected by the toggle
 }
 
+// UndoneMsg is sent when the last operation has been undone
+type UndoneMsg struct {
+	Summary string // One-line description of the undone operation
+}
+
+// ExpiryTickMsg is sent periodically to update remaining times and revert expired guard changes
+type ExpiryTickMsg struct{}
+
 // FileRegisteredMsg is sent when a file has been registered
 type FileRegisteredMsg struct {
 	Path string
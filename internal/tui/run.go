@@ -26,15 +26,15 @@ func Run() error {
 		return fmt.Errorf(".guardfile not found in current directory. Run 'guard init <mode> <owner> <group>' to initialize")
 	}
 
+	// Create filesystem
+	fs := filesystem.NewFileSystem()
+
 	// Create manager and load registry
-	mgr := manager.NewManager(guardfilePath)
+	mgr := manager.NewManager(guardfilePath, fs)
 	if err := mgr.LoadRegistry(); err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
 	}
 
-	// Create filesystem
-	fs := filesystem.NewFileSystem()
-
 	// Create the app
 	app, err := NewApp(cwd, mgr, fs)
 	if err != nil {
@@ -81,15 +81,15 @@ func RunWithPath(rootPath string) error {
 		return err
 	}
 
+	// Create filesystem
+	fs := filesystem.NewFileSystem()
+
 	// Create manager and load registry
-	mgr := manager.NewManager(guardfilePath)
+	mgr := manager.NewManager(guardfilePath, fs)
 	if err := mgr.LoadRegistry(); err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
 	}
 
-	// Create filesystem
-	fs := filesystem.NewFileSystem()
-
 	// Create the app (use guardfile directory as root)
 	guardfileDir := filepath.Dir(guardfilePath)
 	app, err := NewApp(guardfileDir, mgr, fs)
@@ -56,6 +56,9 @@ func (s StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 			}
 		}
 
+	case UndoneMsg:
+		s.message = "Undid " + msg.Summary
+
 	case ErrorMsg:
 		s.message = "Error: " + msg.Err.Error()
 
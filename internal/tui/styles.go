@@ -17,6 +17,7 @@ var (
 	ColorEmpty       = lipgloss.Color("240") // Gray for empty collections
 	ColorPanelBorder = lipgloss.Color("8")   // Gray
 	ColorSelected    = lipgloss.Color("4")   // Blue
+	ColorFocus       = lipgloss.Color("6")   // Cyan
 )
 
 // Styles holds all the styles used in the TUI
@@ -33,14 +34,19 @@ type Styles struct {
 	ItemEmpty    lipgloss.Style
 	ItemFolder   lipgloss.Style
 	ItemFile     lipgloss.Style
+	ItemFocus    lipgloss.Style // Files and folders in the focus set (guard focus)
 
 	// Guard state styles
 	GuardExplicit lipgloss.Style
 	GuardImplicit lipgloss.Style
 	GuardMixed    lipgloss.Style
+	GuardDrift    lipgloss.Style
 	GuardOff      lipgloss.Style
 	GuardNone     lipgloss.Style
 
+	// Remaining time of a time-limited guard change
+	Expiry lipgloss.Style
+
 	// Tree styles
 	TreePrefix lipgloss.Style
 
@@ -83,6 +89,9 @@ func DefaultStyles() *Styles {
 		ItemFolder: lipgloss.NewStyle().
 			Bold(true),
 		ItemFile: lipgloss.NewStyle(),
+		ItemFocus: lipgloss.NewStyle().
+			Foreground(ColorFocus).
+			Bold(true),
 
 		// Guard state styles
 		GuardExplicit: lipgloss.NewStyle().
@@ -92,10 +101,15 @@ func DefaultStyles() *Styles {
 			Foreground(ColorSuccess),
 		GuardMixed: lipgloss.NewStyle().
 			Foreground(ColorWarning),
+		GuardDrift: lipgloss.NewStyle().
+			Foreground(ColorError).
+			Bold(true),
 		GuardOff: lipgloss.NewStyle().
 			Foreground(ColorSecondary),
 		GuardNone: lipgloss.NewStyle().
 			Foreground(ColorDim),
+		Expiry: lipgloss.NewStyle().
+			Foreground(ColorWarning),
 
 		// Tree styles
 		TreePrefix: lipgloss.NewStyle().
@@ -134,6 +148,8 @@ func (s *Styles) RenderGuardState(state GuardState) string {
 		return s.GuardImplicit.Render(indicator)
 	case GuardStateMixed:
 		return s.GuardMixed.Render(indicator)
+	case GuardStateDrift:
+		return s.GuardDrift.Render(indicator)
 	case GuardStateUnguarded:
 		return s.GuardOff.Render(indicator)
 	case GuardStateNotRegistered, GuardStateNoCollection:
@@ -16,6 +16,8 @@ const (
 	GuardStateImplicit
 	// GuardStateMixed means some items are guarded, some are not (folders/collections only)
 	GuardStateMixed
+	// GuardStateDrift means the permissions on disk no longer match the registry (files only)
+	GuardStateDrift
 )
 
 // String returns the display indicator for the guard state
@@ -33,6 +35,8 @@ func (s GuardState) String() string {
 		return "[g]"
 	case GuardStateMixed:
 		return "[~]"
+	case GuardStateDrift:
+		return "[!]"
 	default:
 		return "[?]"
 	}
@@ -680,6 +680,11 @@ teardown_test_env() {
 
     # Remove test directory
     if [ -n "$TEST_DIR" ] && [ -d "$TEST_DIR" ]; then
+        # guard makes the audit log append-only when run as root
+        local audit_log="$TEST_DIR/.guard/audit.jsonl"
+        if [ -f "$audit_log" ] && [ "$(id -u)" -eq 0 ]; then
+            chattr -a "$audit_log" 2>/dev/null || chflags nosappnd "$audit_log" 2>/dev/null || true
+        fi
         rm -rf "$TEST_DIR"
     fi
 }
@@ -35,7 +35,7 @@ test_config_set_no_guardfile() {
     set -e
 
     # Assert exit code 1
-    assert_exit_code $exit_code 1 "guard config set without .guardfile should fail"
+    assert_exit_code $exit_code 3 "guard config set without .guardfile should fail"
 }
 
 # Run test
@@ -46,8 +46,8 @@ test_disable_nonexistent_has_output_autodetect() {
         TESTS_PASSED=$((TESTS_PASSED + 1))
     fi
 
-    # Assert: Should have exit code 1
-    assert_exit_code $exit_code 1 "Should fail for non-existent target"
+    # Assert: Should have exit code 9
+    assert_exit_code $exit_code 9 "Should fail for non-existent target"
 }
 
 # Run test
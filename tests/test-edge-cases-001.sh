@@ -35,8 +35,8 @@ test_guardfile_missing() {
     local exit_code=$?
     set -e
 
-    # Assert: Exit code 1, error message
-    assert_exit_code $exit_code 1 "Should fail with exit code 1"
+    # Assert: Exit code 3, error message
+    assert_exit_code $exit_code 3 "Should fail with exit code 3"
 
     # Check for error message
     if [[ "$output" == *"error"* ]] || [[ "$output" == *"Error"* ]] || [[ "$output" == *"not found"* ]]; then
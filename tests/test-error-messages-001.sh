@@ -32,7 +32,7 @@ test_error_not_found() {
     local exit_code=$?
     set -e
 
-    assert_exit_code $exit_code 1 "Toggle should fail for non-existent target"
+    assert_exit_code $exit_code 9 "Toggle should fail for non-existent target"
     assert_contains "$output" "not found" "Error should contain 'not found'"
 }
 
@@ -53,8 +53,8 @@ test_remove_permission_restore_failure() {
     # Restore directory permissions for cleanup
     chmod 755 restricted_dir
 
-    # Assert: Should fail with exit code 1
-    assert_exit_code $exit_code 1 "Remove should fail when permission restore fails"
+    # Assert: Should fail with exit code 6
+    assert_exit_code $exit_code 6 "Remove should fail when permission restore fails"
 
     # Assert: Error message format
     if echo "$output" | grep -q "^Error:.*[Ff]ailed.*restore.*permission"; then
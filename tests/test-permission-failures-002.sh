@@ -53,8 +53,8 @@ test_reset_permission_restore_failure() {
     # Restore permissions
     chmod 755 restricted_dir
 
-    # Assert: Should fail with exit code 1
-    assert_exit_code $exit_code 1 "Reset should fail when permission restore fails"
+    # Assert: Should fail with exit code 6
+    assert_exit_code $exit_code 6 "Reset should fail when permission restore fails"
 
     # Assert: Error message format
     if echo "$output" | grep -q "^Error:.*[Ff]ailed.*restore.*permission"; then
@@ -54,7 +54,7 @@ test_uninstall_aborts_on_permission_failure() {
     chmod 755 restricted_dir
 
     # Assert: Should fail
-    assert_exit_code $exit_code 1 "Uninstall should fail when permission restore fails"
+    assert_exit_code $exit_code 6 "Uninstall should fail when permission restore fails"
 
     # Assert: .guardfile should be preserved
     if [ -f ".guardfile" ]; then
@@ -37,7 +37,7 @@ test_toggle_auto_detect_not_found() {
     set -e
 
     # Assert: Should fail with not found error
-    assert_exit_code $exit_code 1 "guard toggle should fail for non-existent target"
+    assert_exit_code $exit_code 9 "guard toggle should fail for non-existent target"
 
     # Check for not found error message
     if [[ "$output" == *"not found"* ]] || [[ "$output" == *"not exist"* ]]; then